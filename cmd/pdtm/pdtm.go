@@ -1,6 +1,7 @@
 package main
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"os/signal"
@@ -28,6 +29,13 @@ func main() {
 	}()
 
 	err = pdtmRunner.Run()
+	var exitCodeErr *runner.ExitCodeError
+	if errors.As(err, &exitCodeErr) {
+		if exitCodeErr.Err != nil {
+			gologger.Error().Msgf("%s\n", exitCodeErr.Err)
+		}
+		os.Exit(exitCodeErr.Code)
+	}
 	if err != nil {
 		gologger.Fatal().Msgf("Could not run pdtm: %s\n", err)
 	}
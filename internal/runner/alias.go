@@ -0,0 +1,59 @@
+package runner
+
+import (
+	"strings"
+
+	"github.com/projectdiscovery/goflags"
+	"github.com/projectdiscovery/gologger"
+	"github.com/projectdiscovery/pdtm/pkg/types"
+	"github.com/projectdiscovery/pdtm/pkg/utils"
+)
+
+// resolveAliases replaces the aliases given to the flags expecting tool
+// names, the aliases shadowing a tool or targeting an unknown tool are dropped
+func (r *Runner) resolveAliases(toolList []types.Tool) {
+	for alias, target := range r.options.Aliases {
+		if _, ok := utils.Contains(toolList, alias); ok {
+			gologger.Info().Label("WRN").Msgf("ignoring the alias %s, a project has this name", alias)
+			delete(r.options.Aliases, alias)
+		} else if _, ok := utils.Contains(toolList, target); !ok {
+			gologger.Info().Label("WRN").Msgf("ignoring the alias %s, %s not found in the list", alias, target)
+			delete(r.options.Aliases, alias)
+		}
+	}
+	for _, names := range []*goflags.StringSlice{
+		&r.options.Install, &r.options.Update, &r.options.Remove, &r.options.Reinstall,
+		&r.options.Pin, &r.options.Unpin, &r.options.Requirements,
+	} {
+		*names = r.options.resolveAliases(*names)
+	}
+	for _, name := range []*string{&r.options.History, &r.options.Info, &r.options.Why, &r.options.Rollback} {
+		if *name != "" {
+			*name = r.options.resolveAliases([]string{*name})[0]
+		}
+	}
+}
+
+// resolveAliases returns the tool names (or name@version) with the aliases
+// replaced by the name of their tool
+func (options *Options) resolveAliases(names []string) []string {
+	if len(options.Aliases) == 0 {
+		return names
+	}
+	resolved := make([]string, 0, len(names))
+	for _, name := range names {
+		toolName, version := splitVersion(name)
+		for alias, target := range options.Aliases {
+			if strings.EqualFold(alias, toolName) {
+				gologger.Verbose().Msgf("using %s for the alias %s", target, toolName)
+				name = target
+				if version != "" {
+					name += "@" + version
+				}
+				break
+			}
+		}
+		resolved = append(resolved, name)
+	}
+	return resolved
+}
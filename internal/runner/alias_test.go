@@ -0,0 +1,27 @@
+package runner
+
+import (
+	"testing"
+
+	"github.com/projectdiscovery/goflags"
+	"github.com/projectdiscovery/pdtm/pkg/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolveAliases(t *testing.T) {
+	toolList := []types.Tool{{Name: "nuclei"}, {Name: "httpx"}, {Name: "dnsx"}}
+	r := &Runner{options: &Options{
+		Install: goflags.StringSlice{"nu@3.1.0", "httpx", "NU", "gone"},
+		Why:     "nu",
+	}}
+	r.options.Aliases = map[string]string{
+		"nu":    "nuclei",
+		"httpx": "dnsx",    // shadows a project
+		"gone":  "missing", // targets an unknown project
+	}
+	r.resolveAliases(toolList)
+	require.Equal(t, map[string]string{"nu": "nuclei"}, r.options.Aliases)
+	require.Equal(t, goflags.StringSlice{"nuclei@3.1.0", "httpx", "nuclei", "gone"}, r.options.Install)
+	require.Equal(t, "nuclei", r.options.Why)
+	require.Empty(t, r.options.Info)
+}
@@ -0,0 +1,233 @@
+package runner
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/projectdiscovery/gologger"
+	"github.com/projectdiscovery/pdtm/pkg/state"
+	"github.com/projectdiscovery/pdtm/pkg/types"
+	"github.com/projectdiscovery/pdtm/pkg/utils"
+)
+
+// apiRequest is the body of the install and update api calls
+type apiRequest struct {
+	// Tools contains the names of the tools (name@version for the installs),
+	// all the installed tools are updated when empty
+	Tools []string `json:"tools"`
+}
+
+// serveAPI serves the api on a loopback tcp address or on a unix socket
+// (unix:<path>), the calls on tcp require the bearer token of apiTokenFile
+//
+//	GET  /tools    list the tools
+//	GET  /status   status of the update checks of the daemon
+//	POST /install  install the tools of the request
+//	POST /update   update the tools of the request
+func (r *Runner) serveAPI(addr string) (*http.Server, error) {
+	var listener net.Listener
+	var token string
+	var err error
+	if socket, ok := strings.CutPrefix(addr, "unix:"); ok {
+		_ = os.Remove(socket)
+		if listener, err = net.Listen("unix", socket); err == nil {
+			// only the user running the daemon can call the api
+			err = os.Chmod(socket, 0600)
+		}
+	} else {
+		if err := checkLoopback(addr); err != nil {
+			return nil, err
+		}
+		if token, err = apiToken(); err != nil {
+			return nil, err
+		}
+		listener, err = net.Listen("tcp", addr)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	server := &http.Server{Handler: r.apiMux(token)}
+	go func() {
+		if err := server.Serve(listener); err != nil && err != http.ErrServerClosed {
+			gologger.Error().Msgf("could not serve the api on %s: %s", addr, err)
+		}
+	}()
+	return server, nil
+}
+
+// checkLoopback refuses the tcp addresses reachable from other machines
+func checkLoopback(addr string) error {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return err
+	}
+	if host == "localhost" {
+		return nil
+	}
+	if ip := net.ParseIP(host); ip == nil || !ip.IsLoopback() {
+		return fmt.Errorf("the api only listens on loopback addresses (eg. 127.0.0.1:9091) or unix sockets, not on %s", addr)
+	}
+	return nil
+}
+
+// apiToken returns the bearer token of the api, generated on first use and
+// only readable by the user running the daemon
+func apiToken() (string, error) {
+	if b, err := os.ReadFile(apiTokenFile); err == nil {
+		if token := strings.TrimSpace(string(b)); token != "" {
+			// the file may have been created with a broader mode by hand
+			return token, os.Chmod(apiTokenFile, 0600)
+		}
+	}
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	token := hex.EncodeToString(b)
+	if err := os.MkdirAll(filepath.Dir(apiTokenFile), 0700); err != nil {
+		return "", err
+	}
+	return token, os.WriteFile(apiTokenFile, []byte(token+"\n"), 0600)
+}
+
+// apiMux routes the api calls, a non-empty token is required as bearer token
+func (r *Runner) apiMux(token string) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/tools", r.apiHandler(http.MethodGet, r.apiTools))
+	mux.HandleFunc("/status", r.apiHandler(http.MethodGet, func(*http.Request) (any, error) {
+		return r.status.snapshot(), nil
+	}))
+	mux.HandleFunc("/install", r.apiHandler(http.MethodPost, r.apiInstall))
+	mux.HandleFunc("/update", r.apiHandler(http.MethodPost, func(req *http.Request) (any, error) {
+		var body apiRequest
+		if err := decodeAPIRequest(req, &body); err != nil {
+			return nil, err
+		}
+		return r.updateCheck(r.options.resolveAliases(body.Tools))
+	}))
+	if token == "" {
+		return mux
+	}
+	expected := []byte("Bearer " + token)
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if subtle.ConstantTimeCompare([]byte(req.Header.Get("Authorization")), expected) != 1 {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		mux.ServeHTTP(w, req)
+	})
+}
+
+// apiHandler writes the value returned by fn as json
+func (r *Runner) apiHandler(method string, fn func(*http.Request) (any, error)) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		if req.Method != method {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		// the browsers can't send json to another origin without a preflight,
+		// which rules out the cross-site form posts
+		if method == http.MethodPost {
+			if mediaType, _, _ := mime.ParseMediaType(req.Header.Get("Content-Type")); mediaType != "application/json" {
+				http.Error(w, "expected application/json", http.StatusUnsupportedMediaType)
+				return
+			}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		value, err := fn(req)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			value = map[string]string{"error": err.Error()}
+		}
+		_ = json.NewEncoder(w).Encode(value)
+	}
+}
+
+func decodeAPIRequest(req *http.Request, body *apiRequest) error {
+	if req.ContentLength == 0 {
+		return nil
+	}
+	if err := json.NewDecoder(req.Body).Decode(body); err != nil {
+		return fmt.Errorf("invalid request: %s", err)
+	}
+	return nil
+}
+
+// apiTools returns the tool list as shown by -list
+func (r *Runner) apiTools(*http.Request) (any, error) {
+	listed := []ListedTool{}
+	err := r.withLock(func(toolList []types.Tool) {
+		states := make(map[string]*state.State)
+		for _, tool := range toolList {
+			dir := r.pathFor(tool.Name)
+			st, ok := states[dir]
+			if !ok {
+				st, _ = state.Load(dir)
+				states[dir] = st
+			}
+			listed = append(listed, listTool(tool, dir, st))
+		}
+	})
+	return listed, err
+}
+
+// apiInstall installs the tools of the request, the post-install steps are
+// only run with -yes
+func (r *Runner) apiInstall(req *http.Request) (any, error) {
+	var body apiRequest
+	if err := decodeAPIRequest(req, &body); err != nil {
+		return nil, err
+	}
+	if len(body.Tools) == 0 {
+		return nil, fmt.Errorf("no tools to install")
+	}
+	body.Tools = r.options.resolveAliases(body.Tools)
+	results := []ReportedTool{}
+	var tokenErr error
+	err := r.withLock(func(toolList []types.Tool) {
+		if tokenErr = r.validateToken(); tokenErr != nil {
+			return
+		}
+		for _, toolName := range body.Tools {
+			toolName, version := splitVersion(toolName)
+			result := ReportedTool{Name: toolName, Action: actionInstall, Status: "failed"}
+			dir := r.pathFor(toolName)
+			i, ok := utils.Contains(toolList, toolName)
+			switch {
+			case !r.isAllowedPath(dir):
+				result.Reason = "outside home folder"
+			case !ok:
+				result.Reason = unknownTool(toolList, toolName)
+			default:
+				tool, err := r.resolve(toolList[i], version)
+				if err != nil {
+					result.Reason = err.Error()
+					break
+				}
+				result.Version = tool.Version
+				if r.install(dir, tool) {
+					result.Status = "installed"
+					if r.options.Yes {
+						r.postInstall(dir, tool)
+					}
+				}
+			}
+			r.report(result)
+			results = append(results, result)
+		}
+	})
+	if err == nil {
+		err = tokenErr
+	}
+	return results, err
+}
@@ -0,0 +1,74 @@
+package runner
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckLoopback(t *testing.T) {
+	for addr, allowed := range map[string]bool{
+		"127.0.0.1:9091": true,
+		"[::1]:9091":     true,
+		"localhost:9091": true,
+		":9091":          false,
+		"0.0.0.0:9091":   false,
+		"10.0.0.5:9091":  false,
+		"example.com:80": false,
+	} {
+		require.Equal(t, allowed, checkLoopback(addr) == nil, addr)
+	}
+}
+
+func TestAPIToken(t *testing.T) {
+	previous := apiTokenFile
+	apiTokenFile = filepath.Join(t.TempDir(), "api-token")
+	t.Cleanup(func() { apiTokenFile = previous })
+
+	token, err := apiToken()
+	require.Nil(t, err)
+	require.Len(t, token, 64)
+	info, err := os.Stat(apiTokenFile)
+	require.Nil(t, err)
+	require.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+	// the token is kept across the restarts of the daemon
+	again, err := apiToken()
+	require.Nil(t, err)
+	require.Equal(t, token, again)
+}
+
+func TestAPIMux(t *testing.T) {
+	r := &Runner{options: &Options{}, status: &daemonStatus{}}
+	handler := r.apiMux("secret")
+	call := func(method, path, contentType, authorization string) int {
+		req := httptest.NewRequest(method, path, strings.NewReader(`{}`))
+		if contentType != "" {
+			req.Header.Set("Content-Type", contentType)
+		}
+		if authorization != "" {
+			req.Header.Set("Authorization", authorization)
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	require.Equal(t, http.StatusUnauthorized, call(http.MethodGet, "/status", "", ""))
+	require.Equal(t, http.StatusUnauthorized, call(http.MethodGet, "/status", "", "Bearer wrong"))
+	require.Equal(t, http.StatusOK, call(http.MethodGet, "/status", "", "Bearer secret"))
+	// the cross-site form posts are refused
+	require.Equal(t, http.StatusUnsupportedMediaType, call(http.MethodPost, "/install", "text/plain", "Bearer secret"))
+	require.Equal(t, http.StatusUnsupportedMediaType, call(http.MethodPost, "/update", "application/x-www-form-urlencoded", "Bearer secret"))
+	// an empty install is refused before touching the tools
+	require.Equal(t, http.StatusInternalServerError, call(http.MethodPost, "/install", "application/json; charset=utf-8", "Bearer secret"))
+
+	// the unix socket is protected by its mode instead of a token
+	handler = r.apiMux("")
+	require.Equal(t, http.StatusOK, call(http.MethodGet, "/status", "", ""))
+}
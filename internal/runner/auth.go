@@ -0,0 +1,98 @@
+package runner
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/projectdiscovery/pdtm/pkg"
+)
+
+// exitTokenInvalid is the exit code of -auth-status when github rejects the token
+const exitTokenInvalid = 1
+
+// AuthStatus is the status of the github token printed by -auth-status
+type AuthStatus struct {
+	pkg.TokenStatus `yaml:",inline"`
+	// RateLimit is the github api rate limit of the token
+	RateLimit *RateLimitStatus `json:"rate_limit,omitempty" yaml:"rate_limit,omitempty"`
+}
+
+// validateToken checks GITHUB_TOKEN before the installs and updates, the
+// tokens aren't sent to the cache server of -source
+func (r *Runner) validateToken() error {
+	if r.options.Source != "" {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	return pkg.ValidateToken(ctx)
+}
+
+// showAuthStatus prints the validity, scopes, expiration and rate limit of GITHUB_TOKEN
+func (r *Runner) showAuthStatus() error {
+	if r.options.Source != "" {
+		return fmt.Errorf("the tokens aren't sent to the cache server %s, run -auth-status on it", r.options.Source)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	token, err := pkg.CheckToken(ctx)
+	if err != nil {
+		return fmt.Errorf("could not check GITHUB_TOKEN: %w", err)
+	}
+	status := AuthStatus{TokenStatus: *token}
+	if rate := token.Rate; rate != nil {
+		status.RateLimit = &RateLimitStatus{Limit: rate.Limit, Remaining: rate.Remaining, Reset: rate.Reset.Time}
+	}
+
+	if r.options.structured() {
+		if err := writeResults(r.options, []AuthStatus{status}); err != nil {
+			return err
+		}
+	} else {
+		printAuthStatus(status)
+	}
+	if status.Configured && !status.Valid {
+		return &ExitCodeError{Code: exitTokenInvalid, Err: fmt.Errorf("github rejected GITHUB_TOKEN, renew or unset it")}
+	}
+	return nil
+}
+
+// printAuthStatus prints the status of the token as a table
+func printAuthStatus(status AuthStatus) {
+	t := &table{header: []string{"FIELD", "VALUE"}}
+	if !status.Configured {
+		t.rows = append(t.rows, []string{"token", "GITHUB_TOKEN is not set, the requests are unauthenticated"})
+	} else {
+		validity := "valid"
+		if !status.Valid {
+			validity = status.Problem
+		}
+		t.rows = append(t.rows,
+			[]string{"token", "GITHUB_TOKEN (" + status.Type + ")"},
+			[]string{"status", validity},
+		)
+	}
+	if status.Valid {
+		scopes := strings.Join(status.Scopes, ", ")
+		if scopes == "" {
+			// the public releases don't need any scope
+			scopes = "none"
+		}
+		expires := "never"
+		if status.Expires != nil {
+			expires = fmt.Sprintf("%s (%d days left)", status.Expires.Format("2006-01-02 15:04"), int(time.Until(*status.Expires)/(24*time.Hour)))
+		}
+		t.rows = append(t.rows,
+			[]string{"login", dash(status.Login)},
+			[]string{"scopes", scopes},
+			[]string{"expires", expires},
+		)
+	}
+	if status.RateLimit != nil {
+		t.rows = append(t.rows, []string{"rate limit", fmt.Sprintf("%d/%d remaining, reset at %s", status.RateLimit.Remaining, status.RateLimit.Limit, status.RateLimit.Reset.Format("15:04"))})
+	}
+	t.print(os.Stdout)
+}
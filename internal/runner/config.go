@@ -0,0 +1,450 @@
+package runner
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/projectdiscovery/gologger"
+	"github.com/projectdiscovery/pdtm/pkg"
+	"github.com/projectdiscovery/pdtm/pkg/httpclient"
+	"github.com/projectdiscovery/pdtm/pkg/signature"
+	"github.com/projectdiscovery/pdtm/pkg/types"
+	"gopkg.in/yaml.v3"
+)
+
+// setting validates the value of a persistent setting and returns the
+// yaml node it's written as
+type setting func(value string) (*yaml.Node, error)
+
+// settings contains the settings managed with -config-set, keyed by their
+// (dot separated) path in the config file
+var settings = map[string]setting{
+	"binary-path":           pathSetting,
+	"link-dir":              pathSetting,
+	"cache-ttl":             durationSetting,
+	"cache-dir":             pathSetting,
+	"disable-update-check":  boolSetting,
+	"update-check-interval": durationSetting,
+	"disable-changelog":     boolSetting,
+	"no-color":              boolSetting,
+	"verbose":               boolSetting,
+	"go-bootstrap":          boolSetting,
+	"no-deps":               boolSetting,
+	"keep-quarantine":       boolSetting,
+	"paranoid":              boolSetting,
+	"ip-version":            oneOfSetting("4", "6", "auto"),
+	"resolvers":             resolversSetting,
+	"proxy-pac":             pacSetting,
+	"no-proxy":              stringSetting,
+	"source":                urlSetting,
+	"download-mirror":       mirrorSetting,
+	"host-concurrency":      intSetting,
+	"host-interval":         durationSetting,
+	"host-jitter":           durationSetting,
+	"log":                   boolSetting,
+	"notify":                boolSetting,
+	"notify-webhook":        urlSetting,
+	"report-url":            urlSetting,
+	"schedule":              scheduleSetting,
+	"blackout":              blackoutSetting,
+	"rename-alias":          boolSetting,
+	"log-max-size":          intSetting,
+	"log-max-age":           durationSetting,
+	"keep":                  intSetting,
+	"keep-age":              durationSetting,
+	"keep-size":             intSetting,
+	"registry-key":          registryKeySetting,
+	"provider.type":         oneOfSetting("github", "gitlab", "index", "plugin"),
+	"provider.url":          urlSetting,
+	"provider.group":        stringSetting,
+	"provider.token":        stringSetting,
+	"provider.region":       stringSetting,
+	"provider.sigv4":        boolSetting,
+	"provider.command":      stringSetting,
+}
+
+// projectSettings contains the settings set per project (eg. channels.nuclei)
+var projectSettings = map[string]setting{
+	"channels":  oneOfSetting(string(types.Stable), string(types.PreRelease)),
+	"data-dirs": pathSetting,
+	"aliases":   stringSetting,
+}
+
+func lookupSetting(key string) (setting, error) {
+	if s, ok := settings[key]; ok {
+		return s, nil
+	}
+	if section, project, ok := strings.Cut(key, "."); ok && project != "" {
+		if s, ok := projectSettings[section]; ok {
+			return s, nil
+		}
+	}
+	return nil, fmt.Errorf("unknown setting %s", key)
+}
+
+func stringSetting(value string) (*yaml.Node, error) {
+	return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: value}, nil
+}
+
+func boolSetting(value string) (*yaml.Node, error) {
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		return nil, fmt.Errorf("%s is not a boolean", value)
+	}
+	return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!bool", Value: strconv.FormatBool(b)}, nil
+}
+
+func intSetting(value string) (*yaml.Node, error) {
+	if _, err := strconv.Atoi(value); err != nil {
+		return nil, fmt.Errorf("%s is not a number", value)
+	}
+	return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!int", Value: value}, nil
+}
+
+func durationSetting(value string) (*yaml.Node, error) {
+	if _, err := time.ParseDuration(value); err != nil {
+		return nil, fmt.Errorf("%s is not a duration (eg. 1h30m)", value)
+	}
+	return stringSetting(value)
+}
+
+func pathSetting(value string) (*yaml.Node, error) {
+	if strings.HasPrefix(value, "~/") {
+		value = filepath.Join(homeDir, value[2:])
+	}
+	if !filepath.IsAbs(value) {
+		return nil, fmt.Errorf("%s is not an absolute path", value)
+	}
+	return stringSetting(filepath.Clean(value))
+}
+
+func urlSetting(value string) (*yaml.Node, error) {
+	u, err := url.Parse(value)
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return nil, fmt.Errorf("%s is not a url", value)
+	}
+	return stringSetting(value)
+}
+
+func mirrorSetting(value string) (*yaml.Node, error) {
+	if _, err := pkg.ParseDownloadMirror(value); err != nil {
+		return nil, err
+	}
+	return stringSetting(value)
+}
+
+func registryKeySetting(value string) (*yaml.Node, error) {
+	if _, err := signature.ParsePublicKey(value); err != nil {
+		return nil, fmt.Errorf("invalid minisign public key: %s", err)
+	}
+	return stringSetting(value)
+}
+
+func resolversSetting(value string) (*yaml.Node, error) {
+	for _, server := range strings.Split(value, ",") {
+		if err := httpclient.ValidateResolver(server); err != nil {
+			return nil, err
+		}
+	}
+	return stringSetting(value)
+}
+
+func pacSetting(value string) (*yaml.Node, error) {
+	if strings.HasPrefix(value, "http://") || strings.HasPrefix(value, "https://") {
+		return urlSetting(value)
+	}
+	return pathSetting(value)
+}
+
+func scheduleSetting(value string) (*yaml.Node, error) {
+	if _, err := pkg.ParseSchedule(strings.Split(value, ","), nil); err != nil {
+		return nil, err
+	}
+	return stringSetting(value)
+}
+
+func blackoutSetting(value string) (*yaml.Node, error) {
+	if _, err := pkg.ParseSchedule(nil, strings.Split(value, ",")); err != nil {
+		return nil, err
+	}
+	return stringSetting(value)
+}
+
+func oneOfSetting(values ...string) setting {
+	return func(value string) (*yaml.Node, error) {
+		for _, v := range values {
+			if v == value {
+				return stringSetting(value)
+			}
+		}
+		return nil, fmt.Errorf("%s is not one of %s", value, strings.Join(values, ", "))
+	}
+}
+
+// configFile is the config file edited by the -config-* options, the
+// comments of the file are kept
+type configFile struct {
+	location string
+	raw      []byte
+	doc      yaml.Node
+	// commentOnly is set when the file only contains comments, which are
+	// lost by the decoder
+	commentOnly bool
+}
+
+func readConfigFile(location string) (*configFile, error) {
+	c := &configFile{location: location}
+	b, err := os.ReadFile(location)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	c.raw = b
+	if err := yaml.Unmarshal(b, &c.doc); err != nil {
+		return nil, err
+	}
+	if len(c.doc.Content) == 0 {
+		// files with only comments (like the generated one) have no document
+		c.doc = yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{{Kind: yaml.MappingNode}}}
+		c.commentOnly = true
+		return c, nil
+	}
+	if c.doc.Content[0].Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("%s is not a yaml mapping", location)
+	}
+	return c, nil
+}
+
+func (c *configFile) root() *yaml.Node {
+	return c.doc.Content[0]
+}
+
+// lookup returns the mapping containing the key and the index of the key
+// node in it, the missing mappings are created when create is true
+func (c *configFile) lookup(key string, create bool) (*yaml.Node, int) {
+	parts := strings.Split(key, ".")
+	node := c.root()
+	for i, part := range parts {
+		index := -1
+		for j := 0; j+1 < len(node.Content); j += 2 {
+			if node.Content[j].Value == part {
+				index = j
+				break
+			}
+		}
+		if i == len(parts)-1 {
+			return node, index
+		}
+		switch {
+		case index >= 0 && node.Content[index+1].Kind == yaml.MappingNode:
+			node = node.Content[index+1]
+		case create && index >= 0:
+			node.Content[index+1] = &yaml.Node{Kind: yaml.MappingNode}
+			node = node.Content[index+1]
+		case create:
+			child := &yaml.Node{Kind: yaml.MappingNode}
+			node.Content = append(node.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: part}, child)
+			node = child
+		default:
+			return nil, -1
+		}
+	}
+	return nil, -1
+}
+
+func (c *configFile) get(key string) (string, bool) {
+	node, index := c.lookup(key, false)
+	if node == nil || index < 0 {
+		return "", false
+	}
+	return node.Content[index+1].Value, true
+}
+
+func (c *configFile) set(key string, value *yaml.Node) {
+	node, index := c.lookup(key, true)
+	if index >= 0 {
+		node.Content[index+1] = value
+		return
+	}
+	parts := strings.Split(key, ".")
+	node.Content = append(node.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: parts[len(parts)-1]}, value)
+}
+
+func (c *configFile) unset(key string) bool {
+	node, index := c.lookup(key, false)
+	if node == nil || index < 0 {
+		return false
+	}
+	// keep the comments written above the key
+	if comment := node.Content[index].HeadComment; comment != "" {
+		if index+2 < len(node.Content) {
+			next := node.Content[index+2]
+			next.HeadComment = strings.TrimSpace(comment + "\n\n" + next.HeadComment)
+		} else {
+			node.FootComment = strings.TrimSpace(node.FootComment + "\n\n" + comment)
+		}
+	}
+	node.Content = append(node.Content[:index], node.Content[index+2:]...)
+	// remove the mappings left empty
+	if parent, _, ok := cutLast(key); ok && len(node.Content) == 0 {
+		c.unset(parent)
+	}
+	return true
+}
+
+// values returns the managed settings set in the file
+func (c *configFile) values() map[string]string {
+	values := make(map[string]string)
+	root := c.root()
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		key, value := root.Content[i].Value, root.Content[i+1]
+		if value.Kind != yaml.MappingNode {
+			if _, ok := settings[key]; ok {
+				values[key] = value.Value
+			}
+			continue
+		}
+		for j := 0; j+1 < len(value.Content); j += 2 {
+			subKey := key + "." + value.Content[j].Value
+			if _, err := lookupSetting(subKey); err == nil {
+				values[subKey] = value.Content[j+1].Value
+			}
+		}
+	}
+	return values
+}
+
+func (c *configFile) save() error {
+	var b []byte
+	if len(c.root().Content) > 0 {
+		var buf bytes.Buffer
+		enc := yaml.NewEncoder(&buf)
+		enc.SetIndent(2)
+		if err := enc.Encode(&c.doc); err != nil {
+			return err
+		}
+		if err := enc.Close(); err != nil {
+			return err
+		}
+		b = buf.Bytes()
+	} else {
+		// an empty mapping is written as {}, only its comments are kept
+		var comments []string
+		for _, comment := range []string{c.doc.HeadComment, c.root().HeadComment, c.root().FootComment, c.doc.FootComment} {
+			if comment != "" {
+				comments = append(comments, comment)
+			}
+		}
+		if len(comments) > 0 {
+			b = []byte(strings.Join(comments, "\n\n") + "\n")
+		}
+	}
+	if c.commentOnly && len(bytes.TrimSpace(c.raw)) > 0 {
+		b = append(append(bytes.TrimRight(c.raw, "\n"), '\n', '\n'), b...)
+	}
+	if err := os.MkdirAll(filepath.Dir(c.location), os.ModePerm); err != nil {
+		return err
+	}
+	return os.WriteFile(c.location, b, 0644)
+}
+
+// joinAssignments joins the values containing commas (eg. resolvers or
+// schedule) split by the flag, the parts that don't start with a setting
+// belong to the previous assignment
+func joinAssignments(parts []string) []string {
+	var assignments []string
+	for _, part := range parts {
+		key, _, ok := strings.Cut(part, "=")
+		if _, err := lookupSetting(key); (!ok || err != nil) && len(assignments) > 0 {
+			assignments[len(assignments)-1] += "," + part
+			continue
+		}
+		assignments = append(assignments, part)
+	}
+	return assignments
+}
+
+func cutLast(key string) (string, string, bool) {
+	i := strings.LastIndex(key, ".")
+	if i < 0 {
+		return "", key, false
+	}
+	return key[:i], key[i+1:], true
+}
+
+// maskToken hides the tokens set in the config file, references to
+// environment variables ($NAME) are shown
+func maskToken(key, value string) string {
+	if !strings.HasSuffix(key, "token") || value == "" || strings.HasPrefix(value, "$") {
+		return value
+	}
+	return "********"
+}
+
+// manageConfig handles the -config-* options, it returns false if none was given
+func (r *Runner) manageConfig() (bool, error) {
+	if !r.options.ConfigList && r.options.ConfigGet == "" && len(r.options.ConfigSet) == 0 && len(r.options.ConfigUnset) == 0 {
+		return false, nil
+	}
+	c, err := readConfigFile(r.options.ConfigFile)
+	if err != nil {
+		return true, err
+	}
+
+	switch {
+	case r.options.ConfigList:
+		values := c.values()
+		keys := make([]string, 0, len(values))
+		for key := range values {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			gologger.Silent().Msgf("%s=%s", key, maskToken(key, values[key]))
+		}
+		return true, nil
+	case r.options.ConfigGet != "":
+		if _, err := lookupSetting(r.options.ConfigGet); err != nil {
+			return true, err
+		}
+		value, ok := c.get(r.options.ConfigGet)
+		if !ok {
+			return true, fmt.Errorf("%s is not set", r.options.ConfigGet)
+		}
+		gologger.Silent().Msg(maskToken(r.options.ConfigGet, value))
+		return true, nil
+	}
+
+	for _, assignment := range joinAssignments(r.options.ConfigSet) {
+		key, value, ok := strings.Cut(assignment, "=")
+		if !ok {
+			return true, fmt.Errorf("invalid setting %s, expected key=value", assignment)
+		}
+		s, err := lookupSetting(key)
+		if err != nil {
+			return true, err
+		}
+		node, err := s(value)
+		if err != nil {
+			return true, fmt.Errorf("invalid value for %s: %s", key, err)
+		}
+		c.set(key, node)
+		gologger.Info().Msgf("set %s to %s", key, maskToken(key, node.Value))
+	}
+	for _, key := range r.options.ConfigUnset {
+		if _, err := lookupSetting(key); err != nil {
+			return true, err
+		}
+		if c.unset(key) {
+			gologger.Info().Msgf("unset %s", key)
+		}
+	}
+	return true, c.save()
+}
@@ -0,0 +1,101 @@
+package runner
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/projectdiscovery/goflags"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLookupSetting(t *testing.T) {
+	tests := []struct {
+		key   string
+		value string
+		valid bool
+	}{
+		{"provider.type", "gitlab", true},
+		{"provider.type", "bitbucket", false},
+		{"provider.url", "https://gitlab.example.com", true},
+		{"provider.url", "gitlab.example.com", false},
+		{"provider.sigv4", "true", true},
+		{"provider.sigv4", "yes", false},
+		{"channels.nuclei", "pre-release", true},
+		{"channels.nuclei", "beta", false},
+		{"data-dirs.nuclei-templates", "/opt/nuclei-templates", true},
+		{"data-dirs.nuclei-templates", "nuclei-templates", false},
+		{"aliases.nu", "nuclei", true},
+		{"cache-ttl", "1h30m", true},
+		{"cache-ttl", "90", false},
+		{"keep", "3", true},
+		{"ip-version", "5", false},
+	}
+	for _, tt := range tests {
+		s, err := lookupSetting(tt.key)
+		require.Nil(t, err, tt.key)
+		_, err = s(tt.value)
+		require.Equal(t, tt.valid, err == nil, "%s=%s: %v", tt.key, tt.value, err)
+	}
+
+	for _, key := range []string{"channels", "channels.", "provider", "provider.unknown", "unknown.nuclei", "unknown"} {
+		_, err := lookupSetting(key)
+		require.NotNil(t, err, key)
+	}
+}
+
+func TestConfigSections(t *testing.T) {
+	location := filepath.Join(t.TempDir(), "config.yaml")
+	require.Nil(t, os.WriteFile(location, []byte(`# fetch the releases from gitlab
+provider:
+  type: gitlab
+  # the self hosted instance
+  url: https://gitlab.example.com
+channels:
+  nuclei: stable
+aliases: nu
+`), 0644))
+	r := &Runner{options: &Options{ConfigFile: location}}
+	run := func(set, unset goflags.StringSlice) error {
+		r.options.ConfigSet, r.options.ConfigUnset = set, unset
+		_, err := r.manageConfig()
+		return err
+	}
+
+	// the section is extended, a scalar in place of a section is replaced
+	require.Nil(t, run(goflags.StringSlice{"provider.group=projectdiscovery", "channels.dnsx=pre-release", "aliases.nu=nuclei"}, nil))
+	c, err := readConfigFile(location)
+	require.Nil(t, err)
+	require.Equal(t, map[string]string{
+		"provider.type":   "gitlab",
+		"provider.url":    "https://gitlab.example.com",
+		"provider.group":  "projectdiscovery",
+		"channels.nuclei": "stable",
+		"channels.dnsx":   "pre-release",
+		"aliases.nu":      "nuclei",
+	}, c.values())
+
+	// invalid values are rejected without writing the file
+	before, err := os.ReadFile(location)
+	require.Nil(t, err)
+	require.ErrorContains(t, run(goflags.StringSlice{"channels.naabu=beta"}, nil), "invalid value for channels.naabu")
+	require.ErrorContains(t, run(goflags.StringSlice{"provider.kind=gitlab"}, nil), "unknown setting provider.kind")
+	after, err := os.ReadFile(location)
+	require.Nil(t, err)
+	require.Equal(t, string(before), string(after))
+
+	// the comments are kept and the emptied sections are removed
+	require.Nil(t, run(nil, goflags.StringSlice{"provider.url", "channels.nuclei", "channels.dnsx"}))
+	c, err = readConfigFile(location)
+	require.Nil(t, err)
+	_, ok := c.get("channels.nuclei")
+	require.False(t, ok)
+	value, ok := c.get("provider.group")
+	require.True(t, ok)
+	require.Equal(t, "projectdiscovery", value)
+	content, err := os.ReadFile(location)
+	require.Nil(t, err)
+	require.Contains(t, string(content), "# fetch the releases from gitlab")
+	require.Contains(t, string(content), "# the self hosted instance")
+	require.NotContains(t, string(content), "channels")
+}
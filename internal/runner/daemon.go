@@ -0,0 +1,133 @@
+package runner
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/projectdiscovery/gologger"
+	"github.com/projectdiscovery/pdtm/pkg"
+	"github.com/projectdiscovery/pdtm/pkg/lock"
+	"github.com/projectdiscovery/pdtm/pkg/types"
+	errorutil "github.com/projectdiscovery/utils/errors"
+)
+
+// daemon updates all the projects every -daemon interval until interrupted,
+// the metrics of the checks are served on -metrics and the api on -api
+func (r *Runner) daemon() error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if r.options.Metrics != "" {
+		server := &http.Server{Addr: r.options.Metrics, Handler: r.status}
+		go func() {
+			if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+				gologger.Error().Msgf("could not serve the metrics on %s: %s", r.options.Metrics, err)
+			}
+		}()
+		defer server.Close()
+		gologger.Info().Msgf("serving the metrics on http://%s/metrics", r.options.Metrics)
+	}
+	if r.options.API != "" {
+		server, err := r.serveAPI(r.options.API)
+		if err != nil {
+			return errorutil.NewWithErr(err).Msgf("could not serve the api on %s", r.options.API)
+		}
+		defer server.Close()
+		gologger.Info().Msgf("serving the api on %s", r.options.API)
+	}
+
+	// the daemon doesn't report the tools that aren't installed
+	r.options.UpdateAll = true
+	started := time.Now()
+	r.status.save(started)
+	defer os.Remove(daemonStatusFile)
+	if r.options.Daemon <= 0 {
+		<-ctx.Done()
+		return nil
+	}
+	gologger.Info().Msgf("updating all the projects every %s", r.options.Daemon)
+	for {
+		if _, err := r.updateCheck(nil); err != nil {
+			gologger.Error().Msgf("update check failed: %s", err)
+		}
+		r.status.scheduled(time.Now().Add(r.options.Daemon))
+		r.status.save(started)
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(r.options.Daemon):
+		}
+	}
+}
+
+// withLock calls fn with the tool list while holding the lock, the lock is
+// only held during the call so that other pdtm runs can happen in between
+func (r *Runner) withLock(fn func(toolList []types.Tool)) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	l, err := lock.Acquire(lockFile, lockTimeout)
+	if err != nil {
+		return errorutil.NewWithErr(err).Msgf("could not lock %s", lockFile)
+	}
+	r.lock = l
+	defer r.Close()
+
+	if r.options.Paranoid {
+		r.checkModified()
+	}
+	toolList, err := r.fetchToolList()
+	if err != nil {
+		return err
+	}
+	toolList = withDataPacks(toolList)
+	fn(toolList)
+	r.sendReport()
+	if r.options.LinkDir != "" {
+		r.syncLinks(toolList)
+	}
+	return nil
+}
+
+// updateCheck updates the given installed projects (all when empty)
+func (r *Runner) updateCheck(names []string) ([]UpdateResult, error) {
+	var results []UpdateResult
+	var installed int
+	var tokenErr error
+	err := r.withLock(func(toolList []types.Tool) {
+		for _, tool := range toolList {
+			if r.isUpdatable(r.pathFor(tool.Name), tool) {
+				installed++
+			}
+		}
+		names = currentNames(toolList, names)
+		if len(names) == 0 {
+			names = r.scheduledUpdates(toolList)
+		}
+		// a rejected token fails every download, better stop before the batch
+		if len(names) > 0 {
+			if tokenErr = r.validateToken(); tokenErr != nil {
+				return
+			}
+		}
+		r.migrateRenamed(toolList, names)
+		applyCapabilities := pkg.BatchCapabilities()
+		for _, name := range names {
+			if result, ok := r.update(toolList, name); ok {
+				results = append(results, result)
+				r.report(ReportedTool{Name: result.Name, Action: actionUpdate, Version: result.Version, Status: result.Outcome, Reason: result.Reason})
+			}
+		}
+		applyCapabilities()
+		r.notifyUpdates(results)
+	})
+	if err == nil {
+		err = tokenErr
+	}
+	r.status.record(installed, results, err)
+	return results, err
+}
@@ -0,0 +1,180 @@
+package runner
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"time"
+
+	"github.com/projectdiscovery/gologger"
+	"github.com/projectdiscovery/pdtm/pkg"
+	"github.com/projectdiscovery/pdtm/pkg/lock"
+	"github.com/projectdiscovery/pdtm/pkg/path"
+	"github.com/projectdiscovery/pdtm/pkg/state"
+	"github.com/projectdiscovery/pdtm/pkg/types"
+)
+
+// StatusSummary is the operational snapshot shown by -status
+type StatusSummary struct {
+	Installed    int `json:"installed" yaml:"installed"`
+	Outdated     int `json:"outdated" yaml:"outdated"`
+	Broken       int `json:"broken" yaml:"broken"`
+	NotInstalled int `json:"not_installed" yaml:"not_installed"`
+	// DiskUsage is the size in bytes of the managed paths
+	DiskUsage int64 `json:"disk_usage" yaml:"disk_usage"`
+	// Paths contains the managed paths and whether they are in $PATH
+	Paths map[string]bool `json:"paths" yaml:"paths"`
+	// LastUpdateCheck is the time of the last pdtm version check
+	LastUpdateCheck *time.Time `json:"last_update_check,omitempty" yaml:"last_update_check,omitempty"`
+	// LatestVersion is the latest pdtm version found by the last check
+	LatestVersion string `json:"latest_version,omitempty" yaml:"latest_version,omitempty"`
+	// RateLimit is the github api rate limit, nil when it couldn't be fetched
+	RateLimit *RateLimitStatus `json:"rate_limit,omitempty" yaml:"rate_limit,omitempty"`
+	// Service is true when the service of -service-install is installed
+	Service bool `json:"service" yaml:"service"`
+	// Daemon is the status of the running daemon, nil when none is running
+	Daemon *runningDaemon `json:"daemon,omitempty" yaml:"daemon,omitempty"`
+}
+
+// RateLimitStatus is the github api rate limit
+type RateLimitStatus struct {
+	Limit     int       `json:"limit" yaml:"limit"`
+	Remaining int       `json:"remaining" yaml:"remaining"`
+	Reset     time.Time `json:"reset" yaml:"reset"`
+}
+
+// showStatus prints the summary of the installed tools and of the pdtm setup
+func (r *Runner) showStatus(toolList []types.Tool) error {
+	summary := StatusSummary{Paths: make(map[string]bool)}
+	states := make(map[string]*state.State)
+	for _, tool := range toolList {
+		dir := r.pathFor(tool.Name)
+		st, ok := states[dir]
+		if !ok {
+			st, _ = state.Load(dir)
+			states[dir] = st
+		}
+		switch listTool(tool, dir, st).Status {
+		case statusLatest:
+			summary.Installed++
+		case statusOutdated:
+			summary.Installed++
+			summary.Outdated++
+		case statusBroken:
+			summary.Broken++
+		case statusNotInstalled:
+			summary.NotInstalled++
+		}
+	}
+	for _, dir := range r.managedPaths() {
+		summary.Paths[dir] = path.IsSet(dir)
+		summary.DiskUsage += dirSize(dir)
+	}
+
+	var check versionCheck
+	if b, err := os.ReadFile(versionCheckFile); err == nil && json.Unmarshal(b, &check) == nil && !check.Checked.IsZero() {
+		summary.LastUpdateCheck, summary.LatestVersion = &check.Checked, check.Latest
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if rate, err := pkg.Github.RateLimit(ctx); err == nil {
+		summary.RateLimit = &RateLimitStatus{Limit: rate.Limit, Remaining: rate.Remaining, Reset: rate.Reset.Time}
+	} else {
+		gologger.Verbose().Msgf("could not fetch the github rate limit: %s", err)
+	}
+
+	summary.Service = serviceInstalled()
+	var daemon runningDaemon
+	if b, err := os.ReadFile(daemonStatusFile); err == nil && json.Unmarshal(b, &daemon) == nil && lock.ProcessAlive(daemon.PID) {
+		summary.Daemon = &daemon
+	}
+
+	if r.options.structured() {
+		return writeResults(r.options, []StatusSummary{summary})
+	}
+	r.printStatus(summary)
+	return nil
+}
+
+// printStatus prints the summary as a table
+func (r *Runner) printStatus(summary StatusSummary) {
+	t := &table{header: []string{"CHECK", "STATUS"}}
+	t.rows = append(t.rows, []string{"projects", fmt.Sprintf("%d installed, %d outdated, %d broken, %d not installed", summary.Installed, summary.Outdated, summary.Broken, summary.NotInstalled)})
+	t.rows = append(t.rows, []string{"disk usage", formatSize(summary.DiskUsage)})
+	for _, dir := range r.managedPaths() {
+		health := "in $PATH"
+		if !summary.Paths[dir] {
+			health = "not in $PATH, run pdtm -install-path"
+		}
+		t.rows = append(t.rows, []string{"path", dir + " (" + health + ")"})
+	}
+	lastCheck := "never"
+	if summary.LastUpdateCheck != nil {
+		lastCheck = summary.LastUpdateCheck.Format("2006-01-02 15:04")
+		if summary.LatestVersion != "" {
+			lastCheck += " (latest " + summary.LatestVersion + ")"
+		}
+	}
+	t.rows = append(t.rows, []string{"last update check", lastCheck})
+	rateLimit := "unknown"
+	if summary.RateLimit != nil {
+		rateLimit = fmt.Sprintf("%d/%d remaining, reset at %s", summary.RateLimit.Remaining, summary.RateLimit.Limit, summary.RateLimit.Reset.Format("15:04"))
+	}
+	t.rows = append(t.rows, []string{"github rate limit", rateLimit})
+	service := "not installed"
+	if summary.Service {
+		service = "installed"
+	}
+	t.rows = append(t.rows, []string{"service", service})
+	daemon := "not running"
+	if summary.Daemon != nil {
+		daemon = fmt.Sprintf("running (pid %d), %d checks, %d updates, %d failures", summary.Daemon.PID, summary.Daemon.Checks, summary.Daemon.Updates, summary.Daemon.Failures)
+		if summary.Daemon.NextCheck != nil {
+			daemon += ", next check at " + summary.Daemon.NextCheck.Format("2006-01-02 15:04")
+		}
+	}
+	t.rows = append(t.rows, []string{"daemon", daemon})
+	t.color = func(row, column int, cell string) string {
+		if column == 0 {
+			return au.Bold(cell).String()
+		}
+		return cell
+	}
+	t.print(os.Stdout)
+}
+
+// dirSize returns the size of the files in dir
+func dirSize(dir string) int64 {
+	var size int64
+	_ = filepath.WalkDir(dir, func(_ string, d fs.DirEntry, err error) error {
+		if err != nil || !d.Type().IsRegular() {
+			return nil
+		}
+		if fi, err := d.Info(); err == nil {
+			size += fi.Size()
+		}
+		return nil
+	})
+	return size
+}
+
+// serviceInstalled returns true if the service of -service-install is installed
+func serviceInstalled() bool {
+	switch runtime.GOOS {
+	case "linux":
+		_, err := os.Stat(filepath.Join(systemdDir, serviceName+".timer"))
+		return err == nil
+	case "darwin":
+		_, err := os.Stat(launchdFile)
+		return err == nil
+	case "windows":
+		return runServiceCommand("schtasks", "/Query", "/TN", serviceName) == nil
+	default:
+		return false
+	}
+}
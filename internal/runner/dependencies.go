@@ -0,0 +1,87 @@
+package runner
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/projectdiscovery/gologger"
+	"github.com/projectdiscovery/pdtm/pkg/types"
+	"github.com/projectdiscovery/pdtm/pkg/utils"
+)
+
+// withDependencies returns the given tools (name or name@version) preceded by
+// their dependency chain so that dependencies are installed first
+func (r *Runner) withDependencies(toolList []types.Tool, names []string) ([]string, error) {
+	const (
+		visiting = iota + 1
+		visited
+	)
+	status := make(map[string]int)
+	r.pulledBy = make(map[string][]string)
+	var ordered []string
+	// dependencies requested explicitly keep the requested version
+	requested := make(map[string]string, len(names))
+	for _, entry := range names {
+		name, _ := splitVersion(entry)
+		requested[strings.ToLower(name)] = entry
+	}
+
+	var visit func(entry string, chain []string) error
+	visit = func(entry string, chain []string) error {
+		name, _ := splitVersion(entry)
+		key := strings.ToLower(name)
+		switch status[key] {
+		case visiting:
+			return fmt.Errorf("dependency cycle: %s", strings.Join(append(chain, name), " -> "))
+		case visited:
+			return nil
+		}
+		status[key] = visiting
+		if i, ok := utils.Contains(toolList, name); ok {
+			for _, dependency := range r.dependencies(toolList[i]) {
+				if _, ok := utils.Contains(toolList, dependency); !ok {
+					gologger.Warning().Msgf("dependency %s of %s not found in the list", dependency, name)
+					continue
+				}
+				if entry, ok := requested[strings.ToLower(dependency)]; ok {
+					dependency = entry
+				} else {
+					r.pulledBy[strings.ToLower(dependency)] = append(r.pulledBy[strings.ToLower(dependency)], name)
+				}
+				if err := visit(dependency, append(chain, name)); err != nil {
+					return err
+				}
+			}
+		}
+		status[key] = visited
+		ordered = append(ordered, entry)
+		return nil
+	}
+
+	for _, entry := range names {
+		if err := visit(entry, nil); err != nil {
+			return nil, err
+		}
+	}
+	return ordered, nil
+}
+
+// dependencies returns the dependencies declared by the tool metadata and the config file
+func (r *Runner) dependencies(tool types.Tool) []string {
+	dependencies := append([]string{}, tool.Dependencies...)
+	for name, extra := range r.options.Dependencies {
+		if strings.EqualFold(name, tool.Name) {
+			dependencies = append(dependencies, extra...)
+		}
+	}
+	return dependencies
+}
+
+// installSource returns the git ref and the archive to install the tool from,
+// the dependencies pulled by the requested tools are installed with the default options
+func (r *Runner) installSource(tool types.Tool) (ref, archive string) {
+	if _, pulled := r.pulledBy[strings.ToLower(tool.Name)]; pulled {
+		return "", ""
+	}
+	return r.options.gitRef(), r.options.Archive
+}
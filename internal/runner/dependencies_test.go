@@ -0,0 +1,53 @@
+package runner
+
+import (
+	"testing"
+
+	"github.com/projectdiscovery/pdtm/pkg/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithDependencies(t *testing.T) {
+	toolList := []types.Tool{
+		{Name: "nuclei", Dependencies: []string{"httpx", "nuclei-templates"}},
+		{Name: "httpx", Dependencies: []string{"dnsx"}},
+		{Name: "dnsx"},
+		{Name: "nuclei-templates"},
+	}
+	r := &Runner{options: &Options{Archive: "nuclei.zip", Ref: "dev"}}
+	ordered, err := r.withDependencies(toolList, []string{"nuclei", "dnsx@1.1.1"})
+	require.Nil(t, err)
+	require.Equal(t, []string{"dnsx@1.1.1", "httpx", "nuclei-templates", "nuclei"}, ordered)
+	require.Equal(t, map[string][]string{"httpx": {"nuclei"}, "nuclei-templates": {"nuclei"}}, r.pulledBy)
+
+	// only the requested tools are installed with -archive and -ref
+	ref, archive := r.installSource(toolList[0])
+	require.Equal(t, "dev", ref)
+	require.Equal(t, "nuclei.zip", archive)
+	ref, archive = r.installSource(toolList[1])
+	require.Empty(t, ref)
+	require.Empty(t, archive)
+	ref, _ = r.installSource(toolList[2])
+	require.Equal(t, "dev", ref, "the dependency requested explicitly keeps the options")
+
+	// the dependencies of the config file are added to the metadata ones
+	r.options.Dependencies = map[string][]string{"DNSX": {"nuclei-templates"}}
+	ordered, err = r.withDependencies(toolList, []string{"dnsx"})
+	require.Nil(t, err)
+	require.Equal(t, []string{"nuclei-templates", "dnsx"}, ordered)
+}
+
+func TestWithDependenciesCycle(t *testing.T) {
+	toolList := []types.Tool{
+		{Name: "nuclei", Dependencies: []string{"httpx"}},
+		{Name: "httpx", Dependencies: []string{"dnsx"}},
+		{Name: "dnsx", Dependencies: []string{"nuclei"}},
+	}
+	r := &Runner{options: &Options{}}
+	_, err := r.withDependencies(toolList, []string{"nuclei"})
+	require.EqualError(t, err, "dependency cycle: nuclei -> httpx -> dnsx -> nuclei")
+
+	r.options.Dependencies = map[string][]string{"dnsx": {"dnsx"}}
+	_, err = r.withDependencies(toolList[2:], []string{"dnsx"})
+	require.EqualError(t, err, "dependency cycle: dnsx -> dnsx")
+}
@@ -0,0 +1,87 @@
+package runner
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"text/tabwriter"
+
+	"github.com/projectdiscovery/gologger"
+	"github.com/projectdiscovery/pdtm/pkg"
+	"github.com/projectdiscovery/pdtm/pkg/types"
+)
+
+// breakingChange matches the release notes announcing breaking changes
+var breakingChange = regexp.MustCompile(`(?i)\bbreaking\b|backwards?[ -]incompatible`)
+
+// PendingUpdate describes the update of an outdated tool
+type PendingUpdate struct {
+	OutdatedTool `yaml:",inline"`
+	// Releases is the number of releases between the installed and latest version
+	Releases int `json:"releases,omitempty" yaml:"releases,omitempty"`
+	// Released is the publication date of the latest release
+	Released string `json:"released,omitempty" yaml:"released,omitempty"`
+	// Size is the download size of the latest release (0 if unknown)
+	Size int64 `json:"size,omitempty" yaml:"size,omitempty"`
+	// Breaking is set when the release notes mention breaking changes
+	Breaking bool `json:"breaking" yaml:"breaking"`
+}
+
+// showDiff previews the pending updates of the outdated tools
+func (r *Runner) showDiff(tools []types.Tool) error {
+	outdated, failed := r.checkOutdated(tools)
+	updates := make([]PendingUpdate, 0, len(outdated))
+	for _, result := range outdated {
+		update := PendingUpdate{OutdatedTool: result.OutdatedTool}
+		if size, ok := pkg.DownloadSize(result.latest); ok {
+			update.Size = size
+		}
+		releases, err := pkg.ReleasesBetween(result.latest, result.Installed, result.Latest)
+		if err != nil {
+			gologger.Verbose().Msgf("could not get the releases of %s: %s", result.Name, err)
+		}
+		update.Releases = len(releases)
+		for _, release := range releases {
+			if release.Version == result.Latest && !release.PublishedAt.IsZero() {
+				update.Released = release.PublishedAt.Format("2006-01-02")
+			}
+			if breakingChange.MatchString(release.Notes) {
+				update.Breaking = true
+			}
+		}
+		updates = append(updates, update)
+	}
+
+	if r.options.structured() {
+		if err := writeResults(r.options, updates); err != nil {
+			return err
+		}
+	} else if len(updates) > 0 {
+		tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "\nPROJECT\tINSTALLED\tLATEST\tGAP\tRELEASES\tRELEASED\tSIZE\tBREAKING")
+		for _, update := range updates {
+			// the breaking changes are unknown without the release notes
+			releases, released, size, breaking := "-", "-", "-", "-"
+			if update.Releases > 0 {
+				releases, breaking = fmt.Sprint(update.Releases), "no"
+			}
+			if update.Released != "" {
+				released = update.Released
+			}
+			if update.Size > 0 {
+				size = formatSize(update.Size)
+			}
+			if update.Breaking {
+				breaking = "yes"
+			}
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n", update.Name, update.Installed, update.Latest, update.Severity, releases, released, size, breaking)
+		}
+		tw.Flush()
+	} else {
+		gologger.Info().Msg("all projects are up to date")
+	}
+	if failed > 0 {
+		return &ExitCodeError{Code: exitCheckFailed, Err: fmt.Errorf("could not check %d projects", failed)}
+	}
+	return nil
+}
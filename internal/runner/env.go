@@ -0,0 +1,49 @@
+package runner
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/projectdiscovery/goflags"
+)
+
+// envPrefix is the prefix of the environment variables setting the flags
+const envPrefix = "PDTM_"
+
+// envName returns the environment variable of the flag (eg. PDTM_BINARY_PATH)
+func envName(flagName string) string {
+	return envPrefix + strings.ToUpper(strings.ReplaceAll(flagName, "-", "_"))
+}
+
+// applyEnv sets the flags that weren't given on the command line from their
+// environment variable, so the precedence is flag > env > config file
+func applyEnv(fs *flag.FlagSet) error {
+	set := make(map[flag.Value]bool)
+	fs.Visit(func(fl *flag.Flag) {
+		set[fl.Value] = true
+	})
+	// the short and long name of a flag share the value, the long name is
+	// used for the variable
+	names := make(map[flag.Value]string)
+	fs.VisitAll(func(fl *flag.Flag) {
+		if len(fl.Name) > len(names[fl.Value]) {
+			names[fl.Value] = fl.Name
+		}
+	})
+	for value, name := range names {
+		env, ok := os.LookupEnv(envName(name))
+		if !ok || set[value] {
+			continue
+		}
+		// the variable replaces the values of the config file
+		if slice, ok := value.(*goflags.StringSlice); ok {
+			*slice = nil
+		}
+		if err := value.Set(env); err != nil {
+			return fmt.Errorf("invalid value %q for %s: %s", env, envName(name), err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,165 @@
+package runner
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/projectdiscovery/gologger"
+	"github.com/projectdiscovery/pdtm/pkg"
+	"github.com/projectdiscovery/pdtm/pkg/lock"
+	"github.com/projectdiscovery/pdtm/pkg/path"
+	"github.com/projectdiscovery/pdtm/pkg/state"
+	"github.com/projectdiscovery/pdtm/pkg/types"
+	"github.com/projectdiscovery/pdtm/pkg/utils"
+	errorutil "github.com/projectdiscovery/utils/errors"
+)
+
+// runWith installs the project versions of -with in the exec cache and runs
+// the command with them first in $PATH, the installed projects are untouched
+func (r *Runner) runWith() error {
+	if len(r.options.Command) == 0 {
+		return errors.New("-with runs the command given after --, eg. pdtm -with nuclei@v3.0.4 -- nuclei -version")
+	}
+	l, err := lock.Acquire(lockFile, lockTimeout)
+	if err != nil {
+		return errorutil.NewWithErr(err).Msgf("could not lock %s", lockFile)
+	}
+	r.lock = l
+	// the command runs the installed projects too
+	if r.options.Paranoid {
+		r.checkModified()
+	}
+	dirs, err := r.installWith()
+	// other runs don't have to wait for the command
+	r.Close()
+	if err != nil {
+		return err
+	}
+
+	name := r.options.Command[0]
+	for _, dir := range dirs {
+		if executablePath, exists := path.GetExecutablePath(dir, name); exists {
+			name = executablePath
+			break
+		}
+	}
+	cmd := exec.Command(name, r.options.Command[1:]...)
+	cmd.Env = append(os.Environ(), "PATH="+strings.Join(append(dirs, os.Getenv("PATH")), string(os.PathListSeparator)))
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	err = cmd.Run()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return &ExitCodeError{Code: exitErr.ExitCode()}
+	}
+	return err
+}
+
+// installWith installs the project versions of -with (latest without
+// version) in their exec cache directory and returns the directories
+func (r *Runner) installWith() ([]string, error) {
+	toolList, err := r.fetchToolList()
+	if err != nil {
+		return nil, err
+	}
+	r.resolveAliases(toolList)
+	var dirs []string
+	var validated bool
+	for _, toolName := range r.options.resolveAliases(r.options.With) {
+		name, version := splitVersion(toolName)
+		i, ok := utils.Contains(toolList, name)
+		if !ok || pkg.IsDataPack(toolList[i]) {
+			return nil, fmt.Errorf("%s", unknownTool(toolList, name))
+		}
+		tool, err := r.resolve(toolList[i], version)
+		if err != nil {
+			return nil, fmt.Errorf("could not resolve %s: %s", toolName, err)
+		}
+		dir := filepath.Join(execDir, strings.ToLower(tool.Name), strings.TrimPrefix(tool.Version, "v"))
+		if execInstalled(dir, tool) {
+			gologger.Verbose().Msgf("using %s %s from %s", tool.Name, tool.Version, dir)
+		} else {
+			// a rejected token fails every download
+			if !validated {
+				if err := r.validateToken(); err != nil {
+					return nil, err
+				}
+				validated = true
+			}
+			// an interrupted or modified install is installed again
+			if err := os.RemoveAll(dir); err != nil {
+				return nil, err
+			}
+			if !r.install(dir, tool) {
+				return nil, fmt.Errorf("could not install %s %s", tool.Name, tool.Version)
+			}
+		}
+		// the modification time is the last use for -gc
+		now := time.Now()
+		_ = os.Chtimes(dir, now, now)
+		dirs = append(dirs, dir)
+	}
+	return dirs, nil
+}
+
+// execInstalled returns true if the tool was installed in its exec cache
+// directory and wasn't modified since, an interrupted install leaves a binary
+// that isn't recorded or doesn't match its recorded hash
+func execInstalled(dir string, tool types.Tool) bool {
+	if _, exists := path.GetExecutablePath(dir, tool.Name); !exists {
+		return false
+	}
+	st, err := state.Load(dir)
+	if err != nil {
+		return false
+	}
+	if installed, ok := st.Get(tool.Name); !ok || installed.Hash == "" {
+		return false
+	}
+	modified, err := pkg.ModifiedBinaries(dir)
+	return err == nil && len(modified) == 0
+}
+
+// pruneExecCache removes the project versions of the exec cache unused for
+// longer than maxAge, it returns the number of versions removed and their size
+func pruneExecCache(maxAge time.Duration) (int, int64, error) {
+	tools, err := os.ReadDir(execDir)
+	if os.IsNotExist(err) {
+		return 0, 0, nil
+	}
+	if err != nil {
+		return 0, 0, err
+	}
+	var count int
+	var size int64
+	for _, tool := range tools {
+		if !tool.IsDir() {
+			continue
+		}
+		toolDir := filepath.Join(execDir, tool.Name())
+		versions, err := os.ReadDir(toolDir)
+		if err != nil {
+			return count, size, err
+		}
+		for _, version := range versions {
+			info, err := version.Info()
+			if err != nil || !version.IsDir() || time.Since(info.ModTime()) < maxAge {
+				continue
+			}
+			dir := filepath.Join(toolDir, version.Name())
+			versionSize := dirSize(dir)
+			if err := os.RemoveAll(dir); err != nil {
+				return count, size, err
+			}
+			count++
+			size += versionSize
+		}
+		// the directory of the tool is only removed once empty
+		_ = os.Remove(toolDir)
+	}
+	return count, size, nil
+}
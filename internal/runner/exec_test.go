@@ -0,0 +1,69 @@
+package runner
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/projectdiscovery/pdtm/pkg/state"
+	"github.com/projectdiscovery/pdtm/pkg/types"
+	"github.com/stretchr/testify/require"
+)
+
+// useExecDir points the exec cache to a temporary directory
+func useExecDir(t *testing.T) {
+	previous := execDir
+	execDir = t.TempDir()
+	t.Cleanup(func() { execDir = previous })
+}
+
+func TestExecInstalled(t *testing.T) {
+	useExecDir(t)
+	tool := types.Tool{Name: "dnsx"}
+	dir := filepath.Join(execDir, "dnsx", "1.1.1")
+	binary := filepath.Join(dir, "dnsx")
+	if runtime.GOOS == "windows" {
+		binary += ".exe"
+	}
+	require.False(t, execInstalled(dir, tool))
+
+	// the install was interrupted before the binary was recorded
+	require.Nil(t, os.MkdirAll(dir, 0755))
+	require.Nil(t, os.WriteFile(binary, []byte("dnsx 1.1.1"), 0755))
+	require.False(t, execInstalled(dir, tool))
+
+	hash, err := state.Hash(binary)
+	require.Nil(t, err)
+	st, err := state.Load(dir)
+	require.Nil(t, err)
+	st.Set(&state.Tool{Name: "dnsx", Version: "1.1.1", Hash: hash})
+	require.Nil(t, st.Save())
+	require.True(t, execInstalled(dir, tool))
+
+	// a partially written binary doesn't match the recorded hash
+	require.Nil(t, os.WriteFile(binary, []byte("dnsx"), 0755))
+	require.False(t, execInstalled(dir, tool))
+}
+
+func TestPruneExecCache(t *testing.T) {
+	useExecDir(t)
+	old := time.Now().Add(-48 * time.Hour)
+	for _, version := range []string{"dnsx/1.1.0", "dnsx/1.1.1", "naabu/2.0.0"} {
+		dir := filepath.Join(execDir, filepath.FromSlash(version))
+		require.Nil(t, os.MkdirAll(dir, 0755))
+		require.Nil(t, os.WriteFile(filepath.Join(dir, "binary"), []byte("binary"), 0755))
+		if version != "dnsx/1.1.1" {
+			require.Nil(t, os.Chtimes(dir, old, old))
+		}
+	}
+
+	count, size, err := pruneExecCache(24 * time.Hour)
+	require.Nil(t, err)
+	require.Equal(t, 2, count)
+	require.Equal(t, int64(12), size)
+	require.DirExists(t, filepath.Join(execDir, "dnsx", "1.1.1"))
+	require.NoDirExists(t, filepath.Join(execDir, "dnsx", "1.1.0"))
+	require.NoDirExists(t, filepath.Join(execDir, "naabu"), "the empty directory of the tool is removed")
+}
@@ -0,0 +1,385 @@
+package runner
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+	"text/template"
+	"time"
+	"unicode"
+
+	"github.com/projectdiscovery/gologger"
+	"github.com/projectdiscovery/pdtm/pkg"
+	"github.com/projectdiscovery/pdtm/pkg/state"
+	"github.com/projectdiscovery/pdtm/pkg/types"
+	mapsutil "github.com/projectdiscovery/utils/maps"
+)
+
+// exportData contains the variables available in the export templates
+type exportData struct {
+	Version string
+	Date    string
+	Tools   []pkg.ExportedTool
+	// Platforms contains the tools grouped by platform (os/arch)
+	Platforms []exportPlatform
+}
+
+type exportPlatform struct {
+	OS, Arch string
+	Assets   []exportAsset
+}
+
+type exportAsset struct {
+	Tool    string
+	Version string
+	pkg.ExportedAsset
+}
+
+// exportFormats contains the values of -export
+var exportFormats = map[string]func(io.Writer, exportData) error{
+	"script":       executeTemplate(scriptTemplate),
+	"powershell":   executeTemplate(powershellTemplate),
+	"dockerfile":   executeTemplate(dockerfileTemplate),
+	"devcontainer": writeDevcontainer,
+}
+
+// export writes the installed tools in the -export format
+func (r *Runner) export(toolList []types.Tool) error {
+	write, ok := exportFormats[r.options.Export]
+	if !ok {
+		return fmt.Errorf("invalid export format %s (%s)", r.options.Export, strings.Join(mapsutil.GetSortedKeys(exportFormats), ", "))
+	}
+	tools, err := r.exportedTools(toolList)
+	if err != nil {
+		return err
+	}
+	data := exportData{Version: version, Date: time.Now().Format("2006-01-02"), Tools: tools}
+	for _, platform := range pkg.ExportPlatforms {
+		goos, arch, _ := strings.Cut(platform, "/")
+		group := exportPlatform{OS: goos, Arch: arch}
+		for _, tool := range tools {
+			if asset, ok := tool.Asset(goos, arch); ok {
+				group.Assets = append(group.Assets, exportAsset{Tool: tool.Name, Version: tool.Version, ExportedAsset: asset})
+			}
+		}
+		if len(group.Assets) > 0 {
+			data.Platforms = append(data.Platforms, group)
+		}
+	}
+	// nothing is written on error, a partial script would still run
+	var buf bytes.Buffer
+	if err := write(&buf, data); err != nil {
+		return err
+	}
+	_, err = buf.WriteTo(os.Stdout)
+	return err
+}
+
+// exportFuncs quote the values of the tool list, which come from the api,
+// for the shells of the exported scripts
+var exportFuncs = template.FuncMap{
+	"sh": shellQuote,
+	"ps": powershellQuote,
+}
+
+func executeTemplate(text string) func(io.Writer, exportData) error {
+	tmpl := template.Must(template.New("export").Funcs(exportFuncs).Parse(text))
+	return func(w io.Writer, data exportData) error {
+		return tmpl.Execute(w, data)
+	}
+}
+
+// shellQuote quotes s as a single-quoted posix shell word
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// powershellQuote quotes s as a verbatim powershell string, powershell also
+// ends the single-quoted strings on the typographic quotes
+func powershellQuote(s string) string {
+	return "'" + strings.NewReplacer("'", "''", "\u2018", "\u2018\u2018", "\u2019", "\u2019\u2019", "\u201a", "\u201a\u201a", "\u201b", "\u201b\u201b").Replace(s) + "'"
+}
+
+// exportableName matches the tool names usable as file names in the scripts
+var exportableName = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+
+// checkExportable refuses the tools whose values can't be written safely in
+// the scripts and drops the archives without sha256, which couldn't be
+// verified on the target machines
+func checkExportable(tool pkg.ExportedTool) (pkg.ExportedTool, error) {
+	if !exportableName.MatchString(tool.Name) {
+		return tool, fmt.Errorf("invalid name %q", tool.Name)
+	}
+	values := []string{tool.Version}
+	var assets []pkg.ExportedAsset
+	for _, asset := range tool.Assets {
+		if asset.SHA256 == "" {
+			gologger.Error().Msgf("not exporting the %s/%s archive of %s %s, the release has no checksum to verify it", asset.OS, asset.Arch, tool.Name, tool.Version)
+			continue
+		}
+		values = append(values, asset.URL, asset.SHA256)
+		assets = append(assets, asset)
+	}
+	for _, value := range values {
+		if strings.IndexFunc(value, unicode.IsControl) >= 0 {
+			return tool, fmt.Errorf("invalid value %q", value)
+		}
+	}
+	if len(assets) == 0 {
+		return tool, fmt.Errorf("no release archive with a checksum")
+	}
+	tool.Assets = assets
+	return tool, nil
+}
+
+// unameArchs contains the uname -m patterns of the archs
+var unameArchs = map[string]string{
+	"amd64": "x86_64 | amd64",
+	"arm64": "aarch64 | arm64",
+	"386":   "i386 | i686",
+	"arm":   "armv*",
+}
+
+// writeDevcontainer writes the devcontainer.json properties installing the
+// linux builds of the tools in $HOME/.pdtm/go/bin after the container is
+// created, one command per tool
+func writeDevcontainer(w io.Writer, data exportData) error {
+	commands := make(map[string]string)
+	for _, tool := range data.Tools {
+		var cases []string
+		for _, asset := range tool.Assets {
+			// the archs without uname -m pattern can't be matched
+			if pattern, ok := unameArchs[asset.Arch]; ok && asset.OS == "linux" {
+				cases = append(cases, fmt.Sprintf("%s) url=%s sha256=%s ;;", pattern, shellQuote(asset.URL), shellQuote(asset.SHA256)))
+			}
+		}
+		if len(cases) == 0 {
+			continue
+		}
+		commands[tool.Name] = strings.Join([]string{
+			fmt.Sprintf(`set -e; name=%s; case "$(uname -m)" in %s *) echo "no $name "%s" release archive for linux/$(uname -m)" >&2; exit 1 ;; esac`, shellQuote(tool.Name), strings.Join(cases, " "), shellQuote(tool.Version)),
+			`dir="$(mktemp -d)"`,
+			`curl -fsSL -o "$dir/archive" "$url"`,
+			`echo "$sha256  $dir/archive" | sha256sum -c -`,
+			`case "$url" in *.zip) unzip -q "$dir/archive" -d "$dir" ;; *.tar.zst) zstd -dc "$dir/archive" | tar -x -C "$dir" ;; *.zst) zstd -dc "$dir/archive" > "$dir/$name" ;; *) tar -xzf "$dir/archive" -C "$dir" ;; esac`,
+			`mkdir -p "$HOME/.pdtm/go/bin"`,
+			`find "$dir" -type f -name "$name" -exec install -m 0755 {} "$HOME/.pdtm/go/bin/$name" \;`,
+			`rm -rf "$dir"`,
+		}, "; ")
+	}
+	if len(commands) == 0 {
+		return fmt.Errorf("no linux release archives to export")
+	}
+	devcontainer := map[string]any{
+		"postCreateCommand": commands,
+		"remoteEnv":         map[string]string{"PATH": "${containerEnv:PATH}:${containerEnv:HOME}/.pdtm/go/bin"},
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(devcontainer)
+}
+
+// exportedTools returns the release archives of the installed versions of
+// the tools, the data packs, nightly builds and builds of a git ref are
+// skipped
+func (r *Runner) exportedTools(toolList []types.Tool) ([]pkg.ExportedTool, error) {
+	var exported []pkg.ExportedTool
+	states := make(map[string]*state.State)
+	for _, tool := range toolList {
+		if pkg.IsDataPack(tool) {
+			continue
+		}
+		dir := r.pathFor(tool.Name)
+		st, ok := states[dir]
+		if !ok {
+			st, _ = state.Load(dir)
+			states[dir] = st
+		}
+		row := listTool(tool, dir, st)
+		switch {
+		case row.Status != statusLatest && row.Status != statusOutdated:
+			continue
+		case row.Nightly || row.Ref != "":
+			gologger.Warning().Msgf("skipping %s, nightly builds and builds of a git ref can't be exported", tool.Name)
+			continue
+		}
+		resolved, err := r.resolve(tool, row.Installed)
+		if err != nil {
+			gologger.Warning().Msgf("skipping %s, could not resolve %s: %s", tool.Name, row.Installed, err)
+			continue
+		}
+		tool, err := pkg.Export(resolved)
+		if err == nil {
+			tool, err = checkExportable(tool)
+		}
+		if err != nil {
+			gologger.Error().Msgf("skipping %s: %s", resolved.Name, err)
+			continue
+		}
+		exported = append(exported, tool)
+	}
+	if len(exported) == 0 {
+		return nil, fmt.Errorf("no installed projects to export")
+	}
+	return exported, nil
+}
+
+// scriptTemplate installs the exported tools with a posix shell
+const scriptTemplate = `#!/bin/sh
+# Installs the projectdiscovery tools exported by pdtm {{.Version}} on {{.Date}}:
+{{- range .Tools}} {{.Name}} {{.Version}}{{end}}
+# The binaries are installed in $PDTM_BIN (default $HOME/.pdtm/go/bin).
+set -eu
+
+bin_dir="${PDTM_BIN:-$HOME/.pdtm/go/bin}"
+case "$(uname -s)" in
+Linux) os=linux ;;
+Darwin) os=darwin ;;
+*) echo "unsupported os $(uname -s)" >&2; exit 1 ;;
+esac
+case "$(uname -m)" in
+x86_64 | amd64) arch=amd64 ;;
+aarch64 | arm64) arch=arm64 ;;
+i386 | i686) arch=386 ;;
+armv*) arch=arm ;;
+*) echo "unsupported arch $(uname -m)" >&2; exit 1 ;;
+esac
+tmp="$(mktemp -d)"
+trap 'rm -rf "$tmp"' EXIT
+mkdir -p "$bin_dir"
+
+download() {
+	if command -v curl >/dev/null 2>&1; then
+		curl -fsSL -o "$2" "$1"
+	else
+		wget -qO "$2" "$1"
+	fi
+}
+
+sha256() {
+	if command -v sha256sum >/dev/null 2>&1; then
+		sha256sum "$1" | cut -d ' ' -f 1
+	else
+		shasum -a 256 "$1" | cut -d ' ' -f 1
+	fi
+}
+
+# install_tool name version url sha256
+install_tool() {
+	echo "installing $1 $2"
+	archive="$tmp/$(basename "$3")"
+	download "$3" "$archive"
+	if [ "$(sha256 "$archive")" != "$4" ]; then
+		echo "checksum mismatch for $archive" >&2
+		exit 1
+	fi
+	rm -rf "$tmp/extract" && mkdir "$tmp/extract"
+	case "$archive" in
+	*.zip) unzip -q -o "$archive" -d "$tmp/extract" ;;
+	*.tar.zst) zstd -dc "$archive" | tar -x -C "$tmp/extract" ;;
+	*.zst) zstd -dc "$archive" > "$tmp/extract/$1" ;;
+	*) tar -xzf "$archive" -C "$tmp/extract" ;;
+	esac
+	binary="$(find "$tmp/extract" -type f -name "$1" | head -n 1)"
+	if [ -z "$binary" ]; then
+		echo "$1 not found in $archive" >&2
+		exit 1
+	fi
+	mv -f "$binary" "$bin_dir/$1"
+	chmod +x "$bin_dir/$1"
+}
+
+case "$os/$arch" in
+{{- range .Platforms}}{{if ne .OS "windows"}}
+{{.OS}}/{{.Arch}})
+{{- range .Assets}}
+	install_tool {{sh .Tool}} {{sh .Version}} {{sh .URL}} {{sh .SHA256}}
+{{- end}}
+	;;
+{{- end}}{{end}}
+*)
+	echo "no release archives exported for $os/$arch" >&2
+	exit 1
+	;;
+esac
+echo "installed in $bin_dir, add it to \$PATH if needed"
+`
+
+// powershellTemplate installs the exported tools on windows
+const powershellTemplate = `# Installs the projectdiscovery tools exported by pdtm {{.Version}} on {{.Date}}:
+#{{range .Tools}} {{.Name}} {{.Version}}{{end}}
+# The binaries are installed in $env:PDTM_BIN (default $HOME\.pdtm\go\bin).
+$ErrorActionPreference = "Stop"
+
+$binDir = if ($env:PDTM_BIN) { $env:PDTM_BIN } else { Join-Path $HOME ".pdtm\go\bin" }
+$arch = switch ($env:PROCESSOR_ARCHITECTURE) {
+	"AMD64" { "amd64" }
+	"ARM64" { "arm64" }
+	"x86" { "386" }
+	default { throw "unsupported arch $env:PROCESSOR_ARCHITECTURE" }
+}
+$tmp = Join-Path ([System.IO.Path]::GetTempPath()) ([System.Guid]::NewGuid())
+New-Item -ItemType Directory -Force -Path $binDir, $tmp | Out-Null
+
+function Install-Tool($name, $version, $url, $sha256) {
+	Write-Host "installing $name $version"
+	$archive = Join-Path $tmp ([System.IO.Path]::GetFileName($url))
+	Invoke-WebRequest -UseBasicParsing -Uri $url -OutFile $archive
+	if ((Get-FileHash -Algorithm SHA256 $archive).Hash -ne $sha256) {
+		throw "checksum mismatch for $archive"
+	}
+	$extract = Join-Path $tmp $name
+	Expand-Archive -Force -Path $archive -DestinationPath $extract
+	$binary = Get-ChildItem -Recurse -File -Path $extract -Filter "$name.exe" | Select-Object -First 1
+	if (-not $binary) { throw "$name.exe not found in $archive" }
+	Move-Item -Force $binary.FullName (Join-Path $binDir "$name.exe")
+}
+
+try {
+	switch ($arch) {
+{{- range .Platforms}}{{if eq .OS "windows"}}
+		"{{.Arch}}" {
+{{- range .Assets}}
+			Install-Tool {{ps .Tool}} {{ps .Version}} {{ps .URL}} {{ps .SHA256}}
+{{- end}}
+		}
+{{- end}}{{end}}
+		default { throw "no release archives exported for windows/$arch" }
+	}
+} finally {
+	Remove-Item -Recurse -Force $tmp
+}
+Write-Host "installed in $binDir, add it to the PATH if needed"
+`
+
+// dockerfileTemplate installs the exported linux builds in a build stage,
+// the final stage copies them from it
+const dockerfileTemplate = `# Bakes the projectdiscovery tools exported by pdtm {{.Version}} on {{.Date}}:
+#{{range .Tools}} {{.Name}} {{.Version}}{{end}}
+FROM alpine:3.19 AS pdtm-tools
+ARG TARGETARCH
+RUN apk add --no-cache curl unzip zstd && mkdir -p /pdtm/bin
+{{- range .Tools}}{{if .HasOS "linux"}}{{$tool := .}}
+RUN name={{sh $tool.Name}} && \
+    case "$TARGETARCH" in \
+{{- range .Assets}}{{if eq .OS "linux"}}
+      {{.Arch}}) url={{sh .URL}} sha256={{sh .SHA256}} ;; \
+{{- end}}{{end}}
+      *) echo "no $name "{{sh $tool.Version}}" release archive for linux/$TARGETARCH" >&2; exit 1 ;; \
+    esac && \
+    curl -fsSL -o "/tmp/$name.archive" "$url" && \
+    echo "$sha256  /tmp/$name.archive" | sha256sum -c - && \
+    mkdir "/tmp/$name" && \
+    case "$url" in *.zip) unzip -q "/tmp/$name.archive" -d "/tmp/$name" ;; *.tar.zst) zstd -dc "/tmp/$name.archive" | tar -x -C "/tmp/$name" ;; *.zst) zstd -dc "/tmp/$name.archive" > "/tmp/$name/$name" ;; *) tar -xzf "/tmp/$name.archive" -C "/tmp/$name" ;; esac && \
+    find "/tmp/$name" -type f -name "$name" -exec install -m 0755 {} "/pdtm/bin/$name" \; && \
+    rm -rf "/tmp/$name" "/tmp/$name.archive"
+{{- end}}{{end}}
+
+FROM alpine:3.19
+COPY --from=pdtm-tools /pdtm/bin/ /usr/local/bin/
+`
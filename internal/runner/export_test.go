@@ -0,0 +1,120 @@
+package runner
+
+import (
+	"bytes"
+	"encoding/json"
+	"os/exec"
+	"strings"
+	"testing"
+
+	"github.com/projectdiscovery/pdtm/pkg"
+	"github.com/stretchr/testify/require"
+)
+
+// hostileTool is an exported tool whose values try to escape the quoting
+var hostileTool = pkg.ExportedTool{
+	Name:    "dnsx",
+	Version: "1.1.1'$(touch pwned)`id`\"",
+	Assets: []pkg.ExportedAsset{
+		{OS: "linux", Arch: "amd64", URL: "https://example.com/dnsx'; touch pwned; '.zip", SHA256: "abc"},
+		{OS: "windows", Arch: "amd64", URL: "https://example.com/dnsx’; Remove-Item x; ’.zip", SHA256: "abc"},
+	},
+}
+
+func testExportData(tools ...pkg.ExportedTool) exportData {
+	data := exportData{Version: "v0.0.0", Date: "2026-01-01", Tools: tools}
+	for _, tool := range tools {
+		for _, asset := range tool.Assets {
+			data.Platforms = append(data.Platforms, exportPlatform{OS: asset.OS, Arch: asset.Arch, Assets: []exportAsset{{Tool: tool.Name, Version: tool.Version, ExportedAsset: asset}}})
+		}
+	}
+	return data
+}
+
+func TestShellQuote(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh is not available")
+	}
+	for _, value := range []string{"1.1.1", "it's", "$(id)", "`id`", "'\"'\"", ""} {
+		out, err := exec.Command("sh", "-c", "printf %s "+shellQuote(value)).Output()
+		require.Nil(t, err, value)
+		require.Equal(t, value, string(out))
+	}
+}
+
+func TestPowershellQuote(t *testing.T) {
+	require.Equal(t, `'it''s'`, powershellQuote("it's"))
+	require.Equal(t, "'a’’b'", powershellQuote("a’b"))
+	require.Equal(t, `'$(id) "x"'`, powershellQuote(`$(id) "x"`))
+}
+
+func TestExportScriptQuoting(t *testing.T) {
+	var buf bytes.Buffer
+	require.Nil(t, executeTemplate(scriptTemplate)(&buf, testExportData(hostileTool)))
+	script := buf.String()
+	require.Contains(t, script, "install_tool 'dnsx' '1.1.1'\\''$(touch pwned)`id`\"' 'https://example.com/dnsx'\\''; touch pwned; '\\''.zip' 'abc'")
+	if _, err := exec.LookPath("sh"); err == nil {
+		require.Nil(t, exec.Command("sh", "-n", "-c", script).Run(), "the script should be valid")
+	}
+
+	buf.Reset()
+	require.Nil(t, executeTemplate(powershellTemplate)(&buf, testExportData(hostileTool)))
+	require.Contains(t, buf.String(), "Install-Tool 'dnsx' '1.1.1''$(touch pwned)`id`\"' 'https://example.com/dnsx’’; Remove-Item x; ’’.zip' 'abc'")
+}
+
+func TestCheckExportable(t *testing.T) {
+	tool := pkg.ExportedTool{Name: "dnsx", Version: "1.1.1", Assets: []pkg.ExportedAsset{
+		{OS: "linux", Arch: "amd64", URL: "https://example.com/a.zip", SHA256: "abc"},
+		{OS: "linux", Arch: "arm64", URL: "https://example.com/b.zip"},
+	}}
+	checked, err := checkExportable(tool)
+	require.Nil(t, err)
+	require.Len(t, checked.Assets, 1, "the archives without checksum are not exported")
+
+	tool.Assets = tool.Assets[1:]
+	_, err = checkExportable(tool)
+	require.NotNil(t, err)
+
+	for _, invalid := range []pkg.ExportedTool{
+		{Name: "../dnsx", Version: "1.1.1", Assets: checked.Assets},
+		{Name: "dnsx", Version: "1.1.1\nRUN id", Assets: checked.Assets},
+	} {
+		_, err = checkExportable(invalid)
+		require.NotNil(t, err, invalid.Name)
+	}
+}
+
+func TestExportDockerfileQuoting(t *testing.T) {
+	var buf bytes.Buffer
+	require.Nil(t, executeTemplate(dockerfileTemplate)(&buf, testExportData(hostileTool)))
+	dockerfile := buf.String()
+	require.Contains(t, dockerfile, "amd64) url='https://example.com/dnsx'\\''; touch pwned; '\\''.zip' sha256='abc' ;;")
+	require.NotContains(t, dockerfile, `[ -z "$sha256" ]`)
+
+	// the RUN instruction of the tool is a valid shell command
+	start := strings.Index(dockerfile, "RUN name=")
+	require.True(t, start > 0)
+	end := strings.Index(dockerfile[start:], "\n\n")
+	command := strings.ReplaceAll(strings.TrimPrefix(dockerfile[start:start+end], "RUN "), "\\\n", "\n")
+	if _, err := exec.LookPath("sh"); err == nil {
+		require.Nil(t, exec.Command("sh", "-n", "-c", command).Run(), command)
+	}
+}
+
+func TestExportDevcontainerQuoting(t *testing.T) {
+	tool := hostileTool
+	tool.Assets = append([]pkg.ExportedAsset{{OS: "linux", Arch: "riscv64", URL: "https://example.com/riscv.zip", SHA256: "abc"}}, tool.Assets...)
+	var buf bytes.Buffer
+	require.Nil(t, writeDevcontainer(&buf, testExportData(tool)))
+
+	var devcontainer struct {
+		PostCreateCommand map[string]string `json:"postCreateCommand"`
+	}
+	require.Nil(t, json.Unmarshal(buf.Bytes(), &devcontainer))
+	command := devcontainer.PostCreateCommand["dnsx"]
+	require.NotContains(t, command, "riscv", "the archs without uname pattern are skipped")
+	require.Contains(t, command, "x86_64 | amd64) url='https://example.com/dnsx'\\''; touch pwned; '\\''.zip' sha256='abc' ;;")
+	if _, err := exec.LookPath("sh"); err == nil {
+		require.Nil(t, exec.Command("sh", "-n", "-c", command).Run(), command)
+	}
+}
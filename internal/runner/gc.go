@@ -0,0 +1,72 @@
+package runner
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/projectdiscovery/gologger"
+	"github.com/projectdiscovery/pdtm/pkg"
+)
+
+// collectVersions removes the versions kept for rollbacks in the managed
+// paths the retention doesn't allow and shows the reclaimed space
+func (r *Runner) collectVersions() error {
+	var removed []pkg.StoredVersion
+	for _, dir := range r.managedPaths() {
+		if !r.isAllowedPath(dir) {
+			continue
+		}
+		collected, err := pkg.CollectVersions(dir, pkg.Retention)
+		removed = append(removed, collected...)
+		if err != nil {
+			gologger.Error().Msgf("error while removing the kept versions of %s: %s", dir, err)
+		}
+	}
+	maxAge := pkg.ArchiveMaxAge
+	if pkg.Retention.MaxAge > 0 {
+		maxAge = pkg.Retention.MaxAge
+	}
+	archives, archivesSize, err := pkg.PruneArchives(maxAge, pkg.Retention.MaxSize)
+	if err != nil {
+		gologger.Error().Msgf("error while removing the cached archives: %s", err)
+	}
+	// the archives were cached in the config directory before
+	if legacy := filepath.Join(homeDir, ".config/pdtm/archives"); legacy != archiveCacheDir {
+		if err := os.RemoveAll(legacy); err != nil {
+			gologger.Error().Msgf("error while removing %s: %s", legacy, err)
+		}
+	}
+	execVersions, execSize, err := pruneExecCache(maxAge)
+	if err != nil {
+		gologger.Error().Msgf("error while removing the unused versions of the exec cache: %s", err)
+	}
+	binaries, binariesSize, err := pkg.PruneStore()
+	if err != nil {
+		gologger.Error().Msgf("error while removing the unused binaries of the store: %s", err)
+	}
+	if r.options.structured() {
+		return writeResults(r.options, removed)
+	}
+	if archives > 0 {
+		gologger.Info().Msgf("removed %d cached archives unused for %s or above the size limit, reclaimed %s", archives, maxAge, formatSize(archivesSize))
+	}
+	if execVersions > 0 {
+		gologger.Info().Msgf("removed %d project versions of the exec cache unused for %s, reclaimed %s", execVersions, maxAge, formatSize(execSize))
+	}
+	if binaries > 0 {
+		gologger.Info().Msgf("removed %d unused binaries of the store, reclaimed %s", binaries, formatSize(binariesSize))
+	}
+	if len(removed) == 0 {
+		gologger.Info().Msg("no kept versions to remove")
+		return nil
+	}
+	var reclaimed int64
+	t := &table{header: []string{"PROJECT", "VERSION", "SIZE"}}
+	for _, version := range removed {
+		reclaimed += version.Size
+		t.rows = append(t.rows, []string{version.Name, version.Version, formatSize(version.Size)})
+	}
+	t.print(os.Stdout)
+	gologger.Info().Msgf("removed %d kept versions, reclaimed %s", len(removed), formatSize(reclaimed))
+	return nil
+}
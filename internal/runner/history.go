@@ -0,0 +1,133 @@
+package runner
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/projectdiscovery/gologger"
+	"github.com/projectdiscovery/pdtm/pkg"
+	"github.com/projectdiscovery/pdtm/pkg/path"
+	"github.com/projectdiscovery/pdtm/pkg/state"
+	"github.com/projectdiscovery/pdtm/pkg/types"
+	"github.com/projectdiscovery/pdtm/pkg/utils"
+	sliceutil "github.com/projectdiscovery/utils/slice"
+)
+
+// HistoryEntry is a version of a tool installed on this machine
+type HistoryEntry struct {
+	Version   string     `json:"version" yaml:"version"`
+	Source    string     `json:"source,omitempty" yaml:"source,omitempty"`
+	Installed *time.Time `json:"installed,omitempty" yaml:"installed,omitempty"`
+	Replaced  *time.Time `json:"replaced,omitempty" yaml:"replaced,omitempty"`
+	// Current is true for the installed version
+	Current bool `json:"current,omitempty" yaml:"current,omitempty"`
+	// Kept is true when the binary of the version is kept for rollbacks
+	Kept bool `json:"kept,omitempty" yaml:"kept,omitempty"`
+}
+
+// toolHistory returns the versions of the tool installed in dir, oldest first
+func toolHistory(dir string, tool types.Tool) ([]HistoryEntry, error) {
+	st, err := state.Load(dir)
+	if err != nil {
+		return nil, err
+	}
+	installed, ok := st.Get(tool.Name)
+	if !ok {
+		return nil, fmt.Errorf("no history recorded for %s", tool.Name)
+	}
+	kept := pkg.StoredVersions(dir, tool)
+	var history []HistoryEntry
+	for _, previous := range installed.History {
+		history = append(history, HistoryEntry{
+			Version:   previous.Version,
+			Source:    string(previous.Source),
+			Installed: previous.Installed,
+			Replaced:  previous.Replaced,
+			Kept:      sliceutil.Contains(kept, strings.TrimPrefix(previous.Version, "v")),
+		})
+	}
+	history = append(history, HistoryEntry{Version: installed.Version, Source: string(installed.Source), Installed: installed.Updated, Current: true})
+	return history, nil
+}
+
+// showHistory prints the versions of the tool installed on this machine
+func (r *Runner) showHistory(toolList []types.Tool) error {
+	i, ok := utils.Contains(toolList, r.options.History)
+	if !ok {
+		return fmt.Errorf("%s", unknownTool(toolList, r.options.History))
+	}
+	history, err := toolHistory(r.pathFor(toolList[i].Name), toolList[i])
+	if err != nil {
+		return err
+	}
+	if r.options.structured() {
+		return writeResults(r.options, history)
+	}
+	t := &table{header: []string{"VERSION", "SOURCE", "INSTALLED", "REPLACED", ""}}
+	for _, entry := range history {
+		var note string
+		switch {
+		case entry.Current:
+			note = "current"
+		case entry.Kept:
+			note = "kept"
+		}
+		t.rows = append(t.rows, []string{entry.Version, dash(entry.Source), dash(formatDate(entry.Installed)), dash(formatDate(entry.Replaced)), note})
+	}
+	t.color = func(row, column int, cell string) string {
+		if history[row].Current {
+			return au.BrightGreen(cell).String()
+		}
+		return cell
+	}
+	t.print(os.Stdout)
+	return nil
+}
+
+// rollback replaces the installed tool with the version given with -to (the
+// previous version by default) and pins it so updates don't undo it
+func (r *Runner) rollback(toolList []types.Tool) error {
+	i, ok := utils.Contains(toolList, r.options.Rollback)
+	if !ok {
+		return fmt.Errorf("%s", unknownTool(toolList, r.options.Rollback))
+	}
+	tool := toolList[i]
+	dir := r.pathFor(tool.Name)
+	if !r.isAllowedPath(dir) {
+		return fmt.Errorf("skipping rollback outside home folder: %s", tool.Name)
+	}
+	if err := path.CheckWritable(dir); err != nil {
+		return fmt.Errorf("could not roll back %s: %s, %s", tool.Name, err, notWritableHint)
+	}
+	version := r.options.RollbackTo
+	if version == "" {
+		previous, err := pkg.PreviousVersion(dir, tool)
+		if err != nil {
+			return fmt.Errorf("could not roll back %s: %s, use -to <version>", tool.Name, err)
+		}
+		version = previous
+	}
+	rolledBack := ReportedTool{Name: tool.Name, Action: actionRollback, Version: version, Status: "failed"}
+	defer func() { r.report(rolledBack) }()
+	if err := pkg.Rollback(dir, tool, version); err != nil {
+		rolledBack.Reason = err.Error()
+		return fmt.Errorf("could not roll back %s: %s", tool.Name, err)
+	}
+	rolledBack.Status = "rolled back"
+	if err := pkg.Pin(dir, tool); err != nil {
+		gologger.Error().Msgf("error while pinning %s: %s", tool.Name, err)
+	} else {
+		gologger.Info().Msgf("%s won't be updated until unpinned with -unpin %s", tool.Name, tool.Name)
+	}
+	return nil
+}
+
+// formatDate returns the date of t, empty if not set
+func formatDate(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.Format("2006-01-02 15:04")
+}
@@ -0,0 +1,91 @@
+package runner
+
+import (
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/projectdiscovery/gologger"
+	"github.com/projectdiscovery/pdtm/pkg"
+	"github.com/projectdiscovery/pdtm/pkg/state"
+	"github.com/projectdiscovery/pdtm/pkg/types"
+	"github.com/projectdiscovery/pdtm/pkg/utils"
+)
+
+// buildInfo returns the go build info of the installed binary of the tool,
+// nil for the data packs and the binaries not built with go
+func buildInfo(dir string, tool types.Tool) *pkg.BuildInfo {
+	if pkg.IsDataPack(tool) {
+		return nil
+	}
+	build, err := pkg.ReadBuildInfo(dir, tool)
+	if err != nil {
+		gologger.Verbose().Msgf("could not read the build info of %s: %s", tool.Name, err)
+		return nil
+	}
+	return build
+}
+
+// showInfo prints the install details and the go build info of a tool
+func (r *Runner) showInfo(toolList []types.Tool) error {
+	i, ok := utils.Contains(toolList, r.options.Info)
+	if !ok {
+		return fmt.Errorf("%s", unknownTool(toolList, r.options.Info))
+	}
+	tool := toolList[i]
+	dir := r.pathFor(tool.Name)
+	st, err := state.Load(dir)
+	if err != nil {
+		gologger.Warning().Msgf("could not read state: %s", err)
+	}
+	row := listTool(tool, dir, st)
+	if row.Status == statusNotInstalled || row.Status == statusNotSupported {
+		return fmt.Errorf("%s is not installed in %s", tool.Name, dir)
+	}
+	row.Build = buildInfo(dir, tool)
+	if r.options.structured() {
+		return writeResults(r.options, []ListedTool{row})
+	}
+
+	status := row.Status
+	if row.Broken != "" {
+		status += ": " + row.Broken
+	}
+	t := &table{header: []string{"FIELD", "VALUE"}}
+	t.rows = append(t.rows,
+		[]string{"name", row.Name},
+		[]string{"installed", dash(row.Installed)},
+		[]string{"latest", dash(row.Latest)},
+		[]string{"status", status},
+		[]string{"source", dash(row.Source)},
+		[]string{"origin", dash(row.Origin)},
+		[]string{"path", row.Path},
+		[]string{"first installed", dash(formatDate(row.FirstInstalled))},
+		[]string{"updated", dash(formatDate(row.Updated))},
+	)
+	if build := row.Build; build != nil {
+		revision := build.Revision
+		if build.Modified {
+			revision += " (modified)"
+		}
+		t.rows = append(t.rows,
+			[]string{"go version", build.GoVersion},
+			[]string{"package", build.Path},
+			[]string{"module version", dash(build.Version)},
+			[]string{"vcs revision", dash(revision)},
+			[]string{"vcs time", dash(build.Time)},
+		)
+		keys := make([]string, 0, len(build.Settings))
+		for key, value := range build.Settings {
+			if value != "" {
+				keys = append(keys, key)
+			}
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			t.rows = append(t.rows, []string{"build " + key, build.Settings[key]})
+		}
+	}
+	t.print(os.Stdout)
+	return nil
+}
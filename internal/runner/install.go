@@ -0,0 +1,175 @@
+package runner
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/projectdiscovery/gologger"
+	"github.com/projectdiscovery/pdtm/pkg"
+	"github.com/projectdiscovery/pdtm/pkg/path"
+	"github.com/projectdiscovery/pdtm/pkg/toolchain"
+	"github.com/projectdiscovery/pdtm/pkg/types"
+)
+
+// pendingInstall is a tool resolved for installation in a path
+type pendingInstall struct {
+	dir  string
+	tool types.Tool
+}
+
+// confirmDownload shows the total size of the release assets downloaded to
+// install the tools and asks for confirmation when interactive
+func (r *Runner) confirmDownload(pending []pendingInstall) bool {
+	var total int64
+	var count, unknown int
+	for _, p := range pending {
+		if !r.downloadsAsset(p.dir, p.tool) {
+			continue
+		}
+		count++
+		if size, ok := pkg.DownloadSize(p.tool); ok {
+			total += size
+		} else {
+			unknown++
+		}
+	}
+	if count == 0 {
+		return true
+	}
+	msg := fmt.Sprintf("%d project(s) to download, %s", count, formatSize(total))
+	if unknown > 0 {
+		msg += fmt.Sprintf(" (size of %d unknown)", unknown)
+	}
+	gologger.Info().Msg(msg)
+	if r.options.Yes || !isInteractive() {
+		return true
+	}
+	return confirm("continue?")
+}
+
+// downloadsAsset returns true if installing the tool downloads its release asset
+func (r *Runner) downloadsAsset(dir string, tool types.Tool) bool {
+	ref, archive := r.installSource(tool)
+	if pkg.IsDataPack(tool) || ref != "" || archive != "" || !pkg.HasAsset(tool) {
+		return false
+	}
+	if _, exists := path.GetExecutablePath(dir, tool.Name); exists {
+		return false
+	}
+	// go installs don't download the release asset unless they fail
+	return tool.InstallType != types.Go || !(isGoInstalled() || toolchain.IsInstalled(toolchainDir))
+}
+
+// formatSize returns the size in a human readable unit
+func formatSize(size int64) string {
+	const unit = 1024
+	if size < unit {
+		return fmt.Sprintf("%d B", size)
+	}
+	div, exp := int64(unit), 0
+	for n := size / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %cB", float64(size)/float64(div), "KMGT"[exp])
+}
+
+// install installs the tool with the requested method falling back to the
+// other one on failure, it returns true if the tool has been installed
+func (r *Runner) install(dir string, tool types.Tool) bool {
+	if pkg.IsDataPack(tool) {
+		err := pkg.Install(dir, tool)
+		switch {
+		case err == nil:
+			return true
+		case errors.Is(err, types.ErrIsInstalled):
+			gologger.Info().Msgf("%s: %s", tool.Name, err)
+		default:
+			gologger.Error().Msgf("error while installing %s: %s", tool.Name, err)
+		}
+		return false
+	}
+	ref, archive := r.installSource(tool)
+	if ref != "" {
+		if !r.goAvailable() {
+			gologger.Error().Msgf("error while installing %s: go is required to build from %s (use -go-bootstrap to download it)", tool.Name, ref)
+			return false
+		}
+		if err := pkg.GoInstallRef(dir, tool, ref); err != nil {
+			gologger.Error().Msgf("%s: %s", tool.Name, err)
+			return false
+		}
+		return true
+	}
+	if archive != "" {
+		if err := pkg.InstallFromArchive(dir, tool, archive); err != nil {
+			gologger.Error().Msgf("error while installing %s: %s", tool.Name, err)
+			return false
+		}
+		return true
+	}
+	if tool.InstallType == types.Go && r.goAvailable() {
+		err := pkg.GoInstall(dir, tool)
+		switch {
+		case err == nil:
+			return true
+		case errors.Is(err, types.ErrIsInstalled) || !pkg.HasAsset(tool):
+			gologger.Error().Msgf("%s: %s", tool.Name, err)
+			return false
+		}
+		gologger.Error().Msgf("error while installing %s with go install: %s", tool.Name, err)
+		gologger.Info().Msgf("trying to install %s using release binary", tool.Name)
+		if err := pkg.Install(dir, tool); err != nil {
+			gologger.Error().Msgf("error while installing %s: %s", tool.Name, err)
+			return false
+		}
+		gologger.Info().Msgf("%s installed using release binary", tool.Name)
+		return true
+	}
+
+	err := pkg.Install(dir, tool)
+	switch {
+	case err == nil:
+		return true
+	case errors.Is(err, types.ErrIsInstalled):
+		gologger.Info().Msgf("%s: %s", tool.Name, err)
+		return false
+	}
+	gologger.Error().Msgf("error while installing %s: %s", tool.Name, err)
+	if !r.goAvailable() {
+		gologger.Info().Msgf("go is not installed, use -go-bootstrap to install %s using go install", tool.Name)
+		return false
+	}
+	gologger.Info().Msgf("trying to install %s using go install", tool.Name)
+	if err := pkg.GoInstall(dir, tool); err != nil {
+		gologger.Error().Msgf("%s: %s", tool.Name, err)
+		return false
+	}
+	gologger.Info().Msgf("%s installed using go install", tool.Name)
+	return true
+}
+
+// postInstall runs the post-install steps of the freshly installed tool after confirmation
+func (r *Runner) postInstall(dir string, tool types.Tool) {
+	steps := append([]types.PostInstallStep{}, tool.PostInstall...)
+	steps = append(steps, pkg.CapabilitySteps(tool)...)
+	for name, extra := range r.options.PostInstall {
+		if strings.EqualFold(name, tool.Name) {
+			steps = append(steps, extra...)
+		}
+	}
+	for _, step := range steps {
+		summary := pkg.PostInstallSummary(tool, step)
+		if !r.options.Yes && !isInteractive() {
+			gologger.Info().Msgf("skipping post-install step of %s (not interactive): %s", tool.Name, summary)
+			continue
+		}
+		if !r.confirm(fmt.Sprintf("%s post-install: %s?", tool.Name, summary)) {
+			continue
+		}
+		if err := pkg.RunPostInstall(dir, tool, step); err != nil {
+			gologger.Error().Msgf("post-install step of %s failed: %s", tool.Name, err)
+		}
+	}
+}
@@ -0,0 +1,30 @@
+package runner
+
+import (
+	"github.com/projectdiscovery/gologger"
+	"github.com/projectdiscovery/pdtm/pkg"
+	"github.com/projectdiscovery/pdtm/pkg/path"
+	"github.com/projectdiscovery/pdtm/pkg/types"
+)
+
+// syncLinks creates or updates the symlinks in the link directory of the
+// installed tools and removes the ones of the tools no longer installed
+func (r *Runner) syncLinks(tools []types.Tool) {
+	for _, tool := range tools {
+		if pkg.IsDataPack(tool) {
+			continue
+		}
+		if binary, exists := path.GetExecutablePath(r.pathFor(tool.Name), tool.Name); exists {
+			if err := pkg.Link(binary, r.options.LinkDir); err != nil {
+				gologger.Warning().Msgf("could not link %s: %s", tool.Name, err)
+			}
+			continue
+		}
+		for _, dir := range r.managedPaths() {
+			binary, _ := path.GetExecutablePath(dir, tool.Name)
+			if err := pkg.Unlink(binary, r.options.LinkDir); err != nil {
+				gologger.Warning().Msgf("could not unlink %s: %s", tool.Name, err)
+			}
+		}
+	}
+}
@@ -0,0 +1,338 @@
+package runner
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/projectdiscovery/gologger"
+	"github.com/projectdiscovery/pdtm/pkg"
+	"github.com/projectdiscovery/pdtm/pkg/path"
+	"github.com/projectdiscovery/pdtm/pkg/state"
+	"github.com/projectdiscovery/pdtm/pkg/types"
+	mapsutil "github.com/projectdiscovery/utils/maps"
+)
+
+// status of the listed tools
+const (
+	statusLatest       = "latest"
+	statusOutdated     = "outdated"
+	statusNotInstalled = "not installed"
+	statusNotSupported = "not supported"
+	statusBroken       = "broken"
+)
+
+// ListedTool is a row of the tool list
+type ListedTool struct {
+	Name      string `json:"name" yaml:"name"`
+	Installed string `json:"installed,omitempty" yaml:"installed,omitempty"`
+	Latest    string `json:"latest,omitempty" yaml:"latest,omitempty"`
+	// Status is latest, outdated, not installed, not supported or broken
+	Status string `json:"status" yaml:"status"`
+	// Severity is the size of the version gap of the outdated tools (major,
+	// minor, patch or unknown)
+	Severity string `json:"severity,omitempty" yaml:"severity,omitempty"`
+	// Broken is the reason the install is broken (missing, empty or not executable)
+	Broken string `json:"broken,omitempty" yaml:"broken,omitempty"`
+	// Source is how the tool was installed (release, go, adopted, archive or data)
+	Source string `json:"source,omitempty" yaml:"source,omitempty"`
+	// Origin is where the tool was installed from (repository, go package,
+	// archive or index)
+	Origin  string     `json:"origin,omitempty" yaml:"origin,omitempty"`
+	Path    string     `json:"path" yaml:"path"`
+	Updated *time.Time `json:"updated,omitempty" yaml:"updated,omitempty"`
+	// FirstInstalled is when the tool was first installed
+	FirstInstalled *time.Time `json:"first_installed,omitempty" yaml:"first_installed,omitempty"`
+	Pinned         bool       `json:"pinned,omitempty" yaml:"pinned,omitempty"`
+	Nightly        bool       `json:"nightly,omitempty" yaml:"nightly,omitempty"`
+	Ref            string     `json:"ref,omitempty" yaml:"ref,omitempty"`
+	// Deprecated is the reason the tool is deprecated upstream
+	Deprecated string `json:"deprecated,omitempty" yaml:"deprecated,omitempty"`
+	// Build is the go build info of the binary (with -wide and -info)
+	Build *pkg.BuildInfo `json:"build,omitempty" yaml:"build,omitempty"`
+}
+
+// staleAfter is the age after which the last update of a tool is highlighted
+const staleAfter = 90 * 24 * time.Hour
+
+// listFilters contains the values of -filter
+var listFilters = map[string]func(ListedTool) bool{
+	"installed":    func(t ListedTool) bool { return t.Status != statusNotInstalled && t.Status != statusNotSupported },
+	"outdated":     func(t ListedTool) bool { return t.Status == statusOutdated },
+	"notinstalled": func(t ListedTool) bool { return t.Status == statusNotInstalled },
+}
+
+// listSorts contains the values of -sort
+var listSorts = map[string]func(a, b ListedTool) bool{
+	"name":      func(a, b ListedTool) bool { return a.Name < b.Name },
+	"installed": func(a, b ListedTool) bool { return versionLess(a.Installed, b.Installed) },
+	"latest":    func(a, b ListedTool) bool { return versionLess(a.Latest, b.Latest) },
+	"status":    func(a, b ListedTool) bool { return a.Status < b.Status },
+	"source":    func(a, b ListedTool) bool { return a.Source < b.Source },
+	"origin":    func(a, b ListedTool) bool { return a.Origin < b.Origin },
+	"path":      func(a, b ListedTool) bool { return a.Path < b.Path },
+	"updated": func(a, b ListedTool) bool {
+		// the most recently updated tools first, never updated last
+		return a.Updated != nil && (b.Updated == nil || a.Updated.After(*b.Updated))
+	},
+}
+
+// versionLess compares the versions with semver (1.9.0 before 1.10.0), the
+// versions that can't be parsed are compared as strings
+func versionLess(a, b string) bool {
+	versionA, errA := semver.NewVersion(strings.TrimPrefix(a, "v"))
+	versionB, errB := semver.NewVersion(strings.TrimPrefix(b, "v"))
+	if errA != nil || errB != nil {
+		return a < b
+	}
+	return versionA.LessThan(versionB)
+}
+
+// ListToolsAndEnv prints the table of tools
+func (r *Runner) ListToolsAndEnv(tools []types.Tool) error {
+	filter, ok := listFilters[r.options.Filter]
+	if r.options.Filter != "" && !ok {
+		return fmt.Errorf("invalid filter %s (%s)", r.options.Filter, strings.Join(mapsutil.GetSortedKeys(listFilters), ", "))
+	}
+	less, ok := listSorts[r.options.Sort]
+	if r.options.Sort != "" && !ok {
+		return fmt.Errorf("invalid sort %s (%s)", r.options.Sort, strings.Join(mapsutil.GetSortedKeys(listSorts), ", "))
+	}
+
+	if !r.options.structured() {
+		gologger.Info().Msgf(path.GetOsData() + "\n")
+		// the system installs are in $PATH through their links
+		binaryPath, pathDir := r.options.Path, r.options.Path
+		if r.systemLinkDir != "" {
+			binaryPath, pathDir = systemPath, r.systemLinkDir
+		}
+		gologger.Info().Msgf("Path to download project binary: %s\n", binaryPath)
+		var fmtMsg string
+		if path.IsSet(pathDir) {
+			fmtMsg = "Path %s configured in environment variable $PATH\n"
+		} else {
+			fmtMsg = "Path %s not configured in environment variable $PATH\n"
+		}
+		gologger.Info().Msgf(fmtMsg, pathDir)
+	}
+
+	states := make(map[string]*state.State)
+	listed := make([]ListedTool, 0, len(tools))
+	for _, tool := range tools {
+		dir := r.pathFor(tool.Name)
+		st, ok := states[dir]
+		if !ok {
+			var err error
+			if st, err = state.Load(dir); err != nil {
+				gologger.Warning().Msgf("could not read state: %s", err)
+			}
+			states[dir] = st
+		}
+		row := listTool(tool, dir, st)
+		if r.options.Wide && row.Installed != "" && row.Broken == "" {
+			row.Build = buildInfo(dir, tool)
+		}
+		if filter == nil || filter(row) {
+			listed = append(listed, row)
+		}
+	}
+	if less != nil {
+		sort.SliceStable(listed, func(i, j int) bool { return less(listed[i], listed[j]) })
+	}
+
+	if r.options.structured() {
+		return writeResults(r.options, listed)
+	}
+	r.printList(listed)
+	return nil
+}
+
+// listTool returns the row of the tool installed in dir
+func listTool(tool types.Tool, dir string, st *state.State) ListedTool {
+	row := ListedTool{Name: tool.Name, Latest: tool.Version, Path: dir, Deprecated: tool.Deprecated}
+	if installed, ok := st.Get(tool.Name); ok {
+		row.Source = string(installed.Source)
+		row.Origin = installed.Origin
+		row.Updated = installed.Updated
+		row.FirstInstalled = installed.Installed
+		row.Pinned = installed.Pinned
+		row.Nightly = installed.Nightly
+		row.Ref = installed.Ref
+		row.Installed = installed.Version
+	}
+
+	if reason := pkg.Broken(dir, tool); reason != "" {
+		row.Status, row.Broken = statusBroken, reason
+		return row
+	}
+	if pkg.IsDataPack(tool) {
+		version, ok := pkg.DataPackVersion(dir, tool)
+		if !ok {
+			row.Status = statusNotInstalled
+			return row
+		}
+		row.Installed = version
+		if tool.Version == "" {
+			row.Latest = version
+		}
+	} else {
+		version, err := pkg.InstalledVersion(tool, dir)
+		if err != nil {
+			row.Status = statusNotInstalled
+			if !pkg.HasAsset(tool) && tool.GoInstallPath == "" {
+				row.Status = statusNotSupported
+			}
+			return row
+		}
+		row.Installed = version
+		// binaries installed before the update time was recorded
+		if row.Updated == nil {
+			if executablePath, exists := path.GetExecutablePath(dir, tool.Name); exists {
+				if fi, err := os.Stat(executablePath); err == nil {
+					modTime := fi.ModTime()
+					row.Updated = &modTime
+				}
+			}
+		}
+	}
+	row.Status = statusLatest
+	if severity, outdated := versionGap(row.Installed, row.Latest); outdated && !row.Nightly {
+		row.Status, row.Severity = statusOutdated, severity
+	}
+	return row
+}
+
+// printList prints the tool list as a table, -wide adds the source, origin,
+// path, first install, go version and revision columns
+func (r *Runner) printList(listed []ListedTool) {
+	header := []string{"NAME", "INSTALLED", "LATEST", "STATUS", "UPDATED"}
+	if r.options.Wide {
+		header = append(header, "SOURCE", "ORIGIN", "PATH", "FIRST INSTALLED", "GO", "REVISION")
+	}
+	t := &table{header: header}
+	for _, row := range listed {
+		status := row.Status
+		if row.Broken != "" {
+			status += ": " + row.Broken
+		}
+		var flags []string
+		if row.Pinned {
+			flags = append(flags, "pinned")
+		}
+		if row.Nightly {
+			flags = append(flags, types.Nightly)
+		}
+		if row.Ref != "" {
+			flags = append(flags, "ref "+row.Ref)
+		}
+		if row.Deprecated != "" {
+			flags = append(flags, "deprecated")
+		}
+		if len(flags) > 0 {
+			status += " (" + strings.Join(flags, ", ") + ")"
+		}
+		var updated string
+		if row.Updated != nil {
+			updated = timeAgo(*row.Updated)
+		}
+		cells := []string{row.Name, dash(row.Installed), dash(row.Latest), status, dash(updated)}
+		if r.options.Wide {
+			var firstInstalled string
+			if row.FirstInstalled != nil {
+				firstInstalled = row.FirstInstalled.Format("2006-01-02")
+			}
+			var goVersion, revision string
+			if row.Build != nil {
+				goVersion, revision = row.Build.GoVersion, shortRevision(row.Build.Revision)
+				if row.Build.Modified {
+					revision += "+dirty"
+				}
+			}
+			cells = append(cells, dash(row.Source), dash(row.Origin), row.Path, dash(firstInstalled), dash(goVersion), dash(revision))
+		}
+		t.rows = append(t.rows, cells)
+	}
+	t.color = func(row, column int, cell string) string {
+		// the latest version and status of the outdated tools are colored by
+		// the size of the version gap
+		if (column == 2 || column == 3) && listed[row].Status == statusOutdated {
+			return severityColor(listed[row].Severity, cell)
+		}
+		if column == 4 {
+			if updated := listed[row].Updated; updated != nil && time.Since(*updated) > staleAfter {
+				return au.BrightYellow(cell).String()
+			}
+			return cell
+		}
+		if column != 3 {
+			return cell
+		}
+		switch listed[row].Status {
+		case statusLatest:
+			return au.BrightGreen(cell).String()
+		case statusBroken:
+			return au.Red(cell).String()
+		case statusNotInstalled:
+			return au.BrightYellow(cell).String()
+		default:
+			return au.Gray(10, cell).String()
+		}
+	}
+	t.print(os.Stdout)
+}
+
+// severityColor colors the cell of an update by the size of its version gap,
+// patch updates in green, minor in yellow and major (or unknown) in red
+func severityColor(severity, cell string) string {
+	switch severity {
+	case "patch":
+		return au.BrightGreen(cell).String()
+	case "minor":
+		return au.BrightYellow(cell).String()
+	default:
+		return au.Red(cell).String()
+	}
+}
+
+// timeAgo returns how long ago t was in a human readable way (eg. 3 days ago)
+func timeAgo(t time.Time) string {
+	elapsed := time.Since(t)
+	plural := func(count int, unit string) string {
+		if count == 1 {
+			return fmt.Sprintf("1 %s ago", unit)
+		}
+		return fmt.Sprintf("%d %ss ago", count, unit)
+	}
+	switch {
+	case elapsed < time.Minute:
+		return "just now"
+	case elapsed < time.Hour:
+		return plural(int(elapsed/time.Minute), "minute")
+	case elapsed < 24*time.Hour:
+		return plural(int(elapsed/time.Hour), "hour")
+	case elapsed < 60*24*time.Hour:
+		return plural(int(elapsed/(24*time.Hour)), "day")
+	case elapsed < 365*24*time.Hour:
+		return plural(int(elapsed/(30*24*time.Hour)), "month")
+	default:
+		return plural(int(elapsed/(365*24*time.Hour)), "year")
+	}
+}
+
+// shortRevision returns the first 12 characters of a vcs revision
+func shortRevision(revision string) string {
+	if len(revision) > 12 {
+		return revision[:12]
+	}
+	return revision
+}
+
+func dash(value string) string {
+	if value == "" {
+		return "-"
+	}
+	return value
+}
@@ -0,0 +1,30 @@
+package runner
+
+import (
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestListSortVersions(t *testing.T) {
+	tools := []ListedTool{
+		{Name: "nuclei", Installed: "3.10.0", Latest: "v3.10.0"},
+		{Name: "httpx", Installed: "1.9.0", Latest: "v1.9.0"},
+		{Name: "dnsx"},
+		{Name: "katana", Installed: "1.10.1", Latest: "v1.10.1"},
+	}
+	names := func() []string {
+		var names []string
+		for _, tool := range tools {
+			names = append(names, tool.Name)
+		}
+		return names
+	}
+	sort.SliceStable(tools, func(i, j int) bool { return listSorts["installed"](tools[i], tools[j]) })
+	require.Equal(t, []string{"dnsx", "httpx", "katana", "nuclei"}, names())
+
+	tools[0], tools[3] = tools[3], tools[0]
+	sort.SliceStable(tools, func(i, j int) bool { return listSorts["latest"](tools[i], tools[j]) })
+	require.Equal(t, []string{"dnsx", "httpx", "katana", "nuclei"}, names())
+}
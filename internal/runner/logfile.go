@@ -0,0 +1,81 @@
+package runner
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/projectdiscovery/gologger/levels"
+	"github.com/projectdiscovery/gologger/writer"
+)
+
+// logFileName is the name of the log file in the logs directory
+const logFileName = "pdtm.log"
+
+// ansiEscape matches the color codes of the terminal output
+var ansiEscape = regexp.MustCompile(`\x1b\[[0-9;]*m`)
+
+// teeWriter writes the output to the terminal and to the log file
+type teeWriter struct {
+	cli  *writer.CLI
+	mu   sync.Mutex
+	file *os.File
+}
+
+var _ writer.Writer = &teeWriter{}
+
+// Write writes the output to the terminal and the timestamped line without
+// colors to the log file
+func (w *teeWriter) Write(data []byte, level levels.Level) {
+	w.cli.Write(data, level)
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	_, _ = fmt.Fprintf(w.file, "%s %s\n", time.Now().Format(time.RFC3339), ansiEscape.ReplaceAll(data, nil))
+}
+
+// openLogFile opens the log file of the directory for appending, the file is
+// rotated once larger than maxSize and the rotated files older than maxAge
+// are removed
+func openLogFile(dir string, maxSize int64, maxAge time.Duration) (*os.File, error) {
+	if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+		return nil, err
+	}
+	location := filepath.Join(dir, logFileName)
+	if fi, err := os.Stat(location); err == nil && maxSize > 0 && fi.Size() >= maxSize {
+		rotated := filepath.Join(dir, fmt.Sprintf("pdtm-%s.log", time.Now().Format("2006-01-02T15-04-05")))
+		if err := os.Rename(location, rotated); err != nil {
+			return nil, err
+		}
+	}
+	if maxAge > 0 {
+		for _, rotated := range rotatedLogFiles(dir) {
+			if fi, err := os.Stat(rotated); err == nil && time.Since(fi.ModTime()) > maxAge {
+				_ = os.Remove(rotated)
+			}
+		}
+	}
+	return os.OpenFile(location, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+}
+
+// rotatedLogFiles returns the rotated log files of the directory, oldest first
+func rotatedLogFiles(dir string) []string {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil
+	}
+	var files []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if name != logFileName && strings.HasPrefix(name, "pdtm-") && strings.HasSuffix(name, ".log") {
+			files = append(files, filepath.Join(dir, name))
+		}
+	}
+	sort.Strings(files)
+	return files
+}
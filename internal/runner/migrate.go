@@ -0,0 +1,38 @@
+package runner
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/projectdiscovery/gologger"
+	"github.com/projectdiscovery/pdtm/pkg"
+	"github.com/projectdiscovery/pdtm/pkg/path"
+	errorutil "github.com/projectdiscovery/utils/errors"
+)
+
+// migrate moves the managed binaries to the new path and updates its PATH entry
+func (r *Runner) migrate(to string) error {
+	to, err := filepath.Abs(to)
+	if err != nil {
+		return err
+	}
+	if !path.IsSubPath(homeDir, r.options.Path) || !path.IsSubPath(homeDir, to) {
+		return fmt.Errorf("skipping migrate outside home folder: %s", to)
+	}
+	count, err := pkg.Migrate(r.options.Path, to)
+	if err != nil {
+		return errorutil.NewWithErr(err).Msgf("could not migrate %s to %s", r.options.Path, to)
+	}
+	gologger.Info().Msgf("moved %d projects from %s to %s", count, r.options.Path, to)
+
+	if err := path.CleanENV(r.options.Path); err != nil {
+		gologger.Warning().Msgf("Failed to unset path: %s. Remove it from $PATH manually", r.options.Path)
+	}
+	if err := path.SetENV(to); err != nil {
+		return errorutil.NewWithErr(err).Msgf(`Failed to set path: %s. Add it to $PATH and run again`, to)
+	}
+	if to != defaultPath {
+		gologger.Info().Msgf("use -binary-path %s (or set binary-path in %s) to manage the migrated projects", to, r.options.ConfigFile)
+	}
+	return nil
+}
@@ -0,0 +1,83 @@
+package runner
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/projectdiscovery/gologger"
+	"github.com/projectdiscovery/pdtm/pkg/path"
+)
+
+// notifyUpdates sends the summary of the update run with notify and to the
+// webhook, nothing is sent when no tool was updated or failed
+func (r *Runner) notifyUpdates(results []UpdateResult) {
+	if !r.options.Notify && r.options.NotifyWebhook == "" {
+		return
+	}
+	message, ok := updateMessage(results)
+	if !ok {
+		return
+	}
+	if r.options.Notify {
+		if err := r.sendNotify(message); err != nil {
+			gologger.Warning().Msgf("could not send the update summary with notify: %s", err)
+		}
+	}
+	if r.options.NotifyWebhook != "" {
+		if err := sendWebhook(r.options.NotifyWebhook, message); err != nil {
+			gologger.Warning().Msgf("could not send the update summary to the webhook: %s", err)
+		}
+	}
+}
+
+// updateMessage returns the text summary of the updated and failed tools
+func updateMessage(results []UpdateResult) (string, bool) {
+	var lines []string
+	var updated, failed int
+	for _, result := range results {
+		switch result.Outcome {
+		case updateUpdated:
+			updated++
+			lines = append(lines, fmt.Sprintf("updated %s to %s", result.Name, result.Version))
+		case updateFailed:
+			failed++
+			lines = append(lines, fmt.Sprintf("failed to update %s: %s", result.Name, result.Reason))
+		}
+	}
+	if len(lines) == 0 {
+		return "", false
+	}
+	host, _ := os.Hostname()
+	header := fmt.Sprintf("pdtm on %s: %d updated, %d failed", host, updated, failed)
+	return header + "\n" + strings.Join(lines, "\n"), true
+}
+
+// sendNotify sends the message with the notify tool managed by pdtm (or
+// found in $PATH) using its own provider config
+func (r *Runner) sendNotify(message string) error {
+	binary, exists := path.GetExecutablePath(r.pathFor("notify"), "notify")
+	if !exists {
+		var err error
+		if binary, err = exec.LookPath("notify"); err != nil {
+			return fmt.Errorf("notify is not installed, install it with pdtm -install notify")
+		}
+	}
+	cmd := exec.Command(binary, "-silent", "-bulk")
+	cmd.Stdin = strings.NewReader(message)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("%s: %s", err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// sendWebhook posts the message to a slack or discord compatible webhook
+func sendWebhook(url, message string) error {
+	b, err := json.Marshal(map[string]string{"text": message, "content": message})
+	if err != nil {
+		return err
+	}
+	return postJSON(url, b)
+}
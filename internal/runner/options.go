@@ -3,14 +3,20 @@ package runner
 import (
 	"os"
 	"path/filepath"
+	"strings"
+	"text/template"
+	"time"
 
 	"github.com/logrusorgru/aurora/v4"
 	"github.com/projectdiscovery/goflags"
 	"github.com/projectdiscovery/gologger"
 	"github.com/projectdiscovery/gologger/formatter"
 	"github.com/projectdiscovery/gologger/levels"
+	"github.com/projectdiscovery/gologger/writer"
+	"github.com/projectdiscovery/pdtm/pkg"
+	"github.com/projectdiscovery/pdtm/pkg/httpclient"
+	"github.com/projectdiscovery/pdtm/pkg/types"
 	fileutil "github.com/projectdiscovery/utils/file"
-	updateutils "github.com/projectdiscovery/utils/update"
 )
 
 var (
@@ -26,32 +32,203 @@ var (
 	defaultConfigLocation = filepath.Join(homeDir, ".config/pdtm/config.yaml")
 	cacheFile             = filepath.Join(homeDir, ".config/pdtm/cache.json")
 	defaultPath           = filepath.Join(homeDir, ".pdtm/go/bin")
+	toolchainDir          = filepath.Join(homeDir, ".pdtm/toolchain")
+	lockFile              = filepath.Join(homeDir, ".config/pdtm/pdtm.lock")
+	logsDir               = filepath.Join(homeDir, ".config/pdtm/logs")
+	serveCacheDir         = filepath.Join(homeDir, ".config/pdtm/serve")
+	versionCheckFile      = filepath.Join(homeDir, ".config/pdtm/version-check.json")
+	daemonStatusFile      = filepath.Join(homeDir, ".config/pdtm/daemon.json")
+	apiTokenFile          = filepath.Join(homeDir, ".config/pdtm/api-token")
+	execDir               = filepath.Join(homeDir, ".pdtm/exec")
+	archiveCacheDir       = filepath.Join(userCacheDir(), "archives")
+	binaryStoreDir        = filepath.Join(homeDir, ".pdtm/store")
 )
 
+// userCacheDir returns the pdtm directory of the user cache directory
+// ($XDG_CACHE_HOME, ~/Library/Caches or %LocalAppData%), the config
+// directory when it's unknown
+func userCacheDir() string {
+	if dir, err := os.UserCacheDir(); err == nil {
+		return filepath.Join(dir, "pdtm")
+	}
+	return filepath.Join(homeDir, ".config/pdtm")
+}
+
+// lockTimeout is how long a run waits for another pdtm process to finish
+const lockTimeout = 10 * time.Minute
+
 var au *aurora.Aurora
 
 // Options contains the configuration options for tuning the enumeration process.
 type Options struct {
 	ConfigFile string
 	Path       string
-	NoColor    bool
-	SetPath    bool
-	UnSetPath  bool
+	LinkDir    string
+	// System installs into the root owned system path with sudo
+	System bool
+
+	ConfigList  bool
+	ConfigGet   string
+	ConfigSet   goflags.StringSlice
+	ConfigUnset goflags.StringSlice
+	NoColor     bool
+	SetPath     bool
+	UnSetPath   bool
 
 	Install goflags.StringSlice
 	Update  goflags.StringSlice
 	Remove  goflags.StringSlice
+	Pin     goflags.StringSlice
+	Unpin   goflags.StringSlice
+	// PickUpdates shows the outdated projects to pick the ones to update
+	PickUpdates bool
+
+	Schedule    goflags.StringSlice
+	Blackout    goflags.StringSlice
+	RenameAlias bool
+
+	History    string
+	Info       string
+	Why        string
+	Rollback   string
+	RollbackTo string
+
+	// With contains the project versions the command after -- is run with
+	With    goflags.StringSlice
+	Command []string
+
+	Reinstall goflags.StringSlice
+	Latest    bool
+	Repair    bool
+	Yes       bool
+	Paranoid  bool
+
+	KeepQuarantine bool
+
+	Archive string
+	Ref     string
+	Commit  string
+
+	GoBootstrap bool
+	NoDeps      bool
 
 	InstallAll bool
 	UpdateAll  bool
 	RemoveAll  bool
+	Purge      bool
+	Outdated   bool
+	Diff       bool
+
+	// GC removes the versions kept for rollbacks the retention doesn't allow
+	GC       bool
+	Keep     int
+	KeepAge  time.Duration
+	KeepSize int
+
+	Notify        bool
+	NotifyWebhook string
+
+	PathList    bool
+	PathAdd     string
+	PathRemove  string
+	PathDefault string
+	PathTool    goflags.StringSlice
+	// Env prints the statements of Shell adding the path to $PATH
+	Env   bool
+	Shell string
+	// Project installs the projects of the .pdtm.yaml of the directory
+	Project bool
+	// Local installs into the .pdtm/bin of the project of the directory
+	Local      bool
+	DirenvHook bool
+	Envrc      bool
+
+	Backup  string
+	Restore string
+	Migrate string
+	Export  string
+
+	Requirements    goflags.StringSlice
+	RequirementsAll bool
+	JSON            bool
+	Output          string
+	Format          string
+	ProgressJSON    bool
+	ReportURL       string
+
+	Serve          string
+	ServeMaxSize   int
+	Source         string
+	DownloadMirror string
+
+	Daemon  time.Duration
+	Metrics string
+	API     string
 
-	Verbose            bool
-	Silent             bool
-	Version            bool
-	ShowPath           bool
-	DisableUpdateCheck bool
-	DisableChangeLog   bool
+	ServiceInstall   bool
+	ServiceUninstall bool
+	ServiceInterval  time.Duration
+
+	Sort   string
+	Filter string
+	Wide   bool
+
+	Verbose             bool
+	Silent              bool
+	IPVersion           string
+	Resolvers           goflags.StringSlice
+	ProxyPAC            string
+	NoProxy             goflags.StringSlice
+	HostConcurrency     int
+	HostInterval        time.Duration
+	HostJitter          time.Duration
+	Log                 bool
+	LogMaxSize          int
+	LogMaxAge           time.Duration
+	Version             bool
+	ShowPath            bool
+	ShowStatus          bool
+	Verify              bool
+	AuthStatus          bool
+	DisableUpdateCheck  bool
+	UpdateCheckInterval time.Duration
+	DisableChangeLog    bool
+
+	Refresh  bool
+	CacheTTL time.Duration
+	// CacheDir is the directory of the caches, shareable between machines
+	CacheDir string
+
+	// format is the parsed -format template
+	format *template.Template
+
+	Config
+}
+
+// Config contains the settings that can only be set from the config file
+type Config struct {
+	// Channels contains the release channel of each tool (eg. nuclei: pre-release)
+	Channels map[string]types.Channel `yaml:"channels"`
+	// GoBuild contains the build settings used when installing with go install
+	GoBuild types.GoBuildConfig `yaml:"go-build"`
+	// AssetTemplates contains the release asset name template of each tool
+	AssetTemplates map[string]string `yaml:"asset-templates"`
+	// Provider contains the settings of the provider the releases are fetched from
+	Provider types.ProviderConfig `yaml:"provider"`
+	// RegistryKey is the minisign public key (or key file) the tool list
+	// and index must be signed with
+	RegistryKey string `yaml:"registry-key"`
+	// Dependencies contains additional dependencies of each tool (eg. shuffledns: [dnsx])
+	Dependencies map[string][]string `yaml:"dependencies"`
+	// Binaries contains the additional binaries extracted from the release
+	// archive of each tool (eg. example: [example-helper])
+	Binaries map[string][]string `yaml:"binaries"`
+	// PostInstall contains additional post-install steps of each tool
+	PostInstall map[string][]types.PostInstallStep `yaml:"post-install"`
+	// DataDirs contains the directory of each data pack (eg. nuclei-templates)
+	DataDirs map[string]string `yaml:"data-dirs"`
+	// Aliases contains the names accepted in place of the tool names (eg. sf: subfinder)
+	Aliases map[string]string `yaml:"aliases"`
 }
 
 // ParseOptions parses the command line flags provided by a user
@@ -64,38 +241,169 @@ func ParseOptions() *Options {
 	flagSet.CreateGroup("config", "Config",
 		flagSet.StringVar(&options.ConfigFile, "config", defaultConfigLocation, "cli flag configuration file"),
 		flagSet.StringVarP(&options.Path, "binary-path", "bp", defaultPath, "custom location to download project binary"),
+		flagSet.StringVarP(&options.LinkDir, "link-dir", "ld", "", "create symlinks of the installed projects in a system directory (eg. /usr/local/bin)"),
+		flagSet.BoolVar(&options.System, "system", false, "manage the projects installed in /usr/local/lib/pdtm and linked in /usr/local/bin, writing them with sudo"),
+		flagSet.DurationVarP(&options.CacheTTL, "cache-ttl", "ct", time.Hour, "duration the cached tool list is used without fetching it (0 to disable)"),
+		flagSet.StringVarP(&options.CacheDir, "cache-dir", "cd", "", "directory of the tool list and release archive caches, shareable between machines (eg. a network mount or ci volume)"),
+		flagSet.BoolVar(&options.Refresh, "refresh", false, "fetch the tool list ignoring the cache"),
+		flagSet.BoolVarP(&options.ConfigList, "config-list", "cl", false, "list the settings of the config file"),
+		flagSet.StringVarP(&options.ConfigGet, "config-get", "cg", "", "show the value of a setting of the config file"),
+		flagSet.StringSliceVarP(&options.ConfigSet, "config-set", "cs", nil, "set settings of the config file (comma separated key=value, eg. channels.nuclei=pre-release)", goflags.CommaSeparatedStringSliceOptions),
+		flagSet.StringSliceVarP(&options.ConfigUnset, "config-unset", "cu", nil, "remove settings from the config file (comma separated)", goflags.CommaSeparatedStringSliceOptions),
 	)
 
 	flagSet.CreateGroup("install", "Install",
-		flagSet.StringSliceVarP(&options.Install, "install", "i", nil, "install single or multiple project by name (comma separated)", goflags.NormalizedStringSliceOptions),
+		flagSet.StringSliceVarP(&options.Install, "install", "i", nil, "install single or multiple project by name (comma separated, supports name@version and name@nightly)", goflags.NormalizedStringSliceOptions),
 		flagSet.BoolVarP(&options.InstallAll, "install-all", "ia", false, "install all the projects"),
+		flagSet.StringVarP(&options.Archive, "archive", "ar", "", "install the project from a local release archive (use with -install)"),
+		flagSet.StringVar(&options.Ref, "ref", "", "build the project from a git branch or tag with go install (use with -install)"),
+		flagSet.StringVar(&options.Commit, "commit", "", "build the project from a git commit with go install (use with -install)"),
+		flagSet.BoolVarP(&options.GoBootstrap, "go-bootstrap", "gb", false, "download a go toolchain when go install is required but go is not installed"),
+		flagSet.BoolVarP(&options.NoDeps, "no-deps", "nd", false, "don't install the projects the installed projects depend on"),
 		flagSet.BoolVarP(&options.SetPath, "install-path", "ip", false, "append path to PATH environment variables"),
+		flagSet.StringSliceVarP(&options.Reinstall, "reinstall", "ri", nil, "reinstall single or multiple project at the installed version (comma separated)", goflags.NormalizedStringSliceOptions),
+		flagSet.BoolVar(&options.Latest, "latest", false, "reinstall the projects at the latest version (use with -reinstall)"),
+		flagSet.BoolVar(&options.Repair, "repair", false, "reinstall the projects whose binary is missing, empty or not executable"),
+		flagSet.BoolVar(&options.Paranoid, "paranoid", false, "verify the sha256 of the installed binaries on every run, reporting the modified ones as broken"),
+		flagSet.BoolVarP(&options.Yes, "yes", "y", false, "answer yes to the confirmation prompts (download size, post-install steps, remove-all)"),
+		flagSet.StringSliceVar(&options.With, "with", nil, "run the command after -- with the project versions installed in a cache, leaving the installed ones untouched (eg. -with nuclei@v3.0.4 -- nuclei -u target)", goflags.NormalizedStringSliceOptions),
+		flagSet.BoolVarP(&options.KeepQuarantine, "keep-quarantine", "kq", false, "keep the macOS quarantine attribute and windows mark-of-the-web of the installed binaries"),
 	)
 
 	flagSet.CreateGroup("update", "Update",
 		flagSet.StringSliceVarP(&options.Update, "update", "u", nil, "update single or multiple project by name (comma separated)", goflags.NormalizedStringSliceOptions),
 		flagSet.BoolVarP(&options.UpdateAll, "update-all", "ua", false, "update all the projects"),
+		flagSet.BoolVarP(&options.PickUpdates, "update-pick", "upk", false, "pick the outdated projects to update from a list (interactive)"),
+		flagSet.BoolVarP(&options.Outdated, "outdated", "od", false, "show outdated projects (exit code 1 if updates are available, 2 if a check failed)"),
+		flagSet.BoolVar(&options.Diff, "diff", false, "preview the pending updates (version jump, release date, download size, breaking changes)"),
+		flagSet.StringSliceVar(&options.Pin, "pin", nil, "pin single or multiple project to the installed version (comma separated)", goflags.NormalizedStringSliceOptions),
+		flagSet.StringSliceVar(&options.Unpin, "unpin", nil, "unpin single or multiple project (comma separated)", goflags.NormalizedStringSliceOptions),
+		flagSet.StringVarP(&options.History, "history", "hist", "", "show the versions of a project installed on this machine"),
+		flagSet.StringVar(&options.Why, "why", "", "explain why a project is installed (requested, dependency of other projects or listed in the project file)"),
+		flagSet.StringVar(&options.Info, "info", "", "show the install details and go build info (go version, vcs revision, build settings) of a project"),
+		flagSet.StringVarP(&options.Rollback, "rollback", "rb", "", "roll back a project to its previous version (or to the -to version) and pin it"),
+		flagSet.StringVar(&options.RollbackTo, "to", "", "version to roll back to (use with -rollback)"),
+		flagSet.StringSliceVar(&options.Schedule, "schedule", nil, "minimum interval between the update checks of -update-all per project (eg. nuclei=24h,*=168h)", goflags.NormalizedStringSliceOptions),
+		flagSet.StringSliceVar(&options.Blackout, "blackout", nil, "local time windows during which -update-all updates nothing (eg. 9-17,22:30-06:00)", goflags.NormalizedStringSliceOptions),
+		flagSet.BoolVarP(&options.RenameAlias, "rename-alias", "rna", false, "leave a link with the previous name of the projects renamed upstream when migrating them"),
 		flagSet.CallbackVarP(GetUpdateCallback(), "self-update", "up", "update pdtm to latest version"),
 		flagSet.BoolVarP(&options.DisableUpdateCheck, "disable-update-check", "duc", false, "disable automatic pdtm update check"),
+		flagSet.DurationVarP(&options.UpdateCheckInterval, "update-check-interval", "uci", 24*time.Hour, "minimum interval between two automatic pdtm update checks"),
+		flagSet.BoolVarP(&options.Notify, "notify", "n", false, "send the summary of the updates with notify (slack, discord, telegram...)"),
+		flagSet.StringVarP(&options.NotifyWebhook, "notify-webhook", "nw", "", "post the summary of the updates to a slack or discord compatible webhook url"),
 	)
 
 	flagSet.CreateGroup("remove", "Remove",
 		flagSet.StringSliceVarP(&options.Remove, "remove", "r", nil, "remove single or multiple project by name (comma separated)", goflags.NormalizedStringSliceOptions),
-		flagSet.BoolVarP(&options.RemoveAll, "remove-all", "ra", false, "remove all the projects"),
+		flagSet.BoolVarP(&options.RemoveAll, "remove-all", "ra", false, "remove all the projects managed by pdtm and their path from PATH"),
+		flagSet.BoolVar(&options.Purge, "purge", false, "remove the data directories of the projects installed by pdtm and removed by -remove-all (requires -yes when not interactive)"),
+		flagSet.BoolVar(&options.GC, "gc", false, "remove the versions kept for rollbacks beyond the retention (-keep, -keep-age, -keep-size)"),
+		flagSet.IntVar(&options.Keep, "keep", 2, "number of previous versions of each project kept for rollbacks"),
+		flagSet.DurationVarP(&options.KeepAge, "keep-age", "ka", 0, "age after which the versions kept for rollbacks are removed (eg. 720h, 0 to disable)"),
+		flagSet.IntVarP(&options.KeepSize, "keep-size", "ks", 0, "size in MB of the versions kept for rollbacks above which the oldest are removed (0 to disable)"),
 		flagSet.BoolVarP(&options.UnSetPath, "remove-path", "rp", false, "remove path from PATH environment variables"),
 	)
 
+	flagSet.CreateGroup("path", "Path",
+		flagSet.BoolVarP(&options.PathList, "path-list", "pl", false, "list the paths managed by pdtm"),
+		flagSet.StringVarP(&options.PathAdd, "path-add", "pa", "", "add a path projects can be installed to (eg. a shared /opt path)"),
+		flagSet.StringVarP(&options.PathRemove, "path-remove", "pr", "", "remove a path from the managed paths"),
+		flagSet.StringVarP(&options.PathDefault, "path-default", "pd", "", "set the managed path projects are installed to by default"),
+		flagSet.StringSliceVarP(&options.PathTool, "path-tool", "pt", nil, "assign projects to a managed path (comma separated name=path, empty path to unassign)", goflags.NormalizedStringSliceOptions),
+		flagSet.BoolVar(&options.Env, "env", false, "print the statements adding the path to $PATH instead of editing the shell config (eval \"$(pdtm -env)\")"),
+		flagSet.StringVar(&options.Shell, "shell", "", "shell of the -env statements (bash, zsh, sh, fish, nu, elvish, powershell, default $SHELL)"),
+		flagSet.BoolVar(&options.Project, "project", false, "install the projects of the .pdtm.yaml of the directory in its .pdtm/bin (with -env to only print its activation)"),
+		flagSet.BoolVar(&options.Local, "local", false, "install into the .pdtm/bin of the directory and add the projects to its .pdtm.yaml (use with -install)"),
+		flagSet.BoolVarP(&options.DirenvHook, "direnv-hook", "dh", false, "print the direnv function activating the projects of the .pdtm.yaml with use pdtm"),
+		flagSet.BoolVar(&options.Envrc, "envrc", false, "add use pdtm to the .envrc of the directory (and create its .pdtm.yaml)"),
+	)
+
+	flagSet.CreateGroup("backup", "Backup",
+		flagSet.StringVarP(&options.Backup, "backup", "bk", "", "backup the managed binaries, their kept versions, the data packs and their state to a tar.zst file"),
+		flagSet.StringVarP(&options.Restore, "restore", "rs", "", "restore the managed binaries, their kept versions, the data packs and their state from a tar.zst backup"),
+		flagSet.StringVarP(&options.Migrate, "migrate", "mg", "", "move the managed binaries, their kept versions and their state to a new binary path"),
+		flagSet.StringVarP(&options.Export, "export", "ex", "", "export the installed versions of the projects to install them without pdtm (script, powershell, dockerfile, devcontainer)"),
+	)
+
+	flagSet.CreateGroup("requirements", "Requirements",
+		flagSet.StringSliceVarP(&options.Requirements, "requirements", "req", nil, "show requirements of single or multiple project by name (comma separated)", goflags.NormalizedStringSliceOptions),
+		flagSet.BoolVarP(&options.RequirementsAll, "requirements-all", "reqa", false, "show unmet requirements of all the projects"),
+	)
+
+	flagSet.CreateGroup("serve", "Serve",
+		flagSet.StringVar(&options.Serve, "serve", "", "serve the release metadata and assets to other pdtm clients from a cache (eg. :8080)"),
+		flagSet.IntVarP(&options.ServeMaxSize, "serve-max-size", "sms", 10240, "size in MB of the cache of -serve above which the least recently used responses are removed (0 to disable)"),
+		flagSet.StringVarP(&options.Source, "source", "src", "", "fetch the release metadata and assets through a pdtm cache server (eg. http://cache:8080)"),
+		flagSet.StringVarP(&options.DownloadMirror, "download-mirror", "dm", "", "download the github release assets from a mirror or cdn first, falling back to github (eg. https://dl.example.com)"),
+	)
+
+	flagSet.CreateGroup("daemon", "Daemon",
+		flagSet.DurationVar(&options.Daemon, "daemon", 0, "run in the foreground and update all the projects every interval (eg. 12h)"),
+		flagSet.StringVar(&options.Metrics, "metrics", "", "serve the prometheus metrics of the daemon on the address (eg. 127.0.0.1:9090)"),
+		flagSet.StringVar(&options.API, "api", "", "serve the api of the daemon on a loopback address or unix socket (eg. 127.0.0.1:9091, unix:/run/user/1000/pdtm.sock)"),
+		flagSet.BoolVarP(&options.ServiceInstall, "service-install", "si", false, "install a systemd user timer, launchd agent or scheduled task running -update-all"),
+		flagSet.BoolVarP(&options.ServiceUninstall, "service-uninstall", "su", false, "uninstall the service installed with -service-install"),
+		flagSet.DurationVarP(&options.ServiceInterval, "service-interval", "sin", 12*time.Hour, "interval of the updates of the service"),
+	)
+
+	flagSet.CreateGroup("network", "Network",
+		flagSet.StringVarP(&options.IPVersion, "ip-version", "iv", "auto", "ip version used for the api calls and downloads (4, 6 or auto)"),
+		flagSet.StringSliceVarP(&options.Resolvers, "resolvers", "dns", nil, "dns resolvers (ip[:port] or DNS-over-HTTPS url) used for the api calls and downloads", goflags.CommaSeparatedStringSliceOptions),
+		flagSet.StringVarP(&options.ProxyPAC, "proxy-pac", "pac", "", "proxy auto-config file or url choosing the proxy of the api calls and downloads"),
+		flagSet.StringSliceVarP(&options.NoProxy, "no-proxy", "np", nil, "hosts, domains and networks (CIDR) reached without proxy, in addition to NO_PROXY", goflags.CommaSeparatedStringSliceOptions),
+		flagSet.IntVarP(&options.HostConcurrency, "host-concurrency", "hc", httpclient.DefaultPacing.MaxConcurrent, "maximum number of concurrent requests per host"),
+		flagSet.DurationVarP(&options.HostInterval, "host-interval", "hi", 0, "minimum delay between two requests to the same host (eg. 500ms)"),
+		flagSet.DurationVarP(&options.HostJitter, "host-jitter", "hj", 0, "maximum random delay added to the delay between requests"),
+	)
+
+	flagSet.CreateGroup("output", "Output",
+		flagSet.BoolVarP(&options.JSON, "json", "j", false, "write output in JSON format (same as -output json)"),
+		flagSet.StringVarP(&options.Output, "output", "o", "", "write output in a structured format (json, yaml)"),
+		flagSet.StringVarP(&options.Format, "format", "f", "", "write each result with a go template (eg. '{{.Name}} {{.Installed}}')"),
+		flagSet.BoolVarP(&options.ProgressJSON, "progress-json", "pj", false, "write the progress of the installs as newline-delimited json events to stderr"),
+		flagSet.StringVarP(&options.ReportURL, "report-url", "ru", "", "post a json report of the installed, updated and removed projects to the url"),
+		flagSet.StringVar(&options.Sort, "sort", "", "sort the list by column (name, installed, latest, status, source, origin, path, updated)"),
+		flagSet.StringVar(&options.Filter, "filter", "", "filter the list (installed, outdated, notinstalled)"),
+		flagSet.BoolVar(&options.Wide, "wide", false, "show the install source, origin, path, first install, go version and vcs revision of the projects in the list"),
+	)
+
 	flagSet.CreateGroup("debug", "Debug",
 		flagSet.BoolVarP(&options.ShowPath, "show-path", "sp", false, "show the current binary path then exit"),
+		flagSet.BoolVar(&options.Verify, "verify", false, "cross-check the recorded, -version and go build info versions and the sha256 of the installed binaries (exit code 1 on mismatch)"),
+		flagSet.BoolVar(&options.AuthStatus, "auth-status", false, "check the validity, scopes, expiration and rate limit of GITHUB_TOKEN (exit code 1 if github rejects it)"),
+		flagSet.BoolVar(&options.ShowStatus, "status", false, "show a summary of the projects, disk usage, path, update checks, rate limit and daemon"),
 		flagSet.BoolVar(&options.Version, "version", false, "show version of the project"),
 		flagSet.BoolVarP(&options.Verbose, "verbose", "v", false, "show verbose output"),
 		flagSet.BoolVarP(&options.NoColor, "no-color", "nc", false, "disable output content coloring (ANSI escape codes)"),
+		flagSet.BoolVar(&options.Log, "log", false, "write the output to a log file in $HOME/.config/pdtm/logs"),
+		flagSet.IntVarP(&options.LogMaxSize, "log-max-size", "lms", 10, "size in MB after which the log file is rotated"),
+		flagSet.DurationVarP(&options.LogMaxAge, "log-max-age", "lma", 30*24*time.Hour, "age after which the rotated log files are removed"),
 		flagSet.BoolVarP(&options.DisableChangeLog, "dc", "disable-changelog", false, "disable release changelog in output"),
 	)
 
 	if err := flagSet.Parse(); err != nil {
 		gologger.Fatal().Msgf("%s\n", err)
 	}
+	if err := applyEnv(flagSet.CommandLine); err != nil {
+		gologger.Fatal().Msgf("%s\n", err)
+	}
+	options.Command = flagSet.CommandLine.Args()
+
+	if options.JSON && options.Output == "" {
+		options.Output = outputJSON
+	}
+	switch options.Output {
+	case "", outputJSON, outputYAML:
+	default:
+		gologger.Fatal().Msgf("invalid output format %s (json, yaml)\n", options.Output)
+	}
+	if options.Format != "" {
+		format, err := parseFormat(options.Format)
+		if err != nil {
+			gologger.Fatal().Msgf("invalid format: %s\n", err)
+		}
+		options.format = format
+	}
 
 	// configure aurora for logging
 	au = aurora.New(aurora.WithColors(true))
@@ -115,21 +423,20 @@ func ParseOptions() *Options {
 		os.Exit(0)
 	}
 
-	if !options.DisableUpdateCheck {
-		latestVersion, err := updateutils.GetToolVersionCallback("pdtm", version)()
-		if err != nil {
-			if options.Verbose {
-				gologger.Error().Msgf("pdtm version check failed: %v", err.Error())
-			}
-		} else {
-			gologger.Info().Msgf("Current pdtm version %v %v", version, updateutils.GetVersionDescription(version, latestVersion))
-		}
-	}
-
 	if options.ConfigFile != defaultConfigLocation {
 		_ = options.loadConfigFrom(options.ConfigFile)
 	}
+	if fileutil.FileExists(options.ConfigFile) {
+		if err := fileutil.Unmarshal(fileutil.YAML, []byte(options.ConfigFile), &options.Config); err != nil {
+			gologger.Warning().Msgf("could not read config file %s: %s", options.ConfigFile, err)
+		}
+	}
+	options.validateConfig()
 
+	// checked once the config is read, it can disable the check
+	if !options.DisableUpdateCheck {
+		checkVersion(options.UpdateCheckInterval, options.Verbose)
+	}
 	return options
 }
 
@@ -146,6 +453,61 @@ func (options *Options) configureOutput() {
 	if options.Silent {
 		gologger.DefaultLogger.SetMaxLevel(levels.LevelSilent)
 	}
+	if options.Log {
+		file, err := openLogFile(logsDir, int64(options.LogMaxSize)*1024*1024, options.LogMaxAge)
+		if err != nil {
+			gologger.Warning().Msgf("could not open log file: %s", err)
+			return
+		}
+		gologger.DefaultLogger.SetWriter(&teeWriter{cli: writer.NewCLI(), file: file})
+	}
+}
+
+// validateConfig drops the invalid settings of the config file
+func (options *Options) validateConfig() {
+	for tool, channel := range options.Channels {
+		switch channel {
+		case types.Stable, types.PreRelease:
+		default:
+			gologger.Warning().Msgf("unknown release channel %s for %s, using %s", channel, tool, types.Stable)
+			delete(options.Channels, tool)
+		}
+	}
+	for tool, text := range options.AssetTemplates {
+		if _, err := pkg.ParseAssetTemplate(text); err != nil {
+			gologger.Warning().Msgf("invalid asset template for %s: %s", tool, err)
+			delete(options.AssetTemplates, tool)
+		}
+	}
+}
+
+// readOnly returns true if the run only reads the installed projects, so it
+// doesn't wait for the lock of the runs changing them
+func (options *Options) readOnly() bool {
+	if options.Project || options.Restore != "" || options.Migrate != "" || options.GC {
+		return false
+	}
+	return options.Backup != "" || options.AuthStatus || options.ConfigList || options.ConfigGet != "" || options.PathList ||
+		len(options.Requirements) > 0 || options.RequirementsAll || options.ShowStatus || options.Verify || options.Outdated ||
+		options.Diff || options.Export != "" || options.Info != "" || options.Why != "" || options.History != ""
+}
+
+// gitRef returns the git ref to build the installed projects from
+func (options *Options) gitRef() string {
+	if options.Commit != "" {
+		return options.Commit
+	}
+	return options.Ref
+}
+
+// channel returns the configured release channel of a tool
+func (options *Options) channel(toolName string) types.Channel {
+	for tool, channel := range options.Channels {
+		if strings.EqualFold(tool, toolName) {
+			return channel
+		}
+	}
+	return types.Stable
 }
 
 func (options *Options) loadConfigFrom(location string) error {
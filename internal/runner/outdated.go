@@ -0,0 +1,153 @@
+package runner
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/projectdiscovery/gologger"
+	"github.com/projectdiscovery/pdtm/pkg"
+	ospath "github.com/projectdiscovery/pdtm/pkg/path"
+	"github.com/projectdiscovery/pdtm/pkg/types"
+)
+
+// exit codes of the outdated check and of the update summary
+const (
+	exitOutdated     = 1
+	exitCheckFailed  = 2
+	exitUpdateFailed = 2
+)
+
+// ExitCodeError is returned when pdtm has to exit with a specific exit code
+type ExitCodeError struct {
+	Code int
+	Err  error
+}
+
+func (e *ExitCodeError) Error() string {
+	if e.Err == nil {
+		return fmt.Sprintf("exit code %d", e.Code)
+	}
+	return e.Err.Error()
+}
+
+func (e *ExitCodeError) Unwrap() error {
+	return e.Err
+}
+
+// OutdatedTool contains the installed and latest version of an outdated tool
+type OutdatedTool struct {
+	Name      string `json:"name" yaml:"name"`
+	Installed string `json:"installed" yaml:"installed"`
+	Latest    string `json:"latest" yaml:"latest"`
+	// Severity is the size of the version gap (major, minor, patch or unknown)
+	Severity string `json:"severity" yaml:"severity"`
+}
+
+// outdatedCheck is an installed tool having a newer release
+type outdatedCheck struct {
+	OutdatedTool
+	latest types.Tool
+}
+
+// checkOutdated returns the installed tools having a newer release and the
+// number of tools that couldn't be checked
+func (r *Runner) checkOutdated(tools []types.Tool) ([]outdatedCheck, int) {
+	var outdated []outdatedCheck
+	var failed int
+	for _, tool := range tools {
+		dir := r.pathFor(tool.Name)
+		var installed string
+		if pkg.IsDataPack(tool) {
+			version, ok := pkg.DataPackVersion(dir, tool)
+			if !ok {
+				continue
+			}
+			installed = version
+		} else {
+			if _, exists := ospath.GetExecutablePath(dir, tool.Name); !exists {
+				continue
+			}
+			version, err := pkg.InstalledVersion(tool, dir)
+			if err != nil {
+				gologger.Error().Msgf("could not get installed version of %s: %s", tool.Name, err)
+				failed++
+				continue
+			}
+			installed = version
+		}
+		if pkg.IsNightly(installed) {
+			continue
+		}
+		latest, err := r.resolve(tool, "")
+		if err != nil {
+			gologger.Error().Msgf("could not get latest version of %s: %s", tool.Name, err)
+			failed++
+			continue
+		}
+		severity, ok := versionGap(installed, latest.Version)
+		if !ok {
+			continue
+		}
+		outdated = append(outdated, outdatedCheck{
+			OutdatedTool: OutdatedTool{Name: tool.Name, Installed: installed, Latest: latest.Version, Severity: severity},
+			latest:       latest,
+		})
+	}
+	return outdated, failed
+}
+
+// showOutdated prints the installed tools having a newer release, the exit
+// code is 1 when updates are available and 2 when a check failed
+func (r *Runner) showOutdated(tools []types.Tool) error {
+	outdated, failed := r.checkOutdated(tools)
+	if r.options.structured() {
+		results := make([]OutdatedTool, 0, len(outdated))
+		for _, result := range outdated {
+			results = append(results, result.OutdatedTool)
+		}
+		if err := writeResults(r.options, results); err != nil {
+			return err
+		}
+	} else {
+		for _, result := range outdated {
+			gologger.Silent().Msgf("%s %s ➡ %s (%s)", result.Name, au.Red(result.Installed).String(), severityColor(result.Severity, result.Latest), result.Severity)
+		}
+	}
+	switch {
+	case failed > 0:
+		return &ExitCodeError{Code: exitCheckFailed, Err: fmt.Errorf("could not check %d projects", failed)}
+	case len(outdated) > 0:
+		return &ExitCodeError{Code: exitOutdated}
+	default:
+		if !r.options.structured() {
+			gologger.Info().Msg("all projects are up to date")
+		}
+		return nil
+	}
+}
+
+// versionGap returns the severity of the gap between the installed and
+// latest version, it returns false when the installed version isn't older
+func versionGap(installed, latest string) (string, bool) {
+	installed = strings.TrimPrefix(installed, "v")
+	latest = strings.TrimPrefix(latest, "v")
+	if strings.EqualFold(installed, latest) {
+		return "", false
+	}
+	installedVersion, err1 := semver.NewVersion(installed)
+	latestVersion, err2 := semver.NewVersion(latest)
+	if err1 != nil || err2 != nil {
+		return "unknown", true
+	}
+	switch {
+	case !installedVersion.LessThan(latestVersion):
+		return "", false
+	case installedVersion.Major() != latestVersion.Major():
+		return "major", true
+	case installedVersion.Minor() != latestVersion.Minor():
+		return "minor", true
+	default:
+		return "patch", true
+	}
+}
@@ -0,0 +1,98 @@
+package runner
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"text/template"
+
+	"github.com/projectdiscovery/gologger"
+	"github.com/projectdiscovery/pdtm/pkg"
+	"gopkg.in/yaml.v3"
+)
+
+// output formats of -output
+const (
+	outputJSON = "json"
+	outputYAML = "yaml"
+)
+
+// formatFuncs are the functions available in the -format templates
+var formatFuncs = template.FuncMap{
+	"json": func(v interface{}) (string, error) {
+		b, err := json.Marshal(v)
+		return string(b), err
+	},
+	"join":  strings.Join,
+	"upper": strings.ToUpper,
+	"lower": strings.ToLower,
+}
+
+func parseFormat(text string) (*template.Template, error) {
+	return template.New("format").Funcs(formatFuncs).Option("missingkey=error").Parse(text)
+}
+
+// structured returns true if the results are written as json, yaml or with
+// a template instead of the human readable output
+func (options *Options) structured() bool {
+	return options.Output != "" || options.format != nil
+}
+
+// writeResults writes the results in the format of the options, templates
+// and json are written one result per line and yaml as a single list
+func writeResults[T any](options *Options, results []T) error {
+	if options.format != nil {
+		for _, result := range results {
+			var buf bytes.Buffer
+			if err := options.format.Execute(&buf, result); err != nil {
+				return err
+			}
+			gologger.Silent().Msg(buf.String())
+		}
+		return nil
+	}
+	switch options.Output {
+	case outputJSON:
+		for _, result := range results {
+			b, err := json.Marshal(result)
+			if err != nil {
+				return err
+			}
+			gologger.Silent().Msg(string(b))
+		}
+	case outputYAML:
+		if len(results) == 0 {
+			gologger.Silent().Msg("[]")
+			return nil
+		}
+		var buf bytes.Buffer
+		enc := yaml.NewEncoder(&buf)
+		enc.SetIndent(2)
+		if err := enc.Encode(results); err != nil {
+			return err
+		}
+		if err := enc.Close(); err != nil {
+			return err
+		}
+		gologger.Silent().Msg(strings.TrimSuffix(buf.String(), "\n"))
+	default:
+		return fmt.Errorf("unknown output format %s", options.Output)
+	}
+	return nil
+}
+
+var progressMutex sync.Mutex
+
+// writeProgress writes the progress event as a json line to stderr
+func writeProgress(event pkg.Event) {
+	b, err := json.Marshal(event)
+	if err != nil {
+		return
+	}
+	progressMutex.Lock()
+	defer progressMutex.Unlock()
+	_, _ = os.Stderr.Write(append(b, '\n'))
+}
@@ -0,0 +1,207 @@
+package runner
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/projectdiscovery/gologger"
+	"github.com/projectdiscovery/pdtm/pkg/path"
+	"github.com/projectdiscovery/pdtm/pkg/state"
+	sliceutil "github.com/projectdiscovery/utils/slice"
+)
+
+var pathsFile = filepath.Join(homeDir, ".config/pdtm/paths.json")
+
+// ManagedPaths contains the additional install roots managed by pdtm
+type ManagedPaths struct {
+	// Default is the path used instead of the default binary path
+	Default string `json:"default,omitempty"`
+	// Paths contains the additional paths the tools can be installed to
+	Paths []string `json:"paths,omitempty"`
+	// Tools contains the path assigned to each tool
+	Tools map[string]string `json:"tools,omitempty"`
+}
+
+func loadManagedPaths() (*ManagedPaths, error) {
+	paths := &ManagedPaths{Tools: make(map[string]string)}
+	b, err := os.ReadFile(pathsFile)
+	if errors.Is(err, os.ErrNotExist) {
+		return paths, nil
+	}
+	if err != nil {
+		return paths, err
+	}
+	if err := json.Unmarshal(b, paths); err != nil {
+		return paths, err
+	}
+	if paths.Tools == nil {
+		paths.Tools = make(map[string]string)
+	}
+	return paths, nil
+}
+
+func (m *ManagedPaths) save() error {
+	b, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(pathsFile), os.ModePerm); err != nil {
+		return err
+	}
+	return os.WriteFile(pathsFile, b, 0644)
+}
+
+// pathFor returns the path the tool is managed in: the binary path given with
+// -binary-path, the path assigned to the tool, the managed path it's already
+// installed in or the default path
+func (r *Runner) pathFor(toolName string) string {
+	if r.explicitPath {
+		return r.options.Path
+	}
+	for name, dir := range r.paths.Tools {
+		if strings.EqualFold(name, toolName) {
+			return dir
+		}
+	}
+	for _, dir := range r.managedPaths() {
+		if _, exists := path.GetExecutablePath(dir, toolName); exists {
+			return dir
+		}
+		if st, err := state.Load(dir); err == nil {
+			if _, ok := st.Get(toolName); ok {
+				return dir
+			}
+		}
+	}
+	return r.options.Path
+}
+
+// managedPaths returns the default paths followed by the additional paths
+func (r *Runner) managedPaths() []string {
+	return sliceutil.Dedupe(append([]string{r.options.Path, defaultPath}, r.paths.Paths...))
+}
+
+// notWritableHint lists the alternatives to a path the user can't write to
+const notWritableHint = "use -system to manage it with sudo or -binary-path for a writable path"
+
+// checkWritable returns true if the binaries can be written to dir, the reason
+// and the alternatives are logged otherwise
+func (r *Runner) checkWritable(dir, action, toolName string) bool {
+	err := path.CheckWritable(dir)
+	if err == nil {
+		return true
+	}
+	gologger.Error().Msgf("could not %s %s: %s, %s", action, toolName, err, notWritableHint)
+	return false
+}
+
+// isAllowedPath returns true if pdtm may write to the path, paths outside of
+// the home folder have to be added explicitly with -path-add
+func (r *Runner) isAllowedPath(dir string) bool {
+	return path.IsSubPath(homeDir, dir) || sliceutil.Contains(r.paths.Paths, dir)
+}
+
+// managePaths handles the -path-* options, it returns false if none was given
+func (r *Runner) managePaths() (bool, error) {
+	switch {
+	case r.options.PathList:
+		for _, dir := range r.managedPaths() {
+			var markers []string
+			if dir == r.options.Path {
+				markers = append(markers, "default")
+			}
+			if path.IsSet(dir) {
+				markers = append(markers, "in $PATH")
+			}
+			for name, assigned := range r.paths.Tools {
+				if assigned == dir {
+					markers = append(markers, name)
+				}
+			}
+			if len(markers) > 0 {
+				gologger.Silent().Msgf("%s (%s)", dir, strings.Join(markers, ", "))
+			} else {
+				gologger.Silent().Msg(dir)
+			}
+		}
+		return true, nil
+	case r.options.PathAdd != "":
+		dir, err := filepath.Abs(r.options.PathAdd)
+		if err != nil {
+			return true, err
+		}
+		if !sliceutil.Contains(r.paths.Paths, dir) {
+			r.paths.Paths = append(r.paths.Paths, dir)
+		}
+		gologger.Info().Msgf("added %s to the managed paths", dir)
+		return true, r.paths.save()
+	case r.options.PathRemove != "":
+		dir, err := filepath.Abs(r.options.PathRemove)
+		if err != nil {
+			return true, err
+		}
+		r.paths.Paths = sliceutil.PruneEqual(r.paths.Paths, dir)
+		for name, assigned := range r.paths.Tools {
+			if assigned == dir {
+				delete(r.paths.Tools, name)
+			}
+		}
+		if r.paths.Default == dir {
+			r.paths.Default = ""
+		}
+		gologger.Info().Msgf("removed %s from the managed paths", dir)
+		return true, r.paths.save()
+	case r.options.PathDefault != "":
+		dir, err := filepath.Abs(r.options.PathDefault)
+		if err != nil {
+			return true, err
+		}
+		if !sliceutil.Contains(r.paths.Paths, dir) && dir != defaultPath {
+			return true, fmt.Errorf("%s is not a managed path, add it with -path-add first", dir)
+		}
+		r.paths.Default = dir
+		gologger.Info().Msgf("%s is now the default path", dir)
+		return true, r.paths.save()
+	case len(r.options.PathTool) > 0:
+		for _, assignment := range r.options.PathTool {
+			name, dir, ok := strings.Cut(assignment, "=")
+			if !ok {
+				return true, fmt.Errorf("invalid path assignment %s, expected name=path", assignment)
+			}
+			if dir == "" {
+				delete(r.paths.Tools, strings.ToLower(name))
+				continue
+			}
+			dir, err := filepath.Abs(dir)
+			if err != nil {
+				return true, err
+			}
+			if !sliceutil.Contains(r.paths.Paths, dir) && dir != r.options.Path {
+				return true, fmt.Errorf("%s is not a managed path, add it with -path-add first", dir)
+			}
+			r.paths.Tools[strings.ToLower(name)] = dir
+			gologger.Info().Msgf("%s is managed in %s", name, dir)
+		}
+		return true, r.paths.save()
+	}
+	return false, nil
+}
+
+// printEnv prints the statements of the shell setting PDTM_HOME and adding
+// the binary path to $PATH, to be evaluated instead of editing the shell config
+func (r *Runner) printEnv() error {
+	shell := r.options.Shell
+	if shell == "" {
+		shell = path.CurrentShell()
+	}
+	script, err := path.EnvScript(shell, r.options.Path)
+	if err != nil {
+		return err
+	}
+	gologger.Silent().Msg(script)
+	return nil
+}
@@ -0,0 +1,97 @@
+package runner
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/projectdiscovery/gologger"
+	"github.com/projectdiscovery/pdtm/pkg"
+	"github.com/projectdiscovery/pdtm/pkg/types"
+)
+
+// exitNotInteractive is the exit code of -update-pick without a terminal
+const exitNotInteractive = 2
+
+// pickUpdates shows the outdated projects and returns the ones the user
+// selected to update
+func (r *Runner) pickUpdates(toolList []types.Tool) ([]string, error) {
+	if !isInteractive() {
+		return nil, &ExitCodeError{Code: exitNotInteractive, Err: errors.New("-update-pick requires an interactive terminal, use -update-all instead")}
+	}
+	outdated, _ := r.checkOutdated(toolList)
+	if len(outdated) == 0 {
+		gologger.Info().Msg("all projects are up to date")
+		return nil, nil
+	}
+	for i, result := range outdated {
+		size := "size unknown"
+		if downloadSize, ok := pkg.DownloadSize(result.latest); ok {
+			size = formatSize(downloadSize)
+		}
+		fmt.Fprintf(os.Stderr, "%3d) %s %s ➡ %s (%s, %s)\n", i+1, result.Name, au.Red(result.Installed).String(), severityColor(result.Severity, result.Latest), result.Severity, size)
+	}
+	for {
+		fmt.Fprint(os.Stderr, "projects to update (eg. 1,3-5, all or none) [all]: ")
+		answer, err := stdinReader.ReadString('\n')
+		if err != nil && answer == "" {
+			return nil, nil
+		}
+		selected, err := parseSelection(answer, len(outdated))
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s\n", err)
+			continue
+		}
+		var names []string
+		for _, i := range selected {
+			names = append(names, outdated[i].Name)
+		}
+		return names, nil
+	}
+}
+
+// parseSelection returns the indexes of the items selected by their number
+// or ranges of numbers (comma separated), all the items when empty
+func parseSelection(answer string, count int) ([]int, error) {
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	var selected []int
+	switch answer {
+	case "none", "n":
+		return nil, nil
+	case "", "all", "a":
+		for i := 0; i < count; i++ {
+			selected = append(selected, i)
+		}
+		return selected, nil
+	}
+	seen := make(map[int]bool)
+	for _, part := range strings.Split(answer, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		from, to, isRange := strings.Cut(part, "-")
+		start, err := strconv.Atoi(strings.TrimSpace(from))
+		if err != nil {
+			return nil, fmt.Errorf("invalid selection %s", part)
+		}
+		end := start
+		if isRange {
+			if end, err = strconv.Atoi(strings.TrimSpace(to)); err != nil {
+				return nil, fmt.Errorf("invalid selection %s", part)
+			}
+		}
+		if start < 1 || end > count || start > end {
+			return nil, fmt.Errorf("invalid selection %s: expected numbers between 1 and %d", part, count)
+		}
+		for i := start - 1; i < end; i++ {
+			if !seen[i] {
+				seen[i] = true
+				selected = append(selected, i)
+			}
+		}
+	}
+	return selected, nil
+}
@@ -0,0 +1,29 @@
+package runner
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSelection(t *testing.T) {
+	for answer, expected := range map[string][]int{
+		"":           {0, 1, 2, 3, 4},
+		" ALL\n":     {0, 1, 2, 3, 4},
+		"a":          {0, 1, 2, 3, 4},
+		"none":       nil,
+		"n":          nil,
+		"2":          {1},
+		"1,3-5":      {0, 2, 3, 4},
+		" 4 - 5, 4 ": {3, 4},
+		"3,1,":       {2, 0},
+	} {
+		selected, err := parseSelection(answer, 5)
+		require.Nil(t, err, answer)
+		require.Equal(t, expected, selected, answer)
+	}
+	for _, invalid := range []string{"0", "6", "3-2", "1-6", "x", "1,x", "-1"} {
+		_, err := parseSelection(invalid, 5)
+		require.NotNil(t, err, invalid)
+	}
+}
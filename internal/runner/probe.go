@@ -0,0 +1,112 @@
+package runner
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/projectdiscovery/gologger"
+	pkgversion "github.com/projectdiscovery/pdtm/pkg/version"
+	osutils "github.com/projectdiscovery/utils/os"
+)
+
+// visualStudioRequirements contains the requirement names checked with
+// vswhere, the names of the visual studio components are checked as well
+var visualStudioRequirements = []string{"visualstudio", "vs", "msvc", "buildtools"}
+
+// visualStudioComponentPrefix is the prefix of the visual studio component ids
+// (eg. Microsoft.VisualStudio.Component.VC.Tools.x86.x64)
+const visualStudioComponentPrefix = "Microsoft.VisualStudio."
+
+// probeRequirement checks the requirement with the package metadata tools:
+// pkg-config for the libraries and vswhere for visual studio on windows.
+// probed is false when no tool knows the requirement, the presence and
+// version of the library files are checked instead
+func probeRequirement(requirementName string) (present bool, version string, probed bool) {
+	if osutils.IsWindows() && isVisualStudioRequirement(requirementName) {
+		return vswhereVersion(requirementName)
+	}
+	if strings.HasPrefix(requirementName, "lib") {
+		if version, ok := pkgConfigVersion(requirementName); ok {
+			return true, version, true
+		}
+	}
+	return false, "", false
+}
+
+// pkgConfigVersion returns the version of the library known to pkg-config,
+// false when pkg-config is absent or has no module for the library (eg. the
+// runtime packages without the development files)
+func pkgConfigVersion(lib string) (string, bool) {
+	pkgConfig, err := exec.LookPath("pkg-config")
+	if err != nil {
+		return "", false
+	}
+	// the modules are named with or without the lib prefix (libpcap, openssl)
+	for _, module := range []string{lib, strings.TrimPrefix(lib, "lib")} {
+		cmd, cancel := probeCommand(pkgConfig, "--modversion", module)
+		output, err := cmd.Output()
+		cancel()
+		if err != nil {
+			continue
+		}
+		version := strings.TrimSpace(string(output))
+		if matched := regexRequirementVersion.FindString(version); matched != "" {
+			version = matched
+		}
+		gologger.Verbose().Msgf("pkg-config found %s %s", module, version)
+		return version, true
+	}
+	return "", false
+}
+
+// isVisualStudioRequirement returns true if the requirement is visual studio
+// or one of its components
+func isVisualStudioRequirement(requirementName string) bool {
+	if strings.HasPrefix(requirementName, visualStudioComponentPrefix) {
+		return true
+	}
+	for _, name := range visualStudioRequirements {
+		if strings.EqualFold(name, requirementName) {
+			return true
+		}
+	}
+	return false
+}
+
+// vswhereVersion returns the version of the latest visual studio install
+// having the requirement, probed is false when vswhere isn't installed
+func vswhereVersion(requirementName string) (present bool, version string, probed bool) {
+	vswhere := filepath.Join(os.Getenv("ProgramFiles(x86)"), "Microsoft Visual Studio", "Installer", "vswhere.exe")
+	if _, err := os.Stat(vswhere); err != nil {
+		if vswhere, err = exec.LookPath("vswhere"); err != nil {
+			return false, "", false
+		}
+	}
+	args := []string{"-latest", "-products", "*", "-property", "installationVersion"}
+	if strings.HasPrefix(requirementName, visualStudioComponentPrefix) {
+		args = append(args, "-requires", requirementName)
+	}
+	var outb bytes.Buffer
+	cmd, cancel := probeCommand(vswhere, args...)
+	defer cancel()
+	cmd.Stdout = &outb
+	if err := cmd.Run(); err != nil {
+		gologger.Verbose().Msgf("could not run vswhere: %s", err)
+		return false, "", false
+	}
+	installationVersion := strings.TrimSpace(outb.String())
+	if installationVersion == "" {
+		return false, "", true
+	}
+	return true, regexRequirementVersion.FindString(installationVersion), true
+}
+
+// probeCommand returns the command of a probe (eg. <tool> --version) killed
+// after pkgversion.ProbeTimeout, cancel must be called once it completes
+func probeCommand(name string, args ...string) (cmd *exec.Cmd, cancel context.CancelFunc) {
+	return pkgversion.Command(name, args...)
+}
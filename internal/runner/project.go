@@ -0,0 +1,353 @@
+package runner
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/projectdiscovery/gologger"
+	"github.com/projectdiscovery/pdtm/pkg"
+	"github.com/projectdiscovery/pdtm/pkg/path"
+	"github.com/projectdiscovery/pdtm/pkg/types"
+	"github.com/projectdiscovery/pdtm/pkg/utils"
+	"gopkg.in/yaml.v3"
+)
+
+// projectFile is the file listing the projects used in a directory
+const projectFile = ".pdtm.yaml"
+
+// defaultProjectPath is the path the projects of a directory are installed
+// to, relative to its project file
+const defaultProjectPath = ".pdtm/bin"
+
+// projectTemplate is written by -envrc when the directory has no project file
+const projectTemplate = `# projects used in this directory (name or name@version), installed in
+# .pdtm/bin and added to $PATH by direnv
+tools: []
+`
+
+// direnvHook is the direnv function activating the projects of a directory
+// with "use pdtm" in its .envrc, they are installed with pdtm -project
+const direnvHook = `# pdtm: add to ~/.config/direnv/direnvrc and "use pdtm" to the .envrc
+use_pdtm() {
+  watch_file ` + projectFile + `
+  eval "$(pdtm -project -env -shell bash -duc -nc)"
+}`
+
+// ProjectConfig is the project file of a directory
+type ProjectConfig struct {
+	// Tools contains the projects of the directory (name or name@version)
+	Tools []string `yaml:"tools"`
+	// Path is the path the projects are installed to, relative to the file
+	Path string `yaml:"path,omitempty"`
+	// dir is the directory of the project file
+	dir string
+}
+
+// errNoProjectConfig is returned when neither the directory nor its parents
+// have a project file
+var errNoProjectConfig = fmt.Errorf("no %s found in the directory or its parents", projectFile)
+
+// findProjectConfig returns the project file of dir or of its closest parent
+func findProjectConfig(dir string) (*ProjectConfig, error) {
+	for {
+		b, err := os.ReadFile(filepath.Join(dir, projectFile))
+		if err == nil {
+			config := &ProjectConfig{dir: dir}
+			if err := yaml.Unmarshal(b, config); err != nil {
+				return nil, fmt.Errorf("could not read %s: %s", filepath.Join(dir, projectFile), err)
+			}
+			return config, nil
+		}
+		if !errors.Is(err, os.ErrNotExist) {
+			return nil, err
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return nil, errNoProjectConfig
+		}
+		dir = parent
+	}
+}
+
+// binaryPath returns the path the projects of the directory are installed
+// to, the project files come with cloned repositories so the path has to
+// be in the directory of the file
+func (p *ProjectConfig) binaryPath() (string, error) {
+	dir := filepath.Join(p.dir, defaultProjectPath)
+	if p.Path != "" {
+		dir = p.Path
+		if !filepath.IsAbs(dir) {
+			dir = filepath.Join(p.dir, dir)
+		}
+	}
+	if !path.IsSubPath(resolveSymlinks(p.dir), resolveSymlinks(dir)) {
+		return "", fmt.Errorf("the path %s of %s is outside of the project directory", p.Path, filepath.Join(p.dir, projectFile))
+	}
+	return dir, nil
+}
+
+// resolveSymlinks resolves the symlinks of the existing part of the path
+func resolveSymlinks(p string) string {
+	var missing []string
+	for {
+		if resolved, err := filepath.EvalSymlinks(p); err == nil {
+			return filepath.Join(append([]string{resolved}, missing...)...)
+		}
+		parent := filepath.Dir(p)
+		if parent == p {
+			return filepath.Join(append([]string{p}, missing...)...)
+		}
+		missing = append([]string{filepath.Base(p)}, missing...)
+		p = parent
+	}
+}
+
+// workingProject returns the project file of the working directory and the
+// path its projects are installed to
+func workingProject() (*ProjectConfig, string, error) {
+	wd, err := os.Getwd()
+	if err != nil {
+		return nil, "", err
+	}
+	config, err := findProjectConfig(wd)
+	if err != nil {
+		return nil, "", err
+	}
+	dir, err := config.binaryPath()
+	return config, dir, err
+}
+
+// syncProject installs the projects of the project file of the working
+// directory at their version
+func (r *Runner) syncProject() error {
+	config, dir, err := workingProject()
+	if err != nil {
+		return err
+	}
+	if len(config.Tools) == 0 {
+		return nil
+	}
+	if !r.checkWritable(dir, "install", "the projects of "+filepath.Join(config.dir, projectFile)) {
+		return nil
+	}
+	// a rejected token fails every download, better stop before the batch
+	if err := r.validateToken(); err != nil {
+		return err
+	}
+	toolList, err := r.fetchToolList()
+	if err != nil {
+		return err
+	}
+	r.resolveAliases(toolList)
+	for _, toolName := range r.options.resolveAliases(config.Tools) {
+		r.syncProjectTool(toolList, dir, toolName)
+	}
+	return nil
+}
+
+// activateProject prints the statements activating the path of the project
+// file of the working directory, it runs each time direnv enters the
+// directory so it doesn't install anything (see syncProject)
+func (r *Runner) activateProject() error {
+	config, dir, err := workingProject()
+	if err != nil {
+		return err
+	}
+	for _, toolName := range r.options.resolveAliases(config.Tools) {
+		name, _ := splitVersion(toolName)
+		if _, exists := path.GetExecutablePath(dir, name); !exists {
+			gologger.Info().Label("WRN").Msgf("%s of %s is not installed, run pdtm -project to install the projects", name, filepath.Join(config.dir, projectFile))
+		}
+	}
+	shell := r.options.Shell
+	if shell == "" {
+		shell = path.CurrentShell()
+	}
+	script, err := path.EnvScript(shell, dir)
+	if err != nil {
+		return err
+	}
+	gologger.Silent().Msg(script)
+	return nil
+}
+
+// syncProjectTool installs the tool (name@version) in dir or moves it to the version
+func (r *Runner) syncProjectTool(toolList []types.Tool, dir, toolName string) {
+	name, version := splitVersion(toolName)
+	i, ok := utils.Contains(toolList, name)
+	if !ok || pkg.IsDataPack(toolList[i]) {
+		gologger.Error().Msgf("error while installing %s: %s", name, unknownTool(toolList, name))
+		return
+	}
+	tool, err := r.resolve(toolList[i], version)
+	if err != nil {
+		gologger.Error().Msgf("error while resolving %s: %s", name, err)
+		return
+	}
+	if _, exists := path.GetExecutablePath(dir, tool.Name); !exists {
+		r.install(dir, tool)
+		return
+	}
+	switch err := pkg.Update(dir, tool, true); {
+	case err == nil:
+	case errors.Is(err, types.ErrIsUpToDate):
+		gologger.Verbose().Msgf("%s %s is installed", tool.Name, tool.Version)
+	case errors.Is(err, types.ErrIsPinned) || errors.Is(err, types.ErrIsBuiltFromRef):
+		gologger.Info().Msgf("%s: %s", tool.Name, err)
+	default:
+		gologger.Error().Msgf("error while installing %s %s: %s", tool.Name, tool.Version, err)
+	}
+}
+
+// activationScripts contains the shells of the activation scripts written by
+// -local in the .pdtm directory of the project
+var activationScripts = map[string]string{"activate": "sh", "activate.fish": "fish", "activate.ps1": "powershell"}
+
+// runLocal installs the projects of -install into the path of the project
+// file of the working directory (created when missing), adds them to the
+// file and writes the scripts activating the path
+func (r *Runner) runLocal() error {
+	if len(r.options.Install) == 0 {
+		return errors.New("-local is used with -install")
+	}
+	wd, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+	config, err := localProjectConfig(wd)
+	if err != nil {
+		return err
+	}
+	dir, err := config.binaryPath()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+		return err
+	}
+	requested := append([]string{}, r.options.Install...)
+	// the projects of the directory are neither linked nor added to the shell config
+	r.options.Local, r.options.LinkDir = false, ""
+	r.options.Path, r.explicitPath = dir, true
+	r.paths.Paths = append(r.paths.Paths, dir)
+	runErr := r.Run()
+
+	for _, toolName := range requested {
+		name, _ := splitVersion(toolName)
+		if _, exists := path.GetExecutablePath(dir, name); exists {
+			config.add(toolName)
+		}
+	}
+	if err := config.save(); err != nil {
+		return fmt.Errorf("could not save %s: %s", filepath.Join(config.dir, projectFile), err)
+	}
+	activate := filepath.Join(config.dir, filepath.Dir(defaultProjectPath))
+	if err := os.MkdirAll(activate, os.ModePerm); err != nil {
+		return err
+	}
+	for name, shell := range activationScripts {
+		script, err := path.EnvScript(shell, dir)
+		if err != nil {
+			return err
+		}
+		if err := os.WriteFile(filepath.Join(activate, name), []byte(script+"\n"), 0644); err != nil {
+			return err
+		}
+	}
+	relative, err := filepath.Rel(wd, filepath.Join(activate, "activate"))
+	if err != nil {
+		relative = filepath.Join(activate, "activate")
+	}
+	gologger.Info().Msgf("run source %s to use the projects of %s (or activate.fish, activate.ps1)", relative, config.dir)
+	return runErr
+}
+
+// localProjectConfig returns the project file of dir or of its closest
+// parent, a new one in dir when there is none. A file that can't be read is
+// an error so it isn't overwritten.
+func localProjectConfig(dir string) (*ProjectConfig, error) {
+	config, err := findProjectConfig(dir)
+	if errors.Is(err, errNoProjectConfig) {
+		return &ProjectConfig{dir: dir}, nil
+	}
+	return config, err
+}
+
+// add adds the project (name or name@version) to the file, replacing the
+// entry of the same project
+func (p *ProjectConfig) add(toolName string) {
+	name, _ := splitVersion(toolName)
+	for i, tool := range p.Tools {
+		if existing, _ := splitVersion(tool); strings.EqualFold(existing, name) {
+			p.Tools[i] = toolName
+			return
+		}
+	}
+	p.Tools = append(p.Tools, toolName)
+}
+
+// save writes the projects to the project file, it's edited like the config
+// file so its comments and other keys are kept
+func (p *ProjectConfig) save() error {
+	file, err := readConfigFile(filepath.Join(p.dir, projectFile))
+	if err != nil {
+		return err
+	}
+	node, index := file.lookup("tools", true)
+	if index < 0 || node.Content[index+1].Kind != yaml.SequenceNode {
+		file.set("tools", &yaml.Node{Kind: yaml.SequenceNode})
+		node, index = file.lookup("tools", true)
+	}
+	tools := node.Content[index+1]
+	// the generated file lists no project with tools: []
+	if len(tools.Content) == 0 {
+		tools.Style = 0
+	}
+	for _, toolName := range p.Tools {
+		name, _ := splitVersion(toolName)
+		var found bool
+		for _, item := range tools.Content {
+			if existing, _ := splitVersion(item.Value); item.Kind == yaml.ScalarNode && strings.EqualFold(existing, name) {
+				item.Value, found = toolName, true
+				break
+			}
+		}
+		if !found {
+			tools.Content = append(tools.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: toolName})
+		}
+	}
+	return file.save()
+}
+
+// writeEnvrc adds "use pdtm" to the .envrc of the working directory and
+// writes a project file when it has none
+func writeEnvrc() error {
+	b, err := os.ReadFile(".envrc")
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	for _, line := range strings.Split(string(b), "\n") {
+		if strings.TrimSpace(line) == "use pdtm" {
+			gologger.Info().Msg(".envrc already uses pdtm")
+			return nil
+		}
+	}
+	content := string(b)
+	if content != "" && !strings.HasSuffix(content, "\n") {
+		content += "\n"
+	}
+	if err := os.WriteFile(".envrc", []byte(content+"use pdtm\n"), 0644); err != nil {
+		return err
+	}
+	if _, err := os.Stat(projectFile); errors.Is(err, os.ErrNotExist) {
+		if err := os.WriteFile(projectFile, []byte(projectTemplate), 0644); err != nil {
+			return err
+		}
+		gologger.Info().Msgf("created %s, list the projects of the directory in it", projectFile)
+	}
+	gologger.Info().Msg("added use pdtm to .envrc, run pdtm -direnv-hook >> ~/.config/direnv/direnvrc once and direnv allow")
+	return nil
+}
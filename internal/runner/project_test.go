@@ -0,0 +1,116 @@
+package runner
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestProjectBinaryPath(t *testing.T) {
+	dir := t.TempDir()
+	outside := t.TempDir()
+	require.Nil(t, os.Symlink(outside, filepath.Join(dir, "link")))
+
+	for projectPath, allowed := range map[string]bool{
+		"":                        true,
+		"bin":                     true,
+		"tools/../bin":            true,
+		"..":                      false,
+		"../other/bin":            false,
+		outside:                   false,
+		filepath.Join(dir, "bin"): true,
+		// a checked-in symlink can't point the path outside of the project
+		"link/bin": false,
+	} {
+		config := &ProjectConfig{dir: dir, Path: projectPath}
+		binaryPath, err := config.binaryPath()
+		if !allowed {
+			require.NotNil(t, err, projectPath)
+			continue
+		}
+		require.Nil(t, err, projectPath)
+		require.True(t, filepath.IsAbs(binaryPath))
+	}
+}
+
+func TestFindProjectConfig(t *testing.T) {
+	dir := t.TempDir()
+	nested := filepath.Join(dir, "a", "b")
+	require.Nil(t, os.MkdirAll(nested, 0755))
+	require.Nil(t, os.WriteFile(filepath.Join(dir, projectFile), []byte("tools:\n  - dnsx@1.1.1\n"), 0644))
+
+	config, err := findProjectConfig(nested)
+	require.Nil(t, err)
+	require.Equal(t, dir, config.dir)
+	require.Equal(t, []string{"dnsx@1.1.1"}, config.Tools)
+
+	_, err = findProjectConfig(t.TempDir())
+	require.NotNil(t, err)
+}
+
+func TestLocalProjectConfig(t *testing.T) {
+	dir := t.TempDir()
+	config, err := localProjectConfig(dir)
+	require.Nil(t, err)
+	require.Equal(t, dir, config.dir)
+
+	// an invalid file isn't replaced by a new one
+	invalid := []byte("tools: [dnsx\n")
+	require.Nil(t, os.WriteFile(filepath.Join(dir, projectFile), invalid, 0644))
+	_, err = localProjectConfig(dir)
+	require.NotNil(t, err)
+	b, err := os.ReadFile(filepath.Join(dir, projectFile))
+	require.Nil(t, err)
+	require.Equal(t, invalid, b)
+}
+
+func TestProjectConfigSave(t *testing.T) {
+	dir := t.TempDir()
+	content := `# projects of the repository
+tools:
+  # resolver
+  - dnsx@1.1.0
+  - httpx
+path: bin
+# read by another tool
+owner: security
+`
+	require.Nil(t, os.WriteFile(filepath.Join(dir, projectFile), []byte(content), 0644))
+	config, err := findProjectConfig(dir)
+	require.Nil(t, err)
+	config.add("dnsx@1.1.1")
+	config.add("nuclei")
+	require.Nil(t, config.save())
+
+	b, err := os.ReadFile(filepath.Join(dir, projectFile))
+	require.Nil(t, err)
+	saved := string(b)
+	for _, expected := range []string{"# projects of the repository", "# resolver", "- dnsx@1.1.1", "- httpx", "- nuclei", "path: bin", "# read by another tool", "owner: security"} {
+		require.Contains(t, saved, expected)
+	}
+	config, err = findProjectConfig(dir)
+	require.Nil(t, err)
+	require.Equal(t, []string{"dnsx@1.1.1", "httpx", "nuclei"}, config.Tools)
+
+	// the generated file gets a block list
+	dir = t.TempDir()
+	require.Nil(t, os.WriteFile(filepath.Join(dir, projectFile), []byte(projectTemplate), 0644))
+	config, err = findProjectConfig(dir)
+	require.Nil(t, err)
+	config.add("dnsx")
+	require.Nil(t, config.save())
+	b, err = os.ReadFile(filepath.Join(dir, projectFile))
+	require.Nil(t, err)
+	require.Contains(t, string(b), "# projects used in this directory")
+	require.Contains(t, string(b), "tools:\n  - dnsx\n")
+
+	// a new file is created
+	config = &ProjectConfig{dir: t.TempDir()}
+	config.add("dnsx")
+	require.Nil(t, config.save())
+	config, err = findProjectConfig(config.dir)
+	require.Nil(t, err)
+	require.Equal(t, []string{"dnsx"}, config.Tools)
+}
@@ -0,0 +1,33 @@
+package runner
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/mattn/go-isatty"
+)
+
+var stdinReader = bufio.NewReader(os.Stdin)
+
+// isInteractive returns true if pdtm can ask the user for input
+func isInteractive() bool {
+	return isatty.IsTerminal(os.Stdin.Fd()) || isatty.IsCygwinTerminal(os.Stdin.Fd())
+}
+
+// confirm asks the user a yes/no question, it returns false when stdin isn't interactive
+func confirm(question string) bool {
+	if !isInteractive() {
+		return false
+	}
+	fmt.Fprintf(os.Stderr, "%s [y/N]: ", question)
+	answer, _ := stdinReader.ReadString('\n')
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes"
+}
+
+// confirm asks the user a yes/no question unless -yes is given
+func (r *Runner) confirm(question string) bool {
+	return r.options.Yes || confirm(question)
+}
@@ -0,0 +1,96 @@
+package runner
+
+import (
+	"github.com/projectdiscovery/gologger"
+	"github.com/projectdiscovery/pdtm/pkg"
+	"github.com/projectdiscovery/pdtm/pkg/state"
+	"github.com/projectdiscovery/pdtm/pkg/types"
+)
+
+// reinstall removes and installs the tool again at its recorded version (or
+// the latest one) with the same method, keeping the pin of the tool. It
+// returns the reinstalled version and false if the reinstall failed
+func (r *Runner) reinstall(dir string, tool types.Tool) (string, bool) {
+	st, err := state.Load(dir)
+	if err != nil {
+		gologger.Warning().Msgf("could not read state: %s", err)
+	}
+	installed, ok := st.Get(tool.Name)
+	if !ok {
+		installed = &state.Tool{Name: tool.Name}
+	}
+
+	var version, ref string
+	switch {
+	case r.options.Latest:
+	case installed.Nightly:
+		version = types.Nightly
+	case installed.Ref != "":
+		ref = installed.Ref
+	case installed.Source != state.SourceAdopted:
+		version = installed.Version
+	}
+	resolved, err := r.resolve(tool, version)
+	if err != nil {
+		gologger.Error().Msgf("error while resolving %s: %s", tool.Name, err)
+		return "", false
+	}
+
+	if err := pkg.Remove(dir, tool); err != nil {
+		gologger.Verbose().Msgf("%s", err)
+	}
+	gologger.Info().Msgf("reinstalling %s...", tool.Name)
+	switch {
+	case ref != "":
+		if !r.goAvailable() {
+			gologger.Error().Msgf("error while reinstalling %s: go is required to build from %s (use -go-bootstrap to download it)", tool.Name, ref)
+			return "", false
+		}
+		if err := pkg.GoInstallRef(dir, resolved, ref); err != nil {
+			gologger.Error().Msgf("%s: %s", tool.Name, err)
+			return "", false
+		}
+	case installed.Source == state.SourceGoInstall && r.goAvailable():
+		if err := pkg.GoInstall(dir, resolved); err != nil {
+			gologger.Error().Msgf("%s: %s", tool.Name, err)
+			return "", false
+		}
+	default:
+		if !r.install(dir, resolved) {
+			return "", false
+		}
+	}
+	if installed.Pinned {
+		if err := pkg.Pin(dir, resolved); err != nil {
+			gologger.Error().Msgf("error while pinning %s: %s", tool.Name, err)
+		}
+	}
+	return resolved.Version, true
+}
+
+// checkModified logs the binaries of the managed paths that were modified
+// since pdtm installed them
+func (r *Runner) checkModified() {
+	for _, dir := range r.managedPaths() {
+		names, err := pkg.ModifiedBinaries(dir)
+		if err != nil {
+			gologger.Error().Msgf("could not verify the binaries of %s: %s", dir, err)
+			continue
+		}
+		for _, name := range names {
+			gologger.Error().Msgf("%s in %s was modified since it was installed (use -reinstall %s to restore it)", name, dir, name)
+		}
+	}
+}
+
+// brokenTools returns the names of the tools whose install is broken
+func (r *Runner) brokenTools(tools []types.Tool) []string {
+	var broken []string
+	for _, tool := range tools {
+		if reason := pkg.Broken(r.pathFor(tool.Name), tool); reason != "" {
+			gologger.Info().Msgf("%s is broken (%s)", tool.Name, reason)
+			broken = append(broken, tool.Name)
+		}
+	}
+	return broken
+}
@@ -0,0 +1,73 @@
+package runner
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/projectdiscovery/gologger"
+	"github.com/projectdiscovery/pdtm/pkg"
+	"github.com/projectdiscovery/pdtm/pkg/path"
+	"github.com/projectdiscovery/pdtm/pkg/state"
+	"github.com/projectdiscovery/pdtm/pkg/types"
+	"github.com/projectdiscovery/pdtm/pkg/utils"
+)
+
+// removeAll removes every tool managed by pdtm (including the ones no longer
+// listed), their data directories when purging and the PATH entry of pdtm
+func (r *Runner) removeAll(toolList []types.Tool) error {
+	if !path.IsSubPath(homeDir, r.options.Path) {
+		return fmt.Errorf("skipping remove outside home folder: %s", r.options.Path)
+	}
+	if r.options.Purge && !isInteractive() && !r.options.Yes {
+		return errors.New("-purge removes the data directories, use -yes to confirm it when not running interactively")
+	}
+	question := fmt.Sprintf("remove all the projects installed in %s?", r.options.Path)
+	if r.options.Purge {
+		question = fmt.Sprintf("remove all the projects installed in %s and their data directories?", r.options.Path)
+	}
+	if isInteractive() && !r.confirm(question) {
+		gologger.Info().Msg("remove all aborted")
+		return nil
+	}
+
+	tools := append([]types.Tool{}, toolList...)
+	st, err := state.Load(r.options.Path)
+	if err != nil {
+		gologger.Warning().Msgf("could not read state: %s", err)
+	}
+	var unlisted []string
+	for _, installed := range st.Tools {
+		if _, ok := utils.Contains(tools, installed.Name); !ok {
+			unlisted = append(unlisted, installed.Name)
+		}
+	}
+	sort.Strings(unlisted)
+	for _, name := range unlisted {
+		tools = append(tools, types.Tool{Name: name})
+	}
+
+	for _, tool := range tools {
+		// the data is purged while the tool is still recorded
+		if r.options.Purge {
+			if err := pkg.PurgeData(r.options.Path, tool); err != nil {
+				gologger.Error().Msgf("could not remove %s data: %s", tool.Name, err)
+			}
+		}
+		if err := pkg.Remove(r.options.Path, tool); err != nil {
+			gologger.Verbose().Msgf("%s", err)
+		}
+	}
+	// the project versions installed for -with
+	if err := os.RemoveAll(execDir); err != nil {
+		gologger.Error().Msgf("could not remove %s: %s", execDir, err)
+	}
+	if r.options.LinkDir != "" {
+		r.syncLinks(tools)
+	}
+	if err := path.CleanENV(r.options.Path); err != nil {
+		gologger.Warning().Msgf("Failed to unset path: %s. Remove it from $PATH manually", r.options.Path)
+	}
+	return nil
+}
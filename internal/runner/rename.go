@@ -0,0 +1,56 @@
+package runner
+
+import (
+	"github.com/projectdiscovery/gologger"
+	"github.com/projectdiscovery/pdtm/pkg"
+	"github.com/projectdiscovery/pdtm/pkg/types"
+	"github.com/projectdiscovery/pdtm/pkg/utils"
+)
+
+// currentNames returns the given names with the previous names of the tools
+// renamed upstream replaced by their current name
+func currentNames(toolList []types.Tool, names []string) []string {
+	renamed := make(map[string]string)
+	for _, tool := range toolList {
+		for _, previous := range tool.RenamedFrom {
+			renamed[previous] = tool.Name
+		}
+	}
+	current := make([]string, 0, len(names))
+	for _, name := range names {
+		if renamedTo, ok := renamed[name]; ok {
+			name = renamedTo
+		}
+		current = append(current, name)
+	}
+	return current
+}
+
+// migrateRenamed moves the given tools installed and recorded with a
+// previous name to their current name
+func (r *Runner) migrateRenamed(toolList []types.Tool, names []string) {
+	for _, name := range names {
+		i, ok := utils.Contains(toolList, name)
+		if !ok {
+			continue
+		}
+		tool := toolList[i]
+		dir := r.pathFor(tool.Name)
+		previous, ok := pkg.RenamedFrom(dir, tool)
+		if !ok || !r.isAllowedPath(dir) {
+			continue
+		}
+		if err := pkg.Rename(dir, previous, tool, r.options.RenameAlias); err != nil {
+			gologger.Error().Msgf("could not migrate %s to %s: %s", previous, tool.Name, err)
+			continue
+		}
+		gologger.Info().Msgf("%s was renamed to %s upstream, migrated the installed binary", previous, tool.Name)
+	}
+}
+
+// warnDeprecated warns when the tool is deprecated upstream
+func warnDeprecated(tool types.Tool) {
+	if tool.Deprecated != "" {
+		gologger.Info().Label("WRN").Msgf("%s is deprecated: %s", tool.Name, tool.Deprecated)
+	}
+}
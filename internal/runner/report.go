@@ -0,0 +1,102 @@
+package runner
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"runtime"
+	"time"
+
+	"github.com/projectdiscovery/gologger"
+	"github.com/projectdiscovery/pdtm/pkg"
+	"github.com/projectdiscovery/pdtm/pkg/httpclient"
+	"github.com/projectdiscovery/pdtm/pkg/types"
+)
+
+// actions of the reported tools
+const (
+	actionInstall   = "install"
+	actionReinstall = "reinstall"
+	actionUpdate    = "update"
+	actionRemove    = "remove"
+	actionRollback  = "rollback"
+)
+
+// ReportedTool is a tool installed, updated or removed by the run
+type ReportedTool struct {
+	Name    string `json:"name"`
+	Action  string `json:"action"`
+	Version string `json:"version,omitempty"`
+	// Status is installed, reinstalled, removed, not found or failed for the
+	// installs and removals, the outcome of the update otherwise
+	Status string `json:"status"`
+	Reason string `json:"reason,omitempty"`
+}
+
+// Report is the summary of the run posted to -report-url
+type Report struct {
+	Host    string         `json:"host"`
+	OS      string         `json:"os"`
+	Arch    string         `json:"arch"`
+	Path    string         `json:"path"`
+	Version string         `json:"pdtm_version"`
+	Time    time.Time      `json:"time"`
+	Tools   []ReportedTool `json:"tools"`
+}
+
+// report records the tool for -report-url
+func (r *Runner) report(tool ReportedTool) {
+	if r.options.ReportURL != "" {
+		r.reported = append(r.reported, tool)
+	}
+}
+
+// reportedVersion returns the installed version of the tool when the run is
+// reported
+func (r *Runner) reportedVersion(dir string, tool types.Tool) string {
+	if r.options.ReportURL == "" {
+		return ""
+	}
+	installed, _ := pkg.InstalledVersion(tool, dir)
+	return installed
+}
+
+// sendReport posts the tools installed, updated or removed by the run to
+// -report-url, nothing is sent when the run changed nothing
+func (r *Runner) sendReport() {
+	if r.options.ReportURL == "" || len(r.reported) == 0 {
+		return
+	}
+	host, _ := os.Hostname()
+	b, err := json.Marshal(Report{
+		Host:    host,
+		OS:      runtime.GOOS,
+		Arch:    runtime.GOARCH,
+		Path:    r.options.Path,
+		Version: version,
+		Time:    time.Now().UTC(),
+		Tools:   r.reported,
+	})
+	if err != nil {
+		gologger.Warning().Msgf("could not marshal the report: %s", err)
+		return
+	}
+	r.reported = nil
+	if err := postJSON(r.options.ReportURL, b); err != nil {
+		gologger.Warning().Msgf("could not send the report to %s: %s", r.options.ReportURL, err)
+	}
+}
+
+// postJSON posts the json body to the url and checks the status code
+func postJSON(url string, body []byte) error {
+	resp, err := httpclient.Client.Post(url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+	return nil
+}
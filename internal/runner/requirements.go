@@ -0,0 +1,377 @@
+package runner
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"sort"
+	"strings"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/projectdiscovery/gologger"
+	"github.com/projectdiscovery/pdtm/pkg/types"
+	osutils "github.com/projectdiscovery/utils/os"
+	sliceutil "github.com/projectdiscovery/utils/slice"
+	"github.com/projectdiscovery/utils/syscallutil"
+)
+
+// regexRequirementVersion matches the first dotted version in --version outputs and library file names
+var regexRequirementVersion = regexp.MustCompile(`\d+(\.\d+){1,2}`)
+
+// libraryDirs contains the directories searched for versioned library files
+var libraryDirs = []string{"/lib", "/lib64", "/usr/lib", "/usr/lib64", "/usr/local/lib"}
+
+// packagePrefixes contains the prefixes of the macos package managers, the
+// libraries and executables installed there aren't found by bare name
+// (homebrew on apple silicon and intel, macports)
+var packagePrefixes = []string{"/opt/homebrew", "/usr/local", "/opt/local"}
+
+// requirement states, a requirement is unknown when it is present but its
+// installed version can't be compared with the version constraint
+const (
+	requirementMissing = "missing"
+	requirementMet     = "satisfied"
+	requirementUnknown = "unknown"
+)
+
+// ToolRequirements contains the evaluated requirements of a tool on this host
+type ToolRequirements struct {
+	Tool         string              `json:"tool" yaml:"tool"`
+	OS           string              `json:"os" yaml:"os"`
+	Requirements []RequirementStatus `json:"requirements" yaml:"requirements"`
+}
+
+// RequirementStatus contains the status of a single requirement on this host
+type RequirementStatus struct {
+	Name             string `json:"name" yaml:"name"`
+	Version          string `json:"version,omitempty" yaml:"version,omitempty"`
+	InstalledVersion string `json:"installed_version,omitempty" yaml:"installed_version,omitempty"`
+	Required         bool   `json:"required" yaml:"required"`
+	Satisfied        bool   `json:"satisfied" yaml:"satisfied"`
+	// Status is satisfied, missing or unknown
+	Status      string `json:"status" yaml:"status"`
+	Instruction string `json:"instruction" yaml:"instruction"`
+}
+
+// evaluateRequirements checks all the requirements of the tool for the current OS
+func evaluateRequirements(tool types.Tool) ToolRequirements {
+	result := ToolRequirements{Tool: tool.Name, OS: runtime.GOOS, Requirements: []RequirementStatus{}}
+	for _, spec := range getSpecs(tool) {
+		state, installedVersion := checkRequirement(spec)
+		result.Requirements = append(result.Requirements, RequirementStatus{
+			Name:             spec.Name,
+			Version:          spec.Version,
+			InstalledVersion: installedVersion,
+			Required:         spec.Required,
+			Satisfied:        state == requirementMet,
+			Status:           state,
+			Instruction:      strings.Replace(spec.Instruction, "$CMD", spec.Command, 1),
+		})
+	}
+	return result
+}
+
+// showRequirements prints the requirements of the given tools without installing them,
+// when unmetOnly is set only tools with unmet requirements are reported
+func (r *Runner) showRequirements(tools []types.Tool, unmetOnly bool) error {
+	var results []ToolRequirements
+	for _, tool := range tools {
+		result := evaluateRequirements(tool)
+		if unmetOnly {
+			result.Requirements = unmetRequirements(result.Requirements)
+			if len(result.Requirements) == 0 {
+				continue
+			}
+		}
+		if r.options.structured() {
+			results = append(results, result)
+			continue
+		}
+		if len(result.Requirements) == 0 {
+			gologger.Info().Msgf("%s has no requirements on %s", tool.Name, runtime.GOOS)
+			continue
+		}
+		stringBuilder := &strings.Builder{}
+		stringBuilder.WriteString(fmt.Sprintf("%s\n", au.Bold(tool.Name+" requirements:").String()))
+		for _, requirement := range result.Requirements {
+			status := au.Red(requirement.Status).String()
+			switch requirement.Status {
+			case requirementMet:
+				status = au.BrightGreen(requirement.Status).String()
+			case requirementUnknown:
+				status = au.Yellow(requirement.Status).String()
+			}
+			name := requirement.Name
+			if requirement.Version != "" {
+				name += " " + requirement.Version
+			}
+			if requirement.InstalledVersion != "" {
+				name += fmt.Sprintf(" (installed %s)", requirement.InstalledVersion)
+			}
+			isRequired := au.BrightGreen("optional").String()
+			if requirement.Required {
+				isRequired = au.Yellow("required").String()
+			}
+			stringBuilder.WriteString(fmt.Sprintf("[%s] %s %s\n", status, isRequired, name))
+			if !requirement.Satisfied {
+				stringBuilder.WriteString(fmt.Sprintf("\t%s\n", requirement.Instruction))
+			}
+		}
+		gologger.Info().Msgf("%s", stringBuilder.String())
+	}
+	if r.options.structured() {
+		return writeResults(r.options, results)
+	}
+	return nil
+}
+
+func unmetRequirements(requirements []RequirementStatus) []RequirementStatus {
+	unmet := []RequirementStatus{}
+	for _, requirement := range requirements {
+		if !requirement.Satisfied {
+			unmet = append(unmet, requirement)
+		}
+	}
+	return unmet
+}
+
+func printRequirementInfo(tool types.Tool) {
+	specs := getSpecs(tool)
+
+	printTitle := true
+	stringBuilder := &strings.Builder{}
+	for _, spec := range specs {
+		if requirementSatisfied(spec) {
+			continue
+		}
+		if printTitle {
+			stringBuilder.WriteString(fmt.Sprintf("%s\n", au.Bold(tool.Name+" requirements:").String()))
+			printTitle = false
+		}
+		instruction := getFormattedInstruction(spec)
+		isRequired := getRequirementStatus(spec)
+		stringBuilder.WriteString(fmt.Sprintf("%s %s\n", isRequired, instruction))
+	}
+	if stringBuilder.Len() > 0 {
+		gologger.Info().Msgf("%s", stringBuilder.String())
+	}
+}
+
+func getRequirementStatus(spec types.ToolRequirementSpecification) string {
+	if spec.Required {
+		return au.Yellow("required").String()
+	}
+	return au.BrightGreen("optional").String()
+}
+
+func getFormattedInstruction(spec types.ToolRequirementSpecification) string {
+	instruction := strings.Replace(spec.Instruction, "$CMD", spec.Command, 1)
+	if spec.Version != "" {
+		instruction = fmt.Sprintf("(%s %s) %s", spec.Name, spec.Version, instruction)
+	}
+	return instruction
+}
+
+func getSpecs(tool types.Tool) []types.ToolRequirementSpecification {
+	var specs []types.ToolRequirementSpecification
+	for _, requirement := range tool.Requirements {
+		if requirement.OS == runtime.GOOS {
+			specs = append(specs, requirement.Specification...)
+		}
+	}
+	return specs
+}
+
+// requirementSatisfied checks if the requirement is present on the system and
+// if its version matches the version constraint of the requirement (if any)
+func requirementSatisfied(spec types.ToolRequirementSpecification) bool {
+	state, _ := checkRequirement(spec)
+	return state == requirementMet
+}
+
+// checkRequirement returns the state of the requirement along with the installed
+// version of the requirement when it could be determined, pkg-config and
+// vswhere are used when available and the library files are looked up otherwise
+func checkRequirement(spec types.ToolRequirementSpecification) (string, string) {
+	present, installedVersion, probed := probeRequirement(spec.Name)
+	if !probed {
+		present = requirementPresent(spec.Name)
+		if present {
+			installedVersion = requirementVersion(spec.Name)
+		}
+	}
+	if !present {
+		return requirementMissing, ""
+	}
+	if spec.Version == "" {
+		return requirementMet, installedVersion
+	}
+	constraint, err := semver.NewConstraint(spec.Version)
+	if err != nil {
+		gologger.Verbose().Msgf("invalid version constraint %s for %s: %s", spec.Version, spec.Name, err)
+		return requirementUnknown, installedVersion
+	}
+	if installedVersion == "" {
+		gologger.Verbose().Msgf("could not determine installed version of %s", spec.Name)
+		return requirementUnknown, installedVersion
+	}
+	v, err := semver.NewVersion(installedVersion)
+	if err != nil {
+		gologger.Verbose().Msgf("could not parse installed version %s of %s: %s", installedVersion, spec.Name, err)
+		return requirementUnknown, installedVersion
+	}
+	if !constraint.Check(v) {
+		return requirementMissing, installedVersion
+	}
+	return requirementMet, installedVersion
+}
+
+func requirementPresent(requirementName string) bool {
+	if strings.HasPrefix(requirementName, "lib") {
+		libNames := appendLibExtensionForOS(requirementName)
+		for _, libName := range libNames {
+			_, sysErr := syscallutil.LoadLibrary(libName)
+			if sysErr == nil {
+				return true
+			}
+		}
+		return len(libraryFiles(requirementName)) > 0
+	}
+	_, execErr := exec.LookPath(requirementName)
+	return execErr == nil || prefixExecutable(requirementName) != ""
+}
+
+// packageManagerPrefixes returns the prefixes of the package managers, the
+// prefix of the homebrew in use first
+func packageManagerPrefixes() []string {
+	if !osutils.IsOSX() {
+		return nil
+	}
+	prefixes := append([]string{}, packagePrefixes...)
+	if prefix := os.Getenv("HOMEBREW_PREFIX"); prefix != "" {
+		prefixes = append([]string{prefix}, prefixes...)
+	}
+	return sliceutil.Dedupe(prefixes)
+}
+
+// librarySearchDirs returns the directories searched for library files: the
+// default ones, the ones of the package managers and the pkg-config paths
+func librarySearchDirs() []string {
+	dirs := append([]string{}, libraryDirs...)
+	for _, prefix := range packageManagerPrefixes() {
+		dirs = append(dirs, filepath.Join(prefix, "lib"))
+		// keg-only formulae (eg. libpcap) aren't linked into the prefix
+		kegs, _ := filepath.Glob(filepath.Join(prefix, "opt", "*", "lib"))
+		dirs = append(dirs, kegs...)
+	}
+	// the pkg-config files are in <libdir>/pkgconfig
+	for _, dir := range filepath.SplitList(os.Getenv("PKG_CONFIG_PATH")) {
+		if dir != "" {
+			dirs = append(dirs, filepath.Dir(filepath.Clean(dir)))
+		}
+	}
+	return sliceutil.Dedupe(dirs)
+}
+
+// prefixExecutable returns the path of the executable installed by a
+// package manager outside of $PATH, empty if not found
+func prefixExecutable(name string) string {
+	for _, prefix := range packageManagerPrefixes() {
+		for _, dir := range []string{"bin", "sbin"} {
+			executable := filepath.Join(prefix, dir, name)
+			if fi, err := os.Stat(executable); err == nil && !fi.IsDir() {
+				return executable
+			}
+		}
+	}
+	return ""
+}
+
+// requirementVersion returns the installed version of a requirement or an
+// empty string if it can't be determined
+func requirementVersion(requirementName string) string {
+	if strings.HasPrefix(requirementName, "lib") {
+		return libraryVersion(requirementName)
+	}
+	executable := requirementName
+	if _, err := exec.LookPath(requirementName); err != nil {
+		if prefixed := prefixExecutable(requirementName); prefixed != "" {
+			executable = prefixed
+		}
+	}
+	var outb bytes.Buffer
+	cmd, cancel := probeCommand(executable, "--version")
+	defer cancel()
+	cmd.Stdout = &outb
+	cmd.Stderr = &outb
+	if err := cmd.Run(); err != nil {
+		return ""
+	}
+	return regexRequirementVersion.FindString(outb.String())
+}
+
+// libraryFiles returns the files of the library found in the library search
+// directories, the soname links being resolved to the versioned files
+func libraryFiles(lib string) []string {
+	var patterns []string
+	switch {
+	case osutils.IsLinux():
+		patterns = []string{lib + ".so", lib + ".so.*"}
+	case osutils.IsOSX():
+		patterns = []string{lib + ".dylib", lib + ".*.dylib"}
+	default:
+		return nil
+	}
+
+	var files []string
+	for _, dir := range librarySearchDirs() {
+		for _, pattern := range patterns {
+			// multiarch layouts keep libraries in a subdirectory (eg. /usr/lib/x86_64-linux-gnu)
+			for _, glob := range []string{filepath.Join(dir, pattern), filepath.Join(dir, "*", pattern)} {
+				matches, _ := filepath.Glob(glob)
+				for _, match := range matches {
+					// soname links (eg. libpcap.so.0.8) point to the fully versioned file
+					if target, err := filepath.EvalSymlinks(match); err == nil {
+						match = target
+					}
+					files = append(files, match)
+				}
+			}
+		}
+	}
+	return sliceutil.Dedupe(files)
+}
+
+// libraryVersion extracts the version of a library from the names of its
+// versioned files (eg. libpcap.so.1.10.1 or libpcap.1.10.4.dylib)
+func libraryVersion(lib string) string {
+	var versions []*semver.Version
+	for _, file := range libraryFiles(lib) {
+		name := strings.TrimPrefix(filepath.Base(file), lib)
+		if v, err := semver.NewVersion(regexRequirementVersion.FindString(name)); err == nil {
+			versions = append(versions, v)
+		}
+	}
+	if len(versions) == 0 {
+		return ""
+	}
+	sort.Sort(semver.Collection(versions))
+	return versions[len(versions)-1].String()
+}
+
+func appendLibExtensionForOS(lib string) []string {
+	switch {
+	case osutils.IsWindows():
+		return []string{fmt.Sprintf("%s.dll", lib), lib}
+	case osutils.IsLinux():
+		return []string{fmt.Sprintf("%s.so", lib), lib}
+	case osutils.IsOSX():
+		return []string{fmt.Sprintf("%s.dylib", lib), lib}
+	default:
+		return []string{lib}
+	}
+}
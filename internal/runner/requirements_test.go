@@ -0,0 +1,46 @@
+package runner
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/projectdiscovery/pdtm/pkg/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckRequirement(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the fake requirements are shell scripts")
+	}
+	dir := t.TempDir()
+	t.Setenv("PATH", dir)
+	// the scripts run through the absolute path of their shebang
+	writeScript := func(name, output string) {
+		require.Nil(t, os.WriteFile(filepath.Join(dir, name), []byte("#!/bin/sh\necho '"+output+"'\n"), 0755))
+	}
+	writeScript("chromium", "Chromium 116.0.5845.96")
+	writeScript("noversion", "no version here")
+
+	tests := []struct {
+		name      string
+		spec      types.ToolRequirementSpecification
+		state     string
+		installed string
+	}{
+		{"met", types.ToolRequirementSpecification{Name: "chromium", Version: ">=110"}, requirementMet, "116.0.5845"},
+		{"too old", types.ToolRequirementSpecification{Name: "chromium", Version: ">=120"}, requirementMissing, "116.0.5845"},
+		{"presence only", types.ToolRequirementSpecification{Name: "noversion"}, requirementMet, ""},
+		{"undetectable version", types.ToolRequirementSpecification{Name: "noversion", Version: ">=1.0"}, requirementUnknown, ""},
+		{"invalid constraint", types.ToolRequirementSpecification{Name: "chromium", Version: "newest"}, requirementUnknown, "116.0.5845"},
+		{"absent", types.ToolRequirementSpecification{Name: "firefox", Version: ">=1.0"}, requirementMissing, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			state, installed := checkRequirement(tt.spec)
+			require.Equal(t, tt.state, state)
+			require.Equal(t, tt.installed, installed)
+		})
+	}
+}
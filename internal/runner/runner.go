@@ -1,43 +1,190 @@
 package runner
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"os"
 	"os/exec"
-	"runtime"
+	"path/filepath"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/projectdiscovery/gologger"
 	"github.com/projectdiscovery/pdtm/pkg"
+	"github.com/projectdiscovery/pdtm/pkg/httpclient"
+	"github.com/projectdiscovery/pdtm/pkg/lock"
 	"github.com/projectdiscovery/pdtm/pkg/path"
+	"github.com/projectdiscovery/pdtm/pkg/signature"
+	"github.com/projectdiscovery/pdtm/pkg/toolchain"
 	"github.com/projectdiscovery/pdtm/pkg/types"
 	"github.com/projectdiscovery/pdtm/pkg/utils"
 	errorutil "github.com/projectdiscovery/utils/errors"
-	osutils "github.com/projectdiscovery/utils/os"
-	stringsutil "github.com/projectdiscovery/utils/strings"
-	"github.com/projectdiscovery/utils/syscallutil"
 )
 
-var excludedToolList = []string{"nuclei-templates"}
-
 // Runner contains the internal logic of the program
 type Runner struct {
 	options *Options
+	paths   *ManagedPaths
+	lock    *lock.Lock
+	// explicitPath is set when the binary path is given with -binary-path
+	explicitPath bool
+	// reported contains the tools posted to -report-url
+	reported []ReportedTool
+	schedule *pkg.Schedule
+	// mu serializes the checks of the daemon and the api calls
+	mu     sync.Mutex
+	status *daemonStatus
+	// systemLinkDir is set when the system path is staged by -system
+	systemLinkDir string
+	// pulledBy contains the tools installed as dependencies and the
+	// requested tools depending on them
+	pulledBy map[string][]string
 }
 
 // NewRunner instance
 func NewRunner(options *Options) (*Runner, error) {
-	return &Runner{
-		options: options,
-	}, nil
+	pkg.GoBuild = options.GoBuild
+	pkg.AssetTemplates = options.AssetTemplates
+	pkg.ExtraBinaries = options.Binaries
+	pkg.KeepQuarantine = options.KeepQuarantine
+	pkg.Paranoid = options.Paranoid
+	if options.CacheDir != "" {
+		dir, err := filepath.Abs(options.CacheDir)
+		if err != nil {
+			return nil, err
+		}
+		cacheFile, archiveCacheDir = filepath.Join(dir, "cache.json"), filepath.Join(dir, "archives")
+	}
+	pkg.ArchiveCache = archiveCacheDir
+	pkg.BinaryStore = binaryStoreDir
+	if options.Keep < 0 {
+		return nil, fmt.Errorf("invalid -keep %d: expected 0 or more", options.Keep)
+	}
+	pkg.Retention = pkg.RetentionPolicy{Keep: options.Keep, MaxAge: options.KeepAge, MaxSize: int64(options.KeepSize) * 1024 * 1024}
+	if options.ProgressJSON {
+		pkg.Progress = writeProgress
+	}
+	if err := httpclient.SetIPVersion(options.IPVersion); err != nil {
+		return nil, err
+	}
+	if err := httpclient.SetResolvers(options.Resolvers); err != nil {
+		return nil, err
+	}
+	// an unreachable proxy auto-config (eg. off the vpn) doesn't prevent the runs
+	if err := httpclient.SetProxy(options.ProxyPAC, options.NoProxy); err != nil {
+		gologger.Error().Msgf("%s, using the proxy of the environment", err)
+	}
+	if err := httpclient.SetSource(options.Source, apiHost()); err != nil {
+		return nil, err
+	}
+	mirror, err := pkg.ParseDownloadMirror(options.DownloadMirror)
+	if err != nil {
+		return nil, err
+	}
+	pkg.DownloadMirror = mirror
+	httpclient.SetPacing(httpclient.Pacing{
+		MaxConcurrent: options.HostConcurrency,
+		Interval:      options.HostInterval,
+		Jitter:        options.HostJitter,
+	})
+	for name, dir := range options.DataDirs {
+		pkg.DataPacks[strings.ToLower(name)] = dir
+	}
+	provider, err := pkg.NewProvider(options.Provider)
+	if err != nil {
+		return nil, err
+	}
+	pkg.ActiveProvider = provider
+	if options.RegistryKey != "" {
+		if err := signature.SetPublicKey(options.RegistryKey); err != nil {
+			return nil, errorutil.NewWithErr(err).Msgf("invalid registry key")
+		}
+	}
+	schedule, err := pkg.ParseSchedule(options.Schedule, options.Blackout)
+	if err != nil {
+		return nil, err
+	}
+	paths, err := loadManagedPaths()
+	if err != nil {
+		gologger.Warning().Msgf("could not read managed paths: %s", err)
+	}
+	explicitPath := options.Path != defaultPath
+	if !explicitPath && paths.Default != "" {
+		options.Path = paths.Default
+	}
+	r := &Runner{
+		options:      options,
+		paths:        paths,
+		explicitPath: explicitPath,
+		schedule:     schedule,
+		status:       &daemonStatus{},
+	}
+	pkg.ReplaceDataDir = func(tool types.Tool, dir string) bool {
+		return r.confirm(fmt.Sprintf("%s exists but wasn't installed by pdtm, replace it with %s?", dir, tool.Name))
+	}
+	return r, nil
 }
 
 // Run the instance
 func (r *Runner) Run() error {
+	// the cache server doesn't manage binaries, it runs next to other runs
+	if r.options.Serve != "" {
+		return r.serve()
+	}
+	// the daemon locks each of its checks
+	if r.options.Daemon > 0 || r.options.API != "" {
+		return r.daemon()
+	}
+	// the system path is staged and updated once the run is done
+	if r.options.System {
+		return r.runSystem()
+	}
+	// the command runs once the lock is released
+	if len(r.options.With) > 0 {
+		return r.runWith()
+	}
+	// the project path is installed to like any other path
+	if r.options.Local {
+		return r.runLocal()
+	}
+	if r.options.DirenvHook {
+		gologger.Silent().Msg(direnvHook)
+		return nil
+	}
+	if r.options.Envrc {
+		return writeEnvrc()
+	}
+	if r.options.Env && r.options.Project {
+		return r.activateProject()
+	}
+	if r.options.Env {
+		return r.printEnv()
+	}
+
+	// concurrent runs would write the same binaries and state, the read-only
+	// commands run next to them
+	if !r.options.readOnly() {
+		l, err := lock.Acquire(lockFile, lockTimeout)
+		if err != nil {
+			return errorutil.NewWithErr(err).Msgf("could not lock %s", lockFile)
+		}
+		r.lock = l
+		defer r.Close()
+	}
+
+	if r.options.Paranoid {
+		r.checkModified()
+	}
+	if r.options.Project {
+		return r.syncProject()
+	}
+
 	// add default path to $PATH
-	if r.options.SetPath || r.options.Path == defaultPath {
+	if (r.options.SetPath || r.options.Path == defaultPath) && !r.options.RemoveAll && r.options.Migrate == "" {
 		if err := path.SetENV(r.options.Path); err != nil {
 			return errorutil.NewWithErr(err).Msgf(`Failed to set path: %s. Add it to $PATH and run again`, r.options.Path)
 		}
@@ -49,240 +196,434 @@ func (r *Runner) Run() error {
 		}
 	}
 
-	toolListApi, err := utils.FetchToolList()
-	var toolList []types.Tool
-
-	for _, tool := range toolListApi {
-		if !stringsutil.ContainsAny(tool.Name, excludedToolList...) {
-			toolList = append(toolList, tool)
+	if ok, err := r.manageConfig(); ok {
+		return err
+	}
+	if ok, err := r.manageService(); ok {
+		return err
+	}
+	if ok, err := r.managePaths(); ok {
+		return err
+	}
+	if r.options.Backup != "" {
+		count, err := pkg.Backup(r.options.Path, r.options.Backup)
+		if err != nil {
+			return errorutil.NewWithErr(err).Msgf("could not backup %s", r.options.Path)
 		}
+		gologger.Info().Msgf("backed up %d projects to %s", count, r.options.Backup)
+		return nil
 	}
-
-	// if toolList is not nil save/update the cache
-	// else fetch from cache file
-	if toolList != nil {
-		go func() {
-			if err := UpdateCache(toolList); err != nil {
-				gologger.Warning().Msgf("%s\n", err)
-			}
-		}()
-	} else {
-		toolList, err = FetchFromCache()
+	if r.options.Restore != "" {
+		if !path.IsSubPath(homeDir, r.options.Path) {
+			return fmt.Errorf("skipping restore outside home folder: %s", r.options.Path)
+		}
+		count, err := pkg.Restore(r.options.Path, r.options.Restore)
 		if err != nil {
-			return errors.New("pdtm api is down, please try again later")
+			return errorutil.NewWithErr(err).Msgf("could not restore %s", r.options.Restore)
 		}
-		if toolList != nil {
-			gologger.Warning().Msg("pdtm api is down, using cached information while we fix the issue \n\n")
+		gologger.Info().Msgf("restored %d projects to %s", count, r.options.Path)
+		return nil
+	}
+	if r.options.Migrate != "" {
+		return r.migrate(r.options.Migrate)
+	}
+	if r.options.GC {
+		return r.collectVersions()
+	}
+	if r.options.AuthStatus {
+		return r.showAuthStatus()
+	}
+
+	toolList, err := r.fetchToolList()
+	if err != nil {
+		return err
+	}
+	toolList = withDataPacks(toolList)
+	r.resolveAliases(toolList)
+
+	if len(r.options.Requirements) > 0 {
+		var tools []types.Tool
+		for _, toolName := range r.options.Requirements {
+			if i, ok := utils.Contains(toolList, toolName); ok {
+				tools = append(tools, toolList[i])
+			} else {
+				gologger.Error().Msg(unknownTool(toolList, toolName))
+			}
 		}
+		return r.showRequirements(tools, false)
+	}
+	if r.options.RequirementsAll {
+		return r.showRequirements(toolList, true)
 	}
-	if toolList == nil && err != nil {
+	if r.options.ShowStatus {
+		return r.showStatus(toolList)
+	}
+	if r.options.Verify {
+		return r.verifyInstalls(toolList)
+	}
+	if r.options.Outdated {
+		return r.showOutdated(toolList)
+	}
+	if r.options.Diff {
+		return r.showDiff(toolList)
+	}
+	if r.options.Export != "" {
+		return r.export(toolList)
+	}
+	if r.options.Info != "" {
+		return r.showInfo(toolList)
+	}
+	if r.options.Why != "" {
+		return r.showWhy(toolList)
+	}
+	if r.options.History != "" {
+		return r.showHistory(toolList)
+	}
+	if r.options.Rollback != "" {
+		err := r.rollback(toolList)
+		r.sendReport()
 		return err
 	}
 
+	if r.options.UpdateAll || len(r.options.Update) > 0 {
+		r.options.Update = currentNames(toolList, r.options.Update)
+	}
 	switch {
 	case r.options.InstallAll:
 		for _, tool := range toolList {
 			r.options.Install = append(r.options.Install, tool.Name)
 		}
 	case r.options.UpdateAll:
-		for _, tool := range toolList {
-			r.options.Update = append(r.options.Update, tool.Name)
+		r.options.Update = append(r.options.Update, r.scheduledUpdates(toolList)...)
+	case r.options.PickUpdates:
+		picked, err := r.pickUpdates(toolList)
+		if err != nil {
+			return err
 		}
+		r.options.Update = append(r.options.Update, picked...)
 	case r.options.RemoveAll:
-		for _, tool := range toolList {
-			r.options.Remove = append(r.options.Remove, tool.Name)
-		}
+		err := r.removeAll(toolList)
+		r.sendReport()
+		return err
 	}
 	gologger.Verbose().Msgf("using path %s", r.options.Path)
 
+	if r.options.Repair {
+		broken := r.brokenTools(toolList)
+		if len(broken) == 0 {
+			gologger.Info().Msg("no broken projects found")
+		}
+		r.options.Reinstall = append(r.options.Reinstall, broken...)
+	}
+
+	if !r.options.NoDeps && len(r.options.Install) > 0 {
+		install, err := r.withDependencies(toolList, r.options.Install)
+		if err != nil {
+			return err
+		}
+		r.options.Install = install
+	}
+
+	// a rejected token fails every download, better stop before the batch
+	if r.options.Archive == "" && len(r.options.Install)+len(r.options.Reinstall)+len(r.options.Update) > 0 {
+		if err := r.validateToken(); err != nil {
+			return err
+		}
+	}
+	r.migrateRenamed(toolList, r.options.Update)
+
+	var pending []pendingInstall
 	for _, toolName := range r.options.Install {
-		if !path.IsSubPath(homeDir, r.options.Path) {
+		toolName, version := splitVersion(toolName)
+		dir := r.pathFor(toolName)
+		if !r.isAllowedPath(dir) {
 			gologger.Error().Msgf("skipping install outside home folder: %s", toolName)
 			continue
 		}
+		if !r.checkWritable(dir, "install", toolName) {
+			continue
+		}
 		if i, ok := utils.Contains(toolList, toolName); ok {
-			tool := toolList[i]
-			if tool.InstallType == types.Go && isGoInstalled() {
-				if err := pkg.GoInstall(r.options.Path, tool); err != nil {
-					gologger.Error().Msgf("%s: %s", tool.Name, err)
-				}
-				printRequirementInfo(tool)
+			warnDeprecated(toolList[i])
+			tool, err := r.resolve(toolList[i], version)
+			if err != nil {
+				gologger.Error().Msgf("error while resolving %s: %s", toolName, err)
 				continue
 			}
-
-			if err := pkg.Install(r.options.Path, tool); err != nil {
-				if errors.Is(err, types.ErrIsInstalled) {
-					gologger.Info().Msgf("%s: %s", tool.Name, err)
-				} else {
-					gologger.Error().Msgf("error while installing %s: %s", tool.Name, err)
-					gologger.Info().Msgf("trying to install %s using go install", tool.Name)
-					if err := pkg.GoInstall(r.options.Path, tool); err != nil {
-						gologger.Error().Msgf("%s: %s", tool.Name, err)
-					}
-				}
+			pending = append(pending, pendingInstall{dir: dir, tool: tool})
+		} else {
+			gologger.Error().Msgf("error while installing %s: %s", toolName, unknownTool(toolList, toolName))
+		}
+	}
+	if !r.confirmDownload(pending) {
+		gologger.Info().Msg("install aborted")
+		pending = nil
+	}
+	for _, p := range pending {
+		installed := ReportedTool{Name: p.tool.Name, Action: actionInstall, Version: p.tool.Version, Status: "failed"}
+		if r.install(p.dir, p.tool) {
+			installed.Status = "installed"
+			r.postInstall(p.dir, p.tool)
+			r.recordProvenance(p.dir, p.tool.Name)
+		} else if _, pulled := r.pulledBy[strings.ToLower(p.tool.Name)]; !pulled {
+			// a dependency installed before is now requested explicitly
+			r.recordProvenance(p.dir, p.tool.Name)
+		}
+		r.report(installed)
+		printRequirementInfo(p.tool)
+	}
+	for _, toolName := range r.options.Reinstall {
+		dir := r.pathFor(toolName)
+		if !r.isAllowedPath(dir) {
+			gologger.Error().Msgf("skipping reinstall outside home folder: %s", toolName)
+			continue
+		}
+		if !r.checkWritable(dir, "reinstall", toolName) {
+			continue
+		}
+		if i, ok := utils.Contains(toolList, toolName); ok {
+			reinstalled := ReportedTool{Name: toolName, Action: actionReinstall, Status: "failed"}
+			if version, ok := r.reinstall(dir, toolList[i]); ok {
+				reinstalled.Version, reinstalled.Status = version, "reinstalled"
 			}
-			printRequirementInfo(tool)
+			r.report(reinstalled)
 		} else {
-			gologger.Error().Msgf("error while installing %s: %s not found in the list", toolName, toolName)
+			gologger.Error().Msgf("error while reinstalling %s: %s", toolName, unknownTool(toolList, toolName))
 		}
 	}
+	var updates []UpdateResult
+	applyCapabilities := pkg.BatchCapabilities()
 	for _, tool := range r.options.Update {
-		if !path.IsSubPath(homeDir, r.options.Path) {
-			gologger.Error().Msgf("skipping update outside home folder: %s", tool)
-			continue
+		if result, ok := r.update(toolList, tool); ok {
+			updates = append(updates, result)
+			r.report(ReportedTool{Name: result.Name, Action: actionUpdate, Version: result.Version, Status: result.Outcome, Reason: result.Reason})
 		}
+	}
+	applyCapabilities()
+	var summaryErr error
+	if r.options.UpdateAll {
+		summaryErr = r.showUpdateSummary(updates)
+	}
+	r.notifyUpdates(updates)
+	for _, tool := range r.options.Pin {
 		if i, ok := utils.Contains(toolList, tool); ok {
-			if err := pkg.Update(r.options.Path, toolList[i], r.options.DisableChangeLog); err != nil {
-				if err == types.ErrIsUpToDate {
-					gologger.Info().Msgf("%s: %s", tool, err)
-				} else {
-					gologger.Info().Msgf("%s\n", err)
-				}
+			if err := pkg.Pin(r.pathFor(tool), toolList[i]); err != nil {
+				gologger.Error().Msgf("error while pinning %s: %s", tool, err)
+			}
+		} else {
+			gologger.Error().Msgf("error while pinning %s: %s", tool, unknownTool(toolList, tool))
+		}
+	}
+	for _, tool := range r.options.Unpin {
+		if i, ok := utils.Contains(toolList, tool); ok {
+			if err := pkg.Unpin(r.pathFor(tool), toolList[i]); err != nil {
+				gologger.Error().Msgf("error while unpinning %s: %s", tool, err)
 			}
+		} else {
+			gologger.Error().Msgf("error while unpinning %s: %s", tool, unknownTool(toolList, tool))
 		}
 	}
 	for _, tool := range r.options.Remove {
-		if !path.IsSubPath(homeDir, r.options.Path) {
+		dir := r.pathFor(tool)
+		if !r.isAllowedPath(dir) {
 			gologger.Error().Msgf("skipping remove outside home folder: %s", tool)
 			continue
 		}
+		if !r.checkWritable(dir, "remove", tool) {
+			continue
+		}
 		if i, ok := utils.Contains(toolList, tool); ok {
-			if err := pkg.Remove(r.options.Path, toolList[i]); err != nil {
+			if !r.confirmRemoveDependency(toolList, toolList[i]) {
+				gologger.Info().Msgf("skipping remove of %s", tool)
+				continue
+			}
+			removed := ReportedTool{Name: tool, Action: actionRemove, Version: r.reportedVersion(dir, toolList[i]), Status: "removed"}
+			if err := pkg.Remove(dir, toolList[i]); err != nil {
 				var notFoundError *exec.Error
 				if errors.As(err, &notFoundError) {
 					gologger.Info().Msgf("%s: not found", tool)
+					removed.Status = "not found"
 				} else {
 					gologger.Info().Msgf("%s\n", err)
+					removed.Status, removed.Reason = "failed", err.Error()
 				}
 			}
-
+			r.report(removed)
+		} else {
+			gologger.Error().Msgf("error while removing %s: %s", tool, unknownTool(toolList, tool))
 		}
 	}
-	if len(r.options.Install) == 0 && len(r.options.Update) == 0 && len(r.options.Remove) == 0 {
+	if r.options.LinkDir != "" {
+		r.syncLinks(toolList)
+	}
+	r.sendReport()
+	if len(r.options.Install) == 0 && len(r.options.Update) == 0 && !r.options.UpdateAll && len(r.options.Remove) == 0 &&
+		len(r.options.Pin) == 0 && len(r.options.Unpin) == 0 && len(r.options.Reinstall) == 0 && !r.options.Repair && !r.options.PickUpdates {
 		return r.ListToolsAndEnv(toolList)
 	}
-	return nil
+	return summaryErr
+}
+
+// resolve returns the tool resolved to the given version or to the
+// configured release channel of the tool if no version is given
+func (r *Runner) resolve(tool types.Tool, version string) (types.Tool, error) {
+	if version != "" {
+		return pkg.ResolveVersion(tool, version)
+	}
+	return pkg.Resolve(tool, r.options.channel(tool.Name))
+}
+
+// splitVersion splits a tool name given as name@version
+func splitVersion(toolName string) (string, string) {
+	name, version, _ := strings.Cut(toolName, "@")
+	return name, version
+}
+
+// goAvailable returns true if go install can be used, falling back to the go
+// toolchain downloaded by pdtm when go isn't installed on the system
+func (r *Runner) goAvailable() bool {
+	if isGoInstalled() {
+		return true
+	}
+	if toolchain.IsInstalled(toolchainDir) {
+		pkg.GoBinary = toolchain.GoBinary(toolchainDir)
+		return true
+	}
+	if !r.options.GoBootstrap {
+		return false
+	}
+	goBinary, err := toolchain.Install(toolchainDir)
+	if err != nil {
+		gologger.Error().Msgf("could not install go toolchain: %s", err)
+		return false
+	}
+	pkg.GoBinary = goBinary
+	return true
 }
 
 func isGoInstalled() bool {
-	cmd := exec.Command("go", "version")
+	cmd, cancel := probeCommand(pkg.GoBinary, "version")
+	defer cancel()
 	if err := cmd.Run(); err != nil {
 		return false
 	}
 	return true
 }
 
-func printRequirementInfo(tool types.Tool) {
-	specs := getSpecs(tool)
-
-	printTitle := true
-	stringBuilder := &strings.Builder{}
-	for _, spec := range specs {
-		if requirementSatisfied(spec.Name) {
-			continue
+// fetchToolList returns the tool list from the cache while it's fresh,
+// otherwise from the pdtm api falling back to the cache when it's down
+func (r *Runner) fetchToolList() ([]types.Tool, error) {
+	if !r.options.Refresh && isCacheFresh(r.options.CacheTTL) {
+		toolList, err := FetchFromCache()
+		if err == nil && toolList != nil {
+			gologger.Verbose().Msgf("using cached tool list from %s", toolListCache())
+			return toolList, nil
 		}
-		if printTitle {
-			stringBuilder.WriteString(fmt.Sprintf("%s\n", au.Bold(tool.Name+" requirements:").String()))
-			printTitle = false
+		if errors.Is(err, signature.ErrInvalidSignature) {
+			gologger.Info().Label("WRN").Msgf("ignoring the cached tool list: %s", err)
 		}
-		instruction := getFormattedInstruction(spec)
-		isRequired := getRequirementStatus(spec)
-		stringBuilder.WriteString(fmt.Sprintf("%s %s\n", isRequired, instruction))
 	}
-	if stringBuilder.Len() > 0 {
-		gologger.Info().Msgf("%s", stringBuilder.String())
+
+	toolListApi, signed, err := utils.FetchSignedToolList()
+	if errors.Is(err, signature.ErrInvalidSignature) {
+		// don't fall back to the cache when the registry may be compromised
+		return nil, errorutil.NewWithErr(err).Msgf("could not verify the tool list")
 	}
-}
+	toolList := toolListApi
 
-func getRequirementStatus(spec types.ToolRequirementSpecification) string {
-	if spec.Required {
-		return au.Yellow("required").String()
+	// if toolList is not nil save/update the cache
+	// else fetch from cache file
+	if toolList != nil {
+		if err := UpdateCache(signed); err != nil {
+			gologger.Warning().Msgf("%s\n", err)
+		}
+		return toolList, nil
+	}
+	toolList, err = FetchFromCache()
+	if err != nil || toolList == nil {
+		return nil, errors.New("pdtm api is down, please try again later")
 	}
-	return au.BrightGreen("optional").String()
+	gologger.Warning().Msg("pdtm api is down, using cached information while we fix the issue \n\n")
+	return toolList, nil
 }
 
-func getFormattedInstruction(spec types.ToolRequirementSpecification) string {
-	return strings.Replace(spec.Instruction, "$CMD", spec.Command, 1)
+// withDataPacks adds the data packs missing from the tool list
+func withDataPacks(toolList []types.Tool) []types.Tool {
+	for name := range pkg.DataPacks {
+		if _, ok := utils.Contains(toolList, name); !ok {
+			toolList = append(toolList, types.Tool{Name: name, Repo: name})
+		}
+	}
+	return toolList
 }
 
-func getSpecs(tool types.Tool) []types.ToolRequirementSpecification {
-	var specs []types.ToolRequirementSpecification
-	for _, requirement := range tool.Requirements {
-		if requirement.OS == runtime.GOOS {
-			specs = append(specs, requirement.Specification...)
-		}
+// isCacheFresh returns true if the cache file was updated within the ttl
+func isCacheFresh(ttl time.Duration) bool {
+	if ttl <= 0 {
+		return false
+	}
+	info, err := os.Stat(toolListCache())
+	if err != nil {
+		return false
 	}
-	return specs
+	return time.Since(info.ModTime()) < ttl
+}
+
+// toolListCache returns the cache file of the tool list, each api server and
+// registry key gets its own cache
+func toolListCache() string {
+	sum := sha256.Sum256([]byte(utils.APIServer() + "\n" + signature.KeyID()))
+	return strings.TrimSuffix(cacheFile, ".json") + "-" + hex.EncodeToString(sum[:8]) + ".json"
 }
 
-// UpdateCache creates/updates cache file
-func UpdateCache(toolList []types.Tool) error {
-	b, err := json.Marshal(toolList)
+// UpdateCache creates/updates cache file with the signed tool list, it's
+// replaced at once since other machines may read it from a shared cache directory
+func UpdateCache(signed *utils.SignedToolList) error {
+	b, err := json.Marshal(signed)
+	if err != nil {
+		return err
+	}
+	file := toolListCache()
+	if err := os.MkdirAll(filepath.Dir(file), os.ModePerm); err != nil {
+		return err
+	}
+	f, err := os.CreateTemp(filepath.Dir(file), ".cache-*")
 	if err != nil {
 		return err
 	}
-	return os.WriteFile(cacheFile, b, os.ModePerm)
+	_, err = f.Write(b)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Chmod(f.Name(), 0644)
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), file)
+	}
+	if err != nil {
+		_ = os.Remove(f.Name())
+	}
+	return err
 }
 
-// FetchFromCache loads tool list from cache file
+// FetchFromCache loads tool list from cache file, verifying its signature
+// like the one fetched from the pdtm api
 func FetchFromCache() ([]types.Tool, error) {
-	b, err := os.ReadFile(cacheFile)
+	b, err := os.ReadFile(toolListCache())
 	if err != nil {
 		return nil, err
 	}
-	var toolList []types.Tool
-	if err := json.Unmarshal(b, &toolList); err != nil {
+	var signed utils.SignedToolList
+	if err := json.Unmarshal(b, &signed); err != nil {
 		return nil, err
 	}
-	return toolList, nil
-}
-
-// ListToolsAndEnv prints the list of tools
-func (r *Runner) ListToolsAndEnv(tools []types.Tool) error {
-	gologger.Info().Msgf(path.GetOsData() + "\n")
-	gologger.Info().Msgf("Path to download project binary: %s\n", r.options.Path)
-	var fmtMsg string
-	if path.IsSet(r.options.Path) {
-		fmtMsg = "Path %s configured in environment variable $PATH\n"
-	} else {
-		fmtMsg = "Path %s not configured in environment variable $PATH\n"
-	}
-	gologger.Info().Msgf(fmtMsg, r.options.Path)
-
-	for i, tool := range tools {
-		msg := utils.InstalledVersion(tool, r.options.Path, au)
-		fmt.Printf("%d. %s %s\n", i+1, tool.Name, msg)
-	}
-	return nil
+	return utils.ParseToolList(&signed)
 }
 
 // Close the runner instance
-func (r *Runner) Close() {}
-
-func requirementSatisfied(requirementName string) bool {
-	if strings.HasPrefix(requirementName, "lib") {
-		libNames := appendLibExtensionForOS(requirementName)
-		for _, libName := range libNames {
-			_, sysErr := syscallutil.LoadLibrary(libName)
-			if sysErr == nil {
-				return true
-			}
-		}
-		return false
-	}
-	_, execErr := exec.LookPath(requirementName)
-	return execErr == nil
-}
-
-func appendLibExtensionForOS(lib string) []string {
-	switch {
-	case osutils.IsWindows():
-		return []string{fmt.Sprintf("%s.dll", lib), lib}
-	case osutils.IsLinux():
-		return []string{fmt.Sprintf("%s.so", lib), lib}
-	case osutils.IsOSX():
-		return []string{fmt.Sprintf("%s.dylib", lib), lib}
-	default:
-		return []string{lib}
+func (r *Runner) Close() {
+	if err := r.lock.Release(); err != nil {
+		gologger.Warning().Msgf("could not remove lock %s: %s", lockFile, err)
 	}
 }
@@ -0,0 +1,56 @@
+package runner
+
+import (
+	"crypto/rand"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"aead.dev/minisign"
+	"github.com/projectdiscovery/pdtm/pkg/signature"
+	"github.com/projectdiscovery/pdtm/pkg/utils"
+	"github.com/stretchr/testify/require"
+)
+
+// useRegistryKey sets a new registry key and returns its private key
+func useRegistryKey(t *testing.T) minisign.PrivateKey {
+	public, private, err := minisign.GenerateKey(rand.Reader)
+	require.Nil(t, err)
+	key, err := public.MarshalText()
+	require.Nil(t, err)
+	require.Nil(t, signature.SetPublicKey(string(key)))
+	t.Cleanup(func() { _ = signature.SetPublicKey("") })
+	return private
+}
+
+func TestToolListCache(t *testing.T) {
+	previous := cacheFile
+	cacheFile = filepath.Join(t.TempDir(), "cache.json")
+	t.Cleanup(func() { cacheFile = previous })
+
+	document := []byte(`[{"name":"dnsx"}]`)
+	private := useRegistryKey(t)
+	require.Nil(t, UpdateCache(&utils.SignedToolList{Document: document, Signature: minisign.Sign(private, document)}))
+	toolList, err := FetchFromCache()
+	require.Nil(t, err)
+	require.Len(t, toolList, 1)
+	require.Equal(t, "dnsx", toolList[0].Name)
+
+	// the cached document is verified again
+	signed := toolListCache()
+	tampered := []byte(`[{"name":"evil"}]`)
+	require.Nil(t, UpdateCache(&utils.SignedToolList{Document: tampered, Signature: minisign.Sign(private, document)}))
+	_, err = FetchFromCache()
+	require.ErrorIs(t, err, signature.ErrInvalidSignature)
+	require.Nil(t, UpdateCache(&utils.SignedToolList{Document: document}))
+	_, err = FetchFromCache()
+	require.ErrorIs(t, err, signature.ErrInvalidSignature)
+
+	// another registry key doesn't read the cache of the previous one
+	useRegistryKey(t)
+	require.NotEqual(t, signed, toolListCache())
+	_, err = FetchFromCache()
+	require.True(t, os.IsNotExist(err))
+	require.Nil(t, signature.SetPublicKey(""))
+	require.NotEqual(t, signed, toolListCache())
+}
@@ -0,0 +1,52 @@
+package runner
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/projectdiscovery/gologger"
+	updateutils "github.com/projectdiscovery/utils/update"
+)
+
+// versionCheck is the cached result of the last pdtm version check
+type versionCheck struct {
+	Checked time.Time `json:"checked"`
+	Latest  string    `json:"latest"`
+}
+
+// checkVersion prints an advisory when a newer pdtm release is available,
+// the latest version is fetched at most once per -update-check-interval
+func checkVersion(interval time.Duration, verbose bool) {
+	var check versionCheck
+	if b, err := os.ReadFile(versionCheckFile); err == nil {
+		_ = json.Unmarshal(b, &check)
+	}
+	if time.Since(check.Checked) >= interval {
+		latest, err := updateutils.GetToolVersionCallback("pdtm", version)()
+		if err != nil && verbose {
+			gologger.Error().Msgf("pdtm version check failed: %v", err.Error())
+		}
+		// failed checks are throttled as well, the last known version is kept
+		check.Checked = time.Now()
+		if err == nil {
+			check.Latest = latest
+		}
+		if b, err := json.Marshal(check); err == nil {
+			_ = os.MkdirAll(filepath.Dir(versionCheckFile), os.ModePerm)
+			_ = os.WriteFile(versionCheckFile, b, 0644)
+		}
+	} else {
+		gologger.Verbose().Msgf("using the pdtm version checked at %s", check.Checked.Format(time.RFC3339))
+	}
+	if check.Latest == "" {
+		return
+	}
+
+	if !updateutils.IsOutdated(version, check.Latest) {
+		gologger.Verbose().Msgf("Current pdtm version %v %v", version, updateutils.GetVersionDescription(version, check.Latest))
+		return
+	}
+	gologger.Info().Msgf("pdtm %s is available (current %s), run pdtm -self-update, changelog: https://github.com/projectdiscovery/pdtm/releases/tag/%s", check.Latest, version, check.Latest)
+}
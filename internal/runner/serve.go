@@ -0,0 +1,33 @@
+package runner
+
+import (
+	"net/http"
+	"net/url"
+
+	"github.com/projectdiscovery/gologger"
+	"github.com/projectdiscovery/pdtm/pkg"
+	"github.com/projectdiscovery/pdtm/pkg/utils"
+)
+
+// serve runs the cache server the clients started with -source fetch the
+// release metadata and assets from
+func (r *Runner) serve() error {
+	server := &pkg.CacheServer{
+		Dir:       serveCacheDir,
+		TTL:       r.options.CacheTTL,
+		MaxSize:   int64(r.options.ServeMaxSize) * 1024 * 1024,
+		Upstreams: pkg.DefaultUpstreams(utils.APIServer(), r.options.Provider.URL),
+	}
+	gologger.Info().Msgf("serving the cache %s on %s", serveCacheDir, r.options.Serve)
+	return http.ListenAndServe(r.options.Serve, server)
+}
+
+// apiHost returns the host of the pdtm api, whose tool list is fetched
+// through the -source cache server too
+func apiHost() string {
+	u, err := url.Parse(utils.APIServer())
+	if err != nil {
+		return ""
+	}
+	return u.Host
+}
@@ -0,0 +1,247 @@
+package runner
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/projectdiscovery/gologger"
+)
+
+// serviceName is the name of the systemd units and of the windows scheduled task
+const serviceName = "pdtm-update"
+
+// launchdLabel is the label of the launchd agent
+const launchdLabel = "io.projectdiscovery.pdtm"
+
+var (
+	systemdDir  = filepath.Join(homeDir, ".config/systemd/user")
+	launchdFile = filepath.Join(homeDir, "Library/LaunchAgents", launchdLabel+".plist")
+)
+
+// manageService handles -service-install and -service-uninstall, it returns
+// false if none was given
+func (r *Runner) manageService() (bool, error) {
+	switch {
+	case r.options.ServiceInstall:
+		if r.options.ServiceInterval < time.Minute {
+			return true, fmt.Errorf("invalid service interval %s, expected at least 1m", r.options.ServiceInterval)
+		}
+		return true, r.installService()
+	case r.options.ServiceUninstall:
+		return true, r.uninstallService()
+	}
+	return false, nil
+}
+
+// serviceCommand returns the command run by the service
+func (r *Runner) serviceCommand() ([]string, error) {
+	executable, err := os.Executable()
+	if err != nil {
+		return nil, err
+	}
+	if executable, err = filepath.EvalSymlinks(executable); err != nil {
+		return nil, err
+	}
+	command := []string{executable, "-update-all", "-disable-update-check"}
+	if r.explicitPath {
+		command = append(command, "-binary-path", r.options.Path)
+	}
+	if r.options.ConfigFile != defaultConfigLocation {
+		command = append(command, "-config", r.options.ConfigFile)
+	}
+	return command, nil
+}
+
+// installService writes and enables a systemd user timer (linux), a launchd
+// agent (macOS) or a scheduled task (windows) running -update-all
+func (r *Runner) installService() error {
+	command, err := r.serviceCommand()
+	if err != nil {
+		return err
+	}
+	interval := r.options.ServiceInterval
+	switch runtime.GOOS {
+	case "linux":
+		service := fmt.Sprintf(`[Unit]
+Description=Update the projects installed with pdtm
+Wants=network-online.target
+After=network-online.target
+
+[Service]
+Type=oneshot
+ExecStart=%s
+`, systemdCommand(command))
+		timer := fmt.Sprintf(`[Unit]
+Description=Run %s every %s
+
+[Timer]
+OnBootSec=5min
+OnUnitActiveSec=%d
+Persistent=true
+
+[Install]
+WantedBy=timers.target
+`, serviceName, interval, int(interval.Seconds()))
+		if err := os.MkdirAll(systemdDir, os.ModePerm); err != nil {
+			return err
+		}
+		if err := os.WriteFile(filepath.Join(systemdDir, serviceName+".service"), []byte(service), 0644); err != nil {
+			return err
+		}
+		if err := os.WriteFile(filepath.Join(systemdDir, serviceName+".timer"), []byte(timer), 0644); err != nil {
+			return err
+		}
+		gologger.Info().Msgf("wrote %s", filepath.Join(systemdDir, serviceName+".{service,timer}"))
+		if err := runServiceCommand("systemctl", "--user", "daemon-reload"); err != nil {
+			return err
+		}
+		if err := runServiceCommand("systemctl", "--user", "enable", "--now", serviceName+".timer"); err != nil {
+			return err
+		}
+	case "darwin":
+		var arguments strings.Builder
+		for _, argument := range command {
+			fmt.Fprintf(&arguments, "\t\t<string>%s</string>\n", xmlEscape(argument))
+		}
+		plist := fmt.Sprintf(`<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
+<plist version="1.0">
+<dict>
+	<key>Label</key>
+	<string>%s</string>
+	<key>ProgramArguments</key>
+	<array>
+%s	</array>
+	<key>StartInterval</key>
+	<integer>%d</integer>
+	<key>StandardOutPath</key>
+	<string>%s</string>
+	<key>StandardErrorPath</key>
+	<string>%s</string>
+</dict>
+</plist>
+`, launchdLabel, arguments.String(), int(interval.Seconds()), xmlEscape(serviceLog()), xmlEscape(serviceLog()))
+		for _, dir := range []string{filepath.Dir(launchdFile), filepath.Dir(serviceLog())} {
+			if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+				return err
+			}
+		}
+		// an agent loaded with a previous interval must be unloaded first
+		_ = exec.Command("launchctl", "unload", launchdFile).Run()
+		if err := os.WriteFile(launchdFile, []byte(plist), 0644); err != nil {
+			return err
+		}
+		gologger.Info().Msgf("wrote %s", launchdFile)
+		if err := runServiceCommand("launchctl", "load", "-w", launchdFile); err != nil {
+			return err
+		}
+	case "windows":
+		schedule, err := schtasksSchedule(interval)
+		if err != nil {
+			return err
+		}
+		arguments := append([]string{"/Create", "/F", "/TN", serviceName, "/TR", windowsCommand(command)}, schedule...)
+		if err := runServiceCommand("schtasks", arguments...); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("services are not supported on %s, schedule `%s` with cron instead", runtime.GOOS, strings.Join(command, " "))
+	}
+	gologger.Info().Msgf("installed the %s service, the projects are updated every %s", serviceName, interval)
+	return nil
+}
+
+// uninstallService disables and removes the service written by installService
+func (r *Runner) uninstallService() error {
+	switch runtime.GOOS {
+	case "linux":
+		if err := runServiceCommand("systemctl", "--user", "disable", "--now", serviceName+".timer"); err != nil {
+			gologger.Warning().Msgf("%s", err)
+		}
+		for _, unit := range []string{serviceName + ".service", serviceName + ".timer"} {
+			if err := os.Remove(filepath.Join(systemdDir, unit)); err != nil && !os.IsNotExist(err) {
+				return err
+			}
+		}
+		_ = runServiceCommand("systemctl", "--user", "daemon-reload")
+	case "darwin":
+		if err := runServiceCommand("launchctl", "unload", "-w", launchdFile); err != nil {
+			gologger.Warning().Msgf("%s", err)
+		}
+		if err := os.Remove(launchdFile); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	case "windows":
+		if err := runServiceCommand("schtasks", "/Delete", "/F", "/TN", serviceName); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("services are not supported on %s", runtime.GOOS)
+	}
+	gologger.Info().Msgf("uninstalled the %s service", serviceName)
+	return nil
+}
+
+func runServiceCommand(name string, args ...string) error {
+	gologger.Verbose().Msgf("running %s %s", name, strings.Join(args, " "))
+	if output, err := exec.Command(name, args...).CombinedOutput(); err != nil {
+		return fmt.Errorf("%s %s failed: %s %s", name, strings.Join(args, " "), err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// serviceLog is the file the launchd agent writes its output to
+func serviceLog() string {
+	return filepath.Join(homeDir, ".config/pdtm/logs/service.log")
+}
+
+// systemdCommand quotes the arguments containing spaces
+func systemdCommand(command []string) string {
+	quoted := make([]string, len(command))
+	for i, argument := range command {
+		if strings.ContainsAny(argument, " \t\"\\") {
+			argument = strconv.Quote(argument)
+		}
+		quoted[i] = strings.ReplaceAll(argument, "%", "%%")
+	}
+	return strings.Join(quoted, " ")
+}
+
+// windowsCommand quotes the arguments of the scheduled task
+func windowsCommand(command []string) string {
+	quoted := make([]string, len(command))
+	for i, argument := range command {
+		if strings.ContainsAny(argument, " \t") {
+			argument = `"` + argument + `"`
+		}
+		quoted[i] = argument
+	}
+	return strings.Join(quoted, " ")
+}
+
+// schtasksSchedule returns the schedule arguments of schtasks for the
+// interval, which must be a whole number of days (up to 365), of hours or of
+// minutes below a day
+func schtasksSchedule(interval time.Duration) ([]string, error) {
+	const day = 24 * time.Hour
+	switch {
+	case interval%day == 0 && interval/day <= 365:
+		return []string{"/SC", "DAILY", "/MO", strconv.Itoa(int(interval / day))}, nil
+	case interval%time.Hour == 0 && interval < day:
+		return []string{"/SC", "HOURLY", "/MO", strconv.Itoa(int(interval / time.Hour))}, nil
+	case interval%time.Minute == 0 && interval < day:
+		return []string{"/SC", "MINUTE", "/MO", strconv.Itoa(int(interval / time.Minute))}, nil
+	default:
+		return nil, fmt.Errorf("scheduled tasks can't run every %s, use whole minutes or hours below 24h or whole days up to 365", interval)
+	}
+}
+
+func xmlEscape(value string) string {
+	return strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;").Replace(value)
+}
@@ -0,0 +1,64 @@
+package runner
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSystemdCommand(t *testing.T) {
+	tests := []struct {
+		command  []string
+		expected string
+	}{
+		{[]string{"/usr/local/bin/pdtm", "-update-all"}, "/usr/local/bin/pdtm -update-all"},
+		{[]string{"/home/john doe/pdtm", "-binary-path", "/opt/pd tools"}, `"/home/john doe/pdtm" -binary-path "/opt/pd tools"`},
+		{[]string{"/usr/bin/pdtm", "-config", "/etc/pdtm/100%.yaml"}, "/usr/bin/pdtm -config /etc/pdtm/100%%.yaml"},
+		{[]string{"/usr/bin/pdtm", "-config", `/etc/"pdtm"\config.yaml`}, `/usr/bin/pdtm -config "/etc/\"pdtm\"\\config.yaml"`},
+	}
+	for _, tt := range tests {
+		require.Equal(t, tt.expected, systemdCommand(tt.command))
+	}
+}
+
+func TestWindowsCommand(t *testing.T) {
+	tests := []struct {
+		command  []string
+		expected string
+	}{
+		{[]string{`C:\pdtm\pdtm.exe`, "-update-all"}, `C:\pdtm\pdtm.exe -update-all`},
+		{[]string{`C:\Program Files\pdtm\pdtm.exe`, "-binary-path", `C:\Users\John Doe\.pdtm`}, `"C:\Program Files\pdtm\pdtm.exe" -binary-path "C:\Users\John Doe\.pdtm"`},
+	}
+	for _, tt := range tests {
+		require.Equal(t, tt.expected, windowsCommand(tt.command))
+	}
+}
+
+func TestSchtasksSchedule(t *testing.T) {
+	tests := []struct {
+		interval time.Duration
+		expected []string
+	}{
+		{time.Minute, []string{"/SC", "MINUTE", "/MO", "1"}},
+		{90 * time.Minute, []string{"/SC", "MINUTE", "/MO", "90"}},
+		{12 * time.Hour, []string{"/SC", "HOURLY", "/MO", "12"}},
+		{23*time.Hour + 59*time.Minute, []string{"/SC", "MINUTE", "/MO", "1439"}},
+		{24 * time.Hour, []string{"/SC", "DAILY", "/MO", "1"}},
+		{7 * 24 * time.Hour, []string{"/SC", "DAILY", "/MO", "7"}},
+		{365 * 24 * time.Hour, []string{"/SC", "DAILY", "/MO", "365"}},
+		// intervals schtasks can't express are rejected instead of rounded
+		{36 * time.Hour, nil},
+		{90*time.Minute + 30*time.Second, nil},
+		{366 * 24 * time.Hour, nil},
+	}
+	for _, tt := range tests {
+		schedule, err := schtasksSchedule(tt.interval)
+		if tt.expected == nil {
+			require.NotNil(t, err, tt.interval.String())
+			continue
+		}
+		require.Nil(t, err, tt.interval.String())
+		require.Equal(t, tt.expected, schedule, tt.interval.String())
+	}
+}
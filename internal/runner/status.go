@@ -0,0 +1,161 @@
+package runner
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/projectdiscovery/gologger"
+	"github.com/projectdiscovery/pdtm/pkg/httpclient"
+	mapsutil "github.com/projectdiscovery/utils/maps"
+)
+
+// daemonStatus contains the status of the update checks of the daemon,
+// served by the api and as prometheus metrics
+type daemonStatus struct {
+	mu sync.Mutex
+	// installed is the number of installed tools at the last check
+	installed int
+	// checks, updates and failures count the update checks, the updated
+	// tools and the failed updates since the daemon started
+	checks   int
+	updates  int
+	failures int
+	// lastCheck and lastSuccess are the times of the last check and of the
+	// last check without failed update
+	lastCheck   time.Time
+	lastSuccess time.Time
+	nextCheck   time.Time
+	lastResults []UpdateResult
+	lastError   string
+}
+
+// DaemonStatus is the status of the daemon returned by the api
+type DaemonStatus struct {
+	Checks      int            `json:"checks"`
+	Updates     int            `json:"updates"`
+	Failures    int            `json:"failures"`
+	LastCheck   *time.Time     `json:"last_check,omitempty"`
+	LastSuccess *time.Time     `json:"last_success,omitempty"`
+	NextCheck   *time.Time     `json:"next_check,omitempty"`
+	LastResults []UpdateResult `json:"last_results,omitempty"`
+	LastError   string         `json:"last_error,omitempty"`
+}
+
+// runningDaemon is the status of a daemon written to the daemon status file
+// so that the other pdtm runs can show it
+type runningDaemon struct {
+	PID          int       `json:"pid" yaml:"pid"`
+	Started      time.Time `json:"started" yaml:"started"`
+	DaemonStatus `yaml:",inline"`
+}
+
+// save writes the status of the daemon started at started to the daemon
+// status file
+func (s *daemonStatus) save(started time.Time) {
+	b, err := json.Marshal(runningDaemon{PID: os.Getpid(), Started: started, DaemonStatus: s.snapshot()})
+	if err != nil {
+		return
+	}
+	_ = os.MkdirAll(filepath.Dir(daemonStatusFile), os.ModePerm)
+	if err := os.WriteFile(daemonStatusFile, b, 0644); err != nil {
+		gologger.Verbose().Msgf("could not write the daemon status: %s", err)
+	}
+}
+
+// record updates the status with the results of an update check and the
+// number of installed tools
+func (s *daemonStatus) record(installed int, results []UpdateResult, checkErr error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.checks++
+	s.lastCheck = time.Now()
+	s.lastResults, s.lastError = results, ""
+	if checkErr != nil {
+		s.failures++
+		s.lastError = checkErr.Error()
+		return
+	}
+	s.installed = installed
+	failed := false
+	for _, result := range results {
+		switch result.Outcome {
+		case updateUpdated:
+			s.updates++
+		case updateFailed:
+			s.failures++
+			failed = true
+		}
+	}
+	if !failed {
+		s.lastSuccess = s.lastCheck
+	}
+}
+
+// scheduled sets the time of the next check
+func (s *daemonStatus) scheduled(next time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.nextCheck = next
+}
+
+func (s *daemonStatus) snapshot() DaemonStatus {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return DaemonStatus{
+		Checks:      s.checks,
+		Updates:     s.updates,
+		Failures:    s.failures,
+		LastCheck:   timePtr(s.lastCheck),
+		LastSuccess: timePtr(s.lastSuccess),
+		NextCheck:   timePtr(s.nextCheck),
+		LastResults: s.lastResults,
+		LastError:   s.lastError,
+	}
+}
+
+// ServeHTTP serves the metrics in the prometheus text format
+func (s *daemonStatus) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/metrics" {
+		http.NotFound(w, r)
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	writeMetric(w, "pdtm_tools_installed", "gauge", "Number of installed projects at the last check.", int64(s.installed))
+	writeMetric(w, "pdtm_checks_total", "counter", "Number of update checks.", int64(s.checks))
+	writeMetric(w, "pdtm_updates_total", "counter", "Number of updated projects.", int64(s.updates))
+	writeMetric(w, "pdtm_failures_total", "counter", "Number of failed update checks and project updates.", int64(s.failures))
+	writeMetric(w, "pdtm_last_check_timestamp_seconds", "gauge", "Time of the last update check.", unixTime(s.lastCheck))
+	writeMetric(w, "pdtm_last_success_timestamp_seconds", "gauge", "Time of the last update check without failure.", unixTime(s.lastSuccess))
+
+	remaining := httpclient.RateLimitRemaining()
+	fmt.Fprintf(w, "# HELP pdtm_rate_limit_remaining Requests left before being rate limited by the host.\n# TYPE pdtm_rate_limit_remaining gauge\n")
+	for _, host := range mapsutil.GetSortedKeys(remaining) {
+		fmt.Fprintf(w, "pdtm_rate_limit_remaining{host=%q} %d\n", host, remaining[host])
+	}
+}
+
+func writeMetric(w http.ResponseWriter, name, kind, help string, value int64) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n%s %d\n", name, help, name, kind, name, value)
+}
+
+// unixTime returns the unix time of t, 0 when it's not set
+func unixTime(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+	return t.Unix()
+}
+
+func timePtr(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
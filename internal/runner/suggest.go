@@ -0,0 +1,20 @@
+package runner
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/projectdiscovery/pdtm/pkg/types"
+	"github.com/projectdiscovery/pdtm/pkg/utils"
+)
+
+// unknownTool returns the error message of a tool name not in the list,
+// with the closest tool names as suggestions
+func unknownTool(toolList []types.Tool, toolName string) string {
+	msg := fmt.Sprintf("unknown tool '%s'", toolName)
+	suggestions := utils.Suggest(toolList, toolName)
+	if len(suggestions) == 0 {
+		return msg
+	}
+	return fmt.Sprintf("%s, did you mean '%s'?", msg, strings.Join(suggestions, "' or '"))
+}
@@ -0,0 +1,326 @@
+package runner
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"os/user"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/projectdiscovery/gologger"
+	"github.com/projectdiscovery/pdtm/pkg"
+	"github.com/projectdiscovery/pdtm/pkg/state"
+)
+
+var (
+	// systemPath is the root owned path of the -system installs
+	systemPath = "/usr/local/lib/pdtm"
+	// systemLinkDir is where the -system installs are linked
+	systemLinkDir = "/usr/local/bin"
+	// systemStagingDir contains the user owned copies of the system path
+	systemStagingDir = filepath.Join(homeDir, ".config/pdtm/system")
+)
+
+// systemStage is a user owned copy of the system path the operations are run
+// on, the binaries of the system path are symlinked in it and the state and
+// kept versions are copied
+type systemStage struct {
+	dir    string
+	target string
+	// binaries contains the binaries of the system path when staged
+	binaries  []string
+	stateFile []byte
+	versions  string
+	started   time.Time
+}
+
+// runSystem runs the operations on a copy of the system path and applies the
+// changes to it with sudo, so downloads and extractions never run as root
+func (r *Runner) runSystem() error {
+	if runtime.GOOS == "windows" {
+		return errors.New("-system is not supported on windows")
+	}
+	stage, err := newSystemStage(systemPath)
+	if err != nil {
+		return fmt.Errorf("could not stage %s: %s", systemPath, err)
+	}
+	defer os.RemoveAll(stage.dir)
+
+	linkDir := r.options.LinkDir
+	if linkDir == "" {
+		linkDir = systemLinkDir
+	}
+	r.options.System, r.options.LinkDir, r.systemLinkDir = false, "", linkDir
+	r.options.Path, r.explicitPath = stage.dir, true
+	runErr := r.Run()
+
+	removed, err := stage.apply()
+	if err != nil {
+		return fmt.Errorf("could not apply the changes to %s: %s", systemPath, err)
+	}
+	for _, name := range removed {
+		if err := pkg.Unlink(filepath.Join(systemPath, name), linkDir); err != nil {
+			gologger.Error().Msgf("could not unlink %s: %s", name, err)
+		}
+	}
+	for _, name := range systemBinaries(systemPath) {
+		if err := pkg.Link(filepath.Join(systemPath, name), linkDir); err != nil {
+			gologger.Error().Msgf("could not link %s: %s", name, err)
+		}
+	}
+	return runErr
+}
+
+// newSystemStage stages the system path in a user owned directory
+func newSystemStage(target string) (*systemStage, error) {
+	if err := os.MkdirAll(systemStagingDir, os.ModePerm); err != nil {
+		return nil, err
+	}
+	dir, err := os.MkdirTemp(systemStagingDir, "stage-")
+	if err != nil {
+		return nil, err
+	}
+	stage := &systemStage{dir: dir, target: target, binaries: systemBinaries(target), started: time.Now()}
+	for _, name := range stage.binaries {
+		if err := os.Symlink(filepath.Join(target, name), filepath.Join(dir, name)); err != nil {
+			return nil, err
+		}
+	}
+	if b, err := os.ReadFile(filepath.Join(target, state.FileName)); err == nil {
+		stage.stateFile = b
+		if err := os.WriteFile(filepath.Join(dir, state.FileName), b, 0644); err != nil {
+			return nil, err
+		}
+	}
+	if err := copyDir(filepath.Join(target, pkg.VersionsDir), filepath.Join(dir, pkg.VersionsDir)); err != nil {
+		return nil, err
+	}
+	stage.versions = listDir(filepath.Join(dir, pkg.VersionsDir))
+	return stage, nil
+}
+
+// apply installs the binaries, state and kept versions changed in the stage
+// to the system path as root, it returns the binaries removed from it. The
+// staged files are copied to a root owned directory of the system path and
+// checked against the hashes of the state before replacing the installed ones.
+func (s *systemStage) apply() ([]string, error) {
+	if _, err := os.Stat(s.target); errors.Is(err, os.ErrNotExist) {
+		if err := privileged("install", "-d", "-m", "0755", s.target); err != nil {
+			return nil, err
+		}
+	}
+	if err := s.recordOwner(); err != nil {
+		return nil, err
+	}
+	st, err := state.Load(s.dir)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return nil, err
+	}
+
+	tmp, err := s.rootDir()
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err := privileged("rm", "-rf", tmp); err != nil {
+			gologger.Error().Msgf("could not remove %s: %s", tmp, err)
+		}
+	}()
+
+	staged := make(map[string]bool)
+	for _, entry := range entries {
+		staged[entry.Name()] = true
+		// the binaries still linked to the system path are unchanged
+		if !entry.Type().IsRegular() || strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+		if err := s.applyBinary(st, tmp, entry.Name()); err != nil {
+			return nil, err
+		}
+	}
+	var removed []string
+	for _, name := range s.binaries {
+		if !staged[name] {
+			if err := privileged("rm", "-f", filepath.Join(s.target, name)); err != nil {
+				return removed, err
+			}
+			removed = append(removed, name)
+		}
+	}
+
+	stateFile := filepath.Join(s.dir, state.FileName)
+	if b, err := os.ReadFile(stateFile); err == nil && !bytes.Equal(b, s.stateFile) {
+		if err := privileged("install", "-m", "0644", stateFile, filepath.Join(tmp, state.FileName)); err != nil {
+			return removed, err
+		}
+		if err := privileged("mv", "-f", filepath.Join(tmp, state.FileName), filepath.Join(s.target, state.FileName)); err != nil {
+			return removed, err
+		}
+	}
+	versions := filepath.Join(s.dir, pkg.VersionsDir)
+	if listDir(versions) != s.versions {
+		if _, err := os.Stat(versions); err == nil {
+			if err := privileged("cp", "-R", versions, filepath.Join(tmp, pkg.VersionsDir)); err != nil {
+				return removed, err
+			}
+		}
+		if err := privileged("rm", "-rf", filepath.Join(s.target, pkg.VersionsDir)); err != nil {
+			return removed, err
+		}
+		if _, err := os.Stat(filepath.Join(tmp, pkg.VersionsDir)); err == nil {
+			if err := privileged("mv", filepath.Join(tmp, pkg.VersionsDir), filepath.Join(s.target, pkg.VersionsDir)); err != nil {
+				return removed, err
+			}
+		}
+	}
+	return removed, nil
+}
+
+// rootDir creates a root owned directory in the system path, the staged
+// files are copied to it so they can't be replaced once checked
+func (s *systemStage) rootDir() (string, error) {
+	random := make([]byte, 8)
+	if _, err := rand.Read(random); err != nil {
+		return "", err
+	}
+	dir := filepath.Join(s.target, ".pdtm-apply-"+hex.EncodeToString(random))
+	// mkdir fails instead of reusing an existing directory
+	if err := privileged("mkdir", "-m", "0755", dir); err != nil {
+		return "", err
+	}
+	return dir, nil
+}
+
+// applyBinary installs the staged binary to the system path when it differs
+// from the installed one, the copy must match the hash recorded in the state
+func (s *systemStage) applyBinary(st *state.State, tmp, name string) error {
+	hashes := st.Hashes()
+	expected, ok := hashes[name]
+	if !ok {
+		return fmt.Errorf("no hash of %s recorded in the state, refusing to install it", name)
+	}
+	if installed, err := state.Hash(filepath.Join(s.target, name)); err == nil && installed == expected {
+		gologger.Verbose().Msgf("%s is unchanged", name)
+		return nil
+	}
+	copied := filepath.Join(tmp, name)
+	if err := privileged("install", "-m", "0755", filepath.Join(s.dir, name), copied); err != nil {
+		return err
+	}
+	if hash, err := state.Hash(copied); err != nil || hash != expected {
+		return fmt.Errorf("%s was modified after its install (expected sha256 %s), refusing to install it", name, expected)
+	}
+	if err := privileged("mv", "-f", copied, filepath.Join(s.target, name)); err != nil {
+		return err
+	}
+	// writing the binary dropped its capabilities
+	if tool, ok := st.Get(name); ok && pkg.AllowedCapabilities(name, tool.Capabilities) {
+		if err := privileged("setcap", tool.Capabilities, filepath.Join(s.target, name)); err != nil {
+			gologger.Error().Msgf("could not re-apply the capabilities of %s: %s", name, err)
+		}
+	}
+	return nil
+}
+
+// recordOwner records root as the owner of the tools installed or updated
+// in the stage, along with the user who ran pdtm
+func (s *systemStage) recordOwner() error {
+	st, err := state.Load(s.dir)
+	if err != nil {
+		return err
+	}
+	installedBy := os.Getenv("SUDO_USER")
+	if current, err := user.Current(); err == nil && installedBy == "" {
+		installedBy = current.Username
+	}
+	var changed bool
+	for _, tool := range st.Tools {
+		if tool.Updated != nil && !tool.Updated.Before(s.started) {
+			tool.Owner, tool.InstalledBy = "root", installedBy
+			changed = true
+		}
+	}
+	if !changed {
+		return nil
+	}
+	return st.Save()
+}
+
+// privileged runs the command as root
+var privileged = pkg.Sudo
+
+// systemBinaries returns the binaries of the system path
+func systemBinaries(dir string) []string {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil
+	}
+	var binaries []string
+	for _, entry := range entries {
+		if entry.Type().IsRegular() && !strings.HasPrefix(entry.Name(), ".") {
+			binaries = append(binaries, entry.Name())
+		}
+	}
+	return binaries
+}
+
+// copyDir copies the files of src to dst, a missing src is ignored
+func copyDir(src, dst string) error {
+	err := filepath.WalkDir(src, func(file string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, file)
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return os.MkdirAll(filepath.Join(dst, rel), os.ModePerm)
+		}
+		in, err := os.Open(file)
+		if err != nil {
+			return err
+		}
+		defer in.Close()
+		out, err := os.Create(filepath.Join(dst, rel))
+		if err != nil {
+			return err
+		}
+		if _, err := io.Copy(out, in); err != nil {
+			out.Close()
+			return err
+		}
+		return out.Close()
+	})
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+// listDir returns the files of dir and their size, to detect changes
+func listDir(dir string) string {
+	var listing strings.Builder
+	_ = filepath.WalkDir(dir, func(file string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return nil
+		}
+		if fi, err := d.Info(); err == nil {
+			fmt.Fprintf(&listing, "%s %d\n", file, fi.Size())
+		}
+		return nil
+	})
+	return listing.String()
+}
@@ -0,0 +1,125 @@
+package runner
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/projectdiscovery/pdtm/pkg/state"
+	"github.com/stretchr/testify/require"
+)
+
+// runPrivileged runs the privileged commands as the current user and records them
+func runPrivileged(t *testing.T) *[][]string {
+	var commands [][]string
+	previous := privileged
+	privileged = func(name string, args ...string) error {
+		commands = append(commands, append([]string{name}, args...))
+		return exec.Command(name, args...).Run()
+	}
+	t.Cleanup(func() { privileged = previous })
+	return &commands
+}
+
+func TestSystemStageApply(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("-system is not supported on windows")
+	}
+	commands := runPrivileged(t)
+	previous := systemStagingDir
+	systemStagingDir = t.TempDir()
+	t.Cleanup(func() { systemStagingDir = previous })
+
+	target := t.TempDir()
+	for name, content := range map[string]string{"dnsx": "dnsx 1.1.0", "naabu": "naabu 2.0.0", "httpx": "httpx 1.0.0"} {
+		require.Nil(t, os.WriteFile(filepath.Join(target, name), []byte(content), 0755))
+	}
+	stage, err := newSystemStage(target)
+	require.Nil(t, err)
+
+	// dnsx is updated, httpx is rewritten with the same content and naabu is
+	// modified after its install
+	st, err := state.Load(stage.dir)
+	require.Nil(t, err)
+	for name, content := range map[string]string{"dnsx": "dnsx 1.1.1", "httpx": "httpx 1.0.0", "naabu": "naabu 2.0.1"} {
+		file := filepath.Join(stage.dir, name)
+		require.Nil(t, os.Remove(file))
+		require.Nil(t, os.WriteFile(file, []byte(content), 0755))
+		hash, err := state.Hash(file)
+		require.Nil(t, err)
+		st.Set(&state.Tool{Name: name, Version: "1.0.0", Hash: hash})
+	}
+	require.Nil(t, st.Save())
+	require.Nil(t, os.WriteFile(filepath.Join(stage.dir, "naabu"), []byte("tampered"), 0755))
+
+	_, err = stage.apply()
+	require.ErrorContains(t, err, "naabu was modified")
+	content, err := os.ReadFile(filepath.Join(target, "naabu"))
+	require.Nil(t, err)
+	require.Equal(t, "naabu 2.0.0", string(content), "the modified binary isn't installed")
+
+	// only the binaries changed since the first attempt are installed
+	require.Nil(t, os.WriteFile(filepath.Join(stage.dir, "naabu"), []byte("naabu 2.0.1"), 0755))
+	*commands = nil
+	_, err = stage.apply()
+	require.Nil(t, err)
+	var installed []string
+	for _, command := range *commands {
+		if command[0] == "install" && filepath.Dir(command[len(command)-2]) == stage.dir {
+			installed = append(installed, filepath.Base(command[len(command)-2]))
+		}
+	}
+	require.ElementsMatch(t, []string{"naabu", state.FileName}, installed)
+	for name, expected := range map[string]string{"dnsx": "dnsx 1.1.1", "naabu": "naabu 2.0.1", "httpx": "httpx 1.0.0"} {
+		content, err := os.ReadFile(filepath.Join(target, name))
+		require.Nil(t, err)
+		require.Equal(t, expected, string(content))
+	}
+
+	// the root owned directories are removed
+	entries, err := os.ReadDir(target)
+	require.Nil(t, err)
+	for _, entry := range entries {
+		require.False(t, entry.IsDir(), entry.Name())
+	}
+}
+
+func TestSystemStageUnrecorded(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("-system is not supported on windows")
+	}
+	runPrivileged(t)
+	previous := systemStagingDir
+	systemStagingDir = t.TempDir()
+	t.Cleanup(func() { systemStagingDir = previous })
+
+	target := t.TempDir()
+	stage, err := newSystemStage(target)
+	require.Nil(t, err)
+	require.Nil(t, os.WriteFile(filepath.Join(stage.dir, "dnsx"), []byte("dnsx"), 0755))
+	_, err = stage.apply()
+	require.ErrorContains(t, err, "no hash of dnsx")
+	require.NoFileExists(t, filepath.Join(target, "dnsx"))
+
+	// the additional binaries are installed with the hash recorded along with the tool
+	require.Nil(t, os.WriteFile(filepath.Join(stage.dir, "dnsx-helper"), []byte("dnsx-helper"), 0755))
+	st, err := state.Load(stage.dir)
+	require.Nil(t, err)
+	tool := &state.Tool{Name: "dnsx", Version: "1.1.1", Binaries: []string{"dnsx-helper"}, BinaryHashes: map[string]string{}}
+	for _, name := range []string{"dnsx", "dnsx-helper"} {
+		hash, err := state.Hash(filepath.Join(stage.dir, name))
+		require.Nil(t, err)
+		if name == "dnsx" {
+			tool.Hash = hash
+		} else {
+			tool.BinaryHashes[name] = hash
+		}
+	}
+	st.Set(tool)
+	require.Nil(t, st.Save())
+	_, err = stage.apply()
+	require.Nil(t, err)
+	require.FileExists(t, filepath.Join(target, "dnsx-helper"))
+}
@@ -0,0 +1,45 @@
+package runner
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf8"
+)
+
+// table prints aligned columns, the cells are padded before being colored
+// so the ANSI codes don't break the alignment
+type table struct {
+	header []string
+	rows   [][]string
+	// color returns the colored cell of the row (optional)
+	color func(row, column int, cell string) string
+}
+
+func (t *table) print(w io.Writer) {
+	widths := make([]int, len(t.header))
+	for _, cells := range append([][]string{t.header}, t.rows...) {
+		for i, cell := range cells {
+			if n := utf8.RuneCountInString(cell); n > widths[i] {
+				widths[i] = n
+			}
+		}
+	}
+	line := func(cells []string, row int) string {
+		parts := make([]string, len(cells))
+		for i, cell := range cells {
+			if i < len(cells)-1 {
+				cell += strings.Repeat(" ", widths[i]-utf8.RuneCountInString(cell))
+			}
+			if row >= 0 && t.color != nil {
+				cell = t.color(row, i, cell)
+			}
+			parts[i] = cell
+		}
+		return strings.Join(parts, "  ")
+	}
+	fmt.Fprintln(w, line(t.header, -1))
+	for i, cells := range t.rows {
+		fmt.Fprintln(w, line(cells, i))
+	}
+}
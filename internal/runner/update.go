@@ -0,0 +1,154 @@
+package runner
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/projectdiscovery/gologger"
+	"github.com/projectdiscovery/pdtm/pkg"
+	"github.com/projectdiscovery/pdtm/pkg/path"
+	"github.com/projectdiscovery/pdtm/pkg/state"
+	"github.com/projectdiscovery/pdtm/pkg/types"
+	"github.com/projectdiscovery/pdtm/pkg/utils"
+)
+
+// outcomes of the update of a tool, from the best to the worst
+const (
+	updateCurrent = "current"
+	updateUpdated = "updated"
+	updateHeld    = "held"
+	updateFailed  = "failed"
+)
+
+// UpdateResult is the outcome of the update of a tool
+type UpdateResult struct {
+	Name    string `json:"name" yaml:"name"`
+	Outcome string `json:"outcome" yaml:"outcome"`
+	Version string `json:"version,omitempty" yaml:"version,omitempty"`
+	Reason  string `json:"reason,omitempty" yaml:"reason,omitempty"`
+}
+
+// update updates the tool, it returns false if the tool isn't installed
+func (r *Runner) update(toolList []types.Tool, name string) (UpdateResult, bool) {
+	result := UpdateResult{Name: name}
+	dir := r.pathFor(name)
+	i, ok := utils.Contains(toolList, name)
+	if !ok {
+		gologger.Error().Msgf("error while updating %s: %s", name, unknownTool(toolList, name))
+		return result, false
+	}
+	tool := toolList[i]
+	if !r.isUpdatable(dir, tool) {
+		if !r.options.UpdateAll {
+			gologger.Info().Msgf(types.ErrToolNotFound, name, dir)
+		}
+		return result, false
+	}
+	warnDeprecated(tool)
+	if !r.isAllowedPath(dir) {
+		gologger.Error().Msgf("skipping update outside home folder: %s", name)
+		result.Outcome, result.Reason = updateHeld, "outside home folder"
+		return result, true
+	}
+	if err := path.CheckWritable(dir); err != nil {
+		gologger.Error().Msgf("could not update %s: %s, %s", name, err, notWritableHint)
+		result.Outcome, result.Reason = updateFailed, err.Error()
+		return result, true
+	}
+
+	st, err := state.Load(dir)
+	if err != nil {
+		gologger.Error().Msgf("could not update %s: %s", name, err)
+		result.Outcome, result.Reason = updateFailed, err.Error()
+		return result, true
+	}
+	// nightly builds are kept on the nightly builds
+	var version string
+	if installed, ok := st.Get(name); ok && installed.Nightly {
+		version = types.Nightly
+	}
+	resolved, err := r.resolve(tool, version)
+	if err != nil {
+		gologger.Error().Msgf("error while resolving %s: %s", name, err)
+		result.Outcome, result.Reason = updateFailed, err.Error()
+		return result, true
+	}
+	result.Version = resolved.Version
+	err = pkg.Update(dir, resolved, r.options.DisableChangeLog)
+	switch {
+	case err == nil:
+		result.Outcome = updateUpdated
+	case errors.Is(err, types.ErrIsUpToDate):
+		gologger.Info().Msgf("%s: %s", name, err)
+		result.Outcome = updateCurrent
+		pkg.RecordCheck(dir, tool)
+	case errors.Is(err, types.ErrIsPinned) || errors.Is(err, types.ErrIsBuiltFromRef):
+		gologger.Info().Msgf("%s: %s", name, err)
+		result.Outcome, result.Reason = updateHeld, err.Error()
+	default:
+		gologger.Info().Msgf("%s\n", err)
+		result.Outcome, result.Reason = updateFailed, err.Error()
+	}
+	return result, true
+}
+
+// scheduledUpdates returns the names of the tools due for an update
+// according to -schedule, none during a -blackout window
+func (r *Runner) scheduledUpdates(toolList []types.Tool) []string {
+	now := time.Now()
+	if window, ok := r.schedule.Blackout(now); ok {
+		gologger.Info().Msgf("skipping the updates during the blackout window %s", window)
+		return nil
+	}
+	var names []string
+	for _, tool := range toolList {
+		if r.schedule.Due(tool.Name, pkg.LastCheck(r.pathFor(tool.Name), tool), now) {
+			names = append(names, tool.Name)
+		} else {
+			gologger.Verbose().Msgf("skipping %s, not scheduled for an update yet", tool.Name)
+		}
+	}
+	return names
+}
+
+// isUpdatable returns true if the tool is installed in the path
+func (r *Runner) isUpdatable(dir string, tool types.Tool) bool {
+	if pkg.IsDataPack(tool) {
+		_, ok := pkg.DataPackVersion(dir, tool)
+		return ok
+	}
+	_, exists := path.GetExecutablePath(dir, tool.Name)
+	return exists
+}
+
+// showUpdateSummary prints the outcome of the updates, the exit code is 2
+// when an update failed
+func (r *Runner) showUpdateSummary(results []UpdateResult) error {
+	counts := make(map[string]int)
+	for _, result := range results {
+		counts[result.Outcome]++
+	}
+	if r.options.structured() {
+		if err := writeResults(r.options, results); err != nil {
+			return err
+		}
+	} else if len(results) == 0 {
+		gologger.Info().Msg("no projects to update")
+	} else {
+		tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "\nPROJECT\tRESULT\tVERSION\tREASON")
+		for _, result := range results {
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", result.Name, result.Outcome, result.Version, result.Reason)
+		}
+		tw.Flush()
+		gologger.Info().Msgf("%d updated, %d already current, %d held, %d failed",
+			counts[updateUpdated], counts[updateCurrent], counts[updateHeld], counts[updateFailed])
+	}
+	if counts[updateFailed] > 0 {
+		return &ExitCodeError{Code: exitUpdateFailed, Err: fmt.Errorf("could not update %d projects", counts[updateFailed])}
+	}
+	return nil
+}
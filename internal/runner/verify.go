@@ -0,0 +1,75 @@
+package runner
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/projectdiscovery/gologger"
+	"github.com/projectdiscovery/pdtm/pkg"
+	"github.com/projectdiscovery/pdtm/pkg/path"
+	"github.com/projectdiscovery/pdtm/pkg/types"
+)
+
+// exitTampered is the exit code of -verify when an install may have been tampered with
+const exitTampered = 1
+
+// verifyInstalls cross-checks the versions and the sha256 of the installed
+// binaries, the mismatches are reported as possible tampering
+func (r *Runner) verifyInstalls(toolList []types.Tool) error {
+	var checks []pkg.VersionCheck
+	for _, tool := range toolList {
+		dir := r.pathFor(tool.Name)
+		if pkg.IsDataPack(tool) {
+			continue
+		}
+		if _, exists := path.GetExecutablePath(dir, tool.Name); !exists {
+			continue
+		}
+		check, err := pkg.CheckVersions(dir, tool)
+		if err != nil {
+			gologger.Error().Msgf("could not verify %s: %s", tool.Name, err)
+			continue
+		}
+		checks = append(checks, check)
+	}
+
+	var suspicious int
+	for _, check := range checks {
+		if check.Mismatch != "" || check.Modified {
+			suspicious++
+		}
+	}
+	if r.options.structured() {
+		if err := writeResults(r.options, checks); err != nil {
+			return err
+		}
+	} else {
+		t := &table{header: []string{"NAME", "RECORDED", "REPORTED", "EMBEDDED", "STATUS"}}
+		for _, check := range checks {
+			status := "ok"
+			switch {
+			case check.Mismatch != "" && check.Modified:
+				status = "version mismatch, modified"
+			case check.Mismatch != "":
+				status = "version mismatch"
+			case check.Modified:
+				status = "modified"
+			}
+			t.rows = append(t.rows, []string{check.Name, dash(check.Recorded), dash(check.Reported), dash(check.Embedded), status})
+		}
+		t.color = func(row, column int, cell string) string {
+			if column != 4 {
+				return cell
+			}
+			if cell == "ok" {
+				return au.BrightGreen(cell).String()
+			}
+			return au.Red(cell).String()
+		}
+		t.print(os.Stdout)
+	}
+	if suspicious > 0 {
+		return &ExitCodeError{Code: exitTampered, Err: fmt.Errorf("%d projects may have been tampered with or replaced manually (use -reinstall to restore them)", suspicious)}
+	}
+	return nil
+}
@@ -0,0 +1,39 @@
+package runner
+
+import (
+	"errors"
+	"os"
+	"runtime"
+	"testing"
+
+	"github.com/projectdiscovery/pdtm/pkg/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVerifyInstalls(t *testing.T) {
+	dir := t.TempDir()
+	toolList := []types.Tool{{Name: "dnsx"}, {Name: "httpx"}, {Name: "naabu"}}
+	dnsx := installRecorded(t, dir, "dnsx", "1.1.1", "dnsx")
+	installRecorded(t, dir, "httpx", "1.3.7", "httpx")
+	r := &Runner{options: &Options{Path: dir, Output: outputJSON}, explicitPath: true}
+	require.Nil(t, r.verifyInstalls(toolList))
+
+	// the binary replaced behind pdtm is reported
+	require.Nil(t, os.WriteFile(dnsx, []byte("replaced"), 0755))
+	err := r.verifyInstalls(toolList)
+	var exitErr *ExitCodeError
+	require.True(t, errors.As(err, &exitErr))
+	require.Equal(t, exitTampered, exitErr.Code)
+	require.Contains(t, err.Error(), "1 projects may have been tampered with")
+
+	if runtime.GOOS == "windows" {
+		return
+	}
+	// the binary reporting another version than the recorded one is reported
+	installRecorded(t, dir, "dnsx", "1.1.1", "#!/bin/sh\necho dnsx v1.1.1\n")
+	require.Nil(t, r.verifyInstalls(toolList))
+	installRecorded(t, dir, "dnsx", "1.1.1", "#!/bin/sh\necho dnsx v1.1.2\n")
+	err = r.verifyInstalls(toolList)
+	require.True(t, errors.As(err, &exitErr))
+	require.Equal(t, exitTampered, exitErr.Code)
+}
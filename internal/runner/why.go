@@ -0,0 +1,135 @@
+package runner
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/projectdiscovery/gologger"
+	"github.com/projectdiscovery/pdtm/pkg"
+	"github.com/projectdiscovery/pdtm/pkg/state"
+	"github.com/projectdiscovery/pdtm/pkg/types"
+	"github.com/projectdiscovery/pdtm/pkg/utils"
+	sliceutil "github.com/projectdiscovery/utils/slice"
+)
+
+// Provenance explains why a tool is installed
+type Provenance struct {
+	Name    string `json:"name" yaml:"name"`
+	Version string `json:"version" yaml:"version"`
+	Path    string `json:"path" yaml:"path"`
+	// Explicit is true if the tool was requested with -install
+	Explicit bool `json:"explicit" yaml:"explicit"`
+	// DependencyOf contains the tools the tool was installed as a dependency of
+	DependencyOf []string `json:"dependency_of,omitempty" yaml:"dependency_of,omitempty"`
+	// RequiredBy contains the installed tools depending on the tool
+	RequiredBy []string `json:"required_by,omitempty" yaml:"required_by,omitempty"`
+	// Project is the project file of the working directory listing the tool
+	Project string `json:"project,omitempty" yaml:"project,omitempty"`
+	Pinned  bool   `json:"pinned,omitempty" yaml:"pinned,omitempty"`
+}
+
+// recordProvenance records whether the tool was requested or pulled in as
+// a dependency by the tools of this run
+func (r *Runner) recordProvenance(dir, toolName string) {
+	if err := pkg.SetDependencyOf(dir, toolName, r.pulledBy[strings.ToLower(toolName)]); err != nil {
+		gologger.Warning().Msgf("could not save state: %s", err)
+	}
+}
+
+// installedDependents returns the installed tools depending on the tool,
+// except the ones removed by this run
+func (r *Runner) installedDependents(toolList []types.Tool, tool types.Tool) []string {
+	var dependents []string
+	for _, candidate := range toolList {
+		if sliceutil.Contains(r.options.Remove, candidate.Name) {
+			continue
+		}
+		for _, dependency := range r.dependencies(candidate) {
+			if !strings.EqualFold(dependency, tool.Name) {
+				continue
+			}
+			if _, err := pkg.InstalledVersion(candidate, r.pathFor(candidate.Name)); err == nil {
+				dependents = append(dependents, candidate.Name)
+			}
+			break
+		}
+	}
+	return dependents
+}
+
+// confirmRemoveDependency warns before removing a tool other installed
+// tools depend on, it returns false if the user declines the removal
+func (r *Runner) confirmRemoveDependency(toolList []types.Tool, tool types.Tool) bool {
+	dependents := r.installedDependents(toolList, tool)
+	if len(dependents) == 0 {
+		return true
+	}
+	gologger.Info().Label("WRN").Msgf("%s is a dependency of the installed %s", tool.Name, strings.Join(dependents, ", "))
+	if !isInteractive() {
+		return true
+	}
+	return r.confirm(fmt.Sprintf("remove %s anyway?", tool.Name))
+}
+
+// showWhy explains why the tool is installed
+func (r *Runner) showWhy(toolList []types.Tool) error {
+	provenance, err := r.provenance(toolList, r.options.Why)
+	if err != nil {
+		return err
+	}
+
+	if r.options.structured() {
+		return writeResults(r.options, []Provenance{provenance})
+	}
+	gologger.Silent().Msgf("%s %s is installed in %s", provenance.Name, provenance.Version, provenance.Path)
+	if provenance.Explicit {
+		gologger.Silent().Msg("  requested explicitly")
+	} else {
+		gologger.Silent().Msgf("  installed as a dependency of %s", strings.Join(provenance.DependencyOf, ", "))
+	}
+	if len(provenance.RequiredBy) > 0 {
+		gologger.Silent().Msgf("  required by the installed %s", strings.Join(provenance.RequiredBy, ", "))
+	}
+	if provenance.Project != "" {
+		gologger.Silent().Msgf("  listed in the project file %s", provenance.Project)
+	}
+	if provenance.Pinned {
+		gologger.Silent().Msgf("  pinned to %s", provenance.Version)
+	}
+	return nil
+}
+
+// provenance returns why the installed tool with the given name is installed
+func (r *Runner) provenance(toolList []types.Tool, toolName string) (Provenance, error) {
+	i, ok := utils.Contains(toolList, toolName)
+	if !ok {
+		return Provenance{}, fmt.Errorf("%s", unknownTool(toolList, toolName))
+	}
+	tool := toolList[i]
+	dir := r.pathFor(tool.Name)
+	version, err := pkg.InstalledVersion(tool, dir)
+	if err != nil {
+		return Provenance{}, fmt.Errorf("%s is not installed in %s", tool.Name, dir)
+	}
+	provenance := Provenance{Name: tool.Name, Version: version, Path: dir, Explicit: true}
+	if st, err := state.Load(dir); err == nil {
+		if installed, ok := st.Get(tool.Name); ok {
+			provenance.DependencyOf = installed.DependencyOf
+			provenance.Explicit = len(installed.DependencyOf) == 0
+			provenance.Pinned = installed.Pinned
+		}
+	}
+	provenance.RequiredBy = r.installedDependents(toolList, tool)
+	if wd, err := os.Getwd(); err == nil {
+		if config, err := findProjectConfig(wd); err == nil {
+			for _, entry := range r.options.resolveAliases(config.Tools) {
+				if name, _ := splitVersion(entry); strings.EqualFold(name, tool.Name) {
+					provenance.Project = filepath.Join(config.dir, projectFile)
+				}
+			}
+		}
+	}
+	return provenance, nil
+}
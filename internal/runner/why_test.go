@@ -0,0 +1,76 @@
+package runner
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/projectdiscovery/pdtm/pkg/state"
+	"github.com/projectdiscovery/pdtm/pkg/types"
+	"github.com/stretchr/testify/require"
+)
+
+// installRecorded writes the binary of the tool to dir and records it
+func installRecorded(t *testing.T, dir, name, version, content string, dependencyOf ...string) string {
+	binary := filepath.Join(dir, name)
+	if runtime.GOOS == "windows" {
+		binary += ".exe"
+	}
+	require.Nil(t, os.WriteFile(binary, []byte(content), 0755))
+	hash, err := state.Hash(binary)
+	require.Nil(t, err)
+	st, err := state.Load(dir)
+	require.Nil(t, err)
+	st.Set(&state.Tool{Name: name, Version: version, Hash: hash, DependencyOf: dependencyOf})
+	require.Nil(t, st.Save())
+	return binary
+}
+
+func TestProvenance(t *testing.T) {
+	dir := t.TempDir()
+	toolList := []types.Tool{
+		{Name: "nuclei", Dependencies: []string{"httpx"}},
+		{Name: "httpx"},
+		{Name: "dnsx"},
+	}
+	installRecorded(t, dir, "nuclei", "3.1.0", "nuclei")
+	installRecorded(t, dir, "httpx", "1.3.7", "httpx", "nuclei")
+	r := &Runner{options: &Options{Path: dir}, explicitPath: true}
+
+	provenance, err := r.provenance(toolList, "HTTPX")
+	require.Nil(t, err)
+	require.Equal(t, Provenance{Name: "httpx", Version: "1.3.7", Path: dir, DependencyOf: []string{"nuclei"}, RequiredBy: []string{"nuclei"}}, provenance)
+
+	provenance, err = r.provenance(toolList, "nuclei")
+	require.Nil(t, err)
+	require.True(t, provenance.Explicit)
+	require.Empty(t, provenance.RequiredBy)
+
+	// nuclei being removed in this run doesn't require httpx anymore
+	r.options.Remove = []string{"nuclei"}
+	provenance, err = r.provenance(toolList, "httpx")
+	require.Nil(t, err)
+	require.Empty(t, provenance.RequiredBy)
+
+	_, err = r.provenance(toolList, "dnsx")
+	require.EqualError(t, err, "dnsx is not installed in "+dir)
+	_, err = r.provenance(toolList, "missing")
+	require.NotNil(t, err)
+}
+
+func TestProvenanceProject(t *testing.T) {
+	dir, project := t.TempDir(), t.TempDir()
+	require.Nil(t, os.WriteFile(filepath.Join(project, projectFile), []byte("tools:\n  - dx@1.1.1\n"), 0644))
+	wd, err := os.Getwd()
+	require.Nil(t, err)
+	require.Nil(t, os.Chdir(project))
+	t.Cleanup(func() { _ = os.Chdir(wd) })
+
+	installRecorded(t, dir, "dnsx", "1.1.1", "dnsx")
+	r := &Runner{options: &Options{Path: dir}, explicitPath: true}
+	r.options.Aliases = map[string]string{"dx": "dnsx"}
+	provenance, err := r.provenance([]types.Tool{{Name: "dnsx"}}, "dnsx")
+	require.Nil(t, err)
+	require.Equal(t, filepath.Join(project, projectFile), provenance.Project)
+}
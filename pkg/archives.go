@@ -0,0 +1,237 @@
+package pkg
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/projectdiscovery/gologger"
+	"github.com/projectdiscovery/pdtm/pkg/lock"
+	"github.com/projectdiscovery/pdtm/pkg/state"
+	"github.com/projectdiscovery/pdtm/pkg/types"
+)
+
+// ArchiveCache is the directory keeping the downloaded release archives by
+// their sha256, so reinstalls, rollbacks and installs into other paths reuse
+// them (empty to disable)
+var ArchiveCache string
+
+// ArchiveMaxAge is how long an archive stays cached after its last use
+var ArchiveMaxAge = 30 * 24 * time.Hour
+
+// archiveIndexFile maps the release assets to the sha256 of their archive
+const archiveIndexFile = "index.json"
+
+// archiveIndexMu guards the index of the cache within the process, the lock
+// file guards it from the other processes sharing the cache
+var archiveIndexMu sync.Mutex
+
+// archiveLockTimeout is how long the index waits for the other processes
+var archiveLockTimeout = time.Minute
+
+// archiveKey is the key of the release asset in the index of the cache
+func archiveKey(tool types.Tool, assetName string) string {
+	return strings.ToLower(tool.Name) + "/" + strings.TrimPrefix(tool.Version, "v") + "/" + assetName
+}
+
+// archivePath returns the path of the archive with the given sha256
+func archivePath(sum string) string {
+	return filepath.Join(ArchiveCache, "sha256", sum)
+}
+
+// cachedArchive returns the path and the sha256 of the cached archive of the
+// release asset, its sha256 is the checksum of the asset when known and the
+// one recorded at its download otherwise. The caller verifies it against the
+// upstream checksums before use.
+func cachedArchive(tool types.Tool, assetName string) (string, string, bool) {
+	if ArchiveCache == "" || tool.Version == "" || IsNightly(tool.Version) {
+		return "", "", false
+	}
+	sum := strings.ToLower(tool.Checksums[assetName])
+	if sum == "" {
+		sum = strings.ToLower(tool.Digests[assetName])
+	}
+	if sum == "" {
+		sum = readArchiveIndex()[archiveKey(tool, assetName)]
+	}
+	if sum == "" {
+		return "", "", false
+	}
+	actual, err := state.Hash(archivePath(sum))
+	if err != nil {
+		return "", "", false
+	}
+	if actual != sum {
+		gologger.Verbose().Msgf("removing the corrupted cached archive %s", archivePath(sum))
+		_ = os.Remove(archivePath(sum))
+		return "", "", false
+	}
+	// the modification time is the last use for the pruning
+	now := time.Now()
+	_ = os.Chtimes(archivePath(sum), now, now)
+	return archivePath(sum), sum, true
+}
+
+// cacheArchive keeps the verified archive of the release asset in the cache
+func cacheArchive(tool types.Tool, assetName, archive, sum string) {
+	if ArchiveCache == "" || tool.Version == "" || IsNightly(tool.Version) {
+		return
+	}
+	f, err := os.Open(archive)
+	if err != nil {
+		gologger.Verbose().Msgf("could not cache %s: %s", assetName, err)
+		return
+	}
+	defer f.Close()
+	if err := writeFileAtomicFrom(archivePath(sum), f); err != nil {
+		gologger.Verbose().Msgf("could not cache %s: %s", assetName, err)
+		return
+	}
+	unlock, err := lockArchiveIndex()
+	if err != nil {
+		gologger.Verbose().Msgf("could not lock the archive cache index: %s", err)
+		return
+	}
+	defer unlock()
+	index := readArchiveIndex()
+	index[archiveKey(tool, assetName)] = sum
+	if err := writeArchiveIndex(index); err != nil {
+		gologger.Verbose().Msgf("could not update the archive cache index: %s", err)
+	}
+}
+
+// PruneArchives removes the cached archives unused for longer than maxAge
+// and the least recently used ones above maxSize bytes (0 to disable), it
+// returns the number of archives removed and their size
+func PruneArchives(maxAge time.Duration, maxSize int64) (int, int64, error) {
+	if ArchiveCache == "" {
+		return 0, 0, nil
+	}
+	entries, err := os.ReadDir(filepath.Join(ArchiveCache, "sha256"))
+	if os.IsNotExist(err) {
+		return 0, 0, nil
+	}
+	if err != nil {
+		return 0, 0, err
+	}
+	unlock, err := lockArchiveIndex()
+	if err != nil {
+		return 0, 0, err
+	}
+	defer unlock()
+	var count int
+	var size, total int64
+	var kept []os.FileInfo
+	for _, entry := range entries {
+		fi, err := entry.Info()
+		if err != nil || !fi.Mode().IsRegular() {
+			continue
+		}
+		if time.Since(fi.ModTime()) < maxAge {
+			kept = append(kept, fi)
+			total += fi.Size()
+			continue
+		}
+		if err := os.Remove(archivePath(entry.Name())); err != nil {
+			return count, size, err
+		}
+		count++
+		size += fi.Size()
+	}
+	if maxSize > 0 {
+		sort.Slice(kept, func(i, j int) bool { return kept[i].ModTime().Before(kept[j].ModTime()) })
+		for _, fi := range kept {
+			if total <= maxSize {
+				break
+			}
+			if err := os.Remove(archivePath(fi.Name())); err != nil {
+				return count, size, err
+			}
+			count++
+			size += fi.Size()
+			total -= fi.Size()
+		}
+	}
+	index := readArchiveIndex()
+	for key, sum := range index {
+		if _, err := os.Stat(archivePath(sum)); err != nil {
+			delete(index, key)
+		}
+	}
+	return count, size, writeArchiveIndex(index)
+}
+
+// lockArchiveIndex locks the index of the cache, which may be shared by
+// several machines through a network mount
+func lockArchiveIndex() (func(), error) {
+	archiveIndexMu.Lock()
+	l, err := lock.Acquire(filepath.Join(ArchiveCache, "index.lock"), archiveLockTimeout)
+	if err != nil {
+		archiveIndexMu.Unlock()
+		return nil, err
+	}
+	return func() {
+		_ = l.Release()
+		archiveIndexMu.Unlock()
+	}, nil
+}
+
+// readArchiveIndex returns the index of the cache, empty if it can't be read
+func readArchiveIndex() map[string]string {
+	index := make(map[string]string)
+	if b, err := os.ReadFile(filepath.Join(ArchiveCache, archiveIndexFile)); err == nil {
+		_ = json.Unmarshal(b, &index)
+	}
+	return index
+}
+
+// writeArchiveIndex saves the index of the cache
+func writeArchiveIndex(index map[string]string) error {
+	b, err := json.MarshalIndent(index, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(filepath.Join(ArchiveCache, archiveIndexFile), b)
+}
+
+// writeFileAtomic writes the file through a temporary file so readers never
+// see a partial file
+func writeFileAtomic(file string, data []byte) error {
+	return writeFileAtomicFrom(file, bytes.NewReader(data))
+}
+
+// writeFileAtomicFrom writes the content of the reader like writeFileAtomic
+func writeFileAtomicFrom(file string, reader io.Reader) error {
+	if err := os.MkdirAll(filepath.Dir(file), os.ModePerm); err != nil {
+		return err
+	}
+	f, err := os.CreateTemp(filepath.Dir(file), ".tmp-*")
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(f, reader); err != nil {
+		f.Close()
+		_ = os.Remove(f.Name())
+		return err
+	}
+	if err := f.Close(); err != nil {
+		_ = os.Remove(f.Name())
+		return err
+	}
+	// the cache may be shared with other users
+	if err := os.Chmod(f.Name(), 0644); err != nil {
+		_ = os.Remove(f.Name())
+		return err
+	}
+	if err := os.Rename(f.Name(), file); err != nil {
+		_ = os.Remove(f.Name())
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,89 @@
+package pkg
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestArchiveCache(t *testing.T) {
+	fake := useFakeGithub(t)
+	ArchiveCache = t.TempDir()
+	defer func() { ArchiveCache = "" }()
+	tool := GetToolStruct()
+
+	// the second path reuses the archive downloaded for the first one
+	require.Nil(t, Install(t.TempDir(), tool))
+	downloads := fake.Requests("DownloadAsset")
+	path := t.TempDir()
+	require.Nil(t, Install(path, tool))
+	require.Equal(t, downloads+1, fake.Requests("DownloadAsset"), "only the checksums are downloaded again")
+	installed, err := InstalledVersion(tool, path)
+	require.Nil(t, err)
+	require.Equal(t, "1.1.1", installed)
+
+	archives, err := os.ReadDir(filepath.Join(ArchiveCache, "sha256"))
+	require.Nil(t, err)
+	require.Len(t, archives, 1)
+	// a corrupted archive is downloaded again
+	require.Nil(t, os.WriteFile(filepath.Join(ArchiveCache, "sha256", archives[0].Name()), []byte("corrupted"), 0644))
+	require.Nil(t, Install(t.TempDir(), tool))
+	require.Greater(t, fake.Requests("DownloadAsset"), downloads)
+
+	count, _, err := PruneArchives(time.Hour, 0)
+	require.Nil(t, err)
+	require.Zero(t, count)
+	count, _, err = PruneArchives(0, 0)
+	require.Nil(t, err)
+	require.Equal(t, 1, count)
+	require.Empty(t, readArchiveIndex())
+
+	// an archive only known to the shared index isn't trusted
+	require.Nil(t, Install(t.TempDir(), tool))
+	index := readArchiveIndex()
+	require.Len(t, index, 1)
+	tampered := []byte("tampered")
+	sum := sha256.Sum256(tampered)
+	tamperedSum := hex.EncodeToString(sum[:])
+	require.Nil(t, writeFileAtomic(archivePath(tamperedSum), tampered))
+	for key := range index {
+		index[key] = tamperedSum
+	}
+	require.Nil(t, writeArchiveIndex(index))
+	downloads = fake.Requests("DownloadAsset")
+	require.Nil(t, Install(t.TempDir(), tool))
+	// the checksums are fetched to check the cached archive and again after its download
+	require.Equal(t, downloads+3, fake.Requests("DownloadAsset"), "the archive is downloaded again")
+	for _, sum := range readArchiveIndex() {
+		require.NotEqual(t, tamperedSum, sum)
+	}
+}
+
+func TestPruneArchivesSize(t *testing.T) {
+	ArchiveCache = t.TempDir()
+	defer func() { ArchiveCache = "" }()
+	for i, content := range []string{"oldest", "older", "recent"} {
+		data := []byte(content)
+		sum := sha256.Sum256(data)
+		file := archivePath(hex.EncodeToString(sum[:]))
+		require.Nil(t, writeFileAtomic(file, data))
+		used := time.Now().Add(time.Duration(i-3) * time.Hour)
+		require.Nil(t, os.Chtimes(file, used, used))
+	}
+
+	// the least recently used archives are removed above the size
+	count, size, err := PruneArchives(24*time.Hour, 8)
+	require.Nil(t, err)
+	require.Equal(t, 2, count)
+	require.Equal(t, int64(len("oldest")+len("older")), size)
+	archives, err := os.ReadDir(filepath.Join(ArchiveCache, "sha256"))
+	require.Nil(t, err)
+	require.Len(t, archives, 1)
+	sum := sha256.Sum256([]byte("recent"))
+	require.Equal(t, hex.EncodeToString(sum[:]), archives[0].Name())
+}
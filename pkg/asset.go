@@ -0,0 +1,90 @@
+package pkg
+
+import (
+	"bytes"
+	"strings"
+	"text/template"
+
+	"github.com/projectdiscovery/pdtm/pkg/types"
+)
+
+// archiveExtensions contains the extensions of the supported release assets,
+// a .zst asset being the binary compressed with zstd
+var archiveExtensions = []string{".zip", ".tar.gz", ".tar.zst", ".zst"}
+
+// archiveExtension returns the extension of the asset, empty if not supported
+func archiveExtension(asset string) string {
+	for _, ext := range archiveExtensions {
+		if strings.HasSuffix(strings.ToLower(asset), ext) {
+			return ext
+		}
+	}
+	return ""
+}
+
+// AssetTemplates contains the asset name template of the tools whose
+// release assets don't follow the name_version_os_arch naming
+var AssetTemplates map[string]string
+
+var assetTemplateFuncs = template.FuncMap{
+	"lower": strings.ToLower,
+	"upper": strings.ToUpper,
+}
+
+// assetTemplateData contains the variables available in asset name templates
+type assetTemplateData struct {
+	Name    string
+	Version string
+	OS      string
+	Arch    string
+}
+
+// ParseAssetTemplate parses an asset name template
+// (eg. {{.Name}}-{{.Version}}-{{.OS}}-{{.Arch}}.tar.gz)
+func ParseAssetTemplate(text string) (*template.Template, error) {
+	return template.New("asset").Funcs(assetTemplateFuncs).Option("missingkey=error").Parse(text)
+}
+
+// assetTemplate returns the configured asset name template of the tool
+func assetTemplate(toolName string) (string, bool) {
+	for name, text := range AssetTemplates {
+		if strings.EqualFold(name, toolName) {
+			return text, true
+		}
+	}
+	return "", false
+}
+
+// assetBaseName returns the expected name of the tool asset for the given
+// os and arch, the archive extension is optional when using a template
+func assetBaseName(tool types.Tool, goos, arch string) (string, error) {
+	version := strings.TrimPrefix(tool.Version, "v")
+	if text, ok := assetTemplate(tool.Name); ok {
+		tmpl, err := ParseAssetTemplate(text)
+		if err != nil {
+			return "", err
+		}
+		var buf bytes.Buffer
+		data := assetTemplateData{Name: tool.Name, Version: version, OS: goos, Arch: arch}
+		if err := tmpl.Execute(&buf, data); err != nil {
+			return "", err
+		}
+		return buf.String(), nil
+	}
+	osName := goos
+	if strings.EqualFold(osName, "darwin") {
+		osName = "macOS"
+	}
+	return strings.Join([]string{tool.Name, version, osName, arch}, "_"), nil
+}
+
+// matchAsset returns true if the asset is the expected archive
+func matchAsset(asset, baseName string) bool {
+	for _, ext := range archiveExtensions {
+		if strings.HasSuffix(strings.ToLower(asset), ext) &&
+			(strings.EqualFold(asset, baseName) || strings.EqualFold(asset, baseName+ext)) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,103 @@
+package pkg
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sync"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/projectdiscovery/pdtm/pkg/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFindAssetTemplate(t *testing.T) {
+	tool := types.Tool{
+		Name:    "example",
+		Version: "1.2.3",
+		Assets: map[string]string{
+			"example-v1.2.3-" + runtime.GOOS + "-" + runtime.GOARCH + ".tar.gz": "1",
+			"checksums.txt": "2",
+		},
+	}
+	_, ref, _ := findAsset(tool)
+	require.Empty(t, ref)
+
+	AssetTemplates = map[string]string{"example": "{{.Name}}-v{{.Version}}-{{.OS}}-{{.Arch}}"}
+	defer func() { AssetTemplates = nil }()
+	asset, ref, _ := findAsset(tool)
+	require.Equal(t, "1", ref)
+	require.Equal(t, "example-v1.2.3-"+runtime.GOOS+"-"+runtime.GOARCH+".tar.gz", asset)
+}
+
+func TestExtractZstd(t *testing.T) {
+	name := "example"
+	if runtime.GOOS == "windows" {
+		name += extIfFound
+	}
+	var tarZst bytes.Buffer
+	zw, err := zstd.NewWriter(&tarZst)
+	require.Nil(t, err)
+	tw := tar.NewWriter(zw)
+	require.Nil(t, tw.WriteHeader(&tar.Header{Name: name, Mode: 0755, Size: 7}))
+	_, err = tw.Write([]byte("tar.zst"))
+	require.Nil(t, err)
+	require.Nil(t, tw.Close())
+	require.Nil(t, zw.Close())
+	var zst bytes.Buffer
+	zw, err = zstd.NewWriter(&zst)
+	require.Nil(t, err)
+	_, err = zw.Write([]byte("zst"))
+	require.Nil(t, err)
+	require.Nil(t, zw.Close())
+
+	assets := map[string][]byte{"example_1.0.0_linux_amd64.tar.zst": tarZst.Bytes(), "example_1.0.0_linux_amd64.zst": zst.Bytes()}
+	expected := map[string]string{"example_1.0.0_linux_amd64.tar.zst": "tar.zst", "example_1.0.0_linux_amd64.zst": "zst"}
+	for asset, data := range assets {
+		path := t.TempDir()
+		require.Nil(t, newExtractor(types.Tool{Name: "example"}, path, Event{}).extractAsset(asset, bytes.NewReader(data)))
+		binary, err := os.ReadFile(filepath.Join(path, name))
+		require.Nil(t, err)
+		require.Equal(t, expected[asset], string(binary))
+	}
+	require.True(t, matchAsset("example_1.0.0_linux_amd64.tar.zst", "example_1.0.0_linux_amd64"))
+	require.NotNil(t, newExtractor(types.Tool{Name: "example"}, t.TempDir(), Event{}).extractAsset("example.tar.xz", bytes.NewReader(nil)))
+}
+
+func TestExtractBinaries(t *testing.T) {
+	var archive bytes.Buffer
+	zw := zip.NewWriter(&archive)
+	for _, name := range []string{"example", "dir/example-helper", "README.md"} {
+		w, err := zw.Create(name)
+		require.Nil(t, err)
+		_, err = w.Write([]byte(name))
+		require.Nil(t, err)
+	}
+	require.Nil(t, zw.Close())
+
+	var (
+		mutex sync.Mutex
+		files = map[string]int64{}
+	)
+	Progress = func(event Event) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		if event.Event != EventExtractFile {
+			return
+		}
+		require.Equal(t, event.Total, event.Bytes)
+		files[event.File] = event.Bytes
+	}
+	defer func() { Progress = nil }()
+
+	path := t.TempDir()
+	tool := types.Tool{Name: "example", Binaries: []string{"example-helper"}}
+	require.Nil(t, newExtractor(tool, path, Event{Tool: "example"}).extractAsset("example.zip", &archive))
+	require.Equal(t, map[string]int64{"example": 7, "example-helper": 18}, files)
+	require.FileExists(t, filepath.Join(path, "example-helper"))
+	require.NoFileExists(t, filepath.Join(path, "README.md"))
+}
@@ -0,0 +1,187 @@
+package pkg
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/github"
+	"github.com/projectdiscovery/gologger"
+)
+
+var (
+	// ErrTokenInvalid is returned when github rejects GITHUB_TOKEN
+	ErrTokenInvalid = errors.New("GITHUB_TOKEN is expired or invalid, renew or unset it (see pdtm -auth-status)")
+	// ErrTokenScope is returned when GITHUB_TOKEN lacks the scope of a request
+	ErrTokenScope = errors.New("GITHUB_TOKEN has an insufficient scope (see pdtm -auth-status)")
+)
+
+// tokenExpiryWarning is how long before its expiration the runs warn about the token
+const tokenExpiryWarning = 7 * 24 * time.Hour
+
+// TokenStatus describes the github token (GITHUB_TOKEN) used by pdtm
+type TokenStatus struct {
+	// Configured is false when GITHUB_TOKEN isn't set, the requests are
+	// then unauthenticated and limited to 60 per hour
+	Configured bool `json:"configured" yaml:"configured"`
+	// Type is the kind of token guessed from its prefix (eg. classic)
+	Type  string `json:"type,omitempty" yaml:"type,omitempty"`
+	Valid bool   `json:"valid" yaml:"valid"`
+	// Problem explains why github rejected the token
+	Problem string `json:"problem,omitempty" yaml:"problem,omitempty"`
+	Login   string `json:"login,omitempty" yaml:"login,omitempty"`
+	// Scopes are the oauth scopes of the token, the fine-grained tokens
+	// have permissions instead
+	Scopes []string `json:"scopes,omitempty" yaml:"scopes,omitempty"`
+	// Expires is the expiration of the token, nil if it doesn't expire
+	Expires *time.Time `json:"expires,omitempty" yaml:"expires,omitempty"`
+	// Rate is the core rate limit of the token
+	Rate *github.Rate `json:"-" yaml:"-"`
+}
+
+// CheckToken checks the validity, scopes, expiration and rate limit of
+// GITHUB_TOKEN with the github api
+func CheckToken(ctx context.Context) (*TokenStatus, error) {
+	client := GithubClient()
+	status, err := checkToken(ctx, client, os.Getenv("GITHUB_TOKEN"))
+	if err != nil || !status.Valid {
+		return status, err
+	}
+	// the installation tokens of the github apps can't read their user
+	if user, _, err := client.Users.Get(ctx, ""); err == nil {
+		status.Login = user.GetLogin()
+	}
+	return status, nil
+}
+
+// checkToken checks the token with the rate limit endpoint, which doesn't
+// count against the rate limit
+func checkToken(ctx context.Context, client *github.Client, token string) (*TokenStatus, error) {
+	status := &TokenStatus{Configured: token != "", Type: tokenType(token)}
+	req, err := client.NewRequest("GET", "rate_limit", nil)
+	if err != nil {
+		return status, err
+	}
+	var limits struct {
+		Resources github.RateLimits `json:"resources"`
+	}
+	resp, err := client.Do(ctx, req, &limits)
+	if err != nil {
+		var errResp *github.ErrorResponse
+		if status.Configured && errors.As(err, &errResp) && errResp.Response.StatusCode == http.StatusUnauthorized {
+			status.Problem = "expired or invalid (" + errResp.Message + ")"
+			return status, nil
+		}
+		return status, err
+	}
+	status.Valid = status.Configured
+	status.Rate = limits.Resources.GetCore()
+	if status.Valid {
+		status.Scopes = headerList(resp.Header.Get("X-OAuth-Scopes"))
+		status.Expires = tokenExpiration(resp.Header.Get("GitHub-Authentication-Token-Expiration"))
+	}
+	return status, nil
+}
+
+// ValidateToken fails early when github rejects GITHUB_TOKEN instead of
+// failing each download of a batch, the other errors are left to the requests
+func ValidateToken(ctx context.Context) error {
+	token := os.Getenv("GITHUB_TOKEN")
+	if token == "" || !isGithub() {
+		return nil
+	}
+	status, err := checkToken(ctx, GithubClient(), token)
+	if err != nil {
+		gologger.Verbose().Msgf("could not check GITHUB_TOKEN: %s", err)
+		return nil
+	}
+	if !status.Valid {
+		return fmt.Errorf("%w: %s", ErrTokenInvalid, status.Problem)
+	}
+	if status.Expires != nil && time.Until(*status.Expires) < tokenExpiryWarning {
+		gologger.Info().Label("WRN").Msgf("GITHUB_TOKEN expires on %s", status.Expires.Format("2006-01-02 15:04"))
+	}
+	return nil
+}
+
+// tokenError explains the github errors caused by GITHUB_TOKEN
+func tokenError(err error) error {
+	var errResp *github.ErrorResponse
+	if !errors.As(err, &errResp) || errResp.Response == nil || os.Getenv("GITHUB_TOKEN") == "" {
+		return err
+	}
+	switch errResp.Response.StatusCode {
+	case http.StatusUnauthorized:
+		return fmt.Errorf("%w: %s", ErrTokenInvalid, errResp.Message)
+	case http.StatusForbidden:
+		accepted := headerList(errResp.Response.Header.Get("X-Accepted-OAuth-Scopes"))
+		granted := headerList(errResp.Response.Header.Get("X-OAuth-Scopes"))
+		if len(accepted) > 0 && !hasAnyScope(granted, accepted) {
+			return fmt.Errorf("%w, it needs one of %s", ErrTokenScope, strings.Join(accepted, ", "))
+		}
+		// the fine-grained and app tokens without the permission
+		if strings.HasPrefix(errResp.Message, "Resource not accessible by") {
+			return fmt.Errorf("%w: %s", ErrTokenScope, errResp.Message)
+		}
+	}
+	return err
+}
+
+// tokenType guesses the kind of the token from its prefix
+func tokenType(token string) string {
+	switch {
+	case token == "":
+		return ""
+	case strings.HasPrefix(token, "ghp_"):
+		return "classic personal access token"
+	case strings.HasPrefix(token, "github_pat_"):
+		return "fine-grained personal access token"
+	case strings.HasPrefix(token, "gho_"):
+		return "oauth app token"
+	case strings.HasPrefix(token, "ghs_"):
+		return "github app installation token"
+	case strings.HasPrefix(token, "ghu_"):
+		return "github app user token"
+	default:
+		return "unknown"
+	}
+}
+
+// hasAnyScope returns true if one of the granted scopes is accepted, the
+// scopes include their sub-scopes (eg. repo includes public_repo)
+func hasAnyScope(granted, accepted []string) bool {
+	for _, scope := range granted {
+		for _, want := range accepted {
+			if scope == want || strings.HasPrefix(want, scope+":") || (scope == "repo" && want == "public_repo") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// headerList parses a comma separated header (eg. "repo, read:org")
+func headerList(value string) []string {
+	var values []string
+	for _, v := range strings.Split(value, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			values = append(values, v)
+		}
+	}
+	return values
+}
+
+// tokenExpiration parses the expiration header of the tokens (eg.
+// "2024-06-05 19:52:22 UTC"), nil for the tokens without expiration
+func tokenExpiration(value string) *time.Time {
+	for _, layout := range []string{"2006-01-02 15:04:05 MST", "2006-01-02 15:04:05 -0700"} {
+		if t, err := time.Parse(layout, value); err == nil {
+			return &t
+		}
+	}
+	return nil
+}
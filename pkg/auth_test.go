@@ -0,0 +1,89 @@
+package pkg
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/google/go-github/github"
+	"github.com/stretchr/testify/require"
+)
+
+// tokenServer returns a client of a server answering the rate limit endpoint
+// like github, the token "bad" is rejected
+func tokenServer(t *testing.T) *github.Client {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") == "Bearer bad" {
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = w.Write([]byte(`{"message":"Bad credentials"}`))
+			return
+		}
+		if r.Header.Get("Authorization") == "Bearer good" {
+			w.Header().Set("X-OAuth-Scopes", "repo, read:org")
+			w.Header().Set("GitHub-Authentication-Token-Expiration", "2030-06-05 19:52:22 UTC")
+		}
+		_, _ = w.Write([]byte(`{"resources":{"core":{"limit":5000,"remaining":4990,"reset":1900000000}}}`))
+	}))
+	t.Cleanup(server.Close)
+	client := github.NewClient(nil)
+	client.BaseURL, _ = url.Parse(server.URL + "/")
+	return client
+}
+
+func TestCheckToken(t *testing.T) {
+	client := tokenServer(t)
+	authenticated := func(token string) *github.Client {
+		authClient := github.NewClient(&http.Client{Transport: &bearerTransport{token: token}})
+		authClient.BaseURL = client.BaseURL
+		return authClient
+	}
+
+	status, err := checkToken(context.Background(), authenticated("good"), "ghp_good")
+	require.Nil(t, err)
+	require.True(t, status.Valid)
+	require.Equal(t, "classic personal access token", status.Type)
+	require.Equal(t, []string{"repo", "read:org"}, status.Scopes)
+	require.Equal(t, 2030, status.Expires.Year())
+	require.Equal(t, 4990, status.Rate.Remaining)
+
+	status, err = checkToken(context.Background(), authenticated("bad"), "ghp_bad")
+	require.Nil(t, err)
+	require.False(t, status.Valid)
+	require.Contains(t, status.Problem, "Bad credentials")
+
+	status, err = checkToken(context.Background(), client, "")
+	require.Nil(t, err)
+	require.False(t, status.Configured)
+	require.Equal(t, 5000, status.Rate.Limit)
+}
+
+func TestTokenError(t *testing.T) {
+	t.Setenv("GITHUB_TOKEN", "ghp_test")
+	response := func(status int, header http.Header) error {
+		return &github.ErrorResponse{Response: &http.Response{StatusCode: status, Header: header}, Message: "message"}
+	}
+
+	require.True(t, errors.Is(tokenError(response(http.StatusUnauthorized, http.Header{})), ErrTokenInvalid))
+	header := http.Header{"X-Accepted-Oauth-Scopes": {"repo"}, "X-Oauth-Scopes": {"read:org"}}
+	require.True(t, errors.Is(tokenError(response(http.StatusForbidden, header)), ErrTokenScope))
+	header = http.Header{"X-Accepted-Oauth-Scopes": {"public_repo"}, "X-Oauth-Scopes": {"repo"}}
+	require.False(t, errors.Is(tokenError(response(http.StatusForbidden, header)), ErrTokenScope))
+	require.Nil(t, tokenError(nil))
+
+	t.Setenv("GITHUB_TOKEN", "")
+	require.False(t, errors.Is(tokenError(response(http.StatusUnauthorized, http.Header{})), ErrTokenInvalid))
+}
+
+// bearerTransport authenticates the requests like the oauth2 client of GithubClient
+type bearerTransport struct {
+	token string
+}
+
+func (b *bearerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.Header.Set("Authorization", "Bearer "+b.token)
+	return http.DefaultTransport.RoundTrip(req)
+}
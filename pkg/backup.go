@@ -0,0 +1,366 @@
+package pkg
+
+import (
+	"archive/tar"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	pathpkg "path"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/projectdiscovery/gologger"
+	ospath "github.com/projectdiscovery/pdtm/pkg/path"
+	"github.com/projectdiscovery/pdtm/pkg/state"
+	"github.com/projectdiscovery/pdtm/pkg/types"
+	fileutil "github.com/projectdiscovery/utils/file"
+)
+
+// backupManifest is the name of the file describing the backup
+const backupManifest = "pdtm-backup.json"
+
+// backupDataDir is the directory of the backup containing the data packs,
+// data/<name>/<file>
+const backupDataDir = "data"
+
+// BackupManifest describes the platform the backed up binaries were built for
+type BackupManifest struct {
+	OS      string    `json:"os"`
+	Arch    string    `json:"arch"`
+	Created time.Time `json:"created"`
+}
+
+// Backup writes the managed binaries, their kept versions, the data packs and
+// the state of path to a tar.zst archive
+func Backup(path, output string) (int, error) {
+	st, err := state.Load(path)
+	if err != nil {
+		return 0, err
+	}
+	f, err := os.Create(output)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+	zw, err := zstd.NewWriter(f)
+	if err != nil {
+		return 0, err
+	}
+	tw := tar.NewWriter(zw)
+
+	manifest, err := json.Marshal(BackupManifest{OS: runtime.GOOS, Arch: runtime.GOARCH, Created: time.Now().UTC()})
+	if err != nil {
+		return 0, err
+	}
+	if err := writeTarFile(tw, backupManifest, manifest, 0644); err != nil {
+		return 0, err
+	}
+
+	names := make([]string, 0, len(st.Tools))
+	for name := range st.Tools {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var count int
+	for _, name := range names {
+		executablePath, exists := ospath.GetExecutablePath(path, st.Tools[name].Name)
+		if !exists {
+			continue
+		}
+		if err := addTarFile(tw, executablePath, filepath.Base(executablePath)); err != nil {
+			return count, err
+		}
+		count++
+	}
+	// the versions kept for the rollbacks
+	versions := filepath.Join(path, VersionsDir)
+	for _, name := range names {
+		dir := filepath.Join(versions, strings.ToLower(st.Tools[name].Name))
+		err := filepath.WalkDir(dir, func(file string, d fs.DirEntry, err error) error {
+			if err != nil || !d.Type().IsRegular() {
+				return err
+			}
+			rel, err := filepath.Rel(path, file)
+			if err != nil {
+				return err
+			}
+			return addTarFile(tw, file, filepath.ToSlash(rel))
+		})
+		if err != nil && !os.IsNotExist(err) {
+			return count, err
+		}
+	}
+	for _, name := range names {
+		installed := st.Tools[name]
+		if installed.Source != state.SourceDataPack || !fileutil.FolderExists(installed.Dir) {
+			continue
+		}
+		prefix := backupDataDir + "/" + strings.ToLower(installed.Name) + "/"
+		err := filepath.WalkDir(installed.Dir, func(file string, d fs.DirEntry, err error) error {
+			if err != nil || !d.Type().IsRegular() {
+				return err
+			}
+			rel, err := filepath.Rel(installed.Dir, file)
+			if err != nil {
+				return err
+			}
+			return addTarFile(tw, file, prefix+filepath.ToSlash(rel))
+		})
+		if err != nil {
+			return count, err
+		}
+		count++
+	}
+	if stateFile := filepath.Join(path, state.FileName); fileutil.FileExists(stateFile) {
+		if err := addTarFile(tw, stateFile, state.FileName); err != nil {
+			return count, err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return count, err
+	}
+	if err := zw.Close(); err != nil {
+		return count, err
+	}
+	return count, f.Close()
+}
+
+// Restore extracts the binaries of a backup to path with their modes and
+// merges its state, the data packs are restored to their configured
+// directory and the recorded capabilities are set again
+func Restore(path, input string) (int, error) {
+	f, err := os.Open(input)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+	zr, err := zstd.NewReader(f)
+	if err != nil {
+		return 0, err
+	}
+	defer zr.Close()
+	if err := os.MkdirAll(path, os.ModePerm); err != nil {
+		return 0, err
+	}
+
+	var count int
+	var backupState *state.State
+	// the data packs are extracted next to their directory, which is only
+	// replaced once the whole backup was read
+	dataPacks := make(map[string]string)
+	defer func() {
+		for _, tmpDir := range dataPacks {
+			os.RemoveAll(tmpDir)
+		}
+	}()
+	tr := tar.NewReader(zr)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return count, err
+		}
+		name := header.Name
+		if header.Typeflag != tar.TypeReg || !validBackupName(name) {
+			return count, fmt.Errorf("invalid file in backup: %s", name)
+		}
+		mode := header.FileInfo().Mode().Perm()
+		if strings.HasPrefix(name, VersionsDir+"/") {
+			target := filepath.Join(path, filepath.FromSlash(name))
+			if err := os.MkdirAll(filepath.Dir(target), os.ModePerm); err != nil {
+				return count, err
+			}
+			if err := extractTarFile(tr, target, mode); err != nil {
+				return count, err
+			}
+			continue
+		}
+		if strings.HasPrefix(name, backupDataDir+"/") {
+			pack, file, _ := strings.Cut(strings.TrimPrefix(name, backupDataDir+"/"), "/")
+			tmpDir, ok := dataPacks[pack]
+			if !ok {
+				if tmpDir, err = restoreDataPack(path, pack); err != nil {
+					return count, err
+				}
+				dataPacks[pack] = tmpDir
+			}
+			if tmpDir == "" {
+				continue
+			}
+			target := filepath.Join(tmpDir, filepath.FromSlash(file))
+			if err := os.MkdirAll(filepath.Dir(target), os.ModePerm); err != nil {
+				return count, err
+			}
+			if err := extractTarFile(tr, target, mode); err != nil {
+				return count, err
+			}
+			continue
+		}
+		switch name {
+		case backupManifest:
+			var manifest BackupManifest
+			if err := json.NewDecoder(tr).Decode(&manifest); err != nil {
+				return count, err
+			}
+			if manifest.OS != runtime.GOOS || manifest.Arch != runtime.GOARCH {
+				gologger.Warning().Msgf("backup contains %s/%s binaries", manifest.OS, manifest.Arch)
+			}
+		case state.FileName:
+			backupState = &state.State{}
+			if err := json.NewDecoder(tr).Decode(backupState); err != nil {
+				return count, err
+			}
+		default:
+			target := filepath.Join(path, name)
+			if err := extractTarFile(tr, target, mode); err != nil {
+				return count, err
+			}
+			labelBinary(target)
+			count++
+		}
+	}
+
+	for pack, tmpDir := range dataPacks {
+		if tmpDir == "" {
+			continue
+		}
+		dir, _ := dataDir(types.Tool{Name: pack})
+		if err := replaceDir(tmpDir, dir); err != nil {
+			return count, err
+		}
+		count++
+	}
+
+	if backupState != nil {
+		st, err := state.Load(path)
+		if err != nil {
+			return count, err
+		}
+		for _, tool := range backupState.Tools {
+			if tool.Source == state.SourceDataPack {
+				tmpDir := dataPacks[strings.ToLower(tool.Name)]
+				if tmpDir == "" {
+					continue
+				}
+				tool.Dir, _ = dataDir(types.Tool{Name: tool.Name})
+			}
+			st.Set(tool)
+		}
+		if err := st.Save(); err != nil {
+			return count, err
+		}
+		// writing the binaries dropped their capabilities
+		for _, tool := range backupState.Tools {
+			if executablePath, exists := ospath.GetExecutablePath(path, tool.Name); exists {
+				reapplyCapabilities(executablePath, tool.Name, tool.Capabilities)
+			}
+		}
+	}
+	return count, nil
+}
+
+// restoreDataPack returns the temporary directory the data pack of the backup
+// is extracted to, empty when the data pack isn't configured on this host
+func restoreDataPack(path, pack string) (string, error) {
+	tool := types.Tool{Name: pack}
+	dir, ok := dataDir(tool)
+	if !ok {
+		gologger.Warning().Msgf("skipping the unknown data pack %s of the backup", pack)
+		return "", nil
+	}
+	if err := checkDataDir(path, tool); err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(filepath.Dir(dir), os.ModePerm); err != nil {
+		return "", err
+	}
+	return os.MkdirTemp(filepath.Dir(dir), "."+filepath.Base(dir)+"-")
+}
+
+// validBackupName returns true if the file of the backup is written in path
+// or in a data pack directory, either a file of path itself, a file of the
+// versions directory or a file of a data pack
+func validBackupName(name string) bool {
+	if name == filepath.Base(name) {
+		return !strings.HasPrefix(name, "..")
+	}
+	if pathpkg.Clean(name) != name {
+		return false
+	}
+	switch {
+	case strings.HasPrefix(name, VersionsDir+"/"):
+	case strings.HasPrefix(name, backupDataDir+"/") && strings.Count(name, "/") >= 2:
+	default:
+		return false
+	}
+	for _, element := range strings.Split(name, "/") {
+		if element == ".." || element == "" {
+			return false
+		}
+	}
+	return !strings.Contains(name, "\\")
+}
+
+func writeTarFile(tw *tar.Writer, name string, data []byte, mode int64) error {
+	header := &tar.Header{Name: name, Mode: mode, Size: int64(len(data)), ModTime: time.Now()}
+	if err := tw.WriteHeader(header); err != nil {
+		return err
+	}
+	_, err := tw.Write(data)
+	return err
+}
+
+func addTarFile(tw *tar.Writer, file, name string) error {
+	f, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	header, err := tar.FileInfoHeader(info, "")
+	if err != nil {
+		return err
+	}
+	header.Name = name
+	if err := tw.WriteHeader(header); err != nil {
+		return err
+	}
+	_, err = io.Copy(tw, f)
+	return err
+}
+
+// extractTarFile writes the file of the backup with the mode it was backed up with
+func extractTarFile(reader io.Reader, target string, mode fs.FileMode) error {
+	// write next to the target first so a failed restore keeps the installed binary
+	tmpFile := target + ".tmp"
+	dst, err := os.OpenFile(tmpFile, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, mode)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(dst, reader); err != nil {
+		dst.Close()
+		os.Remove(tmpFile)
+		return err
+	}
+	// the mode given to OpenFile is masked with the umask
+	if err := dst.Chmod(mode); err != nil {
+		dst.Close()
+		os.Remove(tmpFile)
+		return err
+	}
+	if err := dst.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmpFile, target)
+}
@@ -0,0 +1,118 @@
+package pkg
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/projectdiscovery/pdtm/pkg/state"
+	"github.com/projectdiscovery/pdtm/pkg/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBackupRestore(t *testing.T) {
+	var set []string
+	defaultSetcap := setcap
+	setcap = func(file, capabilities string, interactive bool) error {
+		set = append(set, filepath.Base(file)+" "+capabilities)
+		return nil
+	}
+	previous := DataPacks["nuclei-templates"]
+	DataPacks["nuclei-templates"] = filepath.Join(t.TempDir(), "nuclei-templates")
+	t.Cleanup(func() {
+		setcap = defaultSetcap
+		DataPacks["nuclei-templates"] = previous
+	})
+
+	from := t.TempDir()
+	recordBinary(t, from, "dnsx", "dnsx 1.1.1")
+	require.Nil(t, os.Chmod(filepath.Join(from, "dnsx"+extIfFound), 0700))
+	recordBinary(t, from, "naabu", "naabu 2.1.0")
+	kept := storedVersionPath(from, types.Tool{Name: "dnsx"}, "1.1.0")
+	require.Nil(t, os.MkdirAll(filepath.Dir(kept), 0755))
+	require.Nil(t, os.WriteFile(kept, []byte("dnsx 1.1.0"), 0644))
+	templates := filepath.Join(t.TempDir(), "nuclei-templates")
+	require.Nil(t, os.MkdirAll(filepath.Join(templates, "http"), 0755))
+	require.Nil(t, os.WriteFile(filepath.Join(templates, "http", "cve.yaml"), []byte("id: cve"), 0644))
+	st, err := state.Load(from)
+	require.Nil(t, err)
+	naabu, _ := st.Get("naabu")
+	naabu.Capabilities = RawSocketCapabilities
+	st.Set(&state.Tool{Name: "nuclei-templates", Version: "9.0.0", Source: state.SourceDataPack, Dir: templates})
+	require.Nil(t, st.Save())
+
+	backup := filepath.Join(t.TempDir(), "tools.tar.zst")
+	count, err := Backup(from, backup)
+	require.Nil(t, err)
+	require.Equal(t, 3, count)
+
+	to := t.TempDir()
+	count, err = Restore(to, backup)
+	require.Nil(t, err)
+	require.Equal(t, 3, count)
+
+	// the binaries keep their mode
+	info, err := os.Stat(filepath.Join(to, "dnsx"+extIfFound))
+	require.Nil(t, err)
+	if runtime.GOOS != "windows" {
+		require.Equal(t, os.FileMode(0700), info.Mode().Perm())
+	}
+	// the kept versions are restored for the rollbacks
+	require.Equal(t, []string{"1.1.0"}, StoredVersions(to, types.Tool{Name: "dnsx"}))
+	// the data pack is restored to its configured directory
+	data, err := os.ReadFile(filepath.Join(DataPacks["nuclei-templates"], "http", "cve.yaml"))
+	require.Nil(t, err)
+	require.Equal(t, "id: cve", string(data))
+	version, ok := DataPackVersion(to, types.Tool{Name: "nuclei-templates"})
+	require.True(t, ok)
+	require.Equal(t, "9.0.0", version)
+	// the capabilities dropped by writing the binary are set again
+	if runtime.GOOS == "linux" {
+		require.Equal(t, []string{"naabu" + extIfFound + " " + RawSocketCapabilities}, set)
+	}
+}
+
+func TestRestoreUnmanagedDataDir(t *testing.T) {
+	previous := DataPacks["nuclei-templates"]
+	t.Cleanup(func() { DataPacks["nuclei-templates"] = previous })
+
+	from := t.TempDir()
+	templates := filepath.Join(t.TempDir(), "nuclei-templates")
+	require.Nil(t, os.MkdirAll(templates, 0755))
+	require.Nil(t, os.WriteFile(filepath.Join(templates, "cve.yaml"), []byte("id: cve"), 0644))
+	st, err := state.Load(from)
+	require.Nil(t, err)
+	st.Set(&state.Tool{Name: "nuclei-templates", Version: "9.0.0", Source: state.SourceDataPack, Dir: templates})
+	require.Nil(t, st.Save())
+	backup := filepath.Join(t.TempDir(), "tools.tar.zst")
+	_, err = Backup(from, backup)
+	require.Nil(t, err)
+
+	// the directory maintained by nuclei itself isn't replaced
+	DataPacks["nuclei-templates"] = filepath.Join(t.TempDir(), "nuclei-templates")
+	require.Nil(t, os.MkdirAll(DataPacks["nuclei-templates"], 0755))
+	_, err = Restore(t.TempDir(), backup)
+	require.ErrorIs(t, err, ErrUnmanagedDataDir)
+	entries, err := os.ReadDir(filepath.Dir(DataPacks["nuclei-templates"]))
+	require.Nil(t, err)
+	require.Len(t, entries, 1, "the extracted data pack was left behind")
+}
+
+func TestValidBackupName(t *testing.T) {
+	for name, valid := range map[string]bool{
+		"dnsx":                                true,
+		state.FileName:                        true,
+		"..":                                  false,
+		"../dnsx":                             false,
+		VersionsDir + "/dnsx/1.1.0/dnsx.zst":  true,
+		VersionsDir + "/../dnsx":              false,
+		VersionsDir + "/dnsx/../../dnsx":      false,
+		"data/nuclei-templates/http/cve.yaml": true,
+		"data/nuclei-templates":               false,
+		"data/nuclei-templates/../../dnsx":    false,
+		"bin/dnsx":                            false,
+	} {
+		require.Equal(t, valid, validBackupName(name), name)
+	}
+}
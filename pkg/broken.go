@@ -0,0 +1,94 @@
+package pkg
+
+import (
+	"os"
+	"sort"
+
+	ospath "github.com/projectdiscovery/pdtm/pkg/path"
+	"github.com/projectdiscovery/pdtm/pkg/state"
+	"github.com/projectdiscovery/pdtm/pkg/types"
+	osutils "github.com/projectdiscovery/utils/os"
+)
+
+// Paranoid reports the binaries modified since pdtm installed them as broken
+var Paranoid bool
+
+// Broken returns why the installed binary of the tool can't be run (missing,
+// empty or not executable), or an empty string if the install looks sane.
+// Binaries removed by an antivirus quarantine or an interrupted extraction
+// are the usual causes.
+func Broken(path string, tool types.Tool) string {
+	if IsDataPack(tool) {
+		return ""
+	}
+	executablePath, exists := ospath.GetExecutablePath(path, tool.Name)
+	if !exists {
+		// a missing binary is only broken if pdtm installed it
+		if st, err := state.Load(path); err == nil {
+			if _, ok := st.Get(tool.Name); ok {
+				return "missing"
+			}
+		}
+		return ""
+	}
+	fi, err := os.Stat(executablePath)
+	switch {
+	case err != nil:
+		return err.Error()
+	case fi.Size() == 0:
+		return "empty"
+	case !osutils.IsWindows() && fi.Mode().Perm()&0111 == 0:
+		return "not executable"
+	}
+	if Paranoid {
+		if st, err := state.Load(path); err == nil {
+			if installed, ok := st.Get(tool.Name); ok && modified(path, installed) {
+				return "modified"
+			}
+		}
+	}
+	return ""
+}
+
+// ModifiedBinaries returns the names of the tools of path whose binary
+// doesn't match the sha256 recorded when pdtm installed it
+func ModifiedBinaries(path string) ([]string, error) {
+	st, err := state.Load(path)
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, installed := range st.Tools {
+		if modified(path, installed) {
+			names = append(names, installed.Name)
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// modified returns true if the binary of the tool or one of its additional
+// binaries differs from the sha256 recorded in the state, a missing binary is
+// reported as missing instead
+func modified(path string, installed *state.Tool) bool {
+	if installed.Source == state.SourceDataPack {
+		return false
+	}
+	hashes := map[string]string{installed.Name: installed.Hash}
+	for binary, hash := range installed.BinaryHashes {
+		hashes[binary] = hash
+	}
+	for name, expected := range hashes {
+		if expected == "" {
+			continue
+		}
+		executablePath, exists := ospath.GetExecutablePath(path, name)
+		if !exists {
+			continue
+		}
+		if hash, err := state.Hash(executablePath); err != nil || hash != expected {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,58 @@
+package pkg
+
+import (
+	"debug/buildinfo"
+	"fmt"
+
+	ospath "github.com/projectdiscovery/pdtm/pkg/path"
+	"github.com/projectdiscovery/pdtm/pkg/types"
+)
+
+// BuildInfo is the go build info embedded in a binary
+type BuildInfo struct {
+	GoVersion string `json:"go_version" yaml:"go_version"`
+	// Path is the package path of the main package
+	Path string `json:"path" yaml:"path"`
+	// Version is the version of the main module, (devel) for local builds
+	Version string `json:"version,omitempty" yaml:"version,omitempty"`
+	// Revision and Time are the vcs commit the binary was built from
+	Revision string `json:"revision,omitempty" yaml:"revision,omitempty"`
+	Time     string `json:"time,omitempty" yaml:"time,omitempty"`
+	// Modified is true if the work tree had uncommitted changes
+	Modified bool `json:"modified,omitempty" yaml:"modified,omitempty"`
+	// Settings contains the other build settings (eg. CGO_ENABLED, -ldflags, GOARCH)
+	Settings map[string]string `json:"settings,omitempty" yaml:"settings,omitempty"`
+}
+
+// ReadBuildInfo returns the go build info of the installed binary of the tool
+func ReadBuildInfo(path string, tool types.Tool) (*BuildInfo, error) {
+	executablePath, exists := ospath.GetExecutablePath(path, tool.Name)
+	if !exists {
+		return nil, fmt.Errorf(types.ErrToolNotFound, tool.Name, executablePath)
+	}
+	return readBuildInfo(executablePath)
+}
+
+func readBuildInfo(file string) (*BuildInfo, error) {
+	info, err := buildinfo.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	build := &BuildInfo{GoVersion: info.GoVersion, Path: info.Path, Version: info.Main.Version}
+	for _, setting := range info.Settings {
+		switch setting.Key {
+		case "vcs.revision":
+			build.Revision = setting.Value
+		case "vcs.time":
+			build.Time = setting.Value
+		case "vcs.modified":
+			build.Modified = setting.Value == "true"
+		default:
+			if build.Settings == nil {
+				build.Settings = make(map[string]string)
+			}
+			build.Settings[setting.Key] = setting.Value
+		}
+	}
+	return build, nil
+}
@@ -0,0 +1,33 @@
+package pkg
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadBuildInfo(t *testing.T) {
+	// the test binary is a go binary with embedded build info
+	executable, err := os.Executable()
+	require.Nil(t, err)
+	build, err := readBuildInfo(executable)
+	require.Nil(t, err)
+	require.Equal(t, runtime.Version(), build.GoVersion)
+
+	notGo := filepath.Join(t.TempDir(), "example")
+	require.Nil(t, os.WriteFile(notGo, []byte("#!/bin/sh\n"), 0755))
+	_, err = readBuildInfo(notGo)
+	require.NotNil(t, err)
+}
+
+func TestSameVersions(t *testing.T) {
+	require.True(t, sameVersions("1.1.1", "v1.1.1", ""))
+	require.False(t, sameVersions("1.1.1", "", "v1.0.0"))
+	require.True(t, isReleaseVersion("v3.1.10"))
+	require.False(t, isReleaseVersion("(devel)"))
+	require.False(t, isReleaseVersion("v0.0.0-20240212150405-2f3b2b0e8d3c"))
+	require.False(t, isReleaseVersion("v3.1.10+dirty"))
+}
@@ -0,0 +1,147 @@
+package pkg
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+	"strings"
+	"sync"
+
+	"github.com/mattn/go-isatty"
+	"github.com/projectdiscovery/gologger"
+	ospath "github.com/projectdiscovery/pdtm/pkg/path"
+	"github.com/projectdiscovery/pdtm/pkg/state"
+	"github.com/projectdiscovery/pdtm/pkg/types"
+)
+
+// RawSocketCapabilities are the linux capabilities letting the tools open raw
+// sockets (eg. for syn scans) without running as root
+const RawSocketCapabilities = "cap_net_raw,cap_net_admin+eip"
+
+// rawSocketTools contains the tools capturing packets with raw sockets
+var rawSocketTools = []string{"naabu"}
+
+// setcap sets the capabilities of the binary, interactive is false when sudo
+// mustn't prompt for a password
+var setcap = runSetcap
+
+var (
+	capabilityBatchMu sync.Mutex
+	// capabilityBatch contains the capabilities to re-apply at the end of
+	// the batch of updates, nil outside of a batch
+	capabilityBatch []pendingCapabilities
+)
+
+// pendingCapabilities are capabilities to re-apply to a replaced binary
+type pendingCapabilities struct {
+	executablePath, name, capabilities string
+}
+
+// AllowedCapabilities returns true for the raw socket capabilities of the
+// tools needing them, setcap runs with sudo so the capabilities of the pdtm
+// api, the config or the state are never used as is
+func AllowedCapabilities(name, capabilities string) bool {
+	if capabilities != RawSocketCapabilities {
+		return false
+	}
+	for _, tool := range rawSocketTools {
+		if strings.EqualFold(tool, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// CapabilitySteps returns the post-install steps granting the capabilities
+// the tool needs, none outside of linux
+func CapabilitySteps(tool types.Tool) []types.PostInstallStep {
+	if runtime.GOOS != "linux" {
+		return nil
+	}
+	for _, name := range rawSocketTools {
+		if strings.EqualFold(name, tool.Name) {
+			return []types.PostInstallStep{{
+				Description:  fmt.Sprintf("grant %s to %s with setcap so it can use raw sockets without root", RawSocketCapabilities, tool.Name),
+				Capabilities: RawSocketCapabilities,
+			}}
+		}
+	}
+	return nil
+}
+
+// SetCapabilities sets the capabilities of the binary of the tool installed
+// at path and records them so they are re-applied after the updates
+func SetCapabilities(path string, tool types.Tool, capabilities string) error {
+	if !AllowedCapabilities(tool.Name, capabilities) {
+		return fmt.Errorf("refusing to set %q on %s, only %s can be set on %s", capabilities, tool.Name, RawSocketCapabilities, strings.Join(rawSocketTools, ", "))
+	}
+	executablePath, exists := ospath.GetExecutablePath(path, tool.Name)
+	if !exists {
+		return fmt.Errorf(types.ErrToolNotFound, tool.Name, executablePath)
+	}
+	// the capabilities of a binary linked to the store would apply to every path
+	if err := unshare(executablePath); err != nil {
+		return err
+	}
+	if err := setcap(executablePath, capabilities, true); err != nil {
+		return fmt.Errorf("could not set the capabilities of %s: %s", executablePath, err)
+	}
+	st, err := state.Load(path)
+	if err != nil {
+		return err
+	}
+	installed, ok := st.Get(tool.Name)
+	if !ok {
+		installed = &state.Tool{Name: tool.Name, Version: tool.Version}
+	}
+	installed.Capabilities = capabilities
+	st.Set(installed)
+	return st.Save()
+}
+
+// reapplyCapabilities sets the recorded capabilities on the replaced binary,
+// setcap has to be run again since writing a binary drops them
+func reapplyCapabilities(executablePath, name, capabilities string) {
+	// the binary kept by a re-tagged release still has them
+	if capabilities == "" || hasCapabilities(executablePath) {
+		return
+	}
+	if !AllowedCapabilities(name, capabilities) {
+		gologger.Error().Msgf("not re-applying the capabilities %q recorded for %s, only %s can be set", capabilities, name, RawSocketCapabilities)
+		return
+	}
+	capabilityBatchMu.Lock()
+	if capabilityBatch != nil {
+		capabilityBatch = append(capabilityBatch, pendingCapabilities{executablePath: executablePath, name: name, capabilities: capabilities})
+		capabilityBatchMu.Unlock()
+		return
+	}
+	capabilityBatchMu.Unlock()
+	applyCapabilities(executablePath, name, capabilities)
+}
+
+// BatchCapabilities defers the capabilities re-applied by the updates until
+// the returned function is called at the end of the batch, so sudo only
+// prompts once instead of in the middle of the downloads
+func BatchCapabilities() func() {
+	capabilityBatchMu.Lock()
+	capabilityBatch = []pendingCapabilities{}
+	capabilityBatchMu.Unlock()
+	return func() {
+		capabilityBatchMu.Lock()
+		pending := capabilityBatch
+		capabilityBatch = nil
+		capabilityBatchMu.Unlock()
+		for _, p := range pending {
+			applyCapabilities(p.executablePath, p.name, p.capabilities)
+		}
+	}
+}
+
+func applyCapabilities(executablePath, name, capabilities string) {
+	if err := setcap(executablePath, capabilities, isatty.IsTerminal(os.Stdin.Fd())); err != nil {
+		gologger.Error().Msgf("could not re-apply the capabilities of %s (%s), run `sudo setcap %s %s`", name, err, capabilities, executablePath)
+		return
+	}
+	gologger.Verbose().Msgf("re-applied %s to %s", capabilities, executablePath)
+}
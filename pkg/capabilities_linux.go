@@ -0,0 +1,33 @@
+//go:build linux
+
+package pkg
+
+import (
+	"os"
+	"os/exec"
+
+	"golang.org/x/sys/unix"
+)
+
+// runSetcap runs setcap on the binary, through sudo when not running as root
+func runSetcap(file, capabilities string, interactive bool) error {
+	args := []string{"setcap", capabilities, file}
+	if os.Geteuid() != 0 {
+		if !interactive {
+			// fails instead of waiting for a password nobody can type
+			args = append([]string{"-n"}, args...)
+		}
+		args = append([]string{"sudo"}, args...)
+	}
+	cmd := exec.Command(args[0], args[1:]...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stderr
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
+
+// hasCapabilities returns true if capabilities are set on the binary
+func hasCapabilities(file string) bool {
+	_, err := unix.Getxattr(file, "security.capability", nil)
+	return err == nil
+}
@@ -0,0 +1,13 @@
+//go:build !linux
+
+package pkg
+
+import "errors"
+
+// runSetcap fails outside of linux, capabilities are linux specific
+func runSetcap(file, capabilities string, interactive bool) error {
+	return errors.New("capabilities are only supported on linux")
+}
+
+// hasCapabilities is always false outside of linux
+func hasCapabilities(file string) bool { return false }
@@ -0,0 +1,77 @@
+package pkg
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/projectdiscovery/pdtm/pkg/state"
+	"github.com/projectdiscovery/pdtm/pkg/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCapabilities(t *testing.T) {
+	var set []string
+	defaultSetcap := setcap
+	setcap = func(file, capabilities string, interactive bool) error {
+		set = append(set, filepath.Base(file)+" "+capabilities)
+		return nil
+	}
+	defer func() { setcap = defaultSetcap }()
+
+	path := t.TempDir()
+	require.Nil(t, os.WriteFile(filepath.Join(path, "naabu"+extIfFound), []byte("naabu"), 0755))
+	tool := types.Tool{Name: "naabu", Version: "2.1.0"}
+	record(path, tool, "", state.SourceRelease, "naabu")
+	require.Empty(t, set)
+
+	require.Nil(t, SetCapabilities(path, tool, RawSocketCapabilities))
+	// an update replaces the binary, the capabilities are set again
+	tool.Version = "2.2.0"
+	record(path, tool, "", state.SourceRelease, "naabu")
+	require.Equal(t, []string{"naabu" + extIfFound + " " + RawSocketCapabilities, "naabu" + extIfFound + " " + RawSocketCapabilities}, set)
+
+	st, err := state.Load(path)
+	require.Nil(t, err)
+	installed, ok := st.Get("naabu")
+	require.True(t, ok)
+	require.Equal(t, "2.2.0", installed.Version)
+	require.Equal(t, RawSocketCapabilities, installed.Capabilities)
+}
+
+func TestCapabilitiesAllowed(t *testing.T) {
+	var set []string
+	defaultSetcap := setcap
+	setcap = func(file, capabilities string, interactive bool) error {
+		set = append(set, filepath.Base(file)+" "+capabilities)
+		return nil
+	}
+	defer func() { setcap = defaultSetcap }()
+
+	path := t.TempDir()
+	for _, name := range []string{"naabu", "dnsx"} {
+		require.Nil(t, os.WriteFile(filepath.Join(path, name+extIfFound), []byte(name), 0755))
+	}
+	// only the raw socket capabilities of the tools needing them are set
+	require.NotNil(t, SetCapabilities(path, types.Tool{Name: "naabu"}, "cap_sys_admin+eip"))
+	require.NotNil(t, SetCapabilities(path, types.Tool{Name: "dnsx"}, RawSocketCapabilities))
+	require.NotNil(t, RunPostInstall(path, types.Tool{Name: "naabu"}, types.PostInstallStep{Capabilities: "cap_setuid+ep"}))
+	require.Empty(t, set)
+
+	// nor re-applied from an edited state
+	st, err := state.Load(path)
+	require.Nil(t, err)
+	st.Set(&state.Tool{Name: "dnsx", Version: "1.1.0", Capabilities: "cap_setuid+ep"})
+	require.Nil(t, st.Save())
+	record(path, types.Tool{Name: "dnsx", Version: "1.1.1"}, "", state.SourceRelease, "dnsx")
+	require.Empty(t, set)
+
+	// the capabilities of a batch of updates are re-applied at its end
+	require.Nil(t, SetCapabilities(path, types.Tool{Name: "naabu"}, RawSocketCapabilities))
+	set = nil
+	apply := BatchCapabilities()
+	record(path, types.Tool{Name: "naabu", Version: "2.2.0"}, "", state.SourceRelease, "naabu")
+	require.Empty(t, set)
+	apply()
+	require.Equal(t, []string{"naabu" + extIfFound + " " + RawSocketCapabilities}, set)
+}
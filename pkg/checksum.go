@@ -0,0 +1,127 @@
+package pkg
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/projectdiscovery/gologger"
+	"github.com/projectdiscovery/pdtm/pkg/types"
+	mapsutil "github.com/projectdiscovery/utils/maps"
+)
+
+// verifyChecksum checks the sha256 of the downloaded asset against the
+// checksums file of the release, the digest computed by github and the
+// checksum returned by the pdtm api, the install is refused when the sources
+// disagree so that a single compromised channel can't serve a tampered binary
+func verifyChecksum(tool types.Tool, assetName, actual string) error {
+	return checkChecksums(tool, assetName, actual, false)
+}
+
+// verifyCachedArchive checks the cached archive like verifyChecksum, the
+// index of the cache is shared and unsigned so an archive is only reused
+// when an upstream checksum confirms it
+func verifyCachedArchive(tool types.Tool, assetName, actual string) error {
+	return checkChecksums(tool, assetName, actual, true)
+}
+
+// checkChecksums compares the sha256 of the asset with the upstream checksums
+func checkChecksums(tool types.Tool, assetName, actual string, required bool) error {
+	expected := make(map[string]string)
+	if checksum, ok := tool.Checksums[assetName]; ok {
+		expected["pdtm api"] = strings.ToLower(checksum)
+	}
+	checksums, err := releaseChecksums(tool)
+	if err != nil {
+		gologger.Verbose().Msgf("could not fetch the release checksums of %s: %s", tool.Name, err)
+	}
+	if checksum, ok := checksums[assetName]; ok {
+		expected["release"] = checksum
+	}
+	digest, err := releaseDigest(tool, assetName)
+	if err != nil {
+		gologger.Verbose().Msgf("could not fetch the github digest of %s: %s", assetName, err)
+	}
+	if digest != "" {
+		expected["github digest"] = digest
+	}
+
+	if len(expected) == 0 && required {
+		return fmt.Errorf("no upstream checksum available for %s", assetName)
+	}
+	if len(expected) == 0 {
+		gologger.Verbose().Msgf("no checksum available for %s", assetName)
+		return nil
+	}
+	sources := mapsutil.GetSortedKeys(expected)
+	for _, source := range sources[1:] {
+		if expected[source] != expected[sources[0]] {
+			described := make([]string, 0, len(sources))
+			for _, source := range sources {
+				described = append(described, fmt.Sprintf("the %s (%s)", source, expected[source]))
+			}
+			return fmt.Errorf("checksums of %s differ between %s, refusing to install", assetName, strings.Join(described, " and "))
+		}
+	}
+	if checksum := expected[sources[0]]; checksum != actual {
+		return fmt.Errorf("checksum mismatch for %s: expected %s (%s), got %s", assetName, checksum, strings.Join(sources, ", "), actual)
+	}
+	gologger.Verbose().Msgf("verified checksum of %s (%s)", assetName, strings.Join(sources, ", "))
+	return nil
+}
+
+// releaseDigest returns the sha256 digest computed by github for the asset,
+// empty if unknown. The release is looked up when the tool wasn't resolved
+// from github (eg. the latest release returned by the pdtm api)
+func releaseDigest(tool types.Tool, assetName string) (string, error) {
+	if digest, ok := tool.Digests[assetName]; ok {
+		return digest, nil
+	}
+	if tool.Digests != nil || !isGithub() || tool.Repo == "" || tool.Version == "" || IsNightly(tool.Version) {
+		return "", nil
+	}
+	release, err := Github.ReleaseByTag(context.Background(), tool.Repo, "v"+strings.TrimPrefix(tool.Version, "v"))
+	if err != nil {
+		return "", err
+	}
+	for _, asset := range release.Assets {
+		if asset.GetName() == assetName {
+			return asset.SHA256(), nil
+		}
+	}
+	return "", nil
+}
+
+// releaseChecksums returns the checksums of the assets listed in the
+// checksums file of the release (eg. dnsx_1.1.0_checksums.txt), nil if the
+// release has none
+func releaseChecksums(tool types.Tool) (map[string]string, error) {
+	for name, ref := range tool.Assets {
+		if !strings.HasSuffix(name, "_checksums.txt") {
+			continue
+		}
+		body, err := downloadAsset(tool, ref)
+		if err != nil {
+			return nil, err
+		}
+		defer body.Close()
+		return parseChecksums(body)
+	}
+	return nil, nil
+}
+
+// parseChecksums parses a sha256sum formatted file
+func parseChecksums(r io.Reader) (map[string]string, error) {
+	checksums := make(map[string]string)
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) != 2 {
+			continue
+		}
+		checksums[strings.TrimPrefix(fields[1], "*")] = strings.ToLower(fields[0])
+	}
+	return checksums, scanner.Err()
+}
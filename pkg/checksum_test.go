@@ -0,0 +1,88 @@
+package pkg
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/projectdiscovery/pdtm/pkg/types"
+	"github.com/stretchr/testify/require"
+)
+
+// staticProvider serves the assets from memory
+type staticProvider map[string]string
+
+func (p staticProvider) Latest(tool types.Tool) (types.Tool, error) { return tool, nil }
+
+func (p staticProvider) Release(tool types.Tool, _ string) (types.Tool, error) { return tool, nil }
+
+func (p staticProvider) Download(_ types.Tool, ref string) (io.ReadCloser, error) {
+	return io.NopCloser(strings.NewReader(p[ref])), nil
+}
+
+func TestVerifyChecksum(t *testing.T) {
+	data := []byte("dnsx")
+	sum := sha256.Sum256(data)
+	checksum := hex.EncodeToString(sum[:])
+	other := strings.Repeat("0", 64)
+
+	defaultProvider := ActiveProvider
+	defer func() { ActiveProvider = defaultProvider }()
+	ActiveProvider = staticProvider{
+		"valid":   checksum + "  dnsx_1.1.0_linux_amd64.zip\n",
+		"invalid": other + "  dnsx_1.1.0_linux_amd64.zip\n",
+	}
+
+	tool := types.Tool{Name: "dnsx", Assets: map[string]string{"dnsx_1.1.0_checksums.txt": "valid"}}
+	require.Nil(t, verifyChecksum(tool, "dnsx_1.1.0_linux_amd64.zip", checksum))
+	require.NotNil(t, verifyChecksum(tool, "dnsx_1.1.0_linux_amd64.zip", other))
+
+	tool.Checksums = map[string]string{"dnsx_1.1.0_linux_amd64.zip": checksum}
+	require.Nil(t, verifyChecksum(tool, "dnsx_1.1.0_linux_amd64.zip", checksum))
+
+	// the release and the api disagree
+	tool.Assets["dnsx_1.1.0_checksums.txt"] = "invalid"
+	err := verifyChecksum(tool, "dnsx_1.1.0_linux_amd64.zip", checksum)
+	require.ErrorContains(t, err, "refusing to install")
+
+	// the github digest is verified without checksums file
+	tool = types.Tool{Name: "dnsx", Digests: map[string]string{"dnsx_1.1.0_linux_amd64.zip": checksum}}
+	require.Nil(t, verifyChecksum(tool, "dnsx_1.1.0_linux_amd64.zip", checksum))
+	require.ErrorContains(t, verifyChecksum(tool, "dnsx_1.1.0_linux_amd64.zip", other), "checksum mismatch")
+	tool.Checksums = map[string]string{"dnsx_1.1.0_linux_amd64.zip": other}
+	require.ErrorContains(t, verifyChecksum(tool, "dnsx_1.1.0_linux_amd64.zip", checksum), "refusing to install")
+}
+
+func TestReleaseDigest(t *testing.T) {
+	fake := useFakeGithub(t)
+	var release types.GithubRelease
+	require.Nil(t, json.Unmarshal([]byte(`{"tag_name": "v1.1.0", "assets": [
+		{"id": 1, "name": "dnsx_1.1.0_linux_amd64.zip", "digest": "sha256:ABCDEF"},
+		{"id": 2, "name": "dnsx_1.1.0_linux_arm64.zip"}]}`), &release))
+	fake.Releases["dnsx"] = []*types.GithubRelease{&release}
+
+	defaultProvider := ActiveProvider
+	defer func() { ActiveProvider = defaultProvider }()
+	ActiveProvider = &GithubProvider{}
+
+	// tools returned by the pdtm api are looked up
+	tool := types.Tool{Name: "dnsx", Repo: "dnsx", Version: "1.1.0"}
+	digest, err := releaseDigest(tool, "dnsx_1.1.0_linux_amd64.zip")
+	require.Nil(t, err)
+	require.Equal(t, "abcdef", digest)
+	digest, err = releaseDigest(tool, "dnsx_1.1.0_linux_arm64.zip")
+	require.Nil(t, err)
+	require.Empty(t, digest)
+
+	// resolved tools already contain the digests
+	resolved, err := ResolveVersion(tool, "1.1.0")
+	require.Nil(t, err)
+	require.Equal(t, map[string]string{"dnsx_1.1.0_linux_amd64.zip": "abcdef"}, resolved.Digests)
+	calls := fake.Requests("ReleaseByTag")
+	_, err = releaseDigest(resolved, "dnsx_1.1.0_linux_arm64.zip")
+	require.Nil(t, err)
+	require.Equal(t, calls, fake.Requests("ReleaseByTag"))
+}
@@ -2,9 +2,13 @@ package pkg
 
 import (
 	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
+	"github.com/projectdiscovery/pdtm/pkg/githubtest"
 	ospath "github.com/projectdiscovery/pdtm/pkg/path"
+	"github.com/projectdiscovery/pdtm/pkg/state"
 	"github.com/projectdiscovery/pdtm/pkg/types"
 	"github.com/stretchr/testify/require"
 )
@@ -30,7 +34,20 @@ func GetToolStruct() types.Tool {
 	return tool
 }
 
+var _ GithubAPI = (*githubtest.Fake)(nil)
+
+// useFakeGithub serves the recorded github releases during the test
+func useFakeGithub(t *testing.T) *githubtest.Fake {
+	fake, err := githubtest.NewFromFixtures()
+	require.Nil(t, err)
+	previous := Github
+	Github = fake
+	t.Cleanup(func() { Github = previous })
+	return fake
+}
+
 func TestInstall(t *testing.T) {
+	useFakeGithub(t)
 	tool := GetToolStruct()
 
 	pathBin, err := os.MkdirTemp("", "test-dir")
@@ -40,6 +57,12 @@ func TestInstall(t *testing.T) {
 	// install first time
 	err = Install(pathBin, tool)
 	require.Nil(t, err)
+	st, err := state.Load(pathBin)
+	require.Nil(t, err)
+	installed, ok := st.Get(tool.Name)
+	require.True(t, ok)
+	require.Equal(t, state.SourceRelease, installed.Source)
+	require.Equal(t, "github.com/projectdiscovery/dnsx", installed.Origin)
 
 	// installing again should trigger an error
 	err = Install(pathBin, tool)
@@ -47,6 +70,7 @@ func TestInstall(t *testing.T) {
 }
 
 func TestRemove(t *testing.T) {
+	useFakeGithub(t)
 	tool := GetToolStruct()
 
 	pathBin, err := os.MkdirTemp("", "test-dir")
@@ -66,7 +90,29 @@ func TestRemove(t *testing.T) {
 	require.NotNil(t, err)
 }
 
+func TestPurgeData(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+	dataDir := filepath.Join(home, ".config", "dnsx")
+	require.Nil(t, os.MkdirAll(dataDir, 0755))
+	tool := GetToolStruct()
+	path := t.TempDir()
+
+	// the data of a tool pdtm has no record of is kept
+	require.NotNil(t, PurgeData(path, tool))
+	require.DirExists(t, dataDir)
+
+	st, err := state.Load(path)
+	require.Nil(t, err)
+	st.Set(&state.Tool{Name: tool.Name, Version: tool.Version})
+	require.Nil(t, st.Save())
+	require.Nil(t, PurgeData(path, tool))
+	require.NoDirExists(t, dataDir)
+}
+
 func TestUpdateSameVersion(t *testing.T) {
+	useFakeGithub(t)
 	tool := GetToolStruct()
 
 	pathBin, err := os.MkdirTemp("", "test-dir")
@@ -83,6 +129,7 @@ func TestUpdateSameVersion(t *testing.T) {
 }
 
 func TestUpdateNonExistingTool(t *testing.T) {
+	useFakeGithub(t)
 	tool := GetToolStruct()
 
 	pathBin, err := os.MkdirTemp("", "test-dir")
@@ -95,6 +142,7 @@ func TestUpdateNonExistingTool(t *testing.T) {
 }
 
 func TestUpdateToolWithoutAssets(t *testing.T) {
+	useFakeGithub(t)
 	tool := GetToolStruct()
 
 	pathBin, err := os.MkdirTemp("", "test-dir")
@@ -115,3 +163,86 @@ func TestUpdateToolWithoutAssets(t *testing.T) {
 	_, exists := ospath.GetExecutablePath(pathBin, tool.Name)
 	require.True(t, exists)
 }
+
+func TestUpdateSameDigest(t *testing.T) {
+	fake := useFakeGithub(t)
+	tool := GetToolStruct()
+	pathBin := t.TempDir()
+	require.Nil(t, Install(pathBin, tool))
+	st, err := state.Load(pathBin)
+	require.Nil(t, err)
+	installed, ok := st.Get(tool.Name)
+	require.True(t, ok)
+	require.NotEmpty(t, installed.Digest)
+
+	// the release re-tagged with the same archives isn't downloaded
+	retagged := types.Tool{Name: tool.Name, Repo: tool.Repo, Version: "1.1.2", Assets: map[string]string{}, Digests: map[string]string{}}
+	for name, ref := range tool.Assets {
+		name = strings.Replace(name, "1.1.1", "1.1.2", 1)
+		retagged.Assets[name] = ref
+		retagged.Digests[name] = installed.Digest
+	}
+	downloads := fake.Requests("DownloadAsset")
+	require.Nil(t, Update(pathBin, retagged, true))
+	require.Equal(t, downloads, fake.Requests("DownloadAsset"))
+	version, err := InstalledVersion(retagged, pathBin)
+	require.Nil(t, err)
+	require.Equal(t, "1.1.2", version)
+
+	// the binary still reporting the version it was built as is consistent
+	st, err = state.Load(pathBin)
+	require.Nil(t, err)
+	installed, ok = st.Get(tool.Name)
+	require.True(t, ok)
+	require.Equal(t, "1.1.1", installed.ArchiveVersion)
+	check, err := CheckVersions(pathBin, retagged)
+	require.Nil(t, err)
+	require.Equal(t, "1.1.2", check.Recorded)
+	require.Equal(t, "1.1.1", check.Reported)
+	require.Empty(t, check.Mismatch)
+}
+
+func TestParanoid(t *testing.T) {
+	_ = useFakeGithub(t)
+	tool := GetToolStruct()
+	pathBin := t.TempDir()
+	require.Nil(t, Install(pathBin, tool))
+	modified, err := ModifiedBinaries(pathBin)
+	require.Nil(t, err)
+	require.Empty(t, modified)
+
+	executablePath, exists := ospath.GetExecutablePath(pathBin, tool.Name)
+	require.True(t, exists)
+	f, err := os.OpenFile(executablePath, os.O_WRONLY|os.O_APPEND, 0)
+	require.Nil(t, err)
+	_, err = f.WriteString("tampered")
+	require.Nil(t, err)
+	require.Nil(t, f.Close())
+
+	modified, err = ModifiedBinaries(pathBin)
+	require.Nil(t, err)
+	require.Equal(t, []string{tool.Name}, modified)
+	require.Empty(t, Broken(pathBin, tool))
+	Paranoid = true
+	defer func() { Paranoid = false }()
+	require.Equal(t, "modified", Broken(pathBin, tool))
+}
+
+func TestParanoidBinaries(t *testing.T) {
+	pathBin := t.TempDir()
+	tool := types.Tool{Name: "dnsx", Version: "1.1.1", Binaries: []string{"dnsx-helper"}}
+	for _, name := range []string{"dnsx", "dnsx-helper"} {
+		require.Nil(t, os.WriteFile(filepath.Join(pathBin, name+extIfFound), []byte(name), 0755))
+	}
+	record(pathBin, tool, "", state.SourceRelease, "")
+	st, err := state.Load(pathBin)
+	require.Nil(t, err)
+	hashes := st.Hashes()
+	require.Contains(t, hashes, "dnsx")
+	require.Contains(t, hashes, "dnsx-helper", "the additional binaries are hashed")
+
+	require.Nil(t, os.WriteFile(filepath.Join(pathBin, "dnsx-helper"+extIfFound), []byte("tampered"), 0755))
+	modified, err := ModifiedBinaries(pathBin)
+	require.Nil(t, err)
+	require.Equal(t, []string{"dnsx"}, modified)
+}
@@ -0,0 +1,267 @@
+package pkg
+
+import (
+	"archive/zip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/projectdiscovery/gologger"
+	"github.com/projectdiscovery/pdtm/pkg/state"
+	"github.com/projectdiscovery/pdtm/pkg/types"
+	fileutil "github.com/projectdiscovery/utils/file"
+)
+
+// DataPacks contains the directory of the tools whose releases are data
+// directories (eg. nuclei-templates) instead of binaries
+var DataPacks = map[string]string{
+	"nuclei-templates": defaultDataDir("nuclei-templates"),
+}
+
+// ErrUnmanagedDataDir is returned when the directory of a data pack exists
+// but wasn't installed by pdtm (eg. maintained by the tool itself)
+var ErrUnmanagedDataDir = errors.New("the directory isn't managed by pdtm")
+
+// ReplaceDataDir is asked before replacing a data pack directory pdtm has
+// no record of, the directory is kept when nil or when it returns false
+var ReplaceDataDir func(tool types.Tool, dir string) bool
+
+func defaultDataDir(name string) string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return name
+	}
+	return filepath.Join(home, name)
+}
+
+// IsDataPack returns true if the tool is a data pack
+func IsDataPack(tool types.Tool) bool {
+	_, ok := dataDir(tool)
+	return ok
+}
+
+func dataDir(tool types.Tool) (string, bool) {
+	for name, dir := range DataPacks {
+		if strings.EqualFold(name, tool.Name) {
+			return dir, true
+		}
+	}
+	return "", false
+}
+
+// DataPackVersion returns the version of the installed data pack
+func DataPackVersion(path string, tool types.Tool) (string, bool) {
+	dir, _ := dataDir(tool)
+	st, err := state.Load(path)
+	if err != nil {
+		return "", false
+	}
+	installed, ok := st.Get(tool.Name)
+	if !ok || installed.Source != state.SourceDataPack || !fileutil.FolderExists(dir) {
+		return "", false
+	}
+	// the data dir was moved (data-dirs) to a directory pdtm didn't install
+	if installed.Dir != "" && filepath.Clean(installed.Dir) != filepath.Clean(dir) {
+		return "", false
+	}
+	return installed.Version, true
+}
+
+// checkDataDir refuses to replace an existing data directory pdtm has no
+// record of unless ReplaceDataDir accepts it
+func checkDataDir(path string, tool types.Tool) error {
+	dir, _ := dataDir(tool)
+	if !fileutil.FolderExists(dir) {
+		return nil
+	}
+	if _, ok := DataPackVersion(path, tool); ok {
+		return nil
+	}
+	if ReplaceDataDir != nil && ReplaceDataDir(tool, dir) {
+		return nil
+	}
+	return fmt.Errorf("%w: %s (use -yes to replace it)", ErrUnmanagedDataDir, dir)
+}
+
+// installDataPack downloads the source archive of the data pack release to its directory
+func installDataPack(path string, tool types.Tool) error {
+	if _, ok := DataPackVersion(path, tool); ok {
+		return types.ErrIsInstalled
+	}
+	if err := checkDataDir(path, tool); err != nil {
+		return err
+	}
+	gologger.Info().Msgf("installing %s...", tool.Name)
+	tool, err := fetchDataPack(tool)
+	if err != nil {
+		return err
+	}
+	gologger.Info().Msgf("installed %s %s (%s)", tool.Name, tool.Version, au.BrightGreen("latest").String())
+	return recordDataPack(path, tool)
+}
+
+// updateDataPack replaces the data pack with its latest release
+func updateDataPack(path string, tool types.Tool) error {
+	installedVersion, ok := DataPackVersion(path, tool)
+	if !ok {
+		dir, _ := dataDir(tool)
+		return fmt.Errorf(types.ErrToolNotFound, tool.Name, dir)
+	}
+	if isPinned(tool, path) {
+		return types.ErrIsPinned
+	}
+	if tool.Version != "" && strings.EqualFold(strings.TrimPrefix(installedVersion, "v"), strings.TrimPrefix(tool.Version, "v")) {
+		return types.ErrIsUpToDate
+	}
+	gologger.Info().Msgf("updating %s...", tool.Name)
+	tool, err := fetchDataPack(tool)
+	if err != nil {
+		return err
+	}
+	gologger.Info().Msgf("updated %s to %s (%s)", tool.Name, tool.Version, au.BrightGreen("latest").String())
+	return recordDataPack(path, tool)
+}
+
+// removeDataPack removes the data pack directory
+func removeDataPack(path string, tool types.Tool) error {
+	dir, _ := dataDir(tool)
+	if _, ok := DataPackVersion(path, tool); !ok {
+		return fmt.Errorf(types.ErrToolNotFound, tool.Name, dir)
+	}
+	gologger.Info().Msgf("removing %s...", tool.Name)
+	if err := os.RemoveAll(dir); err != nil {
+		return err
+	}
+	forget(path, tool)
+	gologger.Info().Msgf("removed %s", tool.Name)
+	return nil
+}
+
+// fetchDataPack downloads and extracts the release of the data pack, the
+// previous directory is only replaced once the new one has been extracted
+func fetchDataPack(tool types.Tool) (types.Tool, error) {
+	if !isGithub() {
+		return tool, fmt.Errorf("%s is only available from github", tool.Name)
+	}
+	if tool.Version == "" {
+		release, err := Github.LatestRelease(context.Background(), tool.Repo)
+		if err != nil {
+			return tool, err
+		}
+		tool.Version = strings.TrimPrefix(release.GetTagName(), "v")
+	}
+	tag := "v" + strings.TrimPrefix(tool.Version, "v")
+	archive, err := Github.DownloadArchive(context.Background(), tool.Repo, tag)
+	if err != nil {
+		return tool, err
+	}
+	defer archive.Close()
+
+	dir, _ := dataDir(tool)
+	if err := os.MkdirAll(filepath.Dir(dir), os.ModePerm); err != nil {
+		return tool, err
+	}
+	tmpDir, err := os.MkdirTemp(filepath.Dir(dir), "."+filepath.Base(dir)+"-")
+	if err != nil {
+		return tool, err
+	}
+	defer os.RemoveAll(tmpDir)
+	if err := extractSourceZip(archive, tmpDir); err != nil {
+		return tool, err
+	}
+	return tool, replaceDir(tmpDir, dir)
+}
+
+// replaceDir replaces dir with tmpDir, the previous directory is put back
+// when the replacement fails
+func replaceDir(tmpDir, dir string) error {
+	oldDir := tmpDir + ".old"
+	if fileutil.FolderExists(dir) {
+		if err := os.Rename(dir, oldDir); err != nil {
+			return err
+		}
+		defer os.RemoveAll(oldDir)
+	}
+	if err := os.Rename(tmpDir, dir); err != nil {
+		_ = os.Rename(oldDir, dir)
+		return err
+	}
+	return nil
+}
+
+// extractSourceZip extracts a github source archive to dir stripping
+// the top level <repo>-<commit> directory
+func extractSourceZip(reader io.Reader, dir string) error {
+	tmpFile, err := os.CreateTemp("", "pdtm-*.zip")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmpFile.Name())
+	defer tmpFile.Close()
+	size, err := io.Copy(tmpFile, reader)
+	if err != nil {
+		return err
+	}
+	zipReader, err := zip.NewReader(tmpFile, size)
+	if err != nil {
+		return err
+	}
+	for _, f := range zipReader.File {
+		_, name, ok := strings.Cut(f.Name, "/")
+		if !ok || name == "" {
+			continue
+		}
+		target := filepath.Join(dir, filepath.FromSlash(name))
+		if !strings.HasPrefix(target, filepath.Clean(dir)+string(os.PathSeparator)) {
+			return fmt.Errorf("invalid file path in archive: %s", f.Name)
+		}
+		if f.FileInfo().IsDir() {
+			if err := os.MkdirAll(target, os.ModePerm); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := extractZipFile(f, target); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func extractZipFile(f *zip.File, target string) error {
+	if err := os.MkdirAll(filepath.Dir(target), os.ModePerm); err != nil {
+		return err
+	}
+	src, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	dst, err := os.OpenFile(target, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	defer dst.Close()
+	_, err = io.Copy(dst, src)
+	return err
+}
+
+func recordDataPack(path string, tool types.Tool) error {
+	dir, _ := dataDir(tool)
+	st, err := state.Load(path)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	installed := &state.Tool{Name: tool.Name, Version: tool.Version, Source: state.SourceDataPack, Origin: releaseOrigin(tool), Dir: dir, Installed: st.InstalledAt(tool.Name, now), Updated: &now, History: st.HistoryOf(tool.Name, tool.Version, now)}
+	if previous, ok := st.Get(tool.Name); ok {
+		installed.Pinned = previous.Pinned
+	}
+	st.Set(installed)
+	return st.Save()
+}
@@ -0,0 +1,57 @@
+package pkg
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/projectdiscovery/pdtm/pkg/state"
+	"github.com/projectdiscovery/pdtm/pkg/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckDataDir(t *testing.T) {
+	path, dataDir := t.TempDir(), filepath.Join(t.TempDir(), "nuclei-templates")
+	previous := DataPacks["nuclei-templates"]
+	DataPacks["nuclei-templates"] = dataDir
+	t.Cleanup(func() {
+		DataPacks["nuclei-templates"] = previous
+		ReplaceDataDir = nil
+	})
+	tool := types.Tool{Name: "nuclei-templates", Repo: "nuclei-templates"}
+
+	// a missing directory is installed
+	require.Nil(t, checkDataDir(path, tool))
+
+	// the directory maintained by nuclei is kept unless the user accepts
+	require.Nil(t, os.MkdirAll(dataDir, 0755))
+	err := checkDataDir(path, tool)
+	require.True(t, errors.Is(err, ErrUnmanagedDataDir))
+	var asked string
+	ReplaceDataDir = func(tool types.Tool, dir string) bool {
+		asked = dir
+		return true
+	}
+	require.Nil(t, checkDataDir(path, tool))
+	require.Equal(t, dataDir, asked)
+	ReplaceDataDir = nil
+
+	// the directory installed by pdtm is replaced without asking
+	st, err := state.Load(path)
+	require.Nil(t, err)
+	st.Set(&state.Tool{Name: tool.Name, Version: "9.0.0", Source: state.SourceDataPack, Dir: dataDir})
+	require.Nil(t, st.Save())
+	require.Nil(t, checkDataDir(path, tool))
+	version, ok := DataPackVersion(path, tool)
+	require.True(t, ok)
+	require.Equal(t, "9.0.0", version)
+
+	// a data dir moved to another existing directory isn't managed
+	DataPacks["nuclei-templates"] = t.TempDir()
+	_, ok = DataPackVersion(path, tool)
+	require.False(t, ok)
+	require.True(t, errors.Is(checkDataDir(path, tool), ErrUnmanagedDataDir))
+	require.NotNil(t, Remove(path, tool), "the unmanaged directory isn't removed")
+	require.DirExists(t, DataPacks["nuclei-templates"])
+}
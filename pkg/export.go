@@ -0,0 +1,104 @@
+package pkg
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/projectdiscovery/gologger"
+	"github.com/projectdiscovery/pdtm/pkg/types"
+)
+
+// ExportPlatforms contains the platforms (os/arch) the release archives are
+// exported for
+var ExportPlatforms = []string{
+	"linux/amd64", "linux/arm64", "linux/386", "linux/arm",
+	"darwin/amd64", "darwin/arm64",
+	"windows/amd64", "windows/arm64", "windows/386",
+}
+
+// ExportedAsset is the release archive of a tool for a platform
+type ExportedAsset struct {
+	OS     string `json:"os" yaml:"os"`
+	Arch   string `json:"arch" yaml:"arch"`
+	Name   string `json:"name" yaml:"name"`
+	URL    string `json:"url" yaml:"url"`
+	SHA256 string `json:"sha256,omitempty" yaml:"sha256,omitempty"`
+}
+
+// ExportedTool is a tool and its release archives, installable without pdtm
+type ExportedTool struct {
+	Name    string          `json:"name" yaml:"name"`
+	Version string          `json:"version" yaml:"version"`
+	Assets  []ExportedAsset `json:"assets" yaml:"assets"`
+}
+
+// Asset returns the release archive of the tool for the platform
+func (t ExportedTool) Asset(goos, arch string) (ExportedAsset, bool) {
+	for _, asset := range t.Assets {
+		if asset.OS == goos && asset.Arch == arch {
+			return asset, true
+		}
+	}
+	return ExportedAsset{}, false
+}
+
+// HasOS returns true if the tool has a release archive for the os
+func (t ExportedTool) HasOS(goos string) bool {
+	for _, asset := range t.Assets {
+		if asset.OS == goos {
+			return true
+		}
+	}
+	return false
+}
+
+// Export returns the download url and sha256 of the release archives of the
+// tool (resolved to the exported version) for each of the ExportPlatforms
+func Export(tool types.Tool) (ExportedTool, error) {
+	exported := ExportedTool{Name: tool.Name, Version: tool.Version}
+	checksums := tool.Checksums
+	if released, err := releaseChecksums(tool); err != nil {
+		gologger.Verbose().Msgf("could not download the checksums of %s: %s", tool.Name, err)
+	} else if len(released) > 0 {
+		checksums = released
+	}
+	for _, platform := range ExportPlatforms {
+		goos, arch, _ := strings.Cut(platform, "/")
+		baseName, err := assetBaseName(tool, goos, arch)
+		if err != nil {
+			return exported, err
+		}
+		for name, ref := range tool.Assets {
+			if !matchAsset(name, baseName) {
+				continue
+			}
+			assetURL, err := downloadURL(tool, name, ref)
+			if err != nil {
+				return exported, err
+			}
+			checksum := checksums[name]
+			if checksum == "" {
+				checksum = tool.Digests[name]
+			}
+			exported.Assets = append(exported.Assets, ExportedAsset{OS: goos, Arch: arch, Name: name, URL: assetURL, SHA256: checksum})
+			break
+		}
+	}
+	if len(exported.Assets) == 0 {
+		return exported, fmt.Errorf("no release archive found for %s %s", tool.Name, tool.Version)
+	}
+	return exported, nil
+}
+
+// downloadURL returns the public download url of the release asset, the
+// github assets are referenced by id
+func downloadURL(tool types.Tool, name, ref string) (string, error) {
+	if u, err := url.Parse(ref); err == nil && (u.Scheme == "http" || u.Scheme == "https") {
+		return ref, nil
+	}
+	if !isGithub() {
+		return "", fmt.Errorf("no download url for the asset %s of %s", name, tool.Name)
+	}
+	return fmt.Sprintf("https://github.com/%s/%s/releases/download/v%s/%s", types.Organization, tool.Repo, strings.TrimPrefix(tool.Version, "v"), name), nil
+}
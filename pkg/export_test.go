@@ -0,0 +1,46 @@
+package pkg
+
+import (
+	"testing"
+
+	"github.com/projectdiscovery/pdtm/pkg/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExport(t *testing.T) {
+	defaultProvider := ActiveProvider
+	defer func() { ActiveProvider = defaultProvider }()
+	ActiveProvider = staticProvider{}
+
+	tool := types.Tool{
+		Name:    "dnsx",
+		Version: "1.1.0",
+		Assets: map[string]string{
+			"dnsx_1.1.0_linux_amd64.zip":   "https://example.com/dnsx_1.1.0_linux_amd64.zip",
+			"dnsx_1.1.0_macOS_arm64.zip":   "https://example.com/dnsx_1.1.0_macOS_arm64.zip",
+			"dnsx_1.1.0_windows_386.zip":   "https://example.com/dnsx_1.1.0_windows_386.zip",
+			"dnsx_1.1.0_freebsd_amd64.zip": "https://example.com/dnsx_1.1.0_freebsd_amd64.zip",
+		},
+		Checksums: map[string]string{"dnsx_1.1.0_linux_amd64.zip": "abc"},
+	}
+	exported, err := Export(tool)
+	require.Nil(t, err)
+	require.Len(t, exported.Assets, 3)
+
+	asset, ok := exported.Asset("linux", "amd64")
+	require.True(t, ok)
+	require.Equal(t, "https://example.com/dnsx_1.1.0_linux_amd64.zip", asset.URL)
+	require.Equal(t, "abc", asset.SHA256)
+	asset, ok = exported.Asset("darwin", "arm64")
+	require.True(t, ok)
+	require.Equal(t, "dnsx_1.1.0_macOS_arm64.zip", asset.Name)
+	_, ok = exported.Asset("linux", "arm64")
+	require.False(t, ok)
+
+	// github assets are referenced by id
+	ActiveProvider = &GithubProvider{}
+	tool = types.Tool{Name: "dnsx", Repo: "dnsx", Version: "1.1.0", Assets: map[string]string{"dnsx_1.1.0_linux_amd64.zip": "42"}}
+	exported, err = Export(tool)
+	require.Nil(t, err)
+	require.Equal(t, "https://github.com/projectdiscovery/dnsx/releases/download/v1.1.0/dnsx_1.1.0_linux_amd64.zip", exported.Assets[0].URL)
+}
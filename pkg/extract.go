@@ -0,0 +1,253 @@
+package pkg
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/projectdiscovery/pdtm/pkg/types"
+)
+
+// ExtraBinaries contains the additional binaries shipped in the release
+// archives of the tools (eg. helper tools), extracted along with them
+var ExtraBinaries map[string][]string
+
+// ExtractWorkers is the maximum number of binaries extracted concurrently
+// from a zip archive
+var ExtractWorkers = 4
+
+// extractBufferSize is the size of the buffers copying the binaries out of
+// the archives, the entries are streamed to disk instead of read in memory
+const extractBufferSize = 32 * 1024
+
+var extractBuffers = sync.Pool{
+	New: func() any {
+		buffer := make([]byte, extractBufferSize)
+		return &buffer
+	},
+}
+
+// binaryNames returns the names of the binaries extracted from the release
+// archive of the tool, the binary of the tool first
+func binaryNames(tool types.Tool) []string {
+	names := append([]string{tool.Name}, tool.Binaries...)
+	for name, extra := range ExtraBinaries {
+		if strings.EqualFold(name, tool.Name) {
+			names = append(names, extra...)
+		}
+	}
+	return names
+}
+
+// extractor extracts the binaries of a tool from its release asset
+type extractor struct {
+	path  string
+	names []string
+	event Event
+}
+
+func newExtractor(tool types.Tool, path string, event Event) *extractor {
+	event.Event = EventExtractFile
+	return &extractor{path: path, names: binaryNames(tool), event: event}
+}
+
+// extractAsset extracts the binaries of the tool from the release asset to path
+func (x *extractor) extractAsset(assetName string, reader io.Reader) error {
+	switch archiveExtension(assetName) {
+	case ".zip":
+		return x.extractZip(reader)
+	case ".tar.gz":
+		gzipReader, err := gzip.NewReader(reader)
+		if err != nil {
+			return err
+		}
+		return x.extractTar(tar.NewReader(gzipReader))
+	case ".tar.zst":
+		zstdReader, err := zstd.NewReader(reader)
+		if err != nil {
+			return err
+		}
+		defer zstdReader.Close()
+		return x.extractTar(tar.NewReader(zstdReader))
+	case ".zst":
+		return x.extractZst(reader)
+	default:
+		return fmt.Errorf("unsupported archive format: %s", assetName)
+	}
+}
+
+// binary returns the file name of the archive entry if it's one of the binaries
+func (x *extractor) binary(entry string) (string, bool) {
+	name := filepath.Base(filepath.FromSlash(entry))
+	for _, binary := range x.names {
+		if strings.EqualFold(strings.TrimSuffix(name, extIfFound), binary) {
+			return name, true
+		}
+	}
+	return "", false
+}
+
+// extractTar extracts the binaries from the tar archive, whose entries can
+// only be read in order
+func (x *extractor) extractTar(tarReader *tar.Reader) error {
+	for {
+		header, err := tarReader.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		name, ok := x.binary(header.Name)
+		if !ok || !header.FileInfo().Mode().IsRegular() {
+			continue
+		}
+		if err := x.write(name, tarReader, header.Size); err != nil {
+			return err
+		}
+	}
+}
+
+// extractZip extracts the binaries from the zip archive, up to ExtractWorkers
+// at once as its entries can be read independently
+func (x *extractor) extractZip(reader io.Reader) error {
+	readerAt, size, err := sizedReaderAt(reader)
+	if err != nil {
+		return err
+	}
+	zipReader, err := zip.NewReader(readerAt, size)
+	if err != nil {
+		return err
+	}
+	files := make(map[string]*zip.File)
+	for _, f := range zipReader.File {
+		if name, ok := x.binary(f.Name); ok && f.Mode().IsRegular() {
+			if _, ok := files[name]; !ok {
+				files[name] = f
+			}
+		}
+	}
+
+	workers := ExtractWorkers
+	if workers < 1 {
+		workers = 1
+	}
+	var (
+		wg    sync.WaitGroup
+		mutex sync.Mutex
+		errs  []error
+		slots = make(chan struct{}, workers)
+	)
+	for name, f := range files {
+		wg.Add(1)
+		slots <- struct{}{}
+		go func(name string, f *zip.File) {
+			defer func() {
+				<-slots
+				wg.Done()
+			}()
+			if err := x.extractZipFile(name, f); err != nil {
+				mutex.Lock()
+				errs = append(errs, fmt.Errorf("%s: %w", name, err))
+				mutex.Unlock()
+			}
+		}(name, f)
+	}
+	wg.Wait()
+	if len(errs) > 0 {
+		return errs[0]
+	}
+	return nil
+}
+
+func (x *extractor) extractZipFile(name string, f *zip.File) error {
+	fileInArchive, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer fileInArchive.Close()
+	return x.write(name, fileInArchive, int64(f.UncompressedSize64))
+}
+
+// sizedReaderAt returns the reader for random access, only reading it in
+// memory when it isn't an in-memory archive or a file already
+func sizedReaderAt(reader io.Reader) (io.ReaderAt, int64, error) {
+	switch r := reader.(type) {
+	case *bytes.Reader:
+		return r, r.Size(), nil
+	case *os.File:
+		info, err := r.Stat()
+		if err != nil {
+			return nil, 0, err
+		}
+		return r, info.Size(), nil
+	}
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, 0, err
+	}
+	return bytes.NewReader(data), int64(len(data)), nil
+}
+
+// extractZst writes the binary of the tool compressed with zstd
+func (x *extractor) extractZst(reader io.Reader) error {
+	zstdReader, err := zstd.NewReader(reader)
+	if err != nil {
+		return err
+	}
+	defer zstdReader.Close()
+	name := x.names[0]
+	if runtime.GOOS == "windows" {
+		name += extIfFound
+	}
+	return x.write(name, zstdReader, 0)
+}
+
+// write streams the binary to the install path through a fixed size buffer,
+// reporting the bytes written with extract_progress events and an
+// extract_file event once done
+func (x *extractor) write(name string, reader io.Reader, size int64) error {
+	if err := os.MkdirAll(x.path, os.ModePerm); err != nil {
+		return err
+	}
+	filePath := filepath.Join(x.path, name)
+	// the binary may be a hard link to the store, which mustn't be written through
+	_ = os.Remove(filePath)
+	dstFile, err := os.OpenFile(filePath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0755)
+	if err != nil {
+		return err
+	}
+	progress := &progressWriter{Writer: dstFile, event: x.event, last: time.Now()}
+	progress.event.Event, progress.event.File, progress.event.Total = EventExtractProgress, name, size
+	buffer := extractBuffers.Get().(*[]byte)
+	written, err := io.CopyBuffer(progress, reader, *buffer)
+	extractBuffers.Put(buffer)
+	if closeErr := dstFile.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		_ = os.Remove(filePath)
+		return err
+	}
+	// the mode given to OpenFile is restricted by the umask
+	if err := os.Chmod(filePath, 0755); err != nil {
+		return err
+	}
+	clearQuarantine(filePath)
+	labelBinary(filePath)
+
+	event := x.event
+	event.File, event.Bytes, event.Total = name, written, size
+	emit(event)
+	return nil
+}
@@ -2,18 +2,221 @@ package pkg
 
 import (
 	"context"
+	"fmt"
+	"io"
 	"net/http"
 	"os"
+	"strconv"
+	"strings"
 
 	"github.com/google/go-github/github"
+	"github.com/projectdiscovery/gologger"
+	"github.com/projectdiscovery/pdtm/pkg/httpclient"
+	"github.com/projectdiscovery/pdtm/pkg/types"
 	"golang.org/x/oauth2"
 )
 
 func GithubClient() *github.Client {
-	var httpclient *http.Client
+	githubClient := github.NewClient(githubHTTPClient())
+	return githubClient
+}
+
+// githubHTTPClient returns a http client authenticated with the github token (if any)
+func githubHTTPClient() *http.Client {
+	// github client modifies the redirect policy of the given client so it
+	// can't use the shared client directly but still reuses its transport
+	client := httpclient.New()
 	if token := os.Getenv("GITHUB_TOKEN"); token != "" {
-		httpclient = oauth2.NewClient(context.Background(), oauth2.StaticTokenSource(&oauth2.Token{AccessToken: token}))
+		ctx := context.WithValue(context.Background(), oauth2.HTTPClient, client)
+		client = oauth2.NewClient(ctx, oauth2.StaticTokenSource(&oauth2.Token{AccessToken: token}))
 	}
-	githubClient := github.NewClient(httpclient)
-	return githubClient
+	return client
+}
+
+// GithubAPI is the part of the github api used to install and update the
+// tools, it can be replaced with an in-memory fake (see githubtest) to test
+// the install flows without hitting github.com
+type GithubAPI interface {
+	// LatestRelease returns the latest release of the repository
+	LatestRelease(ctx context.Context, repo string) (*types.GithubRelease, error)
+	// ReleaseByTag returns the release of the repository with the given tag
+	ReleaseByTag(ctx context.Context, repo, tag string) (*types.GithubRelease, error)
+	// ListReleases returns the latest releases of the repository, newest first
+	ListReleases(ctx context.Context, repo string, count int) ([]*types.GithubRelease, error)
+	// ListArtifacts returns the workflow artifacts of the repository, newest first
+	ListArtifacts(ctx context.Context, repo string) ([]types.WorkflowArtifact, error)
+	// DownloadAsset returns the content of the release asset with the given id
+	DownloadAsset(ctx context.Context, repo string, id int64) (io.ReadCloser, error)
+	// DownloadArchive returns the source archive (zipball) of the repository at ref
+	DownloadArchive(ctx context.Context, repo, ref string) (io.ReadCloser, error)
+	// Download returns the content of a github url (eg. a workflow artifact)
+	Download(ctx context.Context, url string) (io.ReadCloser, error)
+	// RateLimit returns the core rate limit of the api, the call doesn't count
+	RateLimit(ctx context.Context) (*github.Rate, error)
+}
+
+// Github is the github api used to fetch the projectdiscovery releases
+var Github GithubAPI = githubAPI{}
+
+// githubAPI is the GithubAPI of github.com (or of the -source cache server)
+type githubAPI struct{}
+
+func (githubAPI) LatestRelease(ctx context.Context, repo string) (*types.GithubRelease, error) {
+	var release types.GithubRelease
+	err := githubGet(ctx, fmt.Sprintf("repos/%s/%s/releases/latest", types.Organization, repo), &release)
+	return &release, err
+}
+
+func (githubAPI) ReleaseByTag(ctx context.Context, repo, tag string) (*types.GithubRelease, error) {
+	var release types.GithubRelease
+	err := githubGet(ctx, fmt.Sprintf("repos/%s/%s/releases/tags/%s", types.Organization, repo, tag), &release)
+	return &release, err
+}
+
+func (githubAPI) ListReleases(ctx context.Context, repo string, count int) ([]*types.GithubRelease, error) {
+	var releases []*types.GithubRelease
+	err := githubGet(ctx, fmt.Sprintf("repos/%s/%s/releases?per_page=%d", types.Organization, repo, count), &releases)
+	return releases, err
+}
+
+func (githubAPI) ListArtifacts(ctx context.Context, repo string) ([]types.WorkflowArtifact, error) {
+	var artifacts struct {
+		Artifacts []types.WorkflowArtifact `json:"artifacts"`
+	}
+	err := githubGet(ctx, fmt.Sprintf("repos/%s/%s/actions/artifacts?per_page=100", types.Organization, repo), &artifacts)
+	return artifacts.Artifacts, err
+}
+
+func (githubAPI) DownloadAsset(ctx context.Context, repo string, id int64) (io.ReadCloser, error) {
+	rc, redirectURL, err := GithubClient().Repositories.DownloadReleaseAsset(ctx, types.Organization, repo, id)
+	if err != nil {
+		if arlErr, ok := err.(*github.AbuseRateLimitError); ok {
+			// Provide user with more info regarding the rate limit
+			gologger.Error().Msgf("error for remaining request per hour: %s, RetryAfter: %s", err.Error(), arlErr.RetryAfter)
+		}
+		return nil, tokenError(err)
+	}
+	// the asset is returned directly instead of redirecting when
+	// downloaded through a cache server (-source)
+	if rc != nil {
+		return rc, nil
+	}
+	return download(ctx, httpclient.Client, redirectURL)
+}
+
+func (githubAPI) DownloadArchive(ctx context.Context, repo, ref string) (io.ReadCloser, error) {
+	archiveURL, _, err := GithubClient().Repositories.GetArchiveLink(ctx, types.Organization, repo, github.Zipball, &github.RepositoryContentGetOptions{Ref: ref})
+	if err != nil {
+		return nil, err
+	}
+	return downloadAuthenticated(ctx, archiveURL.String())
+}
+
+func (githubAPI) Download(ctx context.Context, url string) (io.ReadCloser, error) {
+	return downloadAuthenticated(ctx, url)
+}
+
+func (githubAPI) RateLimit(ctx context.Context) (*github.Rate, error) {
+	limits, _, err := GithubClient().RateLimits(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return limits.GetCore(), nil
+}
+
+// githubGet decodes the response of the github api endpoint into v, the
+// responses are decoded by pdtm since the github client drops unknown fields
+func githubGet(ctx context.Context, endpoint string, v interface{}) error {
+	client := GithubClient()
+	req, err := client.NewRequest("GET", endpoint, nil)
+	if err != nil {
+		return err
+	}
+	_, err = client.Do(ctx, req, v)
+	return tokenError(err)
+}
+
+// downloadAuthenticated downloads the github url with the token, the
+// redirect (eg. to the blob storage of the workflow artifacts) is followed
+// without it since the token is only meant for github
+func downloadAuthenticated(ctx context.Context, rawURL string) (io.ReadCloser, error) {
+	client := githubHTTPClient()
+	client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		return http.ErrUseLastResponse
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return resp.Body, nil
+	case http.StatusMovedPermanently, http.StatusFound, http.StatusSeeOther, http.StatusTemporaryRedirect, http.StatusPermanentRedirect:
+		resp.Body.Close()
+		location, err := resp.Location()
+		if err != nil {
+			return nil, err
+		}
+		return download(ctx, httpclient.Client, location.String())
+	default:
+		resp.Body.Close()
+		return nil, fmt.Errorf("unexpected status code %d while downloading %s", resp.StatusCode, rawURL)
+	}
+}
+
+// download returns the body of a successful GET request of the url
+func download(ctx context.Context, client *http.Client, url string) (io.ReadCloser, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("unexpected status code %d while downloading %s", resp.StatusCode, url)
+	}
+	return resp.Body, nil
+}
+
+// GithubProvider fetches the tools from the projectdiscovery github releases
+type GithubProvider struct{}
+
+// Latest returns the tool unchanged since the pdtm api already
+// resolves the tools to their latest github release
+func (p *GithubProvider) Latest(tool types.Tool) (types.Tool, error) {
+	return tool, nil
+}
+
+// Release returns the tool resolved to the github release with the given version
+func (p *GithubProvider) Release(tool types.Tool, version string) (types.Tool, error) {
+	tag := "v" + strings.TrimPrefix(version, "v")
+	release, err := Github.ReleaseByTag(context.Background(), tool.Repo, tag)
+	if err != nil {
+		return tool, err
+	}
+	return withRelease(tool, release), nil
+}
+
+// Origin returns the github repository of the tool
+func (p *GithubProvider) Origin(tool types.Tool) string {
+	return fmt.Sprintf("github.com/%s/%s", types.Organization, tool.Repo)
+}
+
+// Download returns the content of the asset referenced either by
+// its github release asset id or by an (authenticated) download url
+func (p *GithubProvider) Download(tool types.Tool, ref string) (io.ReadCloser, error) {
+	if id, err := strconv.ParseInt(ref, 10, 64); err == nil {
+		if body := downloadFromMirror(tool, ref); body != nil {
+			return body, nil
+		}
+		return Github.DownloadAsset(context.Background(), tool.Repo, id)
+	}
+	return Github.Download(context.Background(), ref)
 }
@@ -0,0 +1,36 @@
+package pkg
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDownloadAuthenticatedRedirect(t *testing.T) {
+	t.Setenv("GITHUB_TOKEN", "ghp_test")
+	var blobAuthorization string
+	blob := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		blobAuthorization = r.Header.Get("Authorization")
+		_, _ = w.Write([]byte("artifact"))
+	}))
+	defer blob.Close()
+	var apiAuthorization string
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		apiAuthorization = r.Header.Get("Authorization")
+		http.Redirect(w, r, blob.URL+"/artifact.zip?sig=signed", http.StatusFound)
+	}))
+	defer api.Close()
+
+	body, err := downloadAuthenticated(context.Background(), api.URL+"/repos/projectdiscovery/nuclei/actions/artifacts/1/zip")
+	require.Nil(t, err)
+	defer body.Close()
+	data, err := io.ReadAll(body)
+	require.Nil(t, err)
+	require.Equal(t, "artifact", string(data))
+	require.Equal(t, "Bearer ghp_test", apiAuthorization)
+	require.Empty(t, blobAuthorization, "the token was sent to the redirect host")
+}
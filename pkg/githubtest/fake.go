@@ -0,0 +1,272 @@
+// Package githubtest provides an in-memory fake of the github api used by
+// pdtm, so the install and update flows can be tested without github.com:
+//
+//	fake, err := githubtest.NewFromFixtures()
+//	...
+//	pkg.Github = fake
+//
+// The release assets without content are served as generated archives
+// containing an executable script printing the version of the release, the
+// checksums files of the releases as the sha256 of these archives.
+package githubtest
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"embed"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/go-github/github"
+	"github.com/projectdiscovery/pdtm/pkg/types"
+)
+
+// fixtures contains releases recorded from the github list releases api
+// (trimmed to the fields used by pdtm), eg.
+//
+//	curl https://api.github.com/repos/projectdiscovery/dnsx/releases > testdata/dnsx.json
+//
+//go:embed testdata/*.json
+var fixtures embed.FS
+
+// NotFoundError is returned for the releases, assets and urls unknown to the fake
+type NotFoundError struct {
+	What string
+}
+
+func (e *NotFoundError) Error() string {
+	return e.What + " not found"
+}
+
+// Fake is an in-memory github api, its fields can be modified before use
+type Fake struct {
+	// Releases contains the releases of each repository, newest first
+	Releases map[string][]*types.GithubRelease
+	// Assets contains the content of the release assets by id
+	Assets map[int64][]byte
+	// Archives contains the source archives by repository and ref (repo@ref)
+	Archives map[string][]byte
+	// Artifacts contains the workflow artifacts of each repository, newest first
+	Artifacts map[string][]types.WorkflowArtifact
+	// URLs contains the content served by url
+	URLs map[string][]byte
+	// Rate is the rate limit returned by RateLimit
+	Rate github.Rate
+
+	mu sync.Mutex
+	// requests counts the calls by method
+	requests map[string]int
+}
+
+// New returns an empty fake
+func New() *Fake {
+	return &Fake{
+		Releases:  make(map[string][]*types.GithubRelease),
+		Assets:    make(map[int64][]byte),
+		Archives:  make(map[string][]byte),
+		Artifacts: make(map[string][]types.WorkflowArtifact),
+		URLs:      make(map[string][]byte),
+		requests:  make(map[string]int),
+	}
+}
+
+// NewFromFixtures returns a fake serving the recorded releases of the
+// fixtures, the repository is the name of the fixture (eg. dnsx.json)
+func NewFromFixtures() (*Fake, error) {
+	f := New()
+	entries, err := fixtures.ReadDir("testdata")
+	if err != nil {
+		return nil, err
+	}
+	for _, entry := range entries {
+		data, err := fixtures.ReadFile(path.Join("testdata", entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		var releases []*types.GithubRelease
+		if err := json.Unmarshal(data, &releases); err != nil {
+			return nil, fmt.Errorf("invalid fixture %s: %w", entry.Name(), err)
+		}
+		f.Releases[strings.TrimSuffix(entry.Name(), ".json")] = releases
+	}
+	return f, nil
+}
+
+// Requests returns the number of calls of the given method (eg. DownloadAsset)
+func (f *Fake) Requests(method string) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.requests[method]
+}
+
+func (f *Fake) called(method string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.requests[method]++
+}
+
+// LatestRelease returns the newest release of the repository which isn't a
+// draft nor a pre-release
+func (f *Fake) LatestRelease(ctx context.Context, repo string) (*types.GithubRelease, error) {
+	f.called("LatestRelease")
+	for _, release := range f.Releases[repo] {
+		if !release.GetDraft() && !release.GetPrerelease() {
+			return release, nil
+		}
+	}
+	return nil, &NotFoundError{What: "latest release of " + repo}
+}
+
+// ReleaseByTag returns the release of the repository with the given tag
+func (f *Fake) ReleaseByTag(ctx context.Context, repo, tag string) (*types.GithubRelease, error) {
+	f.called("ReleaseByTag")
+	for _, release := range f.Releases[repo] {
+		if release.GetTagName() == tag {
+			return release, nil
+		}
+	}
+	return nil, &NotFoundError{What: fmt.Sprintf("release %s of %s", tag, repo)}
+}
+
+// ListReleases returns the count newest releases of the repository
+func (f *Fake) ListReleases(ctx context.Context, repo string, count int) ([]*types.GithubRelease, error) {
+	f.called("ListReleases")
+	releases := f.Releases[repo]
+	if count > 0 && len(releases) > count {
+		releases = releases[:count]
+	}
+	return releases, nil
+}
+
+// ListArtifacts returns the workflow artifacts of the repository
+func (f *Fake) ListArtifacts(ctx context.Context, repo string) ([]types.WorkflowArtifact, error) {
+	f.called("ListArtifacts")
+	return f.Artifacts[repo], nil
+}
+
+// DownloadAsset returns the content of the release asset, a stub archive
+// (or checksums file) when its content isn't set
+func (f *Fake) DownloadAsset(ctx context.Context, repo string, id int64) (io.ReadCloser, error) {
+	f.called("DownloadAsset")
+	if data, ok := f.Assets[id]; ok {
+		return io.NopCloser(bytes.NewReader(data)), nil
+	}
+	for _, release := range f.Releases[repo] {
+		for _, asset := range release.Assets {
+			if asset.GetID() != id {
+				continue
+			}
+			data, err := f.stub(repo, release, asset.GetName())
+			if err != nil {
+				return nil, err
+			}
+			return io.NopCloser(bytes.NewReader(data)), nil
+		}
+	}
+	return nil, &NotFoundError{What: fmt.Sprintf("asset %d of %s", id, repo)}
+}
+
+// DownloadArchive returns the source archive of the repository at ref
+func (f *Fake) DownloadArchive(ctx context.Context, repo, ref string) (io.ReadCloser, error) {
+	f.called("DownloadArchive")
+	data, ok := f.Archives[repo+"@"+ref]
+	if !ok {
+		return nil, &NotFoundError{What: fmt.Sprintf("archive of %s at %s", repo, ref)}
+	}
+	return io.NopCloser(bytes.NewReader(data)), nil
+}
+
+// Download returns the content of the url
+func (f *Fake) Download(ctx context.Context, url string) (io.ReadCloser, error) {
+	f.called("Download")
+	data, ok := f.URLs[url]
+	if !ok {
+		return nil, &NotFoundError{What: url}
+	}
+	return io.NopCloser(bytes.NewReader(data)), nil
+}
+
+// RateLimit returns the rate limit of the fake
+func (f *Fake) RateLimit(ctx context.Context) (*github.Rate, error) {
+	f.called("RateLimit")
+	rate := f.Rate
+	return &rate, nil
+}
+
+// stub returns the generated content of the asset of the release
+func (f *Fake) stub(repo string, release *types.GithubRelease, name string) ([]byte, error) {
+	version := strings.TrimPrefix(release.GetTagName(), "v")
+	if !strings.HasSuffix(name, "_checksums.txt") {
+		return StubArchive(name, repo, version)
+	}
+	var checksums strings.Builder
+	for _, asset := range release.Assets {
+		if asset.GetName() == name {
+			continue
+		}
+		data, err := StubArchive(asset.GetName(), repo, version)
+		if err != nil {
+			return nil, err
+		}
+		sum := sha256.Sum256(data)
+		fmt.Fprintf(&checksums, "%s  %s\n", hex.EncodeToString(sum[:]), asset.GetName())
+	}
+	return []byte(checksums.String()), nil
+}
+
+// StubArchive returns a zip or tar.gz archive (depending on the name of the
+// asset) containing an executable script named binary (binary.exe for the
+// windows assets) printing the version, the archive is always the same for
+// the same arguments
+func StubArchive(name, binary, version string) ([]byte, error) {
+	if strings.Contains(strings.ToLower(name), "windows") {
+		binary += ".exe"
+	}
+	script := []byte(fmt.Sprintf("#!/bin/sh\necho \"Current Version: v%s\"\n", version))
+
+	var buf bytes.Buffer
+	switch {
+	case strings.HasSuffix(name, ".zip"):
+		zw := zip.NewWriter(&buf)
+		header := &zip.FileHeader{Name: binary, Method: zip.Deflate, Modified: time.Unix(0, 0).UTC()}
+		header.SetMode(0755)
+		w, err := zw.CreateHeader(header)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := w.Write(script); err != nil {
+			return nil, err
+		}
+		if err := zw.Close(); err != nil {
+			return nil, err
+		}
+	case strings.HasSuffix(name, ".tar.gz"):
+		gw := gzip.NewWriter(&buf)
+		tw := tar.NewWriter(gw)
+		if err := tw.WriteHeader(&tar.Header{Name: binary, Mode: 0755, Size: int64(len(script))}); err != nil {
+			return nil, err
+		}
+		if _, err := tw.Write(script); err != nil {
+			return nil, err
+		}
+		if err := tw.Close(); err != nil {
+			return nil, err
+		}
+		if err := gw.Close(); err != nil {
+			return nil, err
+		}
+	default:
+		return script, nil
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,150 @@
+package pkg
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/projectdiscovery/pdtm/pkg/httpclient"
+	"github.com/projectdiscovery/pdtm/pkg/types"
+)
+
+const defaultGitlabURL = "https://gitlab.com"
+
+// GitlabProvider fetches the tools from the releases of gitlab projects
+// (eg. internal mirrors of the projectdiscovery repositories)
+type GitlabProvider struct {
+	baseURL *url.URL
+	group   string
+	token   string
+}
+
+type gitlabRelease struct {
+	TagName         string `json:"tag_name"`
+	UpcomingRelease bool   `json:"upcoming_release"`
+	Assets          struct {
+		Links []gitlabLink `json:"links"`
+	} `json:"assets"`
+}
+
+type gitlabLink struct {
+	Name           string `json:"name"`
+	URL            string `json:"url"`
+	DirectAssetURL string `json:"direct_asset_url"`
+}
+
+// NewGitlabProvider returns a gitlab provider, the token can reference an
+// environment variable ($NAME) and falls back to $GITLAB_TOKEN
+func NewGitlabProvider(config types.ProviderConfig) (*GitlabProvider, error) {
+	rawURL := config.URL
+	if rawURL == "" {
+		rawURL = defaultGitlabURL
+	}
+	baseURL, err := url.Parse(strings.TrimSuffix(rawURL, "/"))
+	if err != nil {
+		return nil, err
+	}
+	if baseURL.Scheme == "" || baseURL.Host == "" {
+		return nil, fmt.Errorf("invalid gitlab url %s", rawURL)
+	}
+	p := &GitlabProvider{baseURL: baseURL, group: config.Group, token: os.ExpandEnv(config.Token)}
+	if p.group == "" {
+		p.group = types.Organization
+	}
+	if p.token == "" {
+		p.token = os.Getenv("GITLAB_TOKEN")
+	}
+	return p, nil
+}
+
+// Latest returns the tool resolved to the latest gitlab release of the project
+func (p *GitlabProvider) Latest(tool types.Tool) (types.Tool, error) {
+	var releases []gitlabRelease
+	if err := p.get(p.projectURL(tool)+"/releases?per_page=20", &releases); err != nil {
+		return tool, err
+	}
+	// releases are listed newest first
+	for _, release := range releases {
+		if release.UpcomingRelease {
+			continue
+		}
+		return withGitlabRelease(tool, release), nil
+	}
+	return tool, fmt.Errorf("no releases found for %s", tool.Name)
+}
+
+// Release returns the tool resolved to the gitlab release with the given version
+func (p *GitlabProvider) Release(tool types.Tool, version string) (types.Tool, error) {
+	tag := "v" + strings.TrimPrefix(version, "v")
+	var release gitlabRelease
+	if err := p.get(p.projectURL(tool)+"/releases/"+url.PathEscape(tag), &release); err != nil {
+		return tool, err
+	}
+	return withGitlabRelease(tool, release), nil
+}
+
+// Download returns the content of the release asset link
+func (p *GitlabProvider) Download(tool types.Tool, ref string) (io.ReadCloser, error) {
+	resp, err := p.do(ref)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("unexpected status code %d while downloading %s", resp.StatusCode, tool.Name)
+	}
+	return resp.Body, nil
+}
+
+// Origin returns the gitlab project of the tool
+func (p *GitlabProvider) Origin(tool types.Tool) string {
+	return fmt.Sprintf("%s/%s/%s", p.baseURL.Host, p.group, tool.Repo)
+}
+
+func (p *GitlabProvider) projectURL(tool types.Tool) string {
+	return fmt.Sprintf("%s/api/v4/projects/%s", p.baseURL, url.PathEscape(p.group+"/"+tool.Repo))
+}
+
+func (p *GitlabProvider) get(rawURL string, v interface{}) error {
+	resp, err := p.do(rawURL)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status code %d from %s", resp.StatusCode, p.baseURL.Host)
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+// do requests the given url, the token is only sent to the gitlab instance
+// since asset links may point to other hosts
+func (p *GitlabProvider) do(rawURL string) (*http.Response, error) {
+	req, err := http.NewRequest(http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	if p.token != "" && strings.EqualFold(req.URL.Host, p.baseURL.Host) {
+		req.Header.Set("PRIVATE-TOKEN", p.token)
+	}
+	return httpclient.Client.Do(req)
+}
+
+// withGitlabRelease returns the tool with the version and assets of the given release
+func withGitlabRelease(tool types.Tool, release gitlabRelease) types.Tool {
+	tool.Version = strings.TrimPrefix(release.TagName, "v")
+	tool.Assets = make(map[string]string, len(release.Assets.Links))
+	tool.AssetSizes = nil
+	for _, link := range release.Assets.Links {
+		assetURL := link.DirectAssetURL
+		if assetURL == "" {
+			assetURL = link.URL
+		}
+		tool.Assets[link.Name] = assetURL
+	}
+	return tool
+}
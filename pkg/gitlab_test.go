@@ -0,0 +1,46 @@
+package pkg
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/projectdiscovery/pdtm/pkg/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGitlabProvider(t *testing.T) {
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "secret", r.Header.Get("PRIVATE-TOKEN"))
+		switch r.URL.EscapedPath() {
+		case "/api/v4/projects/mirrors%2Fdnsx/releases":
+			release := gitlabRelease{TagName: "v1.1.0"}
+			release.Assets.Links = []gitlabLink{{Name: "dnsx_1.1.0_linux_amd64.zip", DirectAssetURL: server.URL + "/dnsx.zip"}}
+			_ = json.NewEncoder(w).Encode([]gitlabRelease{{TagName: "v1.2.0", UpcomingRelease: true}, release})
+		case "/dnsx.zip":
+			_, _ = w.Write([]byte("dnsx"))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	provider, err := NewGitlabProvider(types.ProviderConfig{URL: server.URL, Group: "mirrors", Token: "secret"})
+	require.Nil(t, err)
+	tool, err := provider.Latest(types.Tool{Name: "dnsx", Repo: "dnsx"})
+	require.Nil(t, err)
+	require.Equal(t, "1.1.0", tool.Version)
+
+	body, err := provider.Download(tool, tool.Assets["dnsx_1.1.0_linux_amd64.zip"])
+	require.Nil(t, err)
+	defer body.Close()
+	data, err := io.ReadAll(body)
+	require.Nil(t, err)
+	require.Equal(t, "dnsx", string(data))
+
+	_, err = provider.Release(tool, "0.9.0")
+	require.NotNil(t, err)
+}
@@ -0,0 +1,95 @@
+package pkg
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/projectdiscovery/pdtm/pkg/types"
+)
+
+// Hooks are called by the library functions, so the applications embedding
+// pdtm can display the progress and collect metrics without parsing the
+// logs. Unset hooks are ignored.
+type Hooks struct {
+	// OnResolve is called with the tool resolved to a release
+	OnResolve func(tool types.Tool)
+	// OnDownloadProgress is called with the download started, progress and
+	// completed events of the release assets
+	OnDownloadProgress func(event Event)
+	// OnInstalled is called once the tool was installed or updated at path
+	OnInstalled func(tool types.Tool, path string)
+	// OnError is called when resolving, installing, updating or removing
+	// the tool failed
+	OnError func(tool types.Tool, err error)
+}
+
+var (
+	hooksMu sync.RWMutex
+	hooks   []*Hooks
+)
+
+// RegisterHooks registers hooks called by the library functions, they are
+// called in the order they were registered. The returned function
+// unregisters them.
+func RegisterHooks(h Hooks) func() {
+	registered := &h
+	hooksMu.Lock()
+	defer hooksMu.Unlock()
+	hooks = append(hooks, registered)
+	return func() {
+		hooksMu.Lock()
+		defer hooksMu.Unlock()
+		for i, h := range hooks {
+			if h == registered {
+				// a new slice so the callers iterating the previous one are unaffected
+				hooks = append(append([]*Hooks{}, hooks[:i]...), hooks[i+1:]...)
+				return
+			}
+		}
+	}
+}
+
+// callHooks calls fn with each registered hooks
+func callHooks(fn func(h Hooks)) {
+	hooksMu.RLock()
+	registered := hooks
+	hooksMu.RUnlock()
+	for _, h := range registered {
+		fn(*h)
+	}
+}
+
+func hasHooks() bool {
+	hooksMu.RLock()
+	defer hooksMu.RUnlock()
+	return len(hooks) > 0
+}
+
+// notifyResolved calls the hooks of a resolved tool
+func notifyResolved(tool types.Tool, err error) {
+	callHooks(func(h Hooks) {
+		switch {
+		case err != nil && h.OnError != nil:
+			h.OnError(tool, err)
+		case err == nil && h.OnResolve != nil:
+			h.OnResolve(tool)
+		}
+	})
+}
+
+// notifyFinished calls the hooks of an install, update or removal, nothing is
+// reported for the tools that were left as they are
+func notifyFinished(path string, tool types.Tool, err error, installed bool) {
+	if errors.Is(err, types.ErrIsInstalled) || errors.Is(err, types.ErrIsUpToDate) ||
+		errors.Is(err, types.ErrIsPinned) || errors.Is(err, types.ErrIsBuiltFromRef) {
+		return
+	}
+	callHooks(func(h Hooks) {
+		switch {
+		case err != nil && h.OnError != nil:
+			h.OnError(tool, err)
+		case err == nil && installed && h.OnInstalled != nil:
+			h.OnInstalled(tool, path)
+		}
+	})
+}
@@ -0,0 +1,88 @@
+package pkg
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"runtime"
+	"testing"
+
+	"github.com/projectdiscovery/pdtm/pkg/types"
+	"github.com/stretchr/testify/require"
+)
+
+// stubProvider serves a single release of a tool with an in-memory archive
+type stubProvider struct {
+	archive []byte
+}
+
+func (p *stubProvider) Latest(tool types.Tool) (types.Tool, error) {
+	return p.Release(tool, "1.1.0")
+}
+
+func (p *stubProvider) Release(tool types.Tool, version string) (types.Tool, error) {
+	if version != "1.1.0" {
+		return tool, errors.New("version not found")
+	}
+	tool.Version = version
+	tool.Assets = map[string]string{fmt.Sprintf("%s_%s_%s_%s.tar.gz", tool.Name, version, runtime.GOOS, runtime.GOARCH): "1"}
+	tool.AssetSizes = map[string]int64{}
+	return tool, nil
+}
+
+func (p *stubProvider) Download(tool types.Tool, ref string) (io.ReadCloser, error) {
+	return io.NopCloser(bytes.NewReader(p.archive)), nil
+}
+
+func TestHooks(t *testing.T) {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	binary := "dnsx"
+	if runtime.GOOS == "windows" {
+		binary += ".exe"
+	}
+	require.Nil(t, tw.WriteHeader(&tar.Header{Name: binary, Mode: 0755, Size: 4}))
+	_, _ = tw.Write([]byte("dnsx"))
+	require.Nil(t, tw.Close())
+	require.Nil(t, gz.Close())
+
+	previous := ActiveProvider
+	ActiveProvider = &stubProvider{archive: buf.Bytes()}
+	defer func() { ActiveProvider = previous }()
+
+	var events []string
+	unregister := RegisterHooks(Hooks{
+		OnResolve:          func(tool types.Tool) { events = append(events, "resolve "+tool.Version) },
+		OnDownloadProgress: func(event Event) { events = append(events, event.Event) },
+		OnInstalled:        func(tool types.Tool, path string) { events = append(events, "installed "+tool.Name) },
+		OnError:            func(tool types.Tool, err error) { events = append(events, "error "+err.Error()) },
+	})
+	t.Cleanup(unregister)
+
+	tool, err := Resolve(types.Tool{Name: "dnsx", Repo: "dnsx"}, types.Stable)
+	require.Nil(t, err)
+	_, err = ResolveVersion(tool, "0.9.0")
+	require.NotNil(t, err)
+
+	path := t.TempDir()
+	require.Nil(t, Install(path, tool))
+	require.ErrorIs(t, Install(path, tool), types.ErrIsInstalled)
+	require.Equal(t, []string{
+		"resolve 1.1.0",
+		"error version not found",
+		EventDownloadStarted,
+		EventDownloadCompleted,
+		"installed dnsx",
+	}, events)
+
+	// the unregistered hooks aren't called anymore
+	unregister()
+	require.False(t, hasHooks())
+	_, err = ResolveVersion(tool, "0.9.0")
+	require.NotNil(t, err)
+	require.Len(t, events, 5)
+}
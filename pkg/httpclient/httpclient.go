@@ -0,0 +1,135 @@
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+)
+
+var (
+	// transport is the shared transport used by all pdtm http clients so that
+	// connections (and TLS sessions) are reused across api calls and downloads
+	transport = NewTransport()
+
+	// Client is the shared http client used for api calls and asset downloads
+	Client = New()
+
+	// Direct is the shared http client that ignores the source, used by the
+	// cache server to fetch from the upstream hosts
+	Direct = &http.Client{Transport: roundTripperFunc(pacedRoundTrip)}
+)
+
+// configMu guards the settings of the connections (ip version, resolvers,
+// proxy and source), they are set before the first requests but read by
+// every request
+var configMu sync.RWMutex
+
+// dialNetwork is the network the connections are restricted to (tcp4 or
+// tcp6), tcp uses both
+var dialNetwork = "tcp"
+
+// bodyIdleTimeout is how long the response body may stall before the
+// request is aborted, the downloads have no overall timeout since their
+// size is unbounded
+var bodyIdleTimeout = time.Minute
+
+// SetIPVersion restricts the connections to IPv4 ("4") or IPv6 ("6"),
+// "auto" (or an empty version) uses both
+func SetIPVersion(version string) error {
+	configMu.Lock()
+	defer configMu.Unlock()
+	switch version {
+	case "", "auto":
+		dialNetwork = "tcp"
+	case "4":
+		dialNetwork = "tcp4"
+	case "6":
+		dialNetwork = "tcp6"
+	default:
+		return fmt.Errorf("invalid ip version %s (4, 6 or auto)", version)
+	}
+	return nil
+}
+
+// NewTransport returns a transport tuned for many small api calls followed by
+// large asset downloads against a small set of hosts
+func NewTransport() *http.Transport {
+	return &http.Transport{
+		Proxy:                 proxy,
+		DialContext:           dialContext,
+		ForceAttemptHTTP2:     true,
+		MaxIdleConns:          100,
+		MaxIdleConnsPerHost:   10,
+		IdleConnTimeout:       90 * time.Second,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ResponseHeaderTimeout: 30 * time.Second,
+		ExpectContinueTimeout: 1 * time.Second,
+	}
+}
+
+// dialContext opens the connections on the network set with SetIPVersion,
+// resolving the hosts with the resolvers set with SetResolvers
+func dialContext(ctx context.Context, network, addr string) (net.Conn, error) {
+	configMu.RLock()
+	if network == "tcp" {
+		network = dialNetwork
+	}
+	d := net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second, Resolver: resolver}
+	configMu.RUnlock()
+	if ctx.Value(systemResolverKey{}) != nil {
+		d.Resolver = nil
+	}
+	return d.DialContext(ctx, network, addr)
+}
+
+// New returns a new http client backed by the shared transport, paced per
+// host and sent through the source set with SetSource (if any). Callers that
+// need to modify client level settings (eg. redirect policy) must use their
+// own client instead of the shared one.
+func New() *http.Client {
+	return &http.Client{Transport: roundTripperFunc(sourceRoundTrip)}
+}
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// errBodyStalled aborts the requests whose body stalled for bodyIdleTimeout
+var errBodyStalled = errors.New("the response body stalled, no data received")
+
+// idleBody cancels the request once no data was read for bodyIdleTimeout
+type idleBody struct {
+	io.ReadCloser
+	ctx    context.Context
+	stall  *time.Timer
+	cancel context.CancelCauseFunc
+}
+
+func newIdleBody(ctx context.Context, body io.ReadCloser, cancel context.CancelCauseFunc) *idleBody {
+	b := &idleBody{ReadCloser: body, ctx: ctx, cancel: cancel}
+	b.stall = time.AfterFunc(bodyIdleTimeout, func() { cancel(errBodyStalled) })
+	return b
+}
+
+func (b *idleBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if err != nil && context.Cause(b.ctx) == errBodyStalled {
+		return n, errBodyStalled
+	}
+	b.stall.Reset(bodyIdleTimeout)
+	return n, err
+}
+
+func (b *idleBody) Close() error {
+	b.stall.Stop()
+	err := b.ReadCloser.Close()
+	b.cancel(context.Canceled)
+	return err
+}
@@ -0,0 +1,317 @@
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dop251/goja"
+)
+
+// pacTimeout bounds the run of the PAC script, a looping script would
+// otherwise hang every request
+var pacTimeout = 5 * time.Second
+
+// proxyRetryAfter is how long a proxy that couldn't be reached is skipped
+var proxyRetryAfter = time.Minute
+
+// errPACTimeout interrupts the PAC scripts running longer than pacTimeout
+var errPACTimeout = errors.New("the proxy auto-config script timed out")
+
+// pacEvaluationKey marks the context of the lookups run by the PAC script,
+// the DNS-over-HTTPS requests they send can't run the script again
+type pacEvaluationKey struct{}
+
+// proxyAutoConfig runs the FindProxyForURL function of a PAC file
+type proxyAutoConfig struct {
+	mu              sync.Mutex
+	runtime         *goja.Runtime
+	findProxyForURL goja.Callable
+	// ctx is the context of the lookups of the running script
+	ctx context.Context
+
+	checkedMu sync.Mutex
+	// checked contains when each proxy was last checked and whether it was reachable
+	checked map[string]proxyCheck
+}
+
+type proxyCheck struct {
+	reachable bool
+	at        time.Time
+}
+
+func newProxyAutoConfig(script string) (*proxyAutoConfig, error) {
+	p := &proxyAutoConfig{runtime: goja.New(), ctx: context.Background(), checked: make(map[string]proxyCheck)}
+	lookup := func(host string) []net.IP {
+		return lookupIPs(p.ctx, host)
+	}
+	for name, fn := range pacFunctions(lookup) {
+		if err := p.runtime.Set(name, fn); err != nil {
+			return nil, err
+		}
+	}
+	if err := p.run(func() error {
+		_, err := p.runtime.RunString(script)
+		return err
+	}); err != nil {
+		return nil, err
+	}
+	findProxyForURL, ok := goja.AssertFunction(p.runtime.Get("FindProxyForURL"))
+	if !ok {
+		return nil, fmt.Errorf("FindProxyForURL is not defined")
+	}
+	p.findProxyForURL = findProxyForURL
+	return p, nil
+}
+
+// run runs the script, interrupting it after pacTimeout
+func (p *proxyAutoConfig) run(fn func() error) error {
+	interrupted := make(chan struct{})
+	timer := time.AfterFunc(pacTimeout, func() {
+		p.runtime.Interrupt(errPACTimeout)
+		close(interrupted)
+	})
+	err := fn()
+	if !timer.Stop() {
+		<-interrupted
+	}
+	p.runtime.ClearInterrupt()
+	return err
+}
+
+// find returns the proxies returned by FindProxyForURL for the url in
+// order, nil for DIRECT
+func (p *proxyAutoConfig) find(ctx context.Context, u *url.URL) ([]*url.URL, error) {
+	ctx, cancel := context.WithTimeout(context.WithValue(ctx, pacEvaluationKey{}, true), pacTimeout)
+	defer cancel()
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.ctx = ctx
+	defer func() { p.ctx = context.Background() }()
+	var result goja.Value
+	err := p.run(func() error {
+		var err error
+		result, err = p.findProxyForURL(goja.Undefined(), p.runtime.ToValue(u.String()), p.runtime.ToValue(u.Hostname()))
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("proxy auto-config: %w", err)
+	}
+	return parsePACResult(result.String())
+}
+
+// proxy returns the first reachable proxy returned by FindProxyForURL for
+// the url, nil for DIRECT. The first proxy is returned when none is reachable.
+func (p *proxyAutoConfig) proxy(ctx context.Context, u *url.URL) (*url.URL, error) {
+	proxies, err := p.find(ctx, u)
+	if err != nil {
+		return nil, err
+	}
+	for _, proxyURL := range proxies {
+		if proxyURL == nil || p.reachable(ctx, proxyURL.Host) {
+			return proxyURL, nil
+		}
+	}
+	return proxies[0], nil
+}
+
+// reachable returns true if a connection to the proxy can be opened, the
+// result is kept for proxyRetryAfter
+func (p *proxyAutoConfig) reachable(ctx context.Context, host string) bool {
+	p.checkedMu.Lock()
+	check, ok := p.checked[host]
+	p.checkedMu.Unlock()
+	if ok && time.Since(check.at) < proxyRetryAfter {
+		return check.reachable
+	}
+	ctx, cancel := context.WithTimeout(ctx, pacTimeout)
+	defer cancel()
+	conn, err := dialContext(ctx, "tcp", host)
+	if err == nil {
+		conn.Close()
+	}
+	p.checkedMu.Lock()
+	p.checked[host] = proxyCheck{reachable: err == nil, at: time.Now()}
+	p.checkedMu.Unlock()
+	return err == nil
+}
+
+// parsePACResult parses the entries of a FindProxyForURL result
+// (eg. "PROXY proxy.example.com:8080; DIRECT"), nil for DIRECT
+func parsePACResult(result string) ([]*url.URL, error) {
+	var proxies []*url.URL
+	for _, entry := range strings.Split(result, ";") {
+		fields := strings.Fields(entry)
+		if len(fields) == 0 {
+			continue
+		}
+		if strings.EqualFold(fields[0], "DIRECT") {
+			proxies = append(proxies, nil)
+			continue
+		}
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("proxy auto-config: invalid proxy %q", strings.TrimSpace(entry))
+		}
+		var scheme string
+		switch strings.ToUpper(fields[0]) {
+		case "PROXY", "HTTP":
+			scheme = "http"
+		case "HTTPS":
+			scheme = "https"
+		case "SOCKS", "SOCKS5":
+			scheme = "socks5"
+		default:
+			return nil, fmt.Errorf("proxy auto-config: unsupported proxy type %q", fields[0])
+		}
+		proxies = append(proxies, &url.URL{Scheme: scheme, Host: fields[1]})
+	}
+	if len(proxies) == 0 {
+		proxies = append(proxies, nil)
+	}
+	return proxies, nil
+}
+
+// pacFunctions returns the predefined functions of the PAC files resolving
+// the hosts with lookup, the date ranges aren't supported
+func pacFunctions(lookup func(host string) []net.IP) map[string]any {
+	return map[string]any{
+		"isPlainHostName": func(host string) bool {
+			return !strings.Contains(host, ".")
+		},
+		"dnsDomainIs": func(host, domain string) bool {
+			return strings.HasSuffix(strings.ToLower(host), strings.ToLower(domain))
+		},
+		"localHostOrDomainIs": func(host, hostdom string) bool {
+			host, hostdom = strings.ToLower(host), strings.ToLower(hostdom)
+			return host == hostdom || (!strings.Contains(host, ".") && strings.HasPrefix(hostdom, host+"."))
+		},
+		"isResolvable": func(host string) bool {
+			return len(lookup(host)) > 0
+		},
+		"dnsResolve": func(host string) any {
+			for _, ip := range lookup(host) {
+				if ip.To4() != nil {
+					return ip.String()
+				}
+			}
+			return nil
+		},
+		"isInNet": func(host, pattern, mask string) bool {
+			ip := net.ParseIP(host)
+			if ip == nil {
+				ips := lookup(host)
+				if len(ips) == 0 {
+					return false
+				}
+				ip = ips[0]
+			}
+			patternIP, maskIP := net.ParseIP(pattern).To4(), net.ParseIP(mask).To4()
+			if ip.To4() == nil || patternIP == nil || maskIP == nil {
+				return false
+			}
+			return ip.To4().Mask(net.IPMask(maskIP)).Equal(patternIP.Mask(net.IPMask(maskIP)))
+		},
+		"myIpAddress": func() string {
+			// no packet is sent, the route gives the address of the interface
+			conn, err := net.Dial("udp", "192.0.2.1:80")
+			if err != nil {
+				return "127.0.0.1"
+			}
+			defer conn.Close()
+			return conn.LocalAddr().(*net.UDPAddr).IP.String()
+		},
+		"dnsDomainLevels": func(host string) int {
+			return strings.Count(host, ".")
+		},
+		"shExpMatch": func(str, shexp string) bool {
+			// unlike path.Match, * also matches the slashes of the urls
+			pattern := strings.NewReplacer(`\*`, ".*", `\?`, ".").Replace(regexp.QuoteMeta(shexp))
+			matched, _ := regexp.MatchString("^"+pattern+"$", str)
+			return matched
+		},
+		"weekdayRange": func(args ...string) bool {
+			args, now := pacTimeArgs(args)
+			days := []string{"SUN", "MON", "TUE", "WED", "THU", "FRI", "SAT"}
+			start := sliceIndex(days, strings.ToUpper(args[0]))
+			end := start
+			if len(args) > 1 {
+				end = sliceIndex(days, strings.ToUpper(args[1]))
+			}
+			if start < 0 || end < 0 {
+				return false
+			}
+			return inRange(int(now.Weekday()), start, end)
+		},
+		// timeRange(hour), timeRange(hour1, hour2), timeRange(hour1, min1, hour2, min2)
+		// or timeRange(hour1, min1, sec1, hour2, min2, sec2)
+		"timeRange": func(args ...string) bool {
+			args, now := pacTimeArgs(args)
+			values := make([]int, 0, len(args))
+			for _, arg := range args {
+				value, err := strconv.Atoi(arg)
+				if err != nil {
+					return false
+				}
+				values = append(values, value)
+			}
+			if len(values) == 1 {
+				return now.Hour() == values[0]
+			}
+			if len(values)%2 != 0 || len(values) > 6 {
+				return false
+			}
+			// the bounds and the current time at the precision of the arguments
+			seconds := func(parts []int) int {
+				total := 0
+				for i := 0; i < 3; i++ {
+					total *= 60
+					if i < len(parts) {
+						total += parts[i]
+					}
+				}
+				return total
+			}
+			half := len(values) / 2
+			current := seconds([]int{now.Hour(), now.Minute(), now.Second()}[:half])
+			return inRange(current, seconds(values[:half]), seconds(values[half:]))
+		},
+	}
+}
+
+func sliceIndex(values []string, value string) int {
+	for i, v := range values {
+		if v == value {
+			return i
+		}
+	}
+	return -1
+}
+
+// inRange returns true if value is between start and end, wrapping around
+// when end is before start (eg. FRI to MON)
+func inRange(value, start, end int) bool {
+	if start <= end {
+		return start <= value && value <= end
+	}
+	return value >= start || value <= end
+}
+
+// pacTimeArgs returns the arguments of a time function without the
+// trailing GMT and the current time, in UTC with GMT
+func pacTimeArgs(args []string) ([]string, time.Time) {
+	now := time.Now()
+	if len(args) > 0 && strings.EqualFold(args[len(args)-1], "GMT") {
+		args, now = args[:len(args)-1], now.UTC()
+	}
+	if len(args) == 0 {
+		args = []string{""}
+	}
+	return args, now
+}
@@ -0,0 +1,216 @@
+package httpclient
+
+import (
+	"context"
+	"io"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/projectdiscovery/gologger"
+)
+
+// Pacing configures how requests are spread over time for each host so that
+// batch operations don't trip the secondary rate limits of github
+type Pacing struct {
+	// MaxConcurrent is the maximum number of concurrent requests per host (0 for no limit)
+	MaxConcurrent int
+	// Interval is the minimum delay between the start of two requests to a host
+	Interval time.Duration
+	// Jitter is the maximum random delay added to the interval
+	Jitter time.Duration
+}
+
+// DefaultPacing is the pacing used unless configured with SetPacing
+var DefaultPacing = Pacing{MaxConcurrent: 4}
+
+const (
+	// maxRetries is the number of times a rate limited request is retried
+	maxRetries = 3
+	// maxRetryWait is the longest wait before retrying a rate limited request,
+	// longer waits (eg. until the hourly limit resets) fail immediately
+	maxRetryWait = time.Minute
+)
+
+var (
+	pacingMu sync.Mutex
+	pacing   = DefaultPacing
+	pacers   = make(map[string]*hostPacer)
+)
+
+// SetPacing sets the pacing of the requests of all the clients
+func SetPacing(p Pacing) {
+	pacingMu.Lock()
+	defer pacingMu.Unlock()
+	pacing = p
+	pacers = make(map[string]*hostPacer)
+}
+
+type hostPacer struct {
+	slots  chan struct{}
+	pacing Pacing
+
+	mu   sync.Mutex
+	next time.Time
+}
+
+func pacerFor(host string) *hostPacer {
+	pacingMu.Lock()
+	defer pacingMu.Unlock()
+	p, ok := pacers[host]
+	if !ok {
+		p = &hostPacer{pacing: pacing}
+		if pacing.MaxConcurrent > 0 {
+			p.slots = make(chan struct{}, pacing.MaxConcurrent)
+		}
+		pacers[host] = p
+	}
+	return p
+}
+
+// acquire waits for a free slot and for the interval since the last request,
+// the slot is released when the request is canceled while waiting
+func (p *hostPacer) acquire(req *http.Request) error {
+	if p.slots != nil {
+		select {
+		case p.slots <- struct{}{}:
+		case <-req.Context().Done():
+			return req.Context().Err()
+		}
+	}
+	delay := p.pacing.Interval
+	if p.pacing.Jitter > 0 {
+		delay += time.Duration(rand.Int63n(int64(p.pacing.Jitter)))
+	}
+	p.mu.Lock()
+	start := time.Now()
+	if p.next.After(start) {
+		start = p.next
+	}
+	p.next = start.Add(delay)
+	p.mu.Unlock()
+	if err := sleep(req, time.Until(start)); err != nil {
+		p.release()
+		return err
+	}
+	return nil
+}
+
+func (p *hostPacer) release() {
+	if p.slots != nil {
+		<-p.slots
+	}
+}
+
+// pacedRoundTrip sends the request once the host pacing allows it, rate
+// limited requests without body are retried after the delay requested by
+// the host. The slot of the host is held until the response body is closed.
+func pacedRoundTrip(req *http.Request) (*http.Response, error) {
+	p := pacerFor(req.URL.Host)
+	ctx, cancel := context.WithCancelCause(req.Context())
+	req = req.WithContext(ctx)
+	for attempt := 0; ; attempt++ {
+		if err := p.acquire(req); err != nil {
+			cancel(err)
+			return nil, err
+		}
+		resp, err := transport.RoundTrip(req)
+		if err != nil {
+			p.release()
+			cancel(err)
+			return nil, err
+		}
+		recordRateLimit(req.URL.Host, resp)
+		wait, limited := retryAfter(resp, attempt)
+		if !limited || attempt >= maxRetries || req.Body != nil || wait > maxRetryWait {
+			resp.Body = &releasingBody{ReadCloser: newIdleBody(ctx, resp.Body, cancel), release: p.release}
+			return resp, nil
+		}
+		resp.Body.Close()
+		p.release()
+		gologger.Info().Msgf("rate limited by %s, retrying in %s", req.URL.Host, wait)
+		if err := sleep(req, wait); err != nil {
+			cancel(err)
+			return nil, err
+		}
+	}
+}
+
+// rateLimits contains the last X-RateLimit-Remaining header of each host
+var rateLimits sync.Map
+
+func recordRateLimit(host string, resp *http.Response) {
+	if remaining, err := strconv.Atoi(resp.Header.Get("X-RateLimit-Remaining")); err == nil {
+		rateLimits.Store(host, remaining)
+	}
+}
+
+// RateLimitRemaining returns the number of requests left before being rate
+// limited, as last announced by each host
+func RateLimitRemaining() map[string]int {
+	remaining := make(map[string]int)
+	rateLimits.Range(func(host, value any) bool {
+		remaining[host.(string)] = value.(int)
+		return true
+	})
+	return remaining
+}
+
+// retryAfter returns the delay before retrying a rate limited response
+func retryAfter(resp *http.Response, attempt int) (time.Duration, bool) {
+	switch {
+	case resp.StatusCode == http.StatusTooManyRequests:
+	case resp.StatusCode == http.StatusForbidden && (resp.Header.Get("Retry-After") != "" || resp.Header.Get("X-RateLimit-Remaining") == "0"):
+	default:
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if resp.Header.Get("X-RateLimit-Remaining") == "0" {
+		if reset, err := strconv.ParseInt(resp.Header.Get("X-RateLimit-Reset"), 10, 64); err == nil {
+			return time.Until(time.Unix(reset, 0)), true
+		}
+	}
+	// exponential backoff when the host doesn't tell how long to wait
+	return time.Duration(1<<attempt) * time.Second, true
+}
+
+func sleep(req *http.Request, d time.Duration) error {
+	if d <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-req.Context().Done():
+		return req.Context().Err()
+	}
+}
+
+// releasingBody releases the slot of the host once the body is closed, or
+// once it is read to the end or failed so that the bodies left open don't
+// starve the host
+type releasingBody struct {
+	io.ReadCloser
+	once    sync.Once
+	release func()
+}
+
+func (b *releasingBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if err != nil {
+		b.once.Do(b.release)
+	}
+	return n, err
+}
+
+func (b *releasingBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.once.Do(b.release)
+	return err
+}
@@ -0,0 +1,99 @@
+package httpclient
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPacedRoundTripRetriesRateLimited(t *testing.T) {
+	var hits int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&hits, 1) == 1 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer ts.Close()
+
+	resp, err := New().Get(ts.URL)
+	require.Nil(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, int32(2), atomic.LoadInt32(&hits))
+}
+
+func TestPacedRoundTripInterval(t *testing.T) {
+	SetPacing(Pacing{MaxConcurrent: 1, Interval: 100 * time.Millisecond})
+	defer SetPacing(DefaultPacing)
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer ts.Close()
+
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		resp, err := New().Get(ts.URL)
+		require.Nil(t, err)
+		resp.Body.Close()
+	}
+	require.GreaterOrEqual(t, time.Since(start), 200*time.Millisecond)
+}
+
+func TestPacedRoundTripBodyStalled(t *testing.T) {
+	bodyIdleTimeout = 100 * time.Millisecond
+	defer func() { bodyIdleTimeout = time.Minute }()
+
+	stalled := make(chan struct{})
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("partial"))
+		w.(http.Flusher).Flush()
+		select {
+		case <-r.Context().Done():
+		case <-stalled:
+		}
+	}))
+	defer ts.Close()
+	defer close(stalled)
+
+	resp, err := New().Get(ts.URL)
+	require.Nil(t, err)
+	defer resp.Body.Close()
+	_, err = io.ReadAll(resp.Body)
+	require.ErrorIs(t, err, errBodyStalled)
+}
+
+func TestPacedRoundTripReleasesSlot(t *testing.T) {
+	SetPacing(Pacing{MaxConcurrent: 1, Interval: time.Hour})
+	defer SetPacing(DefaultPacing)
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("ok"))
+	}))
+	defer ts.Close()
+
+	// the body read to the end releases the slot without being closed
+	resp, err := New().Get(ts.URL)
+	require.Nil(t, err)
+	_, err = io.ReadAll(resp.Body)
+	require.Nil(t, err)
+
+	// the request canceled while waiting for the interval releases its slot
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, ts.URL, nil)
+	require.Nil(t, err)
+	_, err = New().Do(req)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+
+	p := pacerFor(req.URL.Host)
+	require.Len(t, p.slots, 0, "the slots of the host were not released")
+	resp.Body.Close()
+}
@@ -0,0 +1,204 @@
+package httpclient
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+var (
+	// pac is the proxy auto-config choosing the proxy of the requests, nil
+	// uses the proxy of the environment (HTTPS_PROXY, HTTP_PROXY)
+	pac *proxyAutoConfig
+	// noProxy contains the hosts, domains and networks reached directly
+	noProxy proxyExclusions
+)
+
+// SetProxy sets the proxy auto-config (a file or an http url, empty uses
+// the environment) and the hosts, domains and networks (CIDR) reached
+// without proxy in addition to the ones of NO_PROXY
+func SetProxy(pacLocation string, exclusions []string) error {
+	exclusionList := parseProxyExclusions(append(envNoProxy(), exclusions...))
+	configMu.Lock()
+	noProxy, pac = exclusionList, nil
+	configMu.Unlock()
+	if pacLocation == "" {
+		return nil
+	}
+	script, err := readPAC(pacLocation)
+	if err != nil {
+		return fmt.Errorf("could not read the proxy auto-config %s: %w", pacLocation, err)
+	}
+	config, err := newProxyAutoConfig(script)
+	if err != nil {
+		return fmt.Errorf("invalid proxy auto-config %s: %w", pacLocation, err)
+	}
+	configMu.Lock()
+	pac = config
+	configMu.Unlock()
+	return nil
+}
+
+// proxy returns the proxy of the request, nil for a direct connection. The
+// DNS-over-HTTPS requests of the lookups of the PAC script use the proxy of
+// the environment, the script isn't reentrant.
+func proxy(req *http.Request) (*url.URL, error) {
+	configMu.RLock()
+	exclusions, config := noProxy, pac
+	configMu.RUnlock()
+	if exclusions.match(req.Context(), req.URL.Hostname()) {
+		return nil, nil
+	}
+	if config != nil && req.Context().Value(pacEvaluationKey{}) == nil {
+		return config.proxy(req.Context(), req.URL)
+	}
+	return http.ProxyFromEnvironment(req)
+}
+
+// systemResolverKey marks the context of the lookups resolved with the
+// system resolver, the proxy of the DNS-over-HTTPS requests can't be chosen
+// with lookups sent through them
+type systemResolverKey struct{}
+
+// dohProxy returns the proxy of the DNS-over-HTTPS requests like proxy,
+// resolving the hosts of the exclusions and the PAC script with the system resolver
+func dohProxy(req *http.Request) (*url.URL, error) {
+	return proxy(req.WithContext(context.WithValue(req.Context(), systemResolverKey{}, true)))
+}
+
+func envNoProxy() []string {
+	value := os.Getenv("NO_PROXY")
+	if value == "" {
+		value = os.Getenv("no_proxy")
+	}
+	if value == "" {
+		return nil
+	}
+	return strings.Split(value, ",")
+}
+
+// maxPACSize is the maximum size of a proxy auto-config file
+const maxPACSize = 1 << 20
+
+// readPAC reads the proxy auto-config from a file or an http(s) url, the
+// url is fetched without proxy as it usually is on the local network
+func readPAC(location string) (string, error) {
+	var r io.Reader
+	if !strings.HasPrefix(location, "http://") && !strings.HasPrefix(location, "https://") {
+		f, err := os.Open(strings.TrimPrefix(location, "file://"))
+		if err != nil {
+			return "", err
+		}
+		defer f.Close()
+		r = f
+	} else {
+		client := &http.Client{Timeout: 10 * time.Second, Transport: &http.Transport{Proxy: nil}}
+		resp, err := client.Get(location)
+		if err != nil {
+			return "", err
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return "", fmt.Errorf("unexpected status %s", resp.Status)
+		}
+		r = resp.Body
+	}
+	var b strings.Builder
+	if _, err := io.Copy(&b, io.LimitReader(r, maxPACSize+1)); err != nil {
+		return "", err
+	}
+	if b.Len() > maxPACSize {
+		return "", fmt.Errorf("the proxy auto-config is larger than %d bytes", maxPACSize)
+	}
+	return b.String(), nil
+}
+
+// proxyExclusions contains the hosts reached without proxy
+type proxyExclusions struct {
+	all      bool
+	hosts    []string
+	networks []*net.IPNet
+}
+
+// parseProxyExclusions parses NO_PROXY entries: * for every host, an ip,
+// a network (CIDR), a host or a domain (.example.com or example.com, which
+// also matches its subdomains), the ports are ignored
+func parseProxyExclusions(entries []string) proxyExclusions {
+	var exclusions proxyExclusions
+	for _, entry := range entries {
+		entry = strings.ToLower(strings.TrimSpace(entry))
+		switch {
+		case entry == "":
+			continue
+		case entry == "*":
+			exclusions.all = true
+			continue
+		}
+		if _, network, err := net.ParseCIDR(entry); err == nil {
+			exclusions.networks = append(exclusions.networks, network)
+			continue
+		}
+		if host, _, err := net.SplitHostPort(entry); err == nil {
+			entry = host
+		}
+		exclusions.hosts = append(exclusions.hosts, strings.TrimPrefix(entry, "."))
+	}
+	return exclusions
+}
+
+// match returns true if the host is excluded, the hosts are resolved to
+// match the networks
+func (e proxyExclusions) match(ctx context.Context, host string) bool {
+	host = strings.ToLower(host)
+	if e.all {
+		return true
+	}
+	for _, excluded := range e.hosts {
+		if host == excluded || strings.HasSuffix(host, "."+excluded) {
+			return true
+		}
+	}
+	if len(e.networks) == 0 {
+		return false
+	}
+	ips := []net.IP{net.ParseIP(host)}
+	if ips[0] == nil {
+		ips = lookupIPs(ctx, host)
+	}
+	for _, ip := range ips {
+		for _, network := range e.networks {
+			if network.Contains(ip) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// lookupIPs resolves the host with the configured resolvers, nil if it
+// can't be resolved
+func lookupIPs(ctx context.Context, host string) []net.IP {
+	configMu.RLock()
+	r := resolver
+	configMu.RUnlock()
+	if r == nil || ctx.Value(systemResolverKey{}) != nil {
+		r = net.DefaultResolver
+	}
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	addrs, err := r.LookupIPAddr(ctx, host)
+	if err != nil {
+		return nil
+	}
+	ips := make([]net.IP, 0, len(addrs))
+	for _, addr := range addrs {
+		ips = append(ips, addr.IP)
+	}
+	return ips
+}
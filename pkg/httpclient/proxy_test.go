@@ -0,0 +1,166 @@
+package httpclient
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// proxyStrings returns the proxies of a PAC result, DIRECT for nil
+func proxyStrings(proxies []*url.URL) []string {
+	values := make([]string, 0, len(proxies))
+	for _, proxyURL := range proxies {
+		if proxyURL == nil {
+			values = append(values, "DIRECT")
+			continue
+		}
+		values = append(values, proxyURL.String())
+	}
+	return values
+}
+
+func TestParsePACResult(t *testing.T) {
+	for result, expected := range map[string][]string{
+		"DIRECT":                               {"DIRECT"},
+		"":                                     {"DIRECT"},
+		"PROXY proxy.example.com:8080; DIRECT": {"http://proxy.example.com:8080", "DIRECT"},
+		"HTTPS proxy.example.com:443":          {"https://proxy.example.com:443"},
+		" SOCKS5 127.0.0.1:1080 ; PROXY other:80": {"socks5://127.0.0.1:1080", "http://other:80"},
+	} {
+		proxies, err := parsePACResult(result)
+		require.Nil(t, err, result)
+		require.Equal(t, expected, proxyStrings(proxies), result)
+	}
+
+	_, err := parsePACResult("FTP proxy.example.com:21")
+	require.NotNil(t, err)
+}
+
+func TestProxyExclusions(t *testing.T) {
+	exclusions := parseProxyExclusions([]string{".internal.example.com", "localhost:8080", "10.0.0.0/8", " "})
+	for host, expected := range map[string]bool{
+		"internal.example.com":     true,
+		"git.internal.example.com": true,
+		"notinternal.example.com":  false,
+		"LOCALHOST":                true,
+		"10.1.2.3":                 true,
+		"192.0.2.1":                false,
+	} {
+		require.Equal(t, expected, exclusions.match(context.Background(), host), host)
+	}
+	require.True(t, parseProxyExclusions([]string{"*"}).match(context.Background(), "github.com"))
+}
+
+func TestProxyAutoConfig(t *testing.T) {
+	pac, err := newProxyAutoConfig(`
+function FindProxyForURL(url, host) {
+	if (isPlainHostName(host) || isInNet(host, "10.0.0.0", "255.0.0.0"))
+		return "DIRECT";
+	if (dnsDomainIs(host, ".github.com") && shExpMatch(url, "*/releases/*"))
+		return "PROXY downloads.example.com:3128";
+	return "PROXY proxy.example.com:8080; DIRECT";
+}`)
+	require.Nil(t, err)
+
+	for rawURL, expected := range map[string][]string{
+		"http://intranet/":                          {"DIRECT"},
+		"https://10.1.2.3/api":                      {"DIRECT"},
+		"https://api.github.com/repos/x/releases/1": {"http://downloads.example.com:3128"},
+		"https://api.github.com/repos/x":            {"http://proxy.example.com:8080", "DIRECT"},
+	} {
+		u, err := url.Parse(rawURL)
+		require.Nil(t, err)
+		proxies, err := pac.find(context.Background(), u)
+		require.Nil(t, err, rawURL)
+		require.Equal(t, expected, proxyStrings(proxies), rawURL)
+	}
+
+	_, err = newProxyAutoConfig("var x = 1;")
+	require.NotNil(t, err)
+}
+
+func TestProxyAutoConfigTimeout(t *testing.T) {
+	previous := pacTimeout
+	pacTimeout = 100 * time.Millisecond
+	t.Cleanup(func() { pacTimeout = previous })
+
+	pac, err := newProxyAutoConfig(`
+function FindProxyForURL(url, host) {
+	if (host == "loop") while (true) {}
+	return "DIRECT";
+}`)
+	require.Nil(t, err)
+	_, err = pac.find(context.Background(), &url.URL{Scheme: "https", Host: "loop"})
+	require.ErrorIs(t, err, errPACTimeout)
+	// the script runs again once interrupted
+	proxies, err := pac.find(context.Background(), &url.URL{Scheme: "https", Host: "github.com"})
+	require.Nil(t, err)
+	require.Equal(t, []string{"DIRECT"}, proxyStrings(proxies))
+
+	_, err = newProxyAutoConfig("while (true) {}")
+	require.ErrorIs(t, err, errPACTimeout)
+}
+
+func TestProxyAutoConfigFailover(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.Nil(t, err)
+	defer listener.Close()
+	closed, err := net.Listen("tcp", "127.0.0.1:0")
+	require.Nil(t, err)
+	unreachable := closed.Addr().String()
+	closed.Close()
+
+	pac, err := newProxyAutoConfig(fmt.Sprintf(`
+function FindProxyForURL(url, host) {
+	if (host == "direct") return "PROXY %[1]s; DIRECT";
+	return "PROXY %[1]s; PROXY %[2]s; DIRECT";
+}`, unreachable, listener.Addr().String()))
+	require.Nil(t, err)
+	proxyURL, err := pac.proxy(context.Background(), &url.URL{Scheme: "https", Host: "github.com"})
+	require.Nil(t, err)
+	require.Equal(t, "http://"+listener.Addr().String(), proxyURL.String())
+	proxyURL, err = pac.proxy(context.Background(), &url.URL{Scheme: "https", Host: "direct"})
+	require.Nil(t, err)
+	require.Nil(t, proxyURL)
+}
+
+func TestReadPACSize(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "proxy.pac")
+	require.Nil(t, os.WriteFile(file, bytes.Repeat([]byte(" "), maxPACSize+1), 0644))
+	_, err := readPAC(file)
+	require.NotNil(t, err)
+}
+
+func TestDOHProxy(t *testing.T) {
+	previousPAC, previousNoProxy := pac, noProxy
+	t.Cleanup(func() { pac, noProxy = previousPAC, previousNoProxy })
+	var err error
+	pac, err = newProxyAutoConfig(`function FindProxyForURL(url, host) { return "PROXY 127.0.0.1:1"; }`)
+	require.Nil(t, err)
+	noProxy = parseProxyExclusions([]string{"dns.internal"})
+
+	// the DNS-over-HTTPS queries follow the PAC and the exclusions
+	for rawURL, expected := range map[string]string{
+		"https://cloudflare-dns.com/dns-query": "http://127.0.0.1:1",
+		"https://dns.internal/dns-query":       "",
+	} {
+		req, err := http.NewRequest(http.MethodPost, rawURL, nil)
+		require.Nil(t, err)
+		proxyURL, err := dohProxy(req)
+		require.Nil(t, err)
+		if expected == "" {
+			require.Nil(t, proxyURL, rawURL)
+			continue
+		}
+		require.Equal(t, expected, proxyURL.String(), rawURL)
+	}
+}
@@ -0,0 +1,184 @@
+package httpclient
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// resolver resolves the hosts of the connections, nil uses the system resolver
+var resolver *net.Resolver
+
+// SetResolvers makes the connections resolve hosts with the given dns
+// servers (ip or ip:port) or DNS-over-HTTPS endpoints (https:// urls)
+// instead of the system resolver, the servers are used in turn
+func SetResolvers(servers []string) error {
+	if len(servers) == 0 {
+		configMu.Lock()
+		resolver = nil
+		configMu.Unlock()
+		return nil
+	}
+	dials := make([]dialFunc, 0, len(servers))
+	for _, server := range servers {
+		dial, err := resolverDial(strings.TrimSpace(server))
+		if err != nil {
+			return err
+		}
+		dials = append(dials, dial)
+	}
+	var next uint32
+	configMu.Lock()
+	defer configMu.Unlock()
+	resolver = &net.Resolver{
+		PreferGo: true,
+		Dial: func(ctx context.Context, network, address string) (net.Conn, error) {
+			// the go resolver retries with a new connection, so failing
+			// servers are skipped by the next attempt
+			i := atomic.AddUint32(&next, 1) - 1
+			return dials[int(i)%len(dials)](ctx, network)
+		},
+	}
+	return nil
+}
+
+// ValidateResolver returns an error if the server is not an ip, ip:port or
+// https:// url
+func ValidateResolver(server string) error {
+	_, err := resolverDial(strings.TrimSpace(server))
+	return err
+}
+
+// dialFunc connects to a dns server with the network (udp or tcp) requested by the resolver
+type dialFunc func(ctx context.Context, network string) (net.Conn, error)
+
+func resolverDial(server string) (dialFunc, error) {
+	if strings.HasPrefix(server, "https://") {
+		u, err := url.Parse(server)
+		if err != nil || u.Host == "" {
+			return nil, fmt.Errorf("invalid DNS-over-HTTPS url %s", server)
+		}
+		return func(ctx context.Context, _ string) (net.Conn, error) {
+			return &dohConn{ctx: ctx, url: u.String()}, nil
+		}, nil
+	}
+	address := server
+	if _, _, err := net.SplitHostPort(server); err != nil {
+		address = net.JoinHostPort(server, "53")
+	}
+	host, _, _ := net.SplitHostPort(address)
+	if net.ParseIP(host) == nil {
+		return nil, fmt.Errorf("invalid resolver %s, expected an ip or a https:// url", server)
+	}
+	return func(ctx context.Context, network string) (net.Conn, error) {
+		var d net.Dialer
+		return d.DialContext(ctx, network, address)
+	}, nil
+}
+
+// dohClient sends the DNS-over-HTTPS queries through the proxy chosen like
+// for the other requests, it resolves the host of the endpoint with the
+// system resolver
+var dohClient = &http.Client{
+	Timeout:   10 * time.Second,
+	Transport: &http.Transport{Proxy: dohProxy},
+}
+
+// dohConn is a stream connection to a DNS-over-HTTPS endpoint, the go
+// resolver writes length prefixed queries and reads length prefixed answers
+type dohConn struct {
+	ctx     context.Context
+	url     string
+	query   bytes.Buffer
+	answer  bytes.Buffer
+	closed  bool
+	timeout time.Time
+}
+
+func (c *dohConn) Write(b []byte) (int, error) {
+	if c.closed {
+		return 0, net.ErrClosed
+	}
+	c.query.Write(b)
+	if c.query.Len() < 2 {
+		return len(b), nil
+	}
+	size := int(binary.BigEndian.Uint16(c.query.Bytes()))
+	if c.query.Len() < 2+size {
+		return len(b), nil
+	}
+	msg := c.query.Bytes()[2 : 2+size]
+	answer, err := c.exchange(msg)
+	c.query.Reset()
+	if err != nil {
+		return 0, err
+	}
+	var prefix [2]byte
+	binary.BigEndian.PutUint16(prefix[:], uint16(len(answer)))
+	c.answer.Write(prefix[:])
+	c.answer.Write(answer)
+	return len(b), nil
+}
+
+func (c *dohConn) exchange(msg []byte) ([]byte, error) {
+	ctx := c.ctx
+	if !c.timeout.IsZero() {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithDeadline(ctx, c.timeout)
+		defer cancel()
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.url, bytes.NewReader(msg))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/dns-message")
+	req.Header.Set("Accept", "application/dns-message")
+	resp, err := dohClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code %d from %s", resp.StatusCode, c.url)
+	}
+	answer, err := io.ReadAll(io.LimitReader(resp.Body, 65535))
+	if err != nil {
+		return nil, err
+	}
+	if len(answer) == 0 {
+		return nil, errors.New("empty DNS-over-HTTPS answer")
+	}
+	return answer, nil
+}
+
+func (c *dohConn) Read(b []byte) (int, error) {
+	if c.answer.Len() == 0 {
+		return 0, io.EOF
+	}
+	return c.answer.Read(b)
+}
+
+func (c *dohConn) Close() error {
+	c.closed = true
+	return nil
+}
+
+func (c *dohConn) LocalAddr() net.Addr                { return dohAddr{} }
+func (c *dohConn) RemoteAddr() net.Addr               { return dohAddr{} }
+func (c *dohConn) SetDeadline(t time.Time) error      { c.timeout = t; return nil }
+func (c *dohConn) SetReadDeadline(t time.Time) error  { return nil }
+func (c *dohConn) SetWriteDeadline(t time.Time) error { c.timeout = t; return nil }
+
+type dohAddr struct{}
+
+func (dohAddr) Network() string { return "https" }
+func (dohAddr) String() string  { return "doh" }
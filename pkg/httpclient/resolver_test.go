@@ -0,0 +1,56 @@
+package httpclient
+
+import (
+	"context"
+	"encoding/binary"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// dohHandler answers the A queries with 192.0.2.1 and the other ones without answer
+func dohHandler(w http.ResponseWriter, r *http.Request) {
+	query, _ := io.ReadAll(r.Body)
+	// the question starts after the header and its name ends with a zero length label
+	end := 12
+	for query[end] != 0 {
+		end += int(query[end]) + 1
+	}
+	end += 5
+	qtype := binary.BigEndian.Uint16(query[end-4:])
+
+	answer := append([]byte{}, query[:end]...)
+	answer[2] |= 0x80 // response
+	answer[3] = 0x80  // recursion available, no error
+	binary.BigEndian.PutUint16(answer[10:], 0)
+	if qtype == 1 {
+		binary.BigEndian.PutUint16(answer[6:], 1)
+		answer = append(answer, 0xc0, 0x0c, 0, 1, 0, 1, 0, 0, 0, 60, 0, 4, 192, 0, 2, 1)
+	}
+	w.Header().Set("Content-Type", "application/dns-message")
+	_, _ = w.Write(answer)
+}
+
+func TestSetResolversDoH(t *testing.T) {
+	ts := httptest.NewTLSServer(http.HandlerFunc(dohHandler))
+	defer ts.Close()
+	defaultClient := dohClient
+	dohClient = ts.Client()
+	defer func() { dohClient = defaultClient }()
+
+	require.Nil(t, SetResolvers([]string{ts.URL + "/dns-query"}))
+	defer func() { _ = SetResolvers(nil) }()
+
+	addrs, err := resolver.LookupHost(context.Background(), "pdtm.example")
+	require.Nil(t, err)
+	require.Equal(t, []string{"192.0.2.1"}, addrs)
+}
+
+func TestSetResolversInvalid(t *testing.T) {
+	require.NotNil(t, SetResolvers([]string{"dns.example"}))
+	require.Nil(t, SetResolvers([]string{"1.1.1.1", "[2606:4700::1111]:53"}))
+	require.Nil(t, SetResolvers(nil))
+}
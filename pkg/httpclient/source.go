@@ -0,0 +1,106 @@
+package httpclient
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+)
+
+// GithubHosts are the github hosts serving the releases and their assets
+var GithubHosts = []string{
+	"api.github.com",
+	"github.com",
+	"objects.githubusercontent.com",
+	"release-assets.githubusercontent.com",
+}
+
+// IsReleaseURL returns true for the github urls of the releases and of their
+// assets, the only github urls served by the cache server
+func IsReleaseURL(u *url.URL) bool {
+	if path.Clean(u.Path) != u.Path {
+		return false
+	}
+	parts := strings.Split(strings.TrimPrefix(u.Path, "/"), "/")
+	switch u.Host {
+	case "api.github.com":
+		// repos/<owner>/<repo>/releases/...
+		return len(parts) >= 4 && parts[0] == "repos" && parts[3] == "releases"
+	case "github.com":
+		// <owner>/<repo>/releases/download/<tag>/<asset>
+		return len(parts) == 6 && parts[2] == "releases" && parts[3] == "download"
+	case "objects.githubusercontent.com", "release-assets.githubusercontent.com":
+		// the signed urls the release assets are redirected to
+		return true
+	default:
+		return false
+	}
+}
+
+// IsGithubHost returns true for the hosts of GithubHosts
+func IsGithubHost(host string) bool {
+	for _, githubHost := range GithubHosts {
+		if host == githubHost {
+			return true
+		}
+	}
+	return false
+}
+
+var (
+	// source is the pdtm cache server (pdtm -serve) the requests are sent to
+	// instead of their host, nil sends them directly
+	source *url.URL
+	// sourceHosts are the hosts whose GET requests are sent to the source
+	sourceHosts map[string]bool
+)
+
+// SetSource sends the GET requests of the github releases and of the given
+// hosts (eg. the pdtm api) through the pdtm cache server at the given url (eg.
+// http://cache:8080), an empty url disables it
+func SetSource(location string, hosts ...string) error {
+	if location == "" {
+		configMu.Lock()
+		source = nil
+		configMu.Unlock()
+		return nil
+	}
+	u, err := url.Parse(location)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("invalid source %s, expected a http(s) url", location)
+	}
+	u.Path = strings.TrimSuffix(u.Path, "/")
+	sourceHostList := make(map[string]bool)
+	for _, host := range append(GithubHosts, hosts...) {
+		sourceHostList[host] = true
+	}
+	configMu.Lock()
+	source, sourceHosts = u, sourceHostList
+	configMu.Unlock()
+	return nil
+}
+
+// sourceRoundTrip rewrites https://<host>/<path> to <source>/<host>/<path>,
+// the credentials of the request are not sent to the cache server. The
+// other requests (eg. webhooks, go toolchain, other providers) are sent
+// directly since the cache server only serves the releases.
+func sourceRoundTrip(req *http.Request) (*http.Response, error) {
+	configMu.RLock()
+	location, hosts := source, sourceHosts
+	configMu.RUnlock()
+	if location == nil || req.URL.Host == location.Host || !hosts[req.URL.Host] ||
+		(IsGithubHost(req.URL.Host) && !IsReleaseURL(req.URL)) ||
+		(req.Method != http.MethodGet && req.Method != http.MethodHead) {
+		return pacedRoundTrip(req)
+	}
+	u := *location
+	u.Path = location.Path + "/" + req.URL.Host + req.URL.Path
+	u.RawPath = ""
+	u.RawQuery = req.URL.RawQuery
+	req = req.Clone(req.Context())
+	req.URL = &u
+	req.Host = ""
+	req.Header.Del("Authorization")
+	return pacedRoundTrip(req)
+}
@@ -0,0 +1,226 @@
+package pkg
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/projectdiscovery/pdtm/pkg/httpclient"
+	"github.com/projectdiscovery/pdtm/pkg/signature"
+	"github.com/projectdiscovery/pdtm/pkg/types"
+)
+
+// IndexProvider fetches the tools from a json index of re-hosted binaries
+// served from s3, gcs or any https server, eg.
+//
+//	{"tools": {"dnsx": {"latest": "1.1.0", "versions": {"1.1.0": {"assets": {
+//	  "dnsx_1.1.0_linux_amd64.zip": "s3://bucket/dnsx/dnsx_1.1.0_linux_amd64.zip"}, "sha256": {
+//	  "dnsx_1.1.0_linux_amd64.zip": "0c8f...e1d2"}}}}}}
+//
+// relative asset urls are resolved against the url of the index, the sha256 of
+// the assets are verified like the checksums of a release
+type IndexProvider struct {
+	indexURL *url.URL
+	region   string
+	sigV4    bool
+
+	once  sync.Once
+	index *toolIndex
+	err   error
+}
+
+type toolIndex struct {
+	Tools map[string]indexedTool `json:"tools"`
+}
+
+type indexedTool struct {
+	Latest   string                  `json:"latest"`
+	Versions map[string]indexedAsset `json:"versions"`
+}
+
+type indexedAsset struct {
+	Assets map[string]string `json:"assets"`
+	// Sizes contains the size in bytes of the assets (optional)
+	Sizes map[string]int64 `json:"sizes,omitempty"`
+	// SHA256 contains the hex encoded sha256 of the assets (optional)
+	SHA256 map[string]string `json:"sha256,omitempty"`
+}
+
+// NewIndexProvider returns an index provider, the requests to the host of the
+// index are signed with the credentials of the AWS_ACCESS_KEY_ID and
+// AWS_SECRET_ACCESS_KEY env variables when sigv4 is enabled
+func NewIndexProvider(config types.ProviderConfig) (*IndexProvider, error) {
+	if config.URL == "" {
+		return nil, fmt.Errorf("index provider requires the url of the index")
+	}
+	indexURL, err := url.Parse(storageURL(config.URL, config.Region))
+	if err != nil {
+		return nil, err
+	}
+	if indexURL.Scheme != "https" && indexURL.Scheme != "http" {
+		return nil, fmt.Errorf("unsupported index url %s", config.URL)
+	}
+	p := &IndexProvider{indexURL: indexURL, region: config.Region, sigV4: config.SigV4}
+	if p.region == "" {
+		p.region = os.Getenv("AWS_REGION")
+	}
+	if p.sigV4 && (p.region == "" || os.Getenv("AWS_ACCESS_KEY_ID") == "" || os.Getenv("AWS_SECRET_ACCESS_KEY") == "") {
+		return nil, fmt.Errorf("sigv4 requires a region and the AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY env variables")
+	}
+	return p, nil
+}
+
+// Latest returns the tool resolved to the latest version of the index
+func (p *IndexProvider) Latest(tool types.Tool) (types.Tool, error) {
+	indexed, err := p.tool(tool)
+	if err != nil {
+		return tool, err
+	}
+	return p.Release(tool, indexed.Latest)
+}
+
+// Release returns the tool resolved to the given version of the index
+func (p *IndexProvider) Release(tool types.Tool, version string) (types.Tool, error) {
+	indexed, err := p.tool(tool)
+	if err != nil {
+		return tool, err
+	}
+	version = strings.TrimPrefix(version, "v")
+	release, ok := indexed.Versions[version]
+	if !ok {
+		return tool, fmt.Errorf("version %s of %s not found in the index", version, tool.Name)
+	}
+	tool.Version = version
+	tool.Assets = make(map[string]string, len(release.Assets))
+	tool.AssetSizes = release.Sizes
+	tool.Checksums = release.SHA256
+	for name, location := range release.Assets {
+		assetURL, err := p.indexURL.Parse(storageURL(location, p.region))
+		if err != nil {
+			return tool, err
+		}
+		tool.Assets[name] = assetURL.String()
+	}
+	return tool, nil
+}
+
+// Origin returns the url of the index without credentials
+func (p *IndexProvider) Origin(tool types.Tool) string {
+	origin := *p.indexURL
+	origin.User, origin.RawQuery = nil, ""
+	return origin.String()
+}
+
+// Download returns the content of the asset with the given url
+func (p *IndexProvider) Download(tool types.Tool, ref string) (io.ReadCloser, error) {
+	resp, err := p.do(ref)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("unexpected status code %d while downloading %s", resp.StatusCode, tool.Name)
+	}
+	return resp.Body, nil
+}
+
+func (p *IndexProvider) tool(tool types.Tool) (indexedTool, error) {
+	p.once.Do(func() {
+		p.index, p.err = p.fetchIndex()
+	})
+	if p.err != nil {
+		return indexedTool{}, p.err
+	}
+	for name, indexed := range p.index.Tools {
+		if strings.EqualFold(name, tool.Name) {
+			return indexed, nil
+		}
+	}
+	return indexedTool{}, fmt.Errorf("%s not found in the index", tool.Name)
+}
+
+func (p *IndexProvider) fetchIndex() (*toolIndex, error) {
+	resp, err := p.do(p.indexURL.String())
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code %d while fetching the index", resp.StatusCode)
+	}
+	document, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if err := p.verify(document); err != nil {
+		return nil, err
+	}
+	var index toolIndex
+	if err := json.Unmarshal(document, &index); err != nil {
+		return nil, fmt.Errorf("could not read the index: %w", err)
+	}
+	return &index, nil
+}
+
+// verify checks the detached signature of the index when a registry key is configured
+func (p *IndexProvider) verify(document []byte) error {
+	if !signature.Enabled() {
+		return nil
+	}
+	signatureURL, err := signature.URL(p.indexURL.String())
+	if err != nil {
+		return err
+	}
+	resp, err := p.do(signatureURL)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%w: could not fetch signature (status code %d)", signature.ErrInvalidSignature, resp.StatusCode)
+	}
+	sig, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	return signature.Verify(document, sig)
+}
+
+func (p *IndexProvider) do(rawURL string) (*http.Response, error) {
+	req, err := http.NewRequest(http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	// assets on other hosts must not receive the credentials of the index
+	if p.sigV4 && strings.EqualFold(req.URL.Host, p.indexURL.Host) {
+		credentials := awsCredentials{
+			AccessKeyID:     os.Getenv("AWS_ACCESS_KEY_ID"),
+			SecretAccessKey: os.Getenv("AWS_SECRET_ACCESS_KEY"),
+			SessionToken:    os.Getenv("AWS_SESSION_TOKEN"),
+		}
+		signV4(req, credentials, p.region, "s3", time.Now())
+	}
+	return httpclient.Client.Do(req)
+}
+
+// storageURL converts s3:// and gs:// locations to their https url
+func storageURL(location, region string) string {
+	switch {
+	case strings.HasPrefix(location, "s3://"):
+		bucket, key, _ := strings.Cut(strings.TrimPrefix(location, "s3://"), "/")
+		if region == "" {
+			return fmt.Sprintf("https://%s.s3.amazonaws.com/%s", bucket, key)
+		}
+		return fmt.Sprintf("https://%s.s3.%s.amazonaws.com/%s", bucket, region, key)
+	case strings.HasPrefix(location, "gs://"):
+		return "https://storage.googleapis.com/" + strings.TrimPrefix(location, "gs://")
+	default:
+		return location
+	}
+}
@@ -0,0 +1,64 @@
+package pkg
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/projectdiscovery/pdtm/pkg/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIndexProviderRelease(t *testing.T) {
+	content := "dnsx binary"
+	mux := http.NewServeMux()
+	mux.HandleFunc("/pdtm/index.json", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"tools": {"dnsx": {"latest": "1.1.0", "versions": {"1.1.0": {
+			"assets": {"dnsx_1.1.0_linux_amd64.zip": "dnsx/dnsx_1.1.0_linux_amd64.zip"},
+			"sizes": {"dnsx_1.1.0_linux_amd64.zip": 11},
+			"sha256": {"dnsx_1.1.0_linux_amd64.zip": "` + sha256Hex(content) + `"}}}}}}`))
+	})
+	mux.HandleFunc("/pdtm/dnsx/dnsx_1.1.0_linux_amd64.zip", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(content))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	provider, err := NewIndexProvider(types.ProviderConfig{URL: server.URL + "/pdtm/index.json"})
+	require.Nil(t, err)
+	tool, err := provider.Latest(types.Tool{Name: "DNSX"})
+	require.Nil(t, err)
+	require.Equal(t, "1.1.0", tool.Version)
+	require.Equal(t, server.URL+"/pdtm/dnsx/dnsx_1.1.0_linux_amd64.zip", tool.Assets["dnsx_1.1.0_linux_amd64.zip"])
+	require.Equal(t, int64(11), tool.AssetSizes["dnsx_1.1.0_linux_amd64.zip"])
+
+	body, err := provider.Download(tool, tool.Assets["dnsx_1.1.0_linux_amd64.zip"])
+	require.Nil(t, err)
+	data, err := io.ReadAll(body)
+	body.Close()
+	require.Nil(t, err)
+	require.Nil(t, checkChecksums(tool, "dnsx_1.1.0_linux_amd64.zip", sha256Hex(string(data)), true))
+	require.ErrorContains(t, checkChecksums(tool, "dnsx_1.1.0_linux_amd64.zip", sha256Hex("tampered"), true), "checksum mismatch")
+
+	_, err = provider.Release(tool, "v1.0.0")
+	require.ErrorContains(t, err, "not found in the index")
+	_, err = provider.Latest(types.Tool{Name: "naabu"})
+	require.ErrorContains(t, err, "naabu not found")
+}
+
+func TestStorageURL(t *testing.T) {
+	tests := []struct {
+		location string
+		region   string
+		expected string
+	}{
+		{"s3://bucket/pdtm/index.json", "", "https://bucket.s3.amazonaws.com/pdtm/index.json"},
+		{"s3://bucket/pdtm/index.json", "eu-west-1", "https://bucket.s3.eu-west-1.amazonaws.com/pdtm/index.json"},
+		{"gs://bucket/pdtm/index.json", "", "https://storage.googleapis.com/bucket/pdtm/index.json"},
+		{"dnsx/dnsx.zip", "eu-west-1", "dnsx/dnsx.zip"},
+	}
+	for _, tt := range tests {
+		require.Equal(t, tt.expected, storageURL(tt.location, tt.region), tt.location)
+	}
+}
@@ -1,36 +1,47 @@
 package pkg
 
 import (
-	"archive/tar"
-	"archive/zip"
-	"bytes"
-	"compress/gzip"
-	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"io"
-	"net/http"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"runtime"
-	"strconv"
 	"strings"
+	"time"
 
-	"github.com/google/go-github/github"
 	"github.com/logrusorgru/aurora/v4"
 	"github.com/projectdiscovery/gologger"
 	ospath "github.com/projectdiscovery/pdtm/pkg/path"
+	"github.com/projectdiscovery/pdtm/pkg/state"
+	"github.com/projectdiscovery/pdtm/pkg/toolchain"
 	"github.com/projectdiscovery/pdtm/pkg/types"
+	"github.com/projectdiscovery/pdtm/pkg/version"
 )
 
 var (
 	extIfFound = ".exe"
 	au         = aurora.New(aurora.WithColors(true))
+
+	// GoBuild contains the build settings used by go install
+	GoBuild types.GoBuildConfig
+	// GoBinary is the go binary used by go install
+	GoBinary = "go"
+	// KeepQuarantine keeps the macOS quarantine attribute and the windows
+	// mark-of-the-web of the extracted binaries
+	KeepQuarantine bool
 )
 
 // Install installs given tool at path
-func Install(path string, tool types.Tool) error {
+func Install(path string, tool types.Tool) (err error) {
+	defer func() { notifyFinished(path, tool, err, true) }()
+	if IsDataPack(tool) {
+		return installDataPack(path, tool)
+	}
 	if _, exists := ospath.GetExecutablePath(path, tool.Name); exists {
+		adopt(path, tool)
 		return types.ErrIsInstalled
 	}
 	gologger.Info().Msgf("installing %s...", tool.Name)
@@ -43,185 +54,398 @@ func Install(path string, tool types.Tool) error {
 }
 
 // GoInstall installs given tool at path
-func GoInstall(path string, tool types.Tool) error {
+func GoInstall(path string, tool types.Tool) (err error) {
+	defer func() { notifyFinished(path, tool, err, true) }()
 	if _, exists := ospath.GetExecutablePath(path, tool.Name); exists {
+		adopt(path, tool)
 		return types.ErrIsInstalled
 	}
 	gologger.Info().Msgf("installing %s with go install...", tool.Name)
-	cmd := exec.Command("go", "install", "-v", fmt.Sprintf("github.com/projectdiscovery/%s/%s", tool.Name, tool.GoInstallPath))
-	cmd.Env = append(os.Environ(), "GOBIN="+path)
-	if output, err := cmd.CombinedOutput(); err != nil {
-		return fmt.Errorf("go install failed %s", string(output))
+	if err := goInstall(tool, path, ""); err != nil {
+		return err
 	}
 	gologger.Info().Msgf("installed %s %s (%s)", tool.Name, tool.Version, au.BrightGreen("latest").String())
 	return nil
 }
 
-func install(tool types.Tool, path string) (string, error) {
-	builder := &strings.Builder{}
-	builder.WriteString(tool.Name)
-	builder.WriteString("_")
-	builder.WriteString(strings.TrimPrefix(tool.Version, "v"))
-	builder.WriteString("_")
-	if strings.EqualFold(runtime.GOOS, "darwin") {
-		builder.WriteString("macOS")
-	} else {
-		builder.WriteString(runtime.GOOS)
-	}
-	builder.WriteString("_")
-	builder.WriteString(runtime.GOARCH)
-	var id int
-	var isZip, isTar bool
-loop:
-	for asset, assetID := range tool.Assets {
-		switch {
-		case strings.Contains(asset, ".zip"):
-			if strings.EqualFold(asset, builder.String()+".zip") {
-				id, _ = strconv.Atoi(assetID)
-				isZip = true
-				break loop
-			}
-		case strings.Contains(asset, ".tar.gz"):
-			if strings.EqualFold(asset, builder.String()+".tar.gz") {
-				id, _ = strconv.Atoi(assetID)
-				isTar = true
-				break loop
-			}
-		}
+// GoInstallRef installs given tool at path by building the given git ref
+// (branch, tag or commit) with go install
+func GoInstallRef(path string, tool types.Tool, ref string) (err error) {
+	defer func() { notifyFinished(path, tool, err, true) }()
+	if _, exists := ospath.GetExecutablePath(path, tool.Name); exists {
+		adopt(path, tool)
+		return types.ErrIsInstalled
 	}
-	builder.Reset()
-
-	// handle if id is zero (no asset found)
-	if id == 0 {
-		return "", fmt.Errorf(types.ErrNoAssetFound, runtime.GOOS, runtime.GOARCH)
+	gologger.Info().Msgf("installing %s@%s with go install...", tool.Name, ref)
+	if err := goInstall(tool, path, ref); err != nil {
+		return err
 	}
+	gologger.Info().Msgf("installed %s from %s", tool.Name, ref)
+	return nil
+}
 
-	_, rdurl, err := GithubClient().Repositories.DownloadReleaseAsset(context.Background(), types.Organization, tool.Repo, int64(id))
+// InstallFromArchive installs given tool at path from a local release archive
+func InstallFromArchive(path string, tool types.Tool, archive string) (err error) {
+	defer func() { notifyFinished(path, tool, err, true) }()
+	if _, exists := ospath.GetExecutablePath(path, tool.Name); exists {
+		adopt(path, tool)
+		return types.ErrIsInstalled
+	}
+	gologger.Info().Msgf("installing %s from %s...", tool.Name, archive)
+	f, err := os.Open(archive)
 	if err != nil {
-		if arlErr, ok := err.(*github.AbuseRateLimitError); ok {
-			// Provide user with more info regarding the rate limit
-			gologger.Error().Msgf("error for remaining request per hour: %s, RetryAfter: %s", err.Error(), arlErr.RetryAfter)
-		}
-		return "", err
+		return err
 	}
+	defer f.Close()
 
-	resp, err := http.Get(rdurl)
+	if err := newExtractor(tool, path, Event{Tool: tool.Name, Version: tool.Version, Asset: filepath.Base(archive)}).extractAsset(archive, f); err != nil {
+		return err
+	}
+	if _, exists := ospath.GetExecutablePath(path, tool.Name); !exists {
+		return fmt.Errorf("%s not found in archive %s", tool.Name, archive)
+	}
+	// the archive may not contain the latest release
+	if installedVersion, err := version.ExtractInstalledVersion(tool, path); err == nil {
+		tool.Version = installedVersion
+	}
+	origin, err := filepath.Abs(archive)
 	if err != nil {
-		return "", err
+		origin = archive
 	}
+	record(path, tool, filepath.Base(archive), state.SourceArchive, origin)
+	gologger.Info().Msgf("installed %s %s", tool.Name, tool.Version)
+	return nil
+}
 
-	defer resp.Body.Close()
-	if resp.StatusCode != 200 {
-		return "", err
+// goInstall builds the tool with go install at the given git ref,
+// the resolved version of the tool is built when no ref is given
+func goInstall(tool types.Tool, path, ref string) (err error) {
+	event := Event{Tool: tool.Name, Version: tool.Version, Event: EventBuild}
+	emit(event)
+	defer func() {
+		if err != nil {
+			event.Event, event.Error = EventError, err.Error()
+		} else {
+			event.Event = EventDone
+		}
+		emit(event)
+	}()
+
+	moduleVersion := ref
+	if moduleVersion == "" {
+		moduleVersion = "latest"
+		if tool.Version != "" && !IsNightly(tool.Version) {
+			moduleVersion = "v" + strings.TrimPrefix(tool.Version, "v")
+		}
+	}
+	if err := checkGoVersion(tool, moduleVersion); err != nil {
+		return err
+	}
+	buildOptions := GoBuild.For(tool.Name)
+	args := []string{"install", "-v"}
+	if buildOptions.LDFlags != "" {
+		args = append(args, "-ldflags", buildOptions.LDFlags)
+	}
+	if buildOptions.Tags != "" {
+		args = append(args, "-tags", buildOptions.Tags)
 	}
+	args = append(args, goPackage(tool)+"@"+moduleVersion)
+	cmd := exec.Command(GoBinary, args...)
+	cmd.Env = append(os.Environ(), "GOBIN="+path)
+	if buildOptions.GoFlags != "" {
+		cmd.Env = append(cmd.Env, "GOFLAGS="+buildOptions.GoFlags)
+	}
+	if buildOptions.CGO != nil {
+		cmd.Env = append(cmd.Env, fmt.Sprintf("CGO_ENABLED=%d", boolToInt(*buildOptions.CGO)))
+	}
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("go install failed %s", string(output))
+	}
+	if binary, exists := ospath.GetExecutablePath(path, tool.Name); exists {
+		labelBinary(binary)
+	}
+	if ref != "" {
+		// the version of a build from a git ref is only known by the binary itself
+		if installedVersion, err := version.ExtractInstalledVersion(tool, path); err == nil {
+			tool.Version = installedVersion
+		}
+	}
+	recordRef(path, tool, ref)
+	return nil
+}
+
+func install(tool types.Tool, path string) (version string, err error) {
+	assetName, ref, arch := findAsset(tool)
 
-	switch {
-	case isZip:
-		err := downloadZip(resp.Body, tool.Name, path)
+	// handle if ref is empty (no asset found)
+	if ref == "" {
+		return "", fmt.Errorf(types.ErrNoAssetFound, runtime.GOOS, runtime.GOARCH)
+	}
+	if arch != runtime.GOARCH {
+		gologger.Info().Msgf("no %s/%s build of %s found, installing %s build", runtime.GOOS, runtime.GOARCH, tool.Name, arch)
+	}
+
+	event := Event{Tool: tool.Name, Version: tool.Version, Asset: assetName, Total: tool.AssetSizes[assetName]}
+	defer func() {
 		if err != nil {
+			event.Event, event.Error = EventError, err.Error()
+		} else {
+			event.Event = EventDone
+		}
+		emit(event)
+	}()
+
+	event.Event = EventDownloadStarted
+	emit(event)
+	archive, sum, cached := cachedArchive(tool, assetName)
+	if cached {
+		event.Event = EventVerify
+		emit(event)
+		if err := verifyCachedArchive(tool, assetName, sum); err != nil {
+			gologger.Verbose().Msgf("not using the cached %s: %s", assetName, err)
+			cached = false
+		} else {
+			gologger.Verbose().Msgf("using the cached %s", assetName)
+		}
+	}
+	if !cached {
+		// the archive is streamed to a temporary file while hashed
+		if archive, sum, err = downloadArchive(tool, ref, event); err != nil {
 			return "", err
 		}
-	case isTar:
-		err := downloadTar(resp.Body, tool.Name, path)
-		if err != nil {
+		defer os.Remove(archive)
+	}
+	f, err := os.Open(archive)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	if info, err := f.Stat(); err == nil {
+		event.Bytes = info.Size()
+	}
+	event.Event = EventDownloadCompleted
+	emit(event)
+
+	// the cached archives were verified against the upstream checksums above
+	if !cached {
+		event.Event = EventVerify
+		emit(event)
+		if err := verifyChecksum(tool, assetName, sum); err != nil {
 			return "", err
 		}
+		cacheArchive(tool, assetName, archive, sum)
+	}
+
+	event.Event = EventExtract
+	emit(event)
+	if err := newExtractor(tool, path, event).extractAsset(assetName, f); err != nil {
+		return "", err
 	}
+	recordRelease(path, tool, assetName, sum)
 	return tool.Version, nil
 }
 
-func downloadTar(reader io.Reader, toolName, path string) error {
-	gzipReader, err := gzip.NewReader(reader)
+// downloadArchive downloads the release asset to a temporary file, it returns
+// the path of the file and its sha256
+func downloadArchive(tool types.Tool, ref string, event Event) (string, string, error) {
+	body, err := downloadAsset(tool, ref)
 	if err != nil {
-		return err
+		return "", "", err
 	}
-	tarReader := tar.NewReader(gzipReader)
-	// iterate through the files in the archive
-	for {
-		header, err := tarReader.Next()
-		if err == io.EOF {
-			break
-		}
+	defer body.Close()
+	f, err := os.CreateTemp("", "pdtm-"+tool.Name+"-*")
+	if err != nil {
+		return "", "", err
+	}
+	defer f.Close()
+	progress := &progressReader{Reader: body, event: event, last: time.Now()}
+	progress.event.Event = EventDownloadProgress
+	hash := sha256.New()
+	if _, err := io.Copy(io.MultiWriter(f, hash), progress); err != nil {
+		_ = os.Remove(f.Name())
+		return "", "", err
+	}
+	if err := f.Close(); err != nil {
+		_ = os.Remove(f.Name())
+		return "", "", err
+	}
+	return f.Name(), hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// downloadAsset returns the content of the release asset from the active provider
+func downloadAsset(tool types.Tool, ref string) (io.ReadCloser, error) {
+	return ActiveProvider.Download(tool, ref)
+}
+
+// findAsset returns the release asset of the tool for the current platform
+// preferring native builds over the ones running through emulation
+func findAsset(tool types.Tool) (string, string, string) {
+	for _, arch := range ospath.GetArchs() {
+		baseName, err := assetBaseName(tool, runtime.GOOS, arch)
 		if err != nil {
-			return err
-		}
-		if !strings.EqualFold(strings.TrimSuffix(header.FileInfo().Name(), extIfFound), toolName) {
-			continue
+			gologger.Verbose().Msgf("invalid asset template of %s: %s", tool.Name, err)
+			return "", "", ""
 		}
-		// if the file is not a directory, extract it
-		if !header.FileInfo().IsDir() {
-			filePath := filepath.Join(path, header.FileInfo().Name())
-			if !strings.HasPrefix(filePath, filepath.Clean(path)+string(os.PathSeparator)) {
-				return err
+		for asset, assetID := range tool.Assets {
+			if matchAsset(asset, baseName) {
+				return asset, assetID, arch
 			}
+		}
+	}
+	return "", "", ""
+}
 
-			if err := os.MkdirAll(filepath.Dir(filePath), os.ModePerm); err != nil {
-				return err
-			}
+// HasAsset returns true if a release binary of the tool exists for the current platform
+func HasAsset(tool types.Tool) bool {
+	_, ref, _ := findAsset(tool)
+	return ref != ""
+}
 
-			dstFile, err := os.OpenFile(filePath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, header.FileInfo().Mode())
-			if err != nil {
-				return err
-			}
-			defer dstFile.Close()
-			// copy the file data from the archive
-			_, err = io.Copy(dstFile, tarReader)
-			if err != nil {
-				return err
-			}
-			// set the file permissions
-			err = os.Chmod(dstFile.Name(), 0755)
-			if err != nil {
-				return err
-			}
-		}
+// DownloadSize returns the size of the release asset installed for the
+// current platform, false if the size is unknown
+func DownloadSize(tool types.Tool) (int64, bool) {
+	assetName, _, _ := findAsset(tool)
+	size, ok := tool.AssetSizes[assetName]
+	return size, ok && assetName != ""
+}
+
+func boolToInt(b bool) int {
+	if b {
+		return 1
 	}
-	return nil
+	return 0
 }
 
-func downloadZip(reader io.Reader, toolName, path string) error {
-	buff := bytes.NewBuffer([]byte{})
-	size, err := io.Copy(buff, reader)
+// record stores the details of the installed tool and where it was
+// installed from in the state of path
+func record(path string, tool types.Tool, asset string, source state.Source, origin string) {
+	recordInstall(path, tool, asset, source, "", origin, "")
+}
+
+// recordRelease stores the details of a tool installed from the release
+// asset, along with the sha256 of the asset
+func recordRelease(path string, tool types.Tool, asset, digest string) {
+	recordInstall(path, tool, asset, state.SourceRelease, "", releaseOrigin(tool), digest)
+}
+
+// recordRef stores the details of a tool built from a git ref in the state of path
+func recordRef(path string, tool types.Tool, ref string) {
+	recordInstall(path, tool, "", state.SourceGoInstall, ref, goPackage(tool), "")
+}
+
+// SetDependencyOf records the tools the installed tool is a dependency of,
+// no tools marks it as requested explicitly
+func SetDependencyOf(path, toolName string, dependents []string) error {
+	st, err := state.Load(path)
 	if err != nil {
 		return err
 	}
-	zipReader, err := zip.NewReader(bytes.NewReader(buff.Bytes()), size)
-	if err != nil {
-		return err
+	installed, ok := st.Get(toolName)
+	if !ok {
+		return nil
 	}
-	for _, f := range zipReader.File {
-		if !strings.EqualFold(strings.TrimSuffix(f.Name, extIfFound), toolName) {
-			continue
-		}
-		filePath := filepath.Join(path, f.Name)
-		if !strings.HasPrefix(filePath, filepath.Clean(path)+string(os.PathSeparator)) {
-			return err
-		}
+	installed.DependencyOf = dependents
+	return st.Save()
+}
 
-		if err := os.MkdirAll(filepath.Dir(filePath), os.ModePerm); err != nil {
-			return err
+// checkGoVersion fails when the go binary is older than the go version the
+// tool requires, instead of letting go install fail on newer language features
+func checkGoVersion(tool types.Tool, moduleVersion string) error {
+	required := tool.GoVersion
+	if required == "" {
+		var err error
+		if required, err = toolchain.RequiredVersion(goPackage(tool), moduleVersion); err != nil {
+			gologger.Verbose().Msgf("could not find the go version required by %s: %s", tool.Name, err)
+			return nil
 		}
+	}
+	if required == "" {
+		return nil
+	}
+	installed, err := toolchain.InstalledVersion(GoBinary)
+	if err != nil {
+		gologger.Verbose().Msgf("could not find the version of %s: %s", GoBinary, err)
+		return nil
+	}
+	if !installed.Satisfies(required) {
+		return fmt.Errorf("%s requires go %s but go %s is installed, upgrade go from https://go.dev/dl/ and retry", tool.Name, required, installed.Version)
+	}
+	return nil
+}
 
-		dstFile, err := os.OpenFile(filePath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, f.Mode())
-		if err != nil {
-			return err
-		}
+// goPackage returns the package of the tool built by go install
+func goPackage(tool types.Tool) string {
+	return fmt.Sprintf("github.com/%s/%s/%s", types.Organization, tool.Name, tool.GoInstallPath)
+}
 
-		fileInArchive, err := f.Open()
-		if err != nil {
-			return err
+func recordInstall(path string, tool types.Tool, asset string, source state.Source, ref, origin, digest string) {
+	st, err := state.Load(path)
+	if err != nil {
+		// the other records are kept, the tool is recorded once the state is fixed
+		gologger.Warning().Msgf("could not record %s: %s", tool.Name, err)
+		return
+	}
+	now := time.Now()
+	installed := &state.Tool{
+		Name: tool.Name, Version: tool.Version, Asset: asset, Source: source, Origin: origin, Ref: ref, Digest: digest, Nightly: IsNightly(tool.Version),
+		Installed: st.InstalledAt(tool.Name, now), Updated: &now, History: st.HistoryOf(tool.Name, tool.Version, now),
+	}
+	if previous, ok := st.Get(tool.Name); ok {
+		installed.Capabilities = previous.Capabilities
+		installed.DependencyOf = previous.DependencyOf
+	}
+	for _, binary := range binaryNames(tool)[1:] {
+		if binaryPath, ok := ospath.GetExecutablePath(path, binary); ok {
+			installed.Binaries = append(installed.Binaries, binary)
+			if hash, err := state.Hash(binaryPath); err == nil {
+				if installed.BinaryHashes == nil {
+					installed.BinaryHashes = make(map[string]string)
+				}
+				installed.BinaryHashes[binary] = hash
+			}
 		}
-
-		if _, err := io.Copy(dstFile, fileInArchive); err != nil {
-			return err
+	}
+	executablePath, exists := ospath.GetExecutablePath(path, tool.Name)
+	if exists {
+		installed.Hash, _ = state.Hash(executablePath)
+		if installed.Capabilities == "" {
+			dedupe(executablePath, installed.Hash)
 		}
-		err = os.Chmod(dstFile.Name(), 0755)
-		if err != nil {
-			return err
+		// a release re-tagged with the same binary still reports the version
+		// the binary was first installed as
+		if previous, ok := st.Get(tool.Name); ok && previous.Hash != "" && previous.Hash == installed.Hash {
+			installed.ArchiveVersion = previous.ArchiveVersion
+			if installed.ArchiveVersion == "" {
+				installed.ArchiveVersion = previous.Version
+			}
+			if strings.EqualFold(strings.TrimPrefix(installed.ArchiveVersion, "v"), strings.TrimPrefix(tool.Version, "v")) {
+				installed.ArchiveVersion = ""
+			}
 		}
+	}
+	st.Set(installed)
+	if err := st.Save(); err != nil {
+		gologger.Warning().Msgf("could not save state: %s", err)
+	}
+	if exists {
+		reapplyCapabilities(executablePath, tool.Name, installed.Capabilities)
+	}
+}
 
-		dstFile.Close()
-		fileInArchive.Close()
+// adopt records an existing binary that wasn't installed by pdtm so that
+// it can be managed from now on
+func adopt(path string, tool types.Tool) {
+	st, err := state.Load(path)
+	if err != nil {
+		return
 	}
-	return nil
+	if _, ok := st.Get(tool.Name); ok {
+		return
+	}
+	installedVersion, err := version.ExtractInstalledVersion(tool, path)
+	if err != nil {
+		return
+	}
+	tool.Version = installedVersion
+	record(path, tool, "", state.SourceAdopted, "")
 }
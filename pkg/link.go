@@ -0,0 +1,75 @@
+package pkg
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/projectdiscovery/gologger"
+)
+
+// Link creates or updates the symlink in linkDir pointing to the binary,
+// sudo is used when linkDir isn't writable by the current user
+func Link(binary, linkDir string) error {
+	link := filepath.Join(linkDir, filepath.Base(binary))
+	fi, err := os.Lstat(link)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+	case err != nil:
+		return err
+	case fi.Mode()&os.ModeSymlink == 0:
+		// never replace binaries that weren't linked by pdtm
+		return fmt.Errorf("%s exists and is not a symlink", link)
+	default:
+		if target, _ := os.Readlink(link); target == binary {
+			return nil
+		}
+	}
+
+	// the link is created next to the final one and renamed over it so the
+	// command is never missing while being updated
+	tmpLink := link + ".pdtm-tmp"
+	_ = os.Remove(tmpLink)
+	err = os.Symlink(binary, tmpLink)
+	if err == nil {
+		if err = os.Rename(tmpLink, link); err != nil {
+			_ = os.Remove(tmpLink)
+		}
+	}
+	if errors.Is(err, os.ErrPermission) && runtime.GOOS != "windows" {
+		gologger.Info().Msgf("%s is not writable, linking with sudo", linkDir)
+		return Sudo("ln", "-sfn", binary, link)
+	}
+	return err
+}
+
+// Unlink removes the symlink in linkDir if it points to the binary
+func Unlink(binary, linkDir string) error {
+	link := filepath.Join(linkDir, filepath.Base(binary))
+	if target, err := os.Readlink(link); err != nil || target != binary {
+		return nil
+	}
+	err := os.Remove(link)
+	if errors.Is(err, os.ErrPermission) && runtime.GOOS != "windows" {
+		gologger.Info().Msgf("%s is not writable, unlinking with sudo", linkDir)
+		return Sudo("rm", "-f", link)
+	}
+	return err
+}
+
+// Sudo runs the command as root, through sudo unless pdtm runs as root
+func Sudo(name string, args ...string) error {
+	cmd := exec.Command(name, args...)
+	if os.Geteuid() != 0 {
+		cmd = exec.Command("sudo", append([]string{name}, args...)...)
+	}
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stderr
+	cmd.Stderr = os.Stderr
+	gologger.Verbose().Msgf("running %s", strings.Join(cmd.Args, " "))
+	return cmd.Run()
+}
@@ -0,0 +1,151 @@
+package lock
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/projectdiscovery/gologger"
+)
+
+var (
+	// ErrTimeout is returned when the lock is still held by another process
+	// once the timeout expires
+	ErrTimeout = errors.New("timed out waiting for the lock")
+
+	// StaleAfter is the age after which a lock held by a process of another
+	// host (whose liveness can't be checked) is considered stale
+	StaleAfter = time.Hour
+
+	retryInterval = 500 * time.Millisecond
+)
+
+// Lock is an exclusive lock held by the current process through a lock file
+type Lock struct {
+	file string
+}
+
+// owner is the content of the lock file
+type owner struct {
+	PID     int       `json:"pid"`
+	Host    string    `json:"host"`
+	Created time.Time `json:"created"`
+}
+
+// Acquire creates the lock file, waiting up to timeout for the process
+// holding it to release it. Locks left by processes that are no longer
+// running are removed.
+func Acquire(file string, timeout time.Duration) (*Lock, error) {
+	if err := os.MkdirAll(filepath.Dir(file), os.ModePerm); err != nil {
+		return nil, err
+	}
+	deadline := time.Now().Add(timeout)
+	var waiting bool
+	for {
+		err := create(file)
+		if err == nil {
+			return &Lock{file: file}, nil
+		}
+		if !errors.Is(err, os.ErrExist) {
+			return nil, err
+		}
+
+		current, err := read(file)
+		if err != nil || current.stale() {
+			gologger.Verbose().Msgf("removing stale lock %s", file)
+			if err := removeStale(file); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("%w: %s is held by pid %d", ErrTimeout, file, current.PID)
+		}
+		if !waiting {
+			gologger.Info().Msgf("waiting for another pdtm process (pid %d) to finish", current.PID)
+			waiting = true
+		}
+		time.Sleep(retryInterval)
+	}
+}
+
+// Release removes the lock file if it's still held by the current process,
+// the host is compared too as the lock may be on a shared filesystem
+func (l *Lock) Release() error {
+	if l == nil {
+		return nil
+	}
+	current, err := read(l.file)
+	if err != nil || current.PID != os.Getpid() {
+		return nil
+	}
+	if host, _ := os.Hostname(); current.Host != host {
+		return nil
+	}
+	return os.Remove(l.file)
+}
+
+// removeStale moves the stale lock aside before removing it, a process that
+// replaced it in the meantime keeps its lock as the moved one is put back
+func removeStale(file string) error {
+	aside := fmt.Sprintf("%s.stale-%d-%d", file, os.Getpid(), time.Now().UnixNano())
+	if err := os.Rename(file, aside); err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+	defer os.Remove(aside)
+	if moved, err := read(aside); err == nil && !moved.stale() {
+		if err := os.Link(aside, file); err != nil && !errors.Is(err, os.ErrExist) {
+			return err
+		}
+	}
+	return nil
+}
+
+func create(file string) error {
+	f, err := os.OpenFile(file, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if err != nil {
+		return err
+	}
+	host, _ := os.Hostname()
+	err = json.NewEncoder(f).Encode(owner{PID: os.Getpid(), Host: host, Created: time.Now()})
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		_ = os.Remove(file)
+	}
+	return err
+}
+
+func read(file string) (*owner, error) {
+	b, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	var o owner
+	if err := json.Unmarshal(b, &o); err != nil {
+		// the lock may have been created but not written yet
+		if fi, statErr := os.Stat(file); statErr == nil && time.Since(fi.ModTime()) < 5*time.Second {
+			return &owner{Created: fi.ModTime()}, nil
+		}
+		return nil, err
+	}
+	return &o, nil
+}
+
+// stale returns true if the process holding the lock is no longer running
+func (o *owner) stale() bool {
+	if o.PID == 0 {
+		return false
+	}
+	if host, _ := os.Hostname(); o.Host != host {
+		return time.Since(o.Created) > StaleAfter
+	}
+	return !ProcessAlive(o.PID)
+}
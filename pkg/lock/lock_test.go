@@ -0,0 +1,70 @@
+package lock
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAcquire(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "pdtm.lock")
+
+	l, err := Acquire(file, time.Second)
+	require.Nil(t, err)
+
+	_, err = Acquire(file, 0)
+	require.True(t, errors.Is(err, ErrTimeout), "lock held by a live process should not be acquired")
+
+	require.Nil(t, l.Release())
+	require.NoFileExists(t, file)
+}
+
+func TestAcquireStale(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "pdtm.lock")
+	host, _ := os.Hostname()
+	// pid of a process that isn't running
+	b, _ := json.Marshal(owner{PID: 1 << 22, Host: host, Created: time.Now()})
+	require.Nil(t, os.WriteFile(file, b, 0644))
+
+	l, err := Acquire(file, 0)
+	require.Nil(t, err, "stale lock should be removed")
+	require.Nil(t, l.Release())
+}
+
+func TestReleaseOtherHost(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "pdtm.lock")
+	l, err := Acquire(file, 0)
+	require.Nil(t, err)
+
+	// the lock was taken over by a process of another host with the same pid
+	b, _ := json.Marshal(owner{PID: os.Getpid(), Host: "other-host", Created: time.Now()})
+	require.Nil(t, os.WriteFile(file, b, 0644))
+	require.Nil(t, l.Release())
+	require.FileExists(t, file)
+}
+
+func TestRemoveStaleReplaced(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "pdtm.lock")
+
+	// the stale lock was already replaced by the lock of a live process
+	l, err := Acquire(file, 0)
+	require.Nil(t, err)
+	require.Nil(t, removeStale(file))
+	require.FileExists(t, file, "the live lock is put back")
+	current, err := read(file)
+	require.Nil(t, err)
+	require.Equal(t, os.Getpid(), current.PID)
+	require.Nil(t, l.Release())
+
+	// the stale lock was already removed
+	require.Nil(t, removeStale(file))
+	entries, err := os.ReadDir(dir)
+	require.Nil(t, err)
+	require.Empty(t, entries)
+}
@@ -0,0 +1,22 @@
+//go:build windows
+
+package lock
+
+import "golang.org/x/sys/windows"
+
+// stillActive is the exit code of processes that haven't exited yet
+const stillActive = 259
+
+// ProcessAlive returns true if a process with the pid is running
+func ProcessAlive(pid int) bool {
+	h, err := windows.OpenProcess(windows.PROCESS_QUERY_LIMITED_INFORMATION, false, uint32(pid))
+	if err != nil {
+		return false
+	}
+	defer windows.CloseHandle(h)
+	var code uint32
+	if err := windows.GetExitCodeProcess(h, &code); err != nil {
+		return false
+	}
+	return code == stillActive
+}
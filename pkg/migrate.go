@@ -0,0 +1,145 @@
+package pkg
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	ospath "github.com/projectdiscovery/pdtm/pkg/path"
+	"github.com/projectdiscovery/pdtm/pkg/state"
+)
+
+// Migrate moves the managed binaries and their state from one path to another,
+// the moved binaries are validated against the hashes recorded in the state
+func Migrate(from, to string) (int, error) {
+	if filepath.Clean(from) == filepath.Clean(to) {
+		return 0, fmt.Errorf("%s is already the binary path", to)
+	}
+	src, err := state.Load(from)
+	if err != nil {
+		return 0, err
+	}
+	dst, err := state.Load(to)
+	if err != nil {
+		return 0, err
+	}
+	if err := os.MkdirAll(to, os.ModePerm); err != nil {
+		return 0, err
+	}
+
+	var count int
+	for name, tool := range src.Tools {
+		executablePath, exists := ospath.GetExecutablePath(from, tool.Name)
+		if !exists {
+			continue
+		}
+		if err := migrateTool(from, to, executablePath, tool); err != nil {
+			return count, err
+		}
+		// the state is saved after each tool so that a failed migration
+		// leaves every binary recorded in the path it's in
+		dst.Set(tool)
+		if err := dst.Save(); err != nil {
+			return count, err
+		}
+		src.Delete(name)
+		if err := src.Save(); err != nil {
+			return count, err
+		}
+		count++
+	}
+	if len(src.Tools) == 0 {
+		_ = os.Remove(filepath.Join(from, state.FileName))
+	}
+	_ = os.Remove(filepath.Join(from, VersionsDir))
+	return count, nil
+}
+
+// migrateTool moves the binary of the tool along with its additional binaries
+// and its kept versions, the binary is moved back when it doesn't match the
+// hash recorded at its install
+func migrateTool(from, to, executablePath string, tool *state.Tool) error {
+	target := filepath.Join(to, filepath.Base(executablePath))
+	if err := moveFile(executablePath, target); err != nil {
+		return err
+	}
+	if tool.Hash != "" {
+		if hash, err := state.Hash(target); err != nil || hash != tool.Hash {
+			if err := moveFile(target, executablePath); err != nil {
+				return fmt.Errorf("%s was modified while being moved to %s and could not be moved back: %s", tool.Name, target, err)
+			}
+			return fmt.Errorf("%s was modified since it was installed, it was left in %s", tool.Name, from)
+		}
+	}
+	for _, binary := range tool.Binaries {
+		if binaryPath, exists := ospath.GetExecutablePath(from, binary); exists {
+			if err := moveFile(binaryPath, filepath.Join(to, filepath.Base(binaryPath))); err != nil {
+				return err
+			}
+		}
+	}
+	versions := filepath.Join(from, VersionsDir, strings.ToLower(tool.Name))
+	if _, err := os.Stat(versions); err != nil {
+		return nil
+	}
+	return moveDir(versions, filepath.Join(to, VersionsDir, strings.ToLower(tool.Name)))
+}
+
+// moveDir renames the directory falling back to moving its files when it's
+// on another device or the target already exists
+func moveDir(from, to string) error {
+	if err := os.MkdirAll(filepath.Dir(to), os.ModePerm); err != nil {
+		return err
+	}
+	if err := os.Rename(from, to); err == nil {
+		return nil
+	}
+	err := filepath.WalkDir(from, func(file string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(from, file)
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return os.MkdirAll(filepath.Join(to, rel), os.ModePerm)
+		}
+		return moveFile(file, filepath.Join(to, rel))
+	})
+	if err != nil {
+		return err
+	}
+	return os.RemoveAll(from)
+}
+
+// moveFile renames the file falling back to copying it across devices
+func moveFile(from, to string) error {
+	if err := os.Rename(from, to); err == nil {
+		return nil
+	}
+	in, err := os.Open(from)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	info, err := in.Stat()
+	if err != nil {
+		return err
+	}
+	out, err := os.OpenFile(to, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, info.Mode())
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	if err := out.Close(); err != nil {
+		return err
+	}
+	return os.Remove(from)
+}
@@ -0,0 +1,66 @@
+package pkg
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/projectdiscovery/pdtm/pkg/state"
+	"github.com/projectdiscovery/pdtm/pkg/types"
+	"github.com/stretchr/testify/require"
+)
+
+// recordBinary writes the binary of the tool to path and records its hash
+func recordBinary(t *testing.T, path, name, content string) {
+	file := filepath.Join(path, name+extIfFound)
+	require.Nil(t, os.WriteFile(file, []byte(content), 0755))
+	hash, err := state.Hash(file)
+	require.Nil(t, err)
+	st, err := state.Load(path)
+	require.Nil(t, err)
+	st.Set(&state.Tool{Name: name, Version: "1.1.1", Hash: hash})
+	require.Nil(t, st.Save())
+}
+
+func TestMigrate(t *testing.T) {
+	from, to := t.TempDir(), filepath.Join(t.TempDir(), "bin")
+	recordBinary(t, from, "dnsx", "dnsx 1.1.1")
+	kept := storedVersionPath(from, types.Tool{Name: "dnsx"}, "1.1.0")
+	require.Nil(t, os.MkdirAll(filepath.Dir(kept), 0755))
+	require.Nil(t, os.WriteFile(kept, []byte("dnsx 1.1.0"), 0644))
+
+	count, err := Migrate(from, to)
+	require.Nil(t, err)
+	require.Equal(t, 1, count)
+	require.FileExists(t, filepath.Join(to, "dnsx"+extIfFound))
+	require.Equal(t, []string{"1.1.0"}, StoredVersions(to, types.Tool{Name: "dnsx"}))
+	require.NoDirExists(t, filepath.Join(from, VersionsDir))
+	require.NoFileExists(t, filepath.Join(from, state.FileName))
+}
+
+func TestMigrateModified(t *testing.T) {
+	from, to := t.TempDir(), t.TempDir()
+	recordBinary(t, from, "dnsx", "dnsx 1.1.1")
+	recordBinary(t, from, "naabu", "naabu 2.0.0")
+	require.Nil(t, os.WriteFile(filepath.Join(from, "naabu"+extIfFound), []byte("tampered"), 0755))
+
+	_, err := Migrate(from, to)
+	require.ErrorContains(t, err, "naabu was modified")
+	require.FileExists(t, filepath.Join(from, "naabu"+extIfFound), "the modified binary is moved back")
+	require.NoFileExists(t, filepath.Join(to, "naabu"+extIfFound))
+
+	// the tools moved before the failure are recorded in their new path
+	src, err := state.Load(from)
+	require.Nil(t, err)
+	dst, err := state.Load(to)
+	require.Nil(t, err)
+	for _, name := range []string{"dnsx", "naabu"} {
+		_, inSource := src.Get(name)
+		_, inTarget := dst.Get(name)
+		require.NotEqual(t, inSource, inTarget, name)
+		_, exists := os.Stat(filepath.Join(to, name+extIfFound))
+		require.Equal(t, inTarget, exists == nil, name)
+	}
+	_, ok := dst.Get("naabu")
+	require.False(t, ok)
+}
@@ -0,0 +1,161 @@
+package pkg
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/projectdiscovery/gologger"
+	"github.com/projectdiscovery/pdtm/pkg/httpclient"
+	"github.com/projectdiscovery/pdtm/pkg/types"
+)
+
+// DownloadMirror is the url template of the host the github release assets
+// are downloaded from first (eg. a cdn), github is used when the asset
+// isn't found there or the host doesn't answer in time
+var DownloadMirror *template.Template
+
+// defaultMirrorPath is appended to the mirror urls without template
+const defaultMirrorPath = "/{{.Repo}}/{{.Tag}}/{{.Asset}}"
+
+// mirrorTimeout is how long the mirror has to answer before falling back to
+// github, and how long the download can stall once started
+var mirrorTimeout = 10 * time.Second
+
+// mirroredAsset is the data of the download mirror url template
+type mirroredAsset struct {
+	Name    string
+	Repo    string
+	Version string
+	Tag     string
+	Asset   string
+}
+
+// ParseDownloadMirror parses the url template of the download mirror, the
+// assets of a mirror url without template are expected at /<repo>/<tag>/<asset>
+func ParseDownloadMirror(location string) (*template.Template, error) {
+	if location == "" {
+		return nil, nil
+	}
+	if !strings.HasPrefix(location, "https://") && !strings.HasPrefix(location, "http://") {
+		return nil, fmt.Errorf("invalid download mirror %s: expected an http(s) url", location)
+	}
+	if !strings.Contains(location, "{{") {
+		location = strings.TrimSuffix(location, "/") + defaultMirrorPath
+	}
+	return template.New("mirror").Option("missingkey=error").Parse(location)
+}
+
+// downloadFromMirror returns the github release asset downloaded from the
+// mirror, nil when the github release has to be used instead: the mirror
+// doesn't have the asset, fails or stalls, or the asset doesn't match an
+// upstream checksum (the assets without one are always downloaded from github)
+func downloadFromMirror(tool types.Tool, ref string) io.ReadCloser {
+	if DownloadMirror == nil || tool.Version == "" || IsNightly(tool.Version) {
+		return nil
+	}
+	var assetName string
+	for name, assetRef := range tool.Assets {
+		if assetRef == ref {
+			assetName = name
+			break
+		}
+	}
+	// the checksums always come from github, a compromised mirror could
+	// serve matching checksums with its assets otherwise
+	if assetName == "" || strings.HasSuffix(assetName, "_checksums.txt") {
+		return nil
+	}
+	version := strings.TrimPrefix(tool.Version, "v")
+	var buf bytes.Buffer
+	if err := DownloadMirror.Execute(&buf, mirroredAsset{Name: tool.Name, Repo: tool.Repo, Version: version, Tag: "v" + version, Asset: assetName}); err != nil {
+		gologger.Verbose().Msgf("could not build the mirror url of %s: %s", assetName, err)
+		return nil
+	}
+	mirrorURL := buf.String()
+
+	// the request is canceled when the mirror doesn't answer or stalls
+	ctx, cancel := context.WithCancel(context.Background())
+	stall := time.AfterFunc(mirrorTimeout, cancel)
+	body, err := download(ctx, httpclient.Client, mirrorURL)
+	if err != nil {
+		stall.Stop()
+		cancel()
+		gologger.Verbose().Msgf("could not download %s from the mirror, falling back to github: %s", assetName, err)
+		return nil
+	}
+	stall.Reset(mirrorTimeout)
+	gologger.Verbose().Msgf("downloading %s from %s", assetName, mirrorURL)
+	file, err := bufferMirrorDownload(tool, assetName, &mirrorBody{ReadCloser: body, cancel: cancel, stall: stall})
+	if err != nil {
+		gologger.Verbose().Msgf("could not use %s from the mirror, falling back to github: %s", assetName, err)
+		return nil
+	}
+	return file
+}
+
+// bufferMirrorDownload saves the mirror download to a temporary file, the
+// download is only used once complete and matching an upstream checksum
+// since the mirror isn't trusted, any failure falls back to github
+func bufferMirrorDownload(tool types.Tool, assetName string, body io.ReadCloser) (io.ReadCloser, error) {
+	defer body.Close()
+	f, err := os.CreateTemp("", "pdtm-mirror-*")
+	if err != nil {
+		return nil, err
+	}
+	file := &removingFile{File: f}
+	h := sha256.New()
+	if _, err := io.Copy(io.MultiWriter(f, h), body); err != nil {
+		file.Close()
+		return nil, err
+	}
+	if err := checkChecksums(tool, assetName, hex.EncodeToString(h.Sum(nil)), true); err != nil {
+		file.Close()
+		return nil, err
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		file.Close()
+		return nil, err
+	}
+	return file, nil
+}
+
+// removingFile removes the temporary file once closed
+type removingFile struct {
+	*os.File
+}
+
+func (f *removingFile) Close() error {
+	err := f.File.Close()
+	os.Remove(f.Name())
+	return err
+}
+
+// mirrorBody cancels the download when the mirror sends nothing for
+// mirrorTimeout and releases the context of the request once closed
+type mirrorBody struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+	stall  *time.Timer
+}
+
+func (b *mirrorBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if n > 0 {
+		b.stall.Reset(mirrorTimeout)
+	}
+	return n, err
+}
+
+func (b *mirrorBody) Close() error {
+	b.stall.Stop()
+	defer b.cancel()
+	return b.ReadCloser.Close()
+}
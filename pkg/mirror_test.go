@@ -0,0 +1,87 @@
+package pkg
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/projectdiscovery/pdtm/pkg/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDownloadMirror(t *testing.T) {
+	fake := useFakeGithub(t)
+	fake.Assets[1] = []byte("github")
+	fake.Assets[2] = []byte("github")
+	fake.Assets[3] = []byte("github")
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/dnsx/v1.1.0/dnsx_1.1.0_linux_amd64.zip":
+			_, _ = w.Write([]byte("mirror"))
+		case "/dnsx/v1.1.0/dnsx_1.1.0_linux_386.zip":
+			time.Sleep(time.Second)
+		case "/dnsx/v1.1.0/dnsx_1.1.0_checksums.txt":
+			_, _ = w.Write([]byte("0000  dnsx_1.1.0_linux_amd64.zip\n"))
+		case "/dnsx/v1.1.0/dnsx_1.1.0_linux_arm.zip", "/dnsx/v1.1.0/dnsx_1.1.0_windows_amd64.zip":
+			_, _ = w.Write([]byte("tampered"))
+		case "/dnsx/v1.1.0/dnsx_1.1.0_darwin_amd64.zip":
+			// the headers and the start of the body arrive, then the mirror stalls
+			w.(http.Flusher).Flush()
+			_, _ = w.Write([]byte("mir"))
+			w.(http.Flusher).Flush()
+			time.Sleep(time.Second)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	mirror, err := ParseDownloadMirror(server.URL)
+	require.Nil(t, err)
+	DownloadMirror = mirror
+	defer func() { DownloadMirror = nil }()
+	defaultTimeout := mirrorTimeout
+	mirrorTimeout = 100 * time.Millisecond
+	defer func() { mirrorTimeout = defaultTimeout }()
+
+	tool := types.Tool{Name: "dnsx", Repo: "dnsx", Version: "1.1.0", Assets: map[string]string{
+		"dnsx_1.1.0_linux_amd64.zip":   "1",
+		"dnsx_1.1.0_linux_arm64.zip":   "2",
+		"dnsx_1.1.0_linux_386.zip":     "3",
+		"dnsx_1.1.0_checksums.txt":     "4",
+		"dnsx_1.1.0_darwin_amd64.zip":  "5",
+		"dnsx_1.1.0_linux_arm.zip":     "6",
+		"dnsx_1.1.0_windows_amd64.zip": "7",
+	}, Checksums: map[string]string{
+		"dnsx_1.1.0_linux_amd64.zip":  sha256Hex("mirror"),
+		"dnsx_1.1.0_darwin_amd64.zip": sha256Hex("mirror"),
+		"dnsx_1.1.0_linux_arm.zip":    sha256Hex("github"),
+	}, Digests: map[string]string{}}
+	for id := int64(5); id <= 7; id++ {
+		fake.Assets[id] = []byte("github")
+	}
+	fake.Assets[4] = []byte("github checksums")
+	provider := &GithubProvider{}
+	for ref, expected := range map[string]string{
+		"1": "mirror",
+		"2": "github", // not found on the mirror
+		"3": "github", // the mirror doesn't answer in time
+		"4": "github checksums",
+		"5": "github", // the mirror stalls during the download
+		"6": "github", // the mirror asset doesn't match the checksum
+		"7": "github", // no upstream checksum to verify the mirror asset
+	} {
+		body, err := provider.Download(tool, ref)
+		require.Nil(t, err)
+		data, err := io.ReadAll(body)
+		require.Nil(t, err)
+		require.Nil(t, body.Close())
+		require.Equal(t, expected, string(data), ref)
+	}
+
+	_, err = ParseDownloadMirror("dl.example.com")
+	require.NotNil(t, err)
+}
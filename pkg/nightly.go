@@ -0,0 +1,107 @@
+package pkg
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+
+	ospath "github.com/projectdiscovery/pdtm/pkg/path"
+	"github.com/projectdiscovery/pdtm/pkg/types"
+)
+
+// ErrNightlyTokenRequired is returned when nightly builds are only available as workflow artifacts
+var ErrNightlyTokenRequired = errors.New("GITHUB_TOKEN is required to install nightly builds from workflow artifacts")
+
+// ResolveVersion returns the given tool resolved to the given release version,
+// the nightly version resolves to the latest nightly build of the tool
+func ResolveVersion(tool types.Tool, version string) (resolvedTool types.Tool, err error) {
+	defer func() { notifyResolved(resolvedTool, err) }()
+	if strings.EqualFold(version, types.Nightly) {
+		if !isGithub() {
+			return tool, errors.New("nightly builds are only available from github")
+		}
+		return resolveNightly(tool)
+	}
+	return ActiveProvider.Release(tool, version)
+}
+
+// resolveNightly resolves the tool to the build published with the nightly tag
+// falling back to the latest workflow artifact built for the current platform
+func resolveNightly(tool types.Tool) (types.Tool, error) {
+	release, err := Github.ReleaseByTag(context.Background(), tool.Repo, types.Nightly)
+	if err == nil {
+		for _, osArch := range ospath.CheckOSArchs() {
+			for _, asset := range release.Assets {
+				if ext, ok := platformAssetExt(asset.GetName(), tool.Name, osArch); ok {
+					nightly := nightlyTool(tool, osArch, ext, strconv.FormatInt(asset.GetID(), 10))
+					if digest := asset.SHA256(); digest != "" {
+						for name := range nightly.Assets {
+							nightly.Digests = map[string]string{name: digest}
+						}
+					}
+					return nightly, nil
+				}
+			}
+		}
+	}
+	return resolveNightlyArtifact(tool)
+}
+
+func resolveNightlyArtifact(tool types.Tool) (types.Tool, error) {
+	if os.Getenv("GITHUB_TOKEN") == "" {
+		return tool, ErrNightlyTokenRequired
+	}
+	artifacts, err := Github.ListArtifacts(context.Background(), tool.Repo)
+	if err != nil {
+		return tool, err
+	}
+	// artifacts are listed newest first
+	for _, osArch := range ospath.CheckOSArchs() {
+		osName, arch, _ := strings.Cut(strings.ToLower(osArch), "_")
+		for _, artifact := range artifacts {
+			name := strings.ToLower(artifact.Name)
+			if artifact.Expired || !strings.Contains(name, arch) {
+				continue
+			}
+			if strings.Contains(name, osName) || (osName == "macos" && strings.Contains(name, "darwin")) {
+				return nightlyTool(tool, osArch, ".zip", artifact.ArchiveDownloadURL), nil
+			}
+		}
+	}
+	return tool, fmt.Errorf("no nightly build of %s found for your platform", tool.Name)
+}
+
+// nightlyTool returns the tool with a single asset for the current platform
+// pointing to the given nightly build, the version is made unique per build
+// so that newer nightly builds are picked up on update
+func nightlyTool(tool types.Tool, osArch, ext, ref string) types.Tool {
+	tool.Version = fmt.Sprintf("%s-%s", types.Nightly, path.Base(strings.TrimSuffix(ref, "/zip")))
+	tool.Assets = map[string]string{
+		fmt.Sprintf("%s_%s_%s%s", tool.Name, tool.Version, osArch, ext): ref,
+	}
+	return tool
+}
+
+// platformAssetExt returns the archive extension of the asset if it's
+// a build of the tool for the given os_arch regardless of its version
+func platformAssetExt(asset, toolName, osArch string) (string, bool) {
+	asset = strings.ToLower(asset)
+	if !strings.HasPrefix(asset, strings.ToLower(toolName)+"_") {
+		return "", false
+	}
+	for _, ext := range archiveExtensions {
+		if strings.HasSuffix(asset, "_"+strings.ToLower(osArch)+ext) {
+			return ext, true
+		}
+	}
+	return "", false
+}
+
+// IsNightly returns true if the version is a nightly build version
+func IsNightly(version string) bool {
+	return strings.HasPrefix(version, types.Nightly)
+}
@@ -0,0 +1,98 @@
+package path
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// HomeEnv is the environment variable of the binary path set by EnvScript
+const HomeEnv = "PDTM_HOME"
+
+// envScripts contains the statements setting HomeEnv to the binary path and
+// adding it to $PATH (once) of each shell, they are evaluated by the shell
+// instead of being written to its config file
+var envScripts = map[string]func(path string) string{
+	"sh": func(path string) string {
+		return fmt.Sprintf(`export %[1]s=%[2]s
+case ":$PATH:" in *":$%[1]s:"*) ;; *) export PATH="$%[1]s:$PATH" ;; esac`, HomeEnv, shellString(path))
+	},
+	"fish": func(path string) string {
+		return fmt.Sprintf(`set -gx %[1]s %[2]s
+contains -- $%[1]s $PATH; or set -gx PATH $%[1]s $PATH`, HomeEnv, shellString(path))
+	},
+	"nu": func(path string) string {
+		return fmt.Sprintf(`$env.%[1]s = %[2]s
+$env.PATH = ($env.PATH | split row (char esep) | prepend $env.%[1]s | uniq)`, HomeEnv, nushellString(path))
+	},
+	"elvish": func(path string) string {
+		return fmt.Sprintf(`set-env %[1]s %[2]s
+if (not (has-value $paths $E:%[1]s)) { set paths = [$E:%[1]s $@paths] }`, HomeEnv, elvishString(path))
+	},
+	"powershell": func(path string) string {
+		return fmt.Sprintf(`$env:%[1]s = %[2]s
+if (($env:PATH -split [IO.Path]::PathSeparator) -notcontains $env:%[1]s) { $env:PATH = $env:%[1]s + [IO.Path]::PathSeparator + $env:PATH }`, HomeEnv, powershellString(path))
+	},
+}
+
+// shellAliases maps the shell names to the syntax of their statements
+var shellAliases = map[string]string{
+	"bash": "sh",
+	"zsh":  "sh",
+	"dash": "sh",
+	"ksh":  "sh",
+	"pwsh": "powershell",
+}
+
+// Shells returns the shells supported by EnvScript
+func Shells() []string {
+	return []string{"bash", "zsh", "sh", "fish", "nu", "elvish", "powershell"}
+}
+
+// CurrentShell returns the name of the shell pdtm runs from, powershell on
+// windows and sh when $SHELL isn't set
+func CurrentShell() string {
+	if runtime.GOOS == "windows" {
+		return "powershell"
+	}
+	if shell := os.Getenv("SHELL"); shell != "" {
+		return strings.TrimSuffix(filepath.Base(shell), ".exe")
+	}
+	return "sh"
+}
+
+// EnvScript returns the statements of the shell setting PDTM_HOME to the
+// binary path and adding it to $PATH, to be evaluated with eval "$(pdtm -env)"
+func EnvScript(shell, path string) (string, error) {
+	name := strings.ToLower(shell)
+	if alias, ok := shellAliases[name]; ok {
+		name = alias
+	}
+	script, ok := envScripts[name]
+	if !ok {
+		return "", fmt.Errorf("unsupported shell %s (%s)", shell, strings.Join(Shells(), ", "))
+	}
+	return script(path), nil
+}
+
+// shellString returns the path as a single quoted posix shell and fish string
+func shellString(path string) string {
+	return "'" + strings.ReplaceAll(path, "'", `'\''`) + "'"
+}
+
+// nushellString returns the path as a nushell raw string
+func nushellString(path string) string {
+	return "r#'" + path + "'#"
+}
+
+// elvishString returns the path as a single quoted elvish string
+func elvishString(path string) string {
+	return "'" + strings.ReplaceAll(path, "'", "''") + "'"
+}
+
+// powershellString returns the path as a single quoted powershell string
+func powershellString(path string) string {
+	return "'" + strings.ReplaceAll(path, "'", "''") + "'"
+}
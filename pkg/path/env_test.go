@@ -0,0 +1,26 @@
+package path
+
+import (
+	"os/exec"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestEnvScript(t *testing.T) {
+	script, err := EnvScript("zsh", "/opt/pdtm's bin")
+	require.Nil(t, err)
+	require.True(t, strings.HasPrefix(script, `export PDTM_HOME='/opt/pdtm'\''s bin'`))
+
+	// evaluating the statements twice adds the path once
+	if sh, err := exec.LookPath("sh"); err == nil {
+		out, err := exec.Command(sh, "-c", script+"\n"+script+"\nprintf %s \"$PATH\"").Output()
+		require.Nil(t, err)
+		require.Equal(t, 1, strings.Count(string(out), "/opt/pdtm's bin"))
+		require.True(t, strings.HasPrefix(string(out), "/opt/pdtm's bin:"))
+	}
+
+	_, err = EnvScript("tcsh", "/opt/pdtm")
+	require.NotNil(t, err)
+}
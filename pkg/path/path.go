@@ -12,7 +12,15 @@ import (
 
 type Config struct {
 	shellName string
-	rcFile    string
+	// rcFile is the path of the config file of the shell in the home folder
+	rcFile string
+	// addScript and removeScript return the lines adding the path to
+	// $PATH and removing it from the paths, export lines when not set
+	addScript    func(path string) string
+	removeScript func(paths []string, path string) string
+	// reload is the command applying the config file to the running shell,
+	// source ~/<rcFile> when not set
+	reload string
 }
 
 func IsSet(path string) bool {
@@ -32,9 +40,39 @@ func UnsetENV(path string) error {
 	return err
 }
 
+// CleanENV removes the PATH entries added by pdtm for the path
+func CleanENV(path string) error {
+	return clean(path)
+}
+
+// emulatedArchs contains the architectures whose binaries can run on
+// another platform through emulation, keyed by os/arch
+var emulatedArchs = map[string][]string{
+	// windows on arm runs amd64 binaries through the built-in x64 emulation
+	"windows/arm64": {"amd64"},
+}
+
+// GetArchs returns the architectures whose binaries can run on the
+// current platform in order of preference
+func GetArchs() []string {
+	return append([]string{runtime.GOARCH}, emulatedArchs[runtime.GOOS+"/"+runtime.GOARCH]...)
+}
+
+// CheckOSArchs returns the os_arch names of all the builds that can run
+// on the current platform in order of preference
+func CheckOSArchs() []string {
+	var osArchs []string
+	for _, arc := range GetArchs() {
+		osArchs = append(osArchs, checkOS(runtime.GOOS, arc))
+	}
+	return osArchs
+}
+
 func CheckOS() string {
-	os := runtime.GOOS
-	arc := runtime.GOARCH
+	return checkOS(runtime.GOOS, runtime.GOARCH)
+}
+
+func checkOS(os, arc string) string {
 	switch os {
 	case "windows":
 		return "windows_" + arc
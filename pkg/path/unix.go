@@ -6,8 +6,12 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"os/user"
 	"path/filepath"
+	"runtime"
+	"strconv"
 	"strings"
+	"syscall"
 
 	"github.com/projectdiscovery/gologger"
 	errorutil "github.com/projectdiscovery/utils/errors"
@@ -15,6 +19,9 @@ import (
 	sliceutil "github.com/projectdiscovery/utils/slice"
 )
 
+// generatedComment precedes the snippets added to the rc files
+const generatedComment = "# Generated for pdtm. Do not edit."
+
 var confList = []*Config{
 	{
 		shellName: "bash",
@@ -24,6 +31,61 @@ var confList = []*Config{
 		shellName: "zsh",
 		rcFile:    ".zshrc",
 	},
+	{
+		shellName: "nu",
+		rcFile:    nushellEnvFile(),
+		addScript: func(path string) string {
+			return fmt.Sprintf("$env.PATH = ($env.PATH | split row (char esep) | append %s)", nushellString(path))
+		},
+		removeScript: func(_ []string, path string) string {
+			return fmt.Sprintf("$env.PATH = ($env.PATH | split row (char esep) | where $it != %s)", nushellString(path))
+		},
+		reload: "exec nu",
+	},
+	{
+		shellName: "elvish",
+		rcFile:    filepath.Join(".config", "elvish", "rc.elv"),
+		addScript: func(path string) string {
+			return fmt.Sprintf("set paths = [$@paths %s]", elvishString(path))
+		},
+		removeScript: func(_ []string, path string) string {
+			return fmt.Sprintf("set paths = [(each {|p| if (not-eq $p %s) { put $p } } $paths)]", elvishString(path))
+		},
+		reload: "exec elvish",
+	},
+}
+
+// nushellEnvFile returns the env file of nushell, in the config directory
+// of the os like nushell
+func nushellEnvFile() string {
+	if runtime.GOOS == "darwin" {
+		return filepath.Join("Library", "Application Support", "nushell", "env.nu")
+	}
+	return filepath.Join(".config", "nushell", "env.nu")
+}
+
+// addLine returns the line of the config file adding the path to $PATH
+func (c *Config) addLine(path string) string {
+	if c.addScript != nil {
+		return c.addScript(path)
+	}
+	return fmt.Sprintf("export PATH=$PATH:%s", path)
+}
+
+// removeLine returns the line of the config file removing the path from $PATH
+func (c *Config) removeLine(paths []string, path string) string {
+	if c.removeScript != nil {
+		return c.removeScript(paths, path)
+	}
+	return fmt.Sprintf("export PATH=%s", strings.Join(sliceutil.PruneEqual(paths, path), ":"))
+}
+
+// reloadCommand returns the command applying the config file to the shell
+func (c *Config) reloadCommand() string {
+	if c.reload != "" {
+		return c.reload
+	}
+	return "source ~/" + c.rcFile
 }
 
 func (c *Config) GetRCFilePath() (string, error) {
@@ -36,6 +98,9 @@ func (c *Config) GetRCFilePath() (string, error) {
 		return rcFilePath, nil
 	}
 	// if file doesn't exist create empty file
+	if err := os.MkdirAll(filepath.Dir(rcFilePath), os.ModePerm); err != nil {
+		return "", fmt.Errorf("failed to create rcFile %v got %v", rcFilePath, err)
+	}
 	if err := os.WriteFile(rcFilePath, []byte("#\n"), 0644); err != nil {
 		return "", fmt.Errorf("failed to create rcFile %v got %v", rcFilePath, err)
 	}
@@ -79,7 +144,7 @@ func add(path string) (bool, error) {
 		return false, errorutil.NewWithErr(err).Msgf("add %s to $PATH env", path)
 	}
 
-	script := fmt.Sprintf("export PATH=$PATH:%s\n\n", path)
+	script := conf.addLine(path) + "\n\n"
 	return exportToConfig(conf, path, script)
 }
 
@@ -93,8 +158,7 @@ func remove(path string) (bool, error) {
 	if err != nil {
 		return false, errorutil.NewWithErr(err).Msgf("remove %s from $PATH env", path)
 	}
-	pathVars = sliceutil.PruneEqual(pathVars, path)
-	script := fmt.Sprintf("export PATH=%s\n\n", strings.Join(pathVars, ":"))
+	script := conf.removeLine(pathVars, path) + "\n\n"
 	return exportToConfig(conf, path, script)
 }
 
@@ -115,7 +179,7 @@ func exportToConfig(config *Config, path, script string) (bool, error) {
 	lines := strings.Split(strings.TrimSpace(string(b)), "\n")
 	for _, line := range lines {
 		if strings.EqualFold(line, strings.TrimSpace(script)) {
-			gologger.Info().Msgf("Run `source ~/%s` to add %s to $PATH ", config.rcFile, path)
+			gologger.Info().Msgf("Run `%s` to add %s to $PATH ", config.reloadCommand(), path)
 			return true, nil
 		}
 	}
@@ -123,13 +187,66 @@ func exportToConfig(config *Config, path, script string) (bool, error) {
 	if err != nil {
 		return false, err
 	}
-	script = fmt.Sprintf("\n\n# Generated for pdtm. Do not edit.\n%s", script)
+	script = fmt.Sprintf("\n\n%s\n%s", generatedComment, script)
 	if _, err := f.Write([]byte(script)); err != nil {
 		return false, err
 	}
 	if err := f.Close(); err != nil {
 		return false, err
 	}
-	gologger.Info().Label("WRN").Msgf("Run `source ~/%s` to add $PATH (%s)", config.rcFile, path)
+	gologger.Info().Label("WRN").Msgf("Run `%s` to add $PATH (%s)", config.reloadCommand(), path)
 	return true, nil
 }
+
+// clean removes the PATH snippets generated for the path from the rc files
+func clean(path string) error {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return err
+	}
+	for _, conf := range confList {
+		script := conf.addLine(path)
+		rcFilePath := filepath.Join(home, conf.rcFile)
+		b, err := os.ReadFile(rcFilePath)
+		if err != nil {
+			continue
+		}
+		lines := strings.Split(string(b), "\n")
+		cleaned := make([]string, 0, len(lines))
+		for i := 0; i < len(lines); i++ {
+			if lines[i] == generatedComment && i+1 < len(lines) && lines[i+1] == script {
+				i++
+				continue
+			}
+			if lines[i] == script {
+				continue
+			}
+			cleaned = append(cleaned, lines[i])
+		}
+		if len(cleaned) == len(lines) {
+			continue
+		}
+		if err := os.WriteFile(rcFilePath, []byte(strings.Join(cleaned, "\n")), 0644); err != nil {
+			return err
+		}
+		gologger.Info().Msgf("removed %s from ~/%s", path, conf.rcFile)
+	}
+	return nil
+}
+
+// fileOwner returns the name of the user owning the file, empty if unknown
+func fileOwner(file string) string {
+	fi, err := os.Stat(file)
+	if err != nil {
+		return ""
+	}
+	stat, ok := fi.Sys().(*syscall.Stat_t)
+	if !ok {
+		return ""
+	}
+	uid := strconv.FormatUint(uint64(stat.Uid), 10)
+	if owner, err := user.LookupId(uid); err == nil {
+		return owner.Username
+	}
+	return "uid " + uid
+}
@@ -0,0 +1,37 @@
+//go:build !windows
+
+package path
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAddShells(t *testing.T) {
+	for _, conf := range confList {
+		t.Run(conf.shellName, func(t *testing.T) {
+			home := t.TempDir()
+			t.Setenv("HOME", home)
+			t.Setenv("SHELL", "/usr/bin/"+conf.shellName)
+			t.Setenv("PATH", "/usr/bin")
+
+			added, err := add("/opt/pdtm's bin")
+			require.Nil(t, err)
+			require.True(t, added)
+			b, err := os.ReadFile(filepath.Join(home, conf.rcFile))
+			require.Nil(t, err)
+			require.Contains(t, string(b), generatedComment+"\n"+conf.addLine("/opt/pdtm's bin"))
+
+			require.Nil(t, clean("/opt/pdtm's bin"))
+			b, err = os.ReadFile(filepath.Join(home, conf.rcFile))
+			require.Nil(t, err)
+			require.Equal(t, "#", strings.TrimSpace(string(b)))
+		})
+	}
+	require.Equal(t, "$env.PATH = ($env.PATH | split row (char esep) | append r#'/opt/pdtm's bin'#)", confList[2].addLine("/opt/pdtm's bin"))
+	require.Equal(t, "set paths = [$@paths '/opt/pdtm''s bin']", confList[3].addLine("/opt/pdtm's bin"))
+}
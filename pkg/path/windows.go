@@ -127,3 +127,15 @@ func isSet(path string) (bool, error) {
 	}
 	return index >= 0, nil
 }
+
+// clean removes the path from the user PATH in the registry
+func clean(p string) error {
+	_, err := remove(p)
+	return err
+}
+
+// fileOwner returns the name of the user owning the file, the owner of the
+// windows files isn't looked up
+func fileOwner(string) string {
+	return ""
+}
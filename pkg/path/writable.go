@@ -0,0 +1,54 @@
+package path
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"syscall"
+)
+
+// ErrNotWritable is returned when the binaries can't be written to a path
+var ErrNotWritable = errors.New("not writable")
+
+// CheckWritable returns an error describing why the current user can't
+// write to dir (or create it), so installs fail before downloading anything
+func CheckWritable(dir string) error {
+	// the closest existing directory is the one the missing ones are created in
+	existing := dir
+	for {
+		fi, err := os.Stat(existing)
+		if err == nil {
+			if !fi.IsDir() {
+				return fmt.Errorf("%s: %s is not a directory", dir, existing)
+			}
+			break
+		}
+		parent := filepath.Dir(existing)
+		if parent == existing {
+			return err
+		}
+		existing = parent
+	}
+	f, err := os.CreateTemp(existing, ".pdtm-write-check-")
+	if err == nil {
+		name := f.Name()
+		_ = f.Close()
+		return os.Remove(name)
+	}
+	reason := "permission denied"
+	if isReadOnly(err) {
+		reason = "read-only file system"
+	} else if owner := fileOwner(existing); owner != "" {
+		reason = "owned by " + owner
+	}
+	if existing != dir {
+		return fmt.Errorf("%w: %s can't be created in %s (%s)", ErrNotWritable, dir, existing, reason)
+	}
+	return fmt.Errorf("%w: %s (%s)", ErrNotWritable, dir, reason)
+}
+
+// isReadOnly returns true if the error is caused by a read-only file system
+func isReadOnly(err error) bool {
+	return errors.Is(err, syscall.EROFS)
+}
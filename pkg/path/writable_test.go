@@ -0,0 +1,37 @@
+//go:build !windows
+
+package path
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckWritable(t *testing.T) {
+	dir := t.TempDir()
+	require.Nil(t, CheckWritable(filepath.Join(dir, "missing", "bin")))
+	require.NoDirExists(t, filepath.Join(dir, "missing"))
+
+	file := filepath.Join(dir, "file")
+	require.Nil(t, os.WriteFile(file, nil, 0644))
+	require.NotNil(t, CheckWritable(filepath.Join(file, "bin")))
+
+	if os.Geteuid() == 0 {
+		t.Skip("root can write to read-only directories")
+	}
+	readOnly := filepath.Join(dir, "read-only")
+	require.Nil(t, os.Mkdir(readOnly, 0555))
+	err := CheckWritable(filepath.Join(readOnly, "bin"))
+	require.True(t, errors.Is(err, ErrNotWritable))
+}
+
+func TestIsReadOnly(t *testing.T) {
+	require.True(t, isReadOnly(&os.PathError{Op: "open", Path: "/usr/local/bin/.pdtm-write-check-1", Err: syscall.EROFS}))
+	require.False(t, isReadOnly(&os.PathError{Op: "open", Path: "/usr/local/bin/.pdtm-write-check-1", Err: syscall.EACCES}))
+	require.False(t, isReadOnly(errors.New("read-only file system")))
+}
@@ -0,0 +1,65 @@
+package pkg
+
+import (
+	"fmt"
+
+	"github.com/projectdiscovery/gologger"
+	ospath "github.com/projectdiscovery/pdtm/pkg/path"
+	"github.com/projectdiscovery/pdtm/pkg/state"
+	"github.com/projectdiscovery/pdtm/pkg/types"
+)
+
+// Pin pins given tool to its installed version so that it is skipped on update
+func Pin(path string, tool types.Tool) error {
+	installed, err := setPinned(path, tool, true)
+	if err != nil {
+		return err
+	}
+	gologger.Info().Msgf("pinned %s to %s", tool.Name, installed.Version)
+	return nil
+}
+
+// Unpin removes the pin of given tool
+func Unpin(path string, tool types.Tool) error {
+	if _, err := setPinned(path, tool, false); err != nil {
+		return err
+	}
+	gologger.Info().Msgf("unpinned %s", tool.Name)
+	return nil
+}
+
+func setPinned(path string, tool types.Tool, pinned bool) (*state.Tool, error) {
+	if IsDataPack(tool) {
+		if _, ok := DataPackVersion(path, tool); !ok {
+			dir, _ := dataDir(tool)
+			return nil, fmt.Errorf(types.ErrToolNotFound, tool.Name, dir)
+		}
+	} else {
+		executablePath, exists := ospath.GetExecutablePath(path, tool.Name)
+		if !exists {
+			return nil, fmt.Errorf(types.ErrToolNotFound, tool.Name, executablePath)
+		}
+		// tools installed outside of pdtm need to be recorded before being pinned
+		adopt(path, tool)
+	}
+
+	st, err := state.Load(path)
+	if err != nil {
+		return nil, err
+	}
+	installed, ok := st.Get(tool.Name)
+	if !ok {
+		return nil, fmt.Errorf("could not determine installed version of %s", tool.Name)
+	}
+	installed.Pinned = pinned
+	return installed, st.Save()
+}
+
+func isPinned(tool types.Tool, path string) bool {
+	st, err := state.Load(path)
+	if err != nil {
+		return false
+	}
+	installed, ok := st.Get(tool.Name)
+	return ok && installed.Pinned
+}
@@ -0,0 +1,167 @@
+package pkg
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/projectdiscovery/pdtm/pkg/types"
+)
+
+// PluginProvider delegates to an external executable, so custom sources
+// (internal registries, gitea...) can be added without forking pdtm. The
+// executable is run with the method as last argument and the request
+// written to its stdin:
+//
+//	latest   {"tool": {...}}                      -> {"tool": {...}}
+//	release  {"tool": {...}, "version": "1.1.0"}   -> {"tool": {...}}
+//	download {"tool": {...}, "ref": "<asset ref>"} -> the asset content
+//
+// the returned tool contains the version and the assets (name -> ref),
+// failures are reported with a non zero exit code and a message on stderr
+type PluginProvider struct {
+	command []string
+}
+
+// pluginRequest is written to the stdin of the plugin
+type pluginRequest struct {
+	Tool    types.Tool `json:"tool"`
+	Version string     `json:"version,omitempty"`
+	Ref     string     `json:"ref,omitempty"`
+}
+
+// pluginResponse is read from the stdout of the plugin
+type pluginResponse struct {
+	Tool types.Tool `json:"tool"`
+}
+
+// NewPluginProvider returns a provider running the command of the config,
+// environment variables in the command are expanded
+func NewPluginProvider(config types.ProviderConfig) (*PluginProvider, error) {
+	command := strings.Fields(os.ExpandEnv(config.Command))
+	if len(command) == 0 {
+		return nil, fmt.Errorf("plugin provider requires a command")
+	}
+	if _, err := exec.LookPath(command[0]); err != nil {
+		return nil, fmt.Errorf("plugin %s not found: %w", command[0], err)
+	}
+	return &PluginProvider{command: command}, nil
+}
+
+// Latest returns the tool resolved to its latest release by the plugin
+func (p *PluginProvider) Latest(tool types.Tool) (types.Tool, error) {
+	return p.resolve("latest", pluginRequest{Tool: tool})
+}
+
+// Release returns the tool resolved to the given version by the plugin
+func (p *PluginProvider) Release(tool types.Tool, version string) (types.Tool, error) {
+	return p.resolve("release", pluginRequest{Tool: tool, Version: version})
+}
+
+// Origin returns the executable of the plugin
+func (p *PluginProvider) Origin(tool types.Tool) string {
+	return "plugin " + p.command[0]
+}
+
+// Download returns the asset content written by the plugin to its stdout
+func (p *PluginProvider) Download(tool types.Tool, ref string) (io.ReadCloser, error) {
+	cmd, stderr, err := p.cmd("download", pluginRequest{Tool: tool, Ref: ref})
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+	return &pluginReader{ReadCloser: stdout, cmd: cmd, stderr: stderr}, nil
+}
+
+// resolve runs the method and returns the tool of the response
+func (p *PluginProvider) resolve(method string, request pluginRequest) (types.Tool, error) {
+	cmd, stderr, err := p.cmd(method, request)
+	if err != nil {
+		return request.Tool, err
+	}
+	output, err := cmd.Output()
+	if err != nil {
+		return request.Tool, pluginError(method, err, stderr)
+	}
+	var response pluginResponse
+	if err := json.Unmarshal(output, &response); err != nil {
+		return request.Tool, fmt.Errorf("invalid %s response of the plugin: %w", method, err)
+	}
+	resolved := response.Tool
+	if resolved.Version == "" {
+		return request.Tool, fmt.Errorf("plugin returned no version of %s", request.Tool.Name)
+	}
+	// the plugin only has to return what it resolves
+	tool := request.Tool
+	tool.Version = strings.TrimPrefix(resolved.Version, "v")
+	tool.Assets = resolved.Assets
+	if resolved.AssetSizes != nil {
+		tool.AssetSizes = resolved.AssetSizes
+	}
+	if resolved.Checksums != nil {
+		tool.Checksums = resolved.Checksums
+	}
+	return tool, nil
+}
+
+// cmd returns the command of the method with the request as stdin
+func (p *PluginProvider) cmd(method string, request pluginRequest) (*exec.Cmd, *bytes.Buffer, error) {
+	body, err := json.Marshal(request)
+	if err != nil {
+		return nil, nil, err
+	}
+	args := append(append([]string{}, p.command[1:]...), method)
+	cmd := exec.Command(p.command[0], args...)
+	cmd.Stdin = bytes.NewReader(body)
+	stderr := &bytes.Buffer{}
+	cmd.Stderr = stderr
+	return cmd, stderr, nil
+}
+
+// pluginReader reports the failure of the download once the output is read
+type pluginReader struct {
+	io.ReadCloser
+	cmd    *exec.Cmd
+	stderr *bytes.Buffer
+	done   bool
+}
+
+func (r *pluginReader) Read(b []byte) (int, error) {
+	n, err := r.ReadCloser.Read(b)
+	if err == io.EOF && !r.done {
+		r.done = true
+		if err := r.cmd.Wait(); err != nil {
+			return n, pluginError("download", err, r.stderr)
+		}
+	}
+	return n, err
+}
+
+func (r *pluginReader) Close() error {
+	if r.done {
+		return nil
+	}
+	r.done = true
+	_ = r.ReadCloser.Close()
+	_ = r.cmd.Process.Kill()
+	_ = r.cmd.Wait()
+	return nil
+}
+
+// pluginError returns the error of the plugin with its stderr message
+func pluginError(method string, err error, stderr *bytes.Buffer) error {
+	if message := strings.TrimSpace(stderr.String()); message != "" {
+		return fmt.Errorf("plugin %s failed: %s", method, message)
+	}
+	return fmt.Errorf("plugin %s failed: %w", method, err)
+}
@@ -0,0 +1,56 @@
+package pkg
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/projectdiscovery/pdtm/pkg/types"
+	"github.com/stretchr/testify/require"
+)
+
+const testPlugin = `#!/bin/sh
+request=$(cat)
+case "$1" in
+latest) echo '{"tool": {"version": "v1.1.0", "assets": {"dnsx_1.1.0_linux_amd64.zip": "registry://dnsx"}}}' ;;
+release) echo "version not found" >&2; exit 1 ;;
+download)
+	case "$request" in
+	*'"ref":"registry://dnsx"'*) printf dnsx ;;
+	*) echo "unknown asset" >&2; exit 1 ;;
+	esac ;;
+esac
+`
+
+func TestPluginProvider(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the test plugin is a shell script")
+	}
+	plugin := filepath.Join(t.TempDir(), "pdtm-plugin")
+	require.Nil(t, os.WriteFile(plugin, []byte(testPlugin), 0755))
+
+	provider, err := NewPluginProvider(types.ProviderConfig{Command: plugin})
+	require.Nil(t, err)
+	tool, err := provider.Latest(types.Tool{Name: "dnsx", Repo: "dnsx"})
+	require.Nil(t, err)
+	require.Equal(t, "1.1.0", tool.Version)
+	require.Equal(t, "dnsx", tool.Repo)
+
+	body, err := provider.Download(tool, tool.Assets["dnsx_1.1.0_linux_amd64.zip"])
+	require.Nil(t, err)
+	data, err := io.ReadAll(body)
+	require.Nil(t, err)
+	require.Equal(t, "dnsx", string(data))
+	require.Nil(t, body.Close())
+
+	body, err = provider.Download(tool, "registry://naabu")
+	require.Nil(t, err)
+	_, err = io.ReadAll(body)
+	require.ErrorContains(t, err, "unknown asset")
+	_ = body.Close()
+
+	_, err = provider.Release(tool, "0.9.0")
+	require.ErrorContains(t, err, "version not found")
+}
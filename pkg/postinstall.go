@@ -0,0 +1,72 @@
+package pkg
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	ospath "github.com/projectdiscovery/pdtm/pkg/path"
+	"github.com/projectdiscovery/pdtm/pkg/types"
+	fileutil "github.com/projectdiscovery/utils/file"
+)
+
+// PostInstallSummary returns a short description of the post-install step
+func PostInstallSummary(tool types.Tool, step types.PostInstallStep) string {
+	switch {
+	case step.Description != "":
+		return step.Description
+	case step.File != "":
+		return "create " + step.File
+	case step.Capabilities != "":
+		return fmt.Sprintf("run `sudo setcap %s %s`", step.Capabilities, tool.Name)
+	default:
+		return fmt.Sprintf("run `%s`", strings.Join(append([]string{tool.Name}, step.Args...), " "))
+	}
+}
+
+// RunPostInstall runs the post-install step of the tool installed at path
+func RunPostInstall(path string, tool types.Tool, step types.PostInstallStep) error {
+	if step.File != "" {
+		return createDefaultFile(step.File, step.Content)
+	}
+	if step.Capabilities != "" {
+		return SetCapabilities(path, tool, step.Capabilities)
+	}
+	if len(step.Args) == 0 {
+		return errors.New("post-install step has neither args, file nor capabilities")
+	}
+	executablePath, exists := ospath.GetExecutablePath(path, tool.Name)
+	if !exists {
+		return fmt.Errorf(types.ErrToolNotFound, tool.Name, executablePath)
+	}
+	cmd := exec.Command(executablePath, step.Args...)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
+
+// createDefaultFile writes the content to the file unless it already exists
+func createDefaultFile(file, content string) error {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return err
+	}
+	if strings.HasPrefix(file, "~/") {
+		file = filepath.Join(home, file[2:])
+	}
+	file = os.ExpandEnv(file)
+	if fileutil.FileExists(file) {
+		return nil
+	}
+	if !ospath.IsSubPath(home, file) {
+		return fmt.Errorf("skipping file outside home folder: %s", file)
+	}
+	if err := os.MkdirAll(filepath.Dir(file), 0700); err != nil {
+		return err
+	}
+	// default configs usually end up holding credentials
+	return os.WriteFile(file, []byte(content), 0600)
+}
@@ -0,0 +1,105 @@
+package pkg
+
+import (
+	"io"
+	"sync"
+	"time"
+)
+
+// progress events of the installs
+const (
+	EventDownloadStarted   = "download_started"
+	EventDownloadProgress  = "download_progress"
+	EventDownloadCompleted = "download_completed"
+	EventVerify            = "verify"
+	EventExtract           = "extract"
+	EventExtractProgress   = "extract_progress"
+	EventExtractFile       = "extract_file"
+	EventBuild             = "build"
+	EventDone              = "done"
+	EventError             = "error"
+)
+
+// Event is a step of the install of a tool
+type Event struct {
+	Time    time.Time `json:"time"`
+	Event   string    `json:"event"`
+	Tool    string    `json:"tool"`
+	Version string    `json:"version,omitempty"`
+	Asset   string    `json:"asset,omitempty"`
+	// File is the binary extracted from the asset
+	File string `json:"file,omitempty"`
+	// Bytes is the number of bytes downloaded (or extracted) so far
+	Bytes int64 `json:"bytes,omitempty"`
+	// Total is the size of the download (or of the extracted file), 0 if unknown
+	Total int64  `json:"total,omitempty"`
+	Error string `json:"error,omitempty"`
+}
+
+// Progress receives the progress events of the installs, nil disables them
+var Progress func(Event)
+
+// progressInterval is the minimum delay between two download or extract progress events
+const progressInterval = 250 * time.Millisecond
+
+// emitMutex serializes the events of the binaries extracted concurrently
+var emitMutex sync.Mutex
+
+func emit(event Event) {
+	if !progressEnabled() {
+		return
+	}
+	emitMutex.Lock()
+	defer emitMutex.Unlock()
+	event.Time = time.Now()
+	if Progress != nil {
+		Progress(event)
+	}
+	switch event.Event {
+	case EventDownloadStarted, EventDownloadProgress, EventDownloadCompleted:
+		callHooks(func(h Hooks) {
+			if h.OnDownloadProgress != nil {
+				h.OnDownloadProgress(event)
+			}
+		})
+	}
+}
+
+// progressEnabled returns true if the events are received by Progress or hooks
+func progressEnabled() bool {
+	return Progress != nil || hasHooks()
+}
+
+// progressReader emits download progress events while being read
+type progressReader struct {
+	io.Reader
+	event Event
+	last  time.Time
+}
+
+func (r *progressReader) Read(p []byte) (int, error) {
+	n, err := r.Reader.Read(p)
+	r.event.Bytes += int64(n)
+	if progressEnabled() && time.Since(r.last) >= progressInterval && err == nil {
+		r.last = time.Now()
+		emit(r.event)
+	}
+	return n, err
+}
+
+// progressWriter emits extract progress events while being written
+type progressWriter struct {
+	io.Writer
+	event Event
+	last  time.Time
+}
+
+func (w *progressWriter) Write(p []byte) (int, error) {
+	n, err := w.Writer.Write(p)
+	w.event.Bytes += int64(n)
+	if progressEnabled() && time.Since(w.last) >= progressInterval && err == nil {
+		w.last = time.Now()
+		emit(w.event)
+	}
+	return n, err
+}
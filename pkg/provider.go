@@ -0,0 +1,60 @@
+package pkg
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/projectdiscovery/pdtm/pkg/types"
+)
+
+// Provider resolves the releases of the tools and downloads their assets
+type Provider interface {
+	// Latest returns the tool resolved to its latest release
+	Latest(tool types.Tool) (types.Tool, error)
+	// Release returns the tool resolved to the release with the given version
+	Release(tool types.Tool, version string) (types.Tool, error)
+	// Download returns the content of the release asset with the given reference
+	Download(tool types.Tool, ref string) (io.ReadCloser, error)
+}
+
+// originProvider is implemented by the providers telling where the releases
+// of the tools are fetched from
+type originProvider interface {
+	// Origin returns the location of the releases of the tool
+	Origin(tool types.Tool) string
+}
+
+// releaseOrigin returns where the releases of the tool are fetched from by
+// the active provider, empty if unknown
+func releaseOrigin(tool types.Tool) string {
+	if p, ok := ActiveProvider.(originProvider); ok {
+		return p.Origin(tool)
+	}
+	return ""
+}
+
+// ActiveProvider is the provider used to install and update the tools
+var ActiveProvider Provider = &GithubProvider{}
+
+// NewProvider returns the provider described by the given config
+func NewProvider(config types.ProviderConfig) (Provider, error) {
+	switch strings.ToLower(config.Type) {
+	case "", "github":
+		return &GithubProvider{}, nil
+	case "gitlab":
+		return NewGitlabProvider(config)
+	case "index":
+		return NewIndexProvider(config)
+	case "plugin":
+		return NewPluginProvider(config)
+	default:
+		return nil, fmt.Errorf("unknown provider %s", config.Type)
+	}
+}
+
+// isGithub returns true if the tools are fetched from github
+func isGithub() bool {
+	_, ok := ActiveProvider.(*GithubProvider)
+	return ok
+}
@@ -0,0 +1,26 @@
+//go:build darwin
+
+package pkg
+
+import (
+	"errors"
+
+	"github.com/projectdiscovery/gologger"
+	"golang.org/x/sys/unix"
+)
+
+// quarantineAttr is set by macOS on downloaded files and makes Gatekeeper
+// block them when launched from a shell spawned by Finder
+const quarantineAttr = "com.apple.quarantine"
+
+// clearQuarantine removes the quarantine attribute of the extracted binary
+func clearQuarantine(file string) {
+	if KeepQuarantine {
+		return
+	}
+	err := unix.Removexattr(file, quarantineAttr)
+	if err == nil || errors.Is(err, unix.ENOATTR) {
+		return
+	}
+	gologger.Warning().Msgf("could not remove the quarantine attribute of %s (%s), run `xattr -d %s %s` if macOS blocks it", file, err, quarantineAttr, file)
+}
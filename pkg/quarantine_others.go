@@ -0,0 +1,6 @@
+//go:build !darwin && !windows
+
+package pkg
+
+// clearQuarantine is a no-op outside of macOS and windows
+func clearQuarantine(file string) {}
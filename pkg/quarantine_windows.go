@@ -0,0 +1,26 @@
+//go:build windows
+
+package pkg
+
+import (
+	"errors"
+	"os"
+
+	"github.com/projectdiscovery/gologger"
+)
+
+// zoneIdentifierStream is the alternate data stream holding the
+// mark-of-the-web that makes SmartScreen prompt before running a binary
+const zoneIdentifierStream = ":Zone.Identifier"
+
+// clearQuarantine removes the mark-of-the-web of the extracted binary
+func clearQuarantine(file string) {
+	if KeepQuarantine {
+		return
+	}
+	err := os.Remove(file + zoneIdentifierStream)
+	if err == nil || errors.Is(err, os.ErrNotExist) {
+		return
+	}
+	gologger.Warning().Msgf("could not remove the mark-of-the-web of %s (%s), run `Unblock-File %s` if windows blocks it", file, err, file)
+}
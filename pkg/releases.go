@@ -0,0 +1,56 @@
+package pkg
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/projectdiscovery/pdtm/pkg/types"
+)
+
+// Release is a published release of a tool
+type Release struct {
+	Version     string
+	PublishedAt time.Time
+	Notes       string
+}
+
+// ReleasesBetween returns the releases of the tool newer than the installed
+// version up to the latest one (newest first), only github releases have
+// their date and notes available
+func ReleasesBetween(tool types.Tool, installed, latest string) ([]Release, error) {
+	if !isGithub() {
+		return nil, fmt.Errorf("release notes are only available for github releases")
+	}
+	releases, err := Github.ListReleases(context.Background(), tool.Repo, 100)
+	if err != nil {
+		return nil, err
+	}
+	from, errFrom := semver.NewVersion(strings.TrimPrefix(installed, "v"))
+	to, errTo := semver.NewVersion(strings.TrimPrefix(latest, "v"))
+	var between []Release
+	for _, release := range releases {
+		if release.GetDraft() {
+			continue
+		}
+		version := strings.TrimPrefix(release.GetTagName(), "v")
+		v, err := semver.NewVersion(version)
+		switch {
+		case err != nil || errFrom != nil || errTo != nil:
+			// without comparable versions only the latest release is known
+			if version != strings.TrimPrefix(latest, "v") {
+				continue
+			}
+		case !v.GreaterThan(from) || v.GreaterThan(to):
+			continue
+		}
+		between = append(between, Release{
+			Version:     version,
+			PublishedAt: release.GetPublishedAt().Time,
+			Notes:       release.GetBody(),
+		})
+	}
+	return between, nil
+}
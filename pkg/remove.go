@@ -3,15 +3,23 @@ package pkg
 import (
 	"fmt"
 	"os"
+	"path/filepath"
+	"strings"
 
 	ospath "github.com/projectdiscovery/pdtm/pkg/path"
+	"github.com/projectdiscovery/pdtm/pkg/state"
 	"github.com/projectdiscovery/pdtm/pkg/types"
+	fileutil "github.com/projectdiscovery/utils/file"
 
 	"github.com/projectdiscovery/gologger"
 )
 
 // Remove removes given tool
-func Remove(path string, tool types.Tool) error {
+func Remove(path string, tool types.Tool) (err error) {
+	defer func() { notifyFinished(path, tool, err, false) }()
+	if IsDataPack(tool) {
+		return removeDataPack(path, tool)
+	}
 	executablePath, exists := ospath.GetExecutablePath(path, tool.Name)
 	if exists {
 		gologger.Info().Msgf("removing %s...", tool.Name)
@@ -19,8 +27,67 @@ func Remove(path string, tool types.Tool) error {
 		if err != nil {
 			return err
 		}
+		removeBinaries(path, tool)
+		forget(path, tool)
 		gologger.Info().Msgf("removed %s", tool.Name)
 		return nil
 	}
 	return fmt.Errorf(types.ErrToolNotFound, tool.Name, executablePath)
 }
+
+// removeBinaries removes the additional binaries extracted along with the tool
+func removeBinaries(path string, tool types.Tool) {
+	st, err := state.Load(path)
+	if err != nil {
+		return
+	}
+	installed, ok := st.Get(tool.Name)
+	if !ok {
+		return
+	}
+	for _, binary := range installed.Binaries {
+		if executablePath, exists := ospath.GetExecutablePath(path, binary); exists {
+			if err := os.Remove(executablePath); err != nil {
+				gologger.Warning().Msgf("could not remove %s: %s", executablePath, err)
+			}
+		}
+	}
+}
+
+// forget removes the details of the tool from the state of path
+func forget(path string, tool types.Tool) {
+	st, err := state.Load(path)
+	if err != nil {
+		return
+	}
+	if _, ok := st.Get(tool.Name); !ok {
+		return
+	}
+	st.Delete(tool.Name)
+	if err := st.Save(); err != nil {
+		gologger.Warning().Msgf("could not save state: %s", err)
+	}
+}
+
+// PurgeData removes the data directory of the tool ($HOME/.config/<tool>),
+// only for the tools recorded in the state of path so it must run before the
+// tool is removed
+func PurgeData(path string, tool types.Tool) error {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return err
+	}
+	dir := filepath.Join(home, ".config", strings.ToLower(tool.Name))
+	if strings.EqualFold(tool.Name, "pdtm") || !fileutil.FolderExists(dir) {
+		return nil
+	}
+	st, err := state.Load(path)
+	if err != nil {
+		return err
+	}
+	if _, ok := st.Get(tool.Name); !ok {
+		return fmt.Errorf("%s wasn't installed by pdtm in %s, keeping %s", tool.Name, path, dir)
+	}
+	gologger.Info().Msgf("removing %s data %s...", tool.Name, dir)
+	return os.RemoveAll(dir)
+}
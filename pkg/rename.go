@@ -0,0 +1,79 @@
+package pkg
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/projectdiscovery/gologger"
+	ospath "github.com/projectdiscovery/pdtm/pkg/path"
+	"github.com/projectdiscovery/pdtm/pkg/state"
+	"github.com/projectdiscovery/pdtm/pkg/types"
+)
+
+// RenamedFrom returns the previous name the tool is installed and recorded
+// with at path, false if the tool is installed with its current name (or not
+// at all) or the binary with the previous name wasn't installed by pdtm
+func RenamedFrom(path string, tool types.Tool) (string, bool) {
+	if _, exists := ospath.GetExecutablePath(path, tool.Name); exists {
+		return "", false
+	}
+	st, err := state.Load(path)
+	if err != nil {
+		return "", false
+	}
+	for _, previous := range tool.RenamedFrom {
+		if _, ok := st.Get(previous); !ok {
+			continue
+		}
+		executablePath, exists := ospath.GetExecutablePath(path, previous)
+		if !exists {
+			continue
+		}
+		// aliases left by a previous rename point to the new binary
+		if fi, err := os.Lstat(executablePath); err != nil || fi.Mode()&os.ModeSymlink != 0 {
+			continue
+		}
+		return previous, true
+	}
+	return "", false
+}
+
+// Rename moves the binary and the state of the tool installed at path with
+// its previous name to the current name, a symlink with the previous name
+// pointing to the binary is left when alias is true
+func Rename(path, previous string, tool types.Tool, alias bool) error {
+	previousPath, exists := ospath.GetExecutablePath(path, previous)
+	if !exists {
+		return fmt.Errorf(types.ErrToolNotFound, previous, previousPath)
+	}
+	st, err := state.Load(path)
+	if err != nil {
+		return err
+	}
+	installed, ok := st.Get(previous)
+	if !ok {
+		return fmt.Errorf("%s wasn't installed by pdtm", previousPath)
+	}
+	ext := strings.TrimPrefix(previousPath, filepath.Join(path, previous))
+	executablePath := filepath.Join(path, tool.Name+ext)
+	if err := os.Rename(previousPath, executablePath); err != nil {
+		return err
+	}
+
+	renamed := *installed
+	renamed.Name = tool.Name
+	st.Delete(previous)
+	st.Set(&renamed)
+	if err := st.Save(); err != nil {
+		gologger.Warning().Msgf("could not save state: %s", err)
+	}
+
+	if alias {
+		if err := os.Symlink(filepath.Base(executablePath), previousPath); err != nil {
+			gologger.Warning().Msgf("could not create the %s alias: %s", previous, err)
+		}
+	}
+	return nil
+}
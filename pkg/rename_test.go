@@ -0,0 +1,60 @@
+package pkg
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/projectdiscovery/pdtm/pkg/state"
+	"github.com/projectdiscovery/pdtm/pkg/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRename(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the alias is a symlink")
+	}
+	path := t.TempDir()
+	require.Nil(t, os.WriteFile(filepath.Join(path, "olddnsx"), []byte("dnsx"), 0755))
+	st, err := state.Load(path)
+	require.Nil(t, err)
+	st.Set(&state.Tool{Name: "olddnsx", Version: "1.1.0", Pinned: true})
+	require.Nil(t, st.Save())
+
+	tool := types.Tool{Name: "dnsx", RenamedFrom: []string{"olddnsx"}}
+	previous, ok := RenamedFrom(path, tool)
+	require.True(t, ok)
+	require.Equal(t, "olddnsx", previous)
+	require.Nil(t, Rename(path, previous, tool, true))
+
+	data, err := os.ReadFile(filepath.Join(path, "dnsx"))
+	require.Nil(t, err)
+	require.Equal(t, "dnsx", string(data))
+	target, err := os.Readlink(filepath.Join(path, "olddnsx"))
+	require.Nil(t, err)
+	require.Equal(t, "dnsx", target)
+
+	st, err = state.Load(path)
+	require.Nil(t, err)
+	_, ok = st.Get("olddnsx")
+	require.False(t, ok)
+	renamed, ok := st.Get("dnsx")
+	require.True(t, ok)
+	require.True(t, renamed.Pinned)
+
+	// the alias isn't migrated again
+	_, ok = RenamedFrom(path, tool)
+	require.False(t, ok)
+}
+
+func TestRenameUnrecorded(t *testing.T) {
+	path := t.TempDir()
+	// a binary with the previous name pdtm didn't install is left alone
+	require.Nil(t, os.WriteFile(filepath.Join(path, "olddnsx"+extIfFound), []byte("olddnsx"), 0755))
+	tool := types.Tool{Name: "dnsx", RenamedFrom: []string{"olddnsx"}}
+	_, ok := RenamedFrom(path, tool)
+	require.False(t, ok)
+	require.NotNil(t, Rename(path, "olddnsx", tool, false))
+	require.FileExists(t, filepath.Join(path, "olddnsx"+extIfFound))
+}
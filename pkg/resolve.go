@@ -0,0 +1,54 @@
+package pkg
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/projectdiscovery/pdtm/pkg/types"
+)
+
+// Resolve returns the given tool resolved to the latest release of the channel.
+// Tools are resolved to the latest stable github release by the pdtm api so only
+// other channels and providers require looking up the releases of the tool
+func Resolve(tool types.Tool, channel types.Channel) (resolvedTool types.Tool, err error) {
+	defer func() { notifyResolved(resolvedTool, err) }()
+	switch channel {
+	case "", types.Stable:
+		return ActiveProvider.Latest(tool)
+	case types.PreRelease:
+		if !isGithub() {
+			return tool, fmt.Errorf("%s channel is only available for github releases", channel)
+		}
+		releases, err := Github.ListReleases(context.Background(), tool.Repo, 10)
+		if err != nil {
+			return tool, err
+		}
+		for _, release := range releases {
+			if release.GetDraft() {
+				continue
+			}
+			return withRelease(tool, release), nil
+		}
+		return tool, fmt.Errorf("no releases found for %s", tool.Name)
+	default:
+		return tool, fmt.Errorf("unknown release channel %s", channel)
+	}
+}
+
+// withRelease returns the tool with the version and assets of the given release
+func withRelease(tool types.Tool, release *types.GithubRelease) types.Tool {
+	tool.Version = strings.TrimPrefix(release.GetTagName(), "v")
+	tool.Assets = make(map[string]string, len(release.Assets))
+	tool.AssetSizes = make(map[string]int64, len(release.Assets))
+	tool.Digests = make(map[string]string)
+	for _, asset := range release.Assets {
+		tool.Assets[asset.GetName()] = strconv.FormatInt(asset.GetID(), 10)
+		tool.AssetSizes[asset.GetName()] = int64(asset.GetSize())
+		if digest := asset.SHA256(); digest != "" {
+			tool.Digests[asset.GetName()] = digest
+		}
+	}
+	return tool
+}
@@ -0,0 +1,154 @@
+package pkg
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/projectdiscovery/gologger"
+	"github.com/projectdiscovery/pdtm/pkg/state"
+	"github.com/projectdiscovery/pdtm/pkg/types"
+)
+
+// AnyTool is the schedule key of the tools without their own interval
+const AnyTool = "*"
+
+// Schedule restricts when the tools are updated by -update-all and the daemon
+type Schedule struct {
+	// Intervals contains the minimum duration between two update checks of
+	// a tool, AnyTool applies to the other tools
+	Intervals map[string]time.Duration
+	// Blackouts contains the windows of the day (local time) during which
+	// nothing is updated
+	Blackouts []Window
+}
+
+// Window is a window of the day, it wraps around midnight when To is
+// before From
+type Window struct {
+	From, To time.Duration
+}
+
+func (w Window) String() string {
+	format := func(d time.Duration) string {
+		return fmt.Sprintf("%02d:%02d", int(d.Hours()), int(d.Minutes())%60)
+	}
+	return format(w.From) + "-" + format(w.To)
+}
+
+// contains returns true if the time of the day is in the window
+func (w Window) contains(t time.Time) bool {
+	d := time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute
+	if w.From <= w.To {
+		return d >= w.From && d < w.To
+	}
+	return d >= w.From || d < w.To
+}
+
+// ParseSchedule parses the intervals (name=duration, eg. nuclei=24h or
+// *=168h) and the blackout windows (eg. 9-17 or 22:30-06:00)
+func ParseSchedule(intervals, blackouts []string) (*Schedule, error) {
+	schedule := &Schedule{Intervals: make(map[string]time.Duration)}
+	for _, interval := range intervals {
+		name, value, ok := strings.Cut(interval, "=")
+		if !ok || name == "" {
+			return nil, fmt.Errorf("invalid schedule %s, expected name=duration", interval)
+		}
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid schedule %s: %s", interval, err)
+		}
+		schedule.Intervals[strings.ToLower(name)] = d
+	}
+	for _, blackout := range blackouts {
+		from, to, ok := strings.Cut(blackout, "-")
+		if !ok {
+			return nil, fmt.Errorf("invalid blackout %s, expected from-to (eg. 9-17)", blackout)
+		}
+		var window Window
+		var err error
+		if window.From, err = parseTimeOfDay(from); err == nil {
+			window.To, err = parseTimeOfDay(to)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid blackout %s: %s", blackout, err)
+		}
+		schedule.Blackouts = append(schedule.Blackouts, window)
+	}
+	return schedule, nil
+}
+
+// parseTimeOfDay parses an hour (9) or an hour and minutes (09:30)
+func parseTimeOfDay(value string) (time.Duration, error) {
+	hours, minutes, _ := strings.Cut(strings.TrimSpace(value), ":")
+	h, err := strconv.Atoi(hours)
+	if err != nil || h < 0 || h > 24 {
+		return 0, fmt.Errorf("%s is not a time of the day", value)
+	}
+	m := 0
+	if minutes != "" {
+		if m, err = strconv.Atoi(minutes); err != nil || m < 0 || m > 59 || h == 24 {
+			return 0, fmt.Errorf("%s is not a time of the day", value)
+		}
+	}
+	return time.Duration(h)*time.Hour + time.Duration(m)*time.Minute, nil
+}
+
+// Blackout returns the blackout window containing now, if any
+func (s *Schedule) Blackout(now time.Time) (Window, bool) {
+	for _, window := range s.Blackouts {
+		if window.contains(now) {
+			return window, true
+		}
+	}
+	return Window{}, false
+}
+
+// Due returns true if the update of the tool last checked at lastCheck is
+// due, tools without interval are always due
+func (s *Schedule) Due(name string, lastCheck, now time.Time) bool {
+	interval, ok := s.Intervals[strings.ToLower(name)]
+	if !ok {
+		interval = s.Intervals[AnyTool]
+	}
+	return interval <= 0 || lastCheck.IsZero() || now.Sub(lastCheck) >= interval
+}
+
+// LastCheck returns when the update of the tool was last checked (or the
+// tool last updated)
+func LastCheck(path string, tool types.Tool) time.Time {
+	st, err := state.Load(path)
+	if err != nil {
+		return time.Time{}
+	}
+	installed, ok := st.Get(tool.Name)
+	if !ok {
+		return time.Time{}
+	}
+	var last time.Time
+	for _, t := range []*time.Time{installed.Checked, installed.Updated} {
+		if t != nil && t.After(last) {
+			last = *t
+		}
+	}
+	return last
+}
+
+// RecordCheck records that the tool was checked for an update
+func RecordCheck(path string, tool types.Tool) {
+	st, err := state.Load(path)
+	if err != nil {
+		gologger.Warning().Msgf("could not read state: %s", err)
+		return
+	}
+	installed, ok := st.Get(tool.Name)
+	if !ok {
+		return
+	}
+	now := time.Now()
+	installed.Checked = &now
+	if err := st.Save(); err != nil {
+		gologger.Warning().Msgf("could not save state: %s", err)
+	}
+}
@@ -0,0 +1,34 @@
+package pkg
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSchedule(t *testing.T) {
+	schedule, err := ParseSchedule([]string{"nuclei=24h", "*=168h"}, []string{"9-17", "22:30-6"})
+	require.Nil(t, err)
+
+	at := func(hour, minute int) time.Time { return time.Date(2024, 4, 12, hour, minute, 0, 0, time.Local) }
+	for _, blackout := range []time.Time{at(9, 0), at(16, 59), at(23, 0), at(5, 30)} {
+		_, ok := schedule.Blackout(blackout)
+		require.True(t, ok, blackout)
+	}
+	for _, allowed := range []time.Time{at(17, 0), at(8, 59), at(22, 29), at(6, 0)} {
+		_, ok := schedule.Blackout(allowed)
+		require.False(t, ok, allowed)
+	}
+
+	now := at(12, 0)
+	require.True(t, schedule.Due("nuclei", now.Add(-25*time.Hour), now))
+	require.False(t, schedule.Due("nuclei", now.Add(-time.Hour), now))
+	require.False(t, schedule.Due("httpx", now.Add(-25*time.Hour), now))
+	require.True(t, schedule.Due("httpx", time.Time{}, now))
+
+	_, err = ParseSchedule([]string{"nuclei"}, nil)
+	require.NotNil(t, err)
+	_, err = ParseSchedule(nil, []string{"9-25"})
+	require.NotNil(t, err)
+}
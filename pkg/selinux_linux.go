@@ -0,0 +1,60 @@
+//go:build linux
+
+package pkg
+
+import (
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/projectdiscovery/gologger"
+	"golang.org/x/sys/unix"
+)
+
+// selinuxAttr is the extended attribute of the selinux context of a file
+const selinuxAttr = "security.selinux"
+
+// selinuxBinaryType is the selinux type of the executables, the binaries in
+// the home folder are labeled user_home_t which confined domains can't run
+const selinuxBinaryType = "bin_t"
+
+// selinuxEnabled is the file of the selinux mode, present when it's enabled
+var selinuxEnabled = "/sys/fs/selinux/enforce"
+
+// selinuxActive returns true when selinux is enabled
+func selinuxActive() bool {
+	_, err := os.Stat(selinuxEnabled)
+	return err == nil
+}
+
+// labelBinary sets the selinux type of the installed binary to bin_t on the
+// systems using selinux
+func labelBinary(file string) {
+	if !selinuxActive() {
+		return
+	}
+	buf := make([]byte, 256)
+	n, err := unix.Lgetxattr(file, selinuxAttr, buf)
+	if err != nil {
+		gologger.Verbose().Msgf("could not read the selinux context of %s: %s", file, err)
+		return
+	}
+	context := strings.TrimRight(string(buf[:n]), "\x00")
+	// user:role:type:level
+	parts := strings.SplitN(context, ":", 4)
+	if len(parts) < 3 || parts[2] == selinuxBinaryType {
+		return
+	}
+	parts[2] = selinuxBinaryType
+	labeled := strings.Join(parts, ":")
+	if err = unix.Lsetxattr(file, selinuxAttr, []byte(labeled), 0); err == nil {
+		gologger.Verbose().Msgf("labeled %s %s", file, labeled)
+		return
+	}
+	if chcon, lookErr := exec.LookPath("chcon"); lookErr == nil {
+		if exec.Command(chcon, "-t", selinuxBinaryType, file).Run() == nil {
+			return
+		}
+	}
+	gologger.Warning().Msgf("could not label %s as %s (%s), run `chcon -t %s %s` if selinux blocks it", file, selinuxBinaryType, err, selinuxBinaryType, file)
+}
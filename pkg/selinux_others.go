@@ -0,0 +1,9 @@
+//go:build !linux
+
+package pkg
+
+// labelBinary is a no-op outside of linux
+func labelBinary(file string) {}
+
+// selinuxActive is always false outside of linux
+func selinuxActive() bool { return false }
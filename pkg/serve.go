@@ -0,0 +1,276 @@
+package pkg
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/projectdiscovery/gologger"
+	"github.com/projectdiscovery/pdtm/pkg/httpclient"
+	"github.com/projectdiscovery/pdtm/pkg/types"
+)
+
+// CacheServer serves the release metadata and assets to the pdtm clients
+// started with -source, the responses of the upstream hosts are cached in
+// a directory. Clients request https://<host>/<path> as /<host>/<path>,
+// only the release urls of the github hosts are served.
+type CacheServer struct {
+	// Dir is the directory the responses are cached in
+	Dir string
+	// TTL is the duration the metadata (tool list, releases) is served from
+	// the cache, the release assets never expire
+	TTL time.Duration
+	// MaxSize is the size in bytes of the cache above which the least
+	// recently used responses are removed (0 to disable)
+	MaxSize int64
+	// Upstreams contains the allowed upstream hosts and their scheme
+	Upstreams map[string]string
+
+	locks   sync.Map
+	pruneMu sync.Mutex
+}
+
+// DefaultUpstreams returns the hosts of the pdtm api, github and of the
+// given provider url (if any)
+func DefaultUpstreams(apiURL, providerURL string) map[string]string {
+	upstreams := make(map[string]string)
+	for _, host := range httpclient.GithubHosts {
+		upstreams[host] = "https"
+	}
+	for _, location := range []string{apiURL, providerURL} {
+		if u, err := url.Parse(location); err == nil && u.Host != "" {
+			upstreams[u.Host] = u.Scheme
+		}
+	}
+	return upstreams
+}
+
+// cachedResponse describes a cached response body
+type cachedResponse struct {
+	URL                string `json:"url"`
+	ContentType        string `json:"content_type,omitempty"`
+	ContentDisposition string `json:"content_disposition,omitempty"`
+}
+
+func (s *CacheServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	host, upstreamPath, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/"), "/")
+	scheme, ok := s.Upstreams[host]
+	if !ok {
+		http.Error(w, fmt.Sprintf("%s is not an allowed upstream", host), http.StatusForbidden)
+		return
+	}
+	upstream := &url.URL{Scheme: scheme, Host: host, Path: "/" + upstreamPath, RawQuery: r.URL.RawQuery}
+	if httpclient.IsGithubHost(host) && !httpclient.IsReleaseURL(upstream) {
+		http.Error(w, fmt.Sprintf("%s is not a release url", upstream), http.StatusForbidden)
+		return
+	}
+	accept := r.Header.Get("Accept")
+
+	sum := sha256.Sum256([]byte(upstream.String() + "\n" + accept))
+	key := hex.EncodeToString(sum[:])
+	body, meta := filepath.Join(s.Dir, key), filepath.Join(s.Dir, key+".json")
+
+	// concurrent requests of the same url wait for a single upstream fetch
+	mu, _ := s.locks.LoadOrStore(key, &sync.Mutex{})
+	mu.(*sync.Mutex).Lock()
+	fi, err := os.Stat(body)
+	cached := err == nil
+	fetched := false
+	if !cached || (!isImmutable(upstream, accept) && time.Since(fi.ModTime()) > s.TTL) {
+		status, err := s.fetch(upstream, accept, body, meta)
+		fetched = err == nil
+		switch {
+		case err != nil && cached:
+			gologger.Warning().Msgf("could not fetch %s, serving the cached response: %s", upstream, err)
+		case err != nil:
+			mu.(*sync.Mutex).Unlock()
+			gologger.Error().Msgf("could not fetch %s: %s", upstream, err)
+			http.Error(w, err.Error(), status)
+			return
+		default:
+			gologger.Verbose().Msgf("fetched %s", upstream)
+		}
+	} else {
+		gologger.Verbose().Msgf("serving %s from the cache", upstream)
+	}
+	// the response is opened before unlocking so pruning can't remove it first
+	var response cachedResponse
+	if b, err := os.ReadFile(meta); err == nil {
+		_ = json.Unmarshal(b, &response)
+	}
+	now := time.Now()
+	_ = os.Chtimes(meta, now, now)
+	f, err := os.Open(body)
+	mu.(*sync.Mutex).Unlock()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer f.Close()
+	if fetched {
+		s.prune()
+	}
+	fi, err = f.Stat()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if response.ContentType != "" {
+		w.Header().Set("Content-Type", response.ContentType)
+	}
+	if response.ContentDisposition != "" {
+		w.Header().Set("Content-Disposition", response.ContentDisposition)
+	}
+	http.ServeContent(w, r, "", fi.ModTime(), f)
+}
+
+// fetch downloads the upstream response to the cache, it returns the status
+// code to answer with on error
+func (s *CacheServer) fetch(upstream *url.URL, accept, body, meta string) (int, error) {
+	req, err := http.NewRequest(http.MethodGet, upstream.String(), nil)
+	if err != nil {
+		return http.StatusBadRequest, err
+	}
+	if accept != "" {
+		req.Header.Set("Accept", accept)
+	}
+	// the token of the server is only used for the github releases, the
+	// ones of the clients are never forwarded
+	if token := os.Getenv("GITHUB_TOKEN"); token != "" && isReleaseEndpoint(upstream) {
+		req.Header.Set("Authorization", "token "+token)
+	}
+	resp, err := httpclient.Direct.Do(req)
+	if err != nil {
+		return http.StatusBadGateway, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return resp.StatusCode, fmt.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+
+	if err := os.MkdirAll(s.Dir, os.ModePerm); err != nil {
+		return http.StatusInternalServerError, err
+	}
+	tmp, err := os.CreateTemp(s.Dir, ".fetch-*")
+	if err != nil {
+		return http.StatusInternalServerError, err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := io.Copy(tmp, resp.Body); err != nil {
+		tmp.Close()
+		return http.StatusBadGateway, err
+	}
+	if err := tmp.Close(); err != nil {
+		return http.StatusInternalServerError, err
+	}
+	b, err := json.Marshal(cachedResponse{
+		URL:                upstream.String(),
+		ContentType:        resp.Header.Get("Content-Type"),
+		ContentDisposition: resp.Header.Get("Content-Disposition"),
+	})
+	if err != nil {
+		return http.StatusInternalServerError, err
+	}
+	if err := os.WriteFile(meta, b, 0644); err != nil {
+		return http.StatusInternalServerError, err
+	}
+	if err := os.Rename(tmp.Name(), body); err != nil {
+		return http.StatusInternalServerError, err
+	}
+	return http.StatusOK, nil
+}
+
+// prune removes the least recently used responses (by the time of their
+// metadata file, touched on each use) while the cache is above MaxSize, the
+// responses being fetched are skipped
+func (s *CacheServer) prune() {
+	if s.MaxSize <= 0 {
+		return
+	}
+	s.pruneMu.Lock()
+	defer s.pruneMu.Unlock()
+	entries, err := os.ReadDir(s.Dir)
+	if err != nil {
+		return
+	}
+	type cacheEntry struct {
+		key  string
+		size int64
+		used time.Time
+	}
+	var cached []cacheEntry
+	var total int64
+	for _, entry := range entries {
+		key := entry.Name()
+		if strings.HasPrefix(key, ".") || strings.HasSuffix(key, ".json") {
+			continue
+		}
+		fi, err := entry.Info()
+		if err != nil || !fi.Mode().IsRegular() {
+			continue
+		}
+		used := fi.ModTime()
+		if mi, err := os.Stat(filepath.Join(s.Dir, key+".json")); err == nil {
+			used = mi.ModTime()
+		}
+		cached = append(cached, cacheEntry{key: key, size: fi.Size(), used: used})
+		total += fi.Size()
+	}
+	sort.Slice(cached, func(i, j int) bool { return cached[i].used.Before(cached[j].used) })
+	for _, entry := range cached {
+		if total <= s.MaxSize {
+			break
+		}
+		mu, _ := s.locks.LoadOrStore(entry.key, &sync.Mutex{})
+		if !mu.(*sync.Mutex).TryLock() {
+			continue
+		}
+		err := os.Remove(filepath.Join(s.Dir, entry.key))
+		if err == nil {
+			_ = os.Remove(filepath.Join(s.Dir, entry.key+".json"))
+			total -= entry.size
+		}
+		mu.(*sync.Mutex).Unlock()
+		if err != nil {
+			gologger.Verbose().Msgf("could not remove %s from the cache: %s", entry.key, err)
+		}
+	}
+}
+
+// isReleaseEndpoint returns true for the github api endpoints of the
+// projectdiscovery releases and their assets, the only ones the cache server
+// lends its token to
+func isReleaseEndpoint(upstream *url.URL) bool {
+	if upstream.Host != "api.github.com" {
+		return false
+	}
+	if path.Clean(upstream.Path) != upstream.Path {
+		return false
+	}
+	parts := strings.Split(strings.TrimPrefix(upstream.Path, "/"), "/")
+	return len(parts) >= 4 && parts[0] == "repos" && parts[1] == types.Organization && parts[3] == "releases"
+}
+
+// isImmutable returns true for the release assets, which are never modified
+// once published
+func isImmutable(upstream *url.URL, accept string) bool {
+	return accept == "application/octet-stream" ||
+		strings.Contains(upstream.Path, "/releases/download/") ||
+		upstream.Host == "objects.githubusercontent.com" ||
+		upstream.Host == "release-assets.githubusercontent.com"
+}
@@ -0,0 +1,169 @@
+package pkg
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/projectdiscovery/pdtm/pkg/httpclient"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCacheServer(t *testing.T) {
+	var hits int32
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&hits, 1)
+		require.Empty(t, r.Header.Get("Authorization"))
+		_, _ = w.Write([]byte(r.URL.Path))
+	}))
+	defer upstream.Close()
+	upstreamURL, _ := url.Parse(upstream.URL)
+
+	cache := httptest.NewServer(&CacheServer{
+		Dir:       t.TempDir(),
+		TTL:       time.Hour,
+		Upstreams: map[string]string{upstreamURL.Host: "http"},
+	})
+	defer cache.Close()
+	require.Nil(t, httpclient.SetSource(cache.URL, upstreamURL.Host))
+	defer func() { _ = httpclient.SetSource("") }()
+
+	for i := 0; i < 2; i++ {
+		req, _ := http.NewRequest(http.MethodGet, upstream.URL+"/api/v1/tools/", nil)
+		req.Header.Set("Authorization", "token secret")
+		resp, err := httpclient.Client.Do(req)
+		require.Nil(t, err)
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		require.Equal(t, "/api/v1/tools/", string(body))
+	}
+	require.Equal(t, int32(1), atomic.LoadInt32(&hits), "second request should be served from the cache")
+
+	resp, err := http.Get(cache.URL + "/example.com/")
+	require.Nil(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusForbidden, resp.StatusCode)
+
+	// the requests other than the GETs of the source hosts are sent directly
+	resp, err = httpclient.Client.Post(upstream.URL+"/webhook", "application/json", nil)
+	require.Nil(t, err)
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	require.Equal(t, "/webhook", string(body))
+	require.Equal(t, int32(2), atomic.LoadInt32(&hits))
+}
+
+func TestIsReleaseEndpoint(t *testing.T) {
+	for endpoint, expected := range map[string]bool{
+		"https://api.github.com/repos/projectdiscovery/nuclei/releases/latest":        true,
+		"https://api.github.com/repos/projectdiscovery/nuclei/releases/assets/1234":   true,
+		"https://api.github.com/repos/projectdiscovery/nuclei/releases?per_page=10":   true,
+		"https://api.github.com/repos/other/private/releases/latest":                  false,
+		"https://api.github.com/user":                                                 false,
+		"https://api.github.com/repos/projectdiscovery/nuclei/actions/artifacts":      false,
+		"https://api.github.com/repos/projectdiscovery/nuclei/releases/../../../user": false,
+		"https://github.com/repos/projectdiscovery/nuclei/releases/latest":            false,
+	} {
+		u, err := url.Parse(endpoint)
+		require.Nil(t, err)
+		require.Equal(t, expected, isReleaseEndpoint(u), endpoint)
+	}
+}
+
+func TestIsReleaseURL(t *testing.T) {
+	for endpoint, expected := range map[string]bool{
+		"https://api.github.com/repos/projectdiscovery/nuclei/releases/latest":                              true,
+		"https://api.github.com/repos/projectdiscovery/nuclei/releases?per_page=10":                         true,
+		"https://api.github.com/repos/projectdiscovery/nuclei/releases/assets/1234":                         true,
+		"https://github.com/projectdiscovery/dnsx/releases/download/v1.1.0/dnsx_1.1.0_linux_amd64.zip":      true,
+		"https://release-assets.githubusercontent.com/github-production-release-asset/1234?sp=r&sig=signed": true,
+		"https://api.github.com/user":                                                            false,
+		"https://api.github.com/repos/projectdiscovery/nuclei/zipball/main":                      false,
+		"https://api.github.com/repos/projectdiscovery/nuclei/releases/../../../../user":         false,
+		"https://github.com/projectdiscovery/dnsx/archive/refs/heads/main.zip":                   false,
+		"https://github.com/projectdiscovery/dnsx/releases/download/v1.1.0/../../../../settings": false,
+		"https://raw.githubusercontent.com/projectdiscovery/dnsx/main/go.mod":                    false,
+		"https://codeload.github.com/projectdiscovery/dnsx/zip/refs/heads/main":                  false,
+		"https://example.com/repos/projectdiscovery/nuclei/releases/latest":                      false,
+	} {
+		u, err := url.Parse(endpoint)
+		require.Nil(t, err)
+		require.Equal(t, expected, httpclient.IsReleaseURL(u), endpoint)
+	}
+}
+
+func TestCacheServerMaxSize(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write(make([]byte, 1024))
+	}))
+	defer upstream.Close()
+	upstreamURL, _ := url.Parse(upstream.URL)
+
+	dir := t.TempDir()
+	cache := httptest.NewServer(&CacheServer{
+		Dir:       dir,
+		TTL:       time.Hour,
+		MaxSize:   2048,
+		Upstreams: map[string]string{upstreamURL.Host: "http"},
+	})
+	defer cache.Close()
+
+	get := func(path string) {
+		resp, err := http.Get(cache.URL + "/" + upstreamURL.Host + path)
+		require.Nil(t, err)
+		_, _ = io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+	}
+	cacheSize := func() int64 {
+		var size int64
+		entries, err := os.ReadDir(dir)
+		require.Nil(t, err)
+		for _, entry := range entries {
+			if !strings.HasSuffix(entry.Name(), ".json") {
+				fi, err := entry.Info()
+				require.Nil(t, err)
+				size += fi.Size()
+			}
+		}
+		return size
+	}
+	get("/first")
+	get("/second")
+	// the first response is used again, the second one is the least recently used
+	time.Sleep(10 * time.Millisecond)
+	get("/first")
+	time.Sleep(10 * time.Millisecond)
+	get("/third")
+	require.Equal(t, int64(2048), cacheSize())
+
+	key := func(path string) string {
+		return filepath.Join(dir, sha256Hex("http://"+upstreamURL.Host+path+"\n"))
+	}
+	require.FileExists(t, key("/first"))
+	require.NoFileExists(t, key("/second"))
+	require.FileExists(t, key("/third"))
+}
+
+func TestCacheServerGithubPaths(t *testing.T) {
+	cache := httptest.NewServer(&CacheServer{
+		Dir:       t.TempDir(),
+		TTL:       time.Hour,
+		Upstreams: DefaultUpstreams("", ""),
+	})
+	defer cache.Close()
+	for _, path := range []string{"/api.github.com/user", "/github.com/projectdiscovery/dnsx/settings", "/raw.githubusercontent.com/projectdiscovery/dnsx/main/go.mod"} {
+		resp, err := http.Get(cache.URL + path)
+		require.Nil(t, err)
+		resp.Body.Close()
+		require.Equal(t, http.StatusForbidden, resp.StatusCode, path)
+	}
+}
@@ -0,0 +1,87 @@
+package signature
+
+import (
+	"errors"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"aead.dev/minisign"
+	fileutil "github.com/projectdiscovery/utils/file"
+)
+
+// Extension is appended to the path of a registry document to get its detached signature
+const Extension = ".minisig"
+
+// ErrInvalidSignature is returned when a registry document isn't signed by the registry key
+var ErrInvalidSignature = errors.New("invalid registry signature")
+
+// publicKey verifies the registry documents, verification is disabled when nil
+var publicKey *minisign.PublicKey
+
+// SetPublicKey sets the minisign public key (or the path of the
+// public key file) the registry documents must be signed with, an empty key
+// disables the verification
+func SetPublicKey(key string) error {
+	if key == "" {
+		publicKey = nil
+		return nil
+	}
+	pk, err := ParsePublicKey(key)
+	if err != nil {
+		return err
+	}
+	publicKey = pk
+	return nil
+}
+
+// ParsePublicKey parses the minisign public key (or the public key file)
+func ParsePublicKey(key string) (*minisign.PublicKey, error) {
+	if fileutil.FileExists(key) {
+		pk, err := minisign.PublicKeyFromFile(key)
+		if err != nil {
+			return nil, err
+		}
+		return &pk, nil
+	}
+	var pk minisign.PublicKey
+	if err := pk.UnmarshalText([]byte(strings.TrimSpace(key))); err != nil {
+		return nil, err
+	}
+	return &pk, nil
+}
+
+// Enabled returns true if the registry documents must be verified
+func Enabled() bool {
+	return publicKey != nil
+}
+
+// KeyID returns the id of the registry key, empty when verification is disabled
+func KeyID() string {
+	if publicKey == nil {
+		return ""
+	}
+	return strings.ToUpper(strconv.FormatUint(publicKey.ID(), 16))
+}
+
+// URL returns the url of the detached signature of the document
+func URL(documentURL string) (string, error) {
+	u, err := url.Parse(documentURL)
+	if err != nil {
+		return "", err
+	}
+	u.Path = strings.TrimSuffix(u.Path, "/") + Extension
+	u.RawPath = ""
+	return u.String(), nil
+}
+
+// Verify checks the detached minisign signature of the document
+func Verify(document, signature []byte) error {
+	if publicKey == nil {
+		return nil
+	}
+	if !minisign.Verify(*publicKey, document, signature) {
+		return ErrInvalidSignature
+	}
+	return nil
+}
@@ -0,0 +1,27 @@
+package signature
+
+import (
+	"crypto/rand"
+	"testing"
+
+	"aead.dev/minisign"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVerify(t *testing.T) {
+	public, private, err := minisign.GenerateKey(rand.Reader)
+	require.Nil(t, err)
+	key, err := public.MarshalText()
+	require.Nil(t, err)
+	require.Nil(t, SetPublicKey(string(key)))
+	defer func() { publicKey = nil }()
+
+	document := []byte(`[{"name":"dnsx"}]`)
+	sig := minisign.Sign(private, document)
+	require.Nil(t, Verify(document, sig))
+	require.ErrorIs(t, Verify([]byte(`[{"name":"evil"}]`), sig), ErrInvalidSignature)
+
+	signatureURL, err := URL("https://api.pdtm.sh/api/v1/tools/?os=linux")
+	require.Nil(t, err)
+	require.Equal(t, "https://api.pdtm.sh/api/v1/tools.minisig?os=linux", signatureURL)
+}
@@ -0,0 +1,242 @@
+package state
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// FileName is the name of the state file kept next to the installed binaries
+const FileName = ".pdtm-state.json"
+
+// Source is the method used to install a tool
+type Source string
+
+const (
+	// SourceRelease is a tool installed from a release binary
+	SourceRelease Source = "release"
+	// SourceGoInstall is a tool built with go install
+	SourceGoInstall Source = "go"
+	// SourceAdopted is an existing binary that wasn't installed by pdtm
+	SourceAdopted Source = "adopted"
+	// SourceArchive is a tool installed from a local release archive
+	SourceArchive Source = "archive"
+	// SourceDataPack is a data directory installed from a release source archive
+	SourceDataPack Source = "data"
+)
+
+// Tool contains the recorded details of an installed tool
+type Tool struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+	Source  Source `json:"source,omitempty"`
+	// Origin is where the tool was installed from (repository, go package,
+	// archive or index)
+	Origin string `json:"origin,omitempty"`
+	Asset  string `json:"asset,omitempty"`
+	Ref    string `json:"ref,omitempty"`
+	Hash   string `json:"hash,omitempty"`
+	// Digest is the sha256 of the release archive the tool was installed from
+	Digest string `json:"digest,omitempty"`
+	// ArchiveVersion is the version the binary was installed as when later
+	// releases were re-tagged with the same binary, the version it reports
+	ArchiveVersion string `json:"archive_version,omitempty"`
+	Pinned         bool   `json:"pinned,omitempty"`
+	Nightly        bool   `json:"nightly,omitempty"`
+	Dir            string `json:"dir,omitempty"`
+	// Owner is the user owning the binary, root for the system installs
+	Owner string `json:"owner,omitempty"`
+	// InstalledBy is the user who installed or last updated a system install
+	InstalledBy string `json:"installed_by,omitempty"`
+	// Capabilities are the linux capabilities set on the binary, re-applied
+	// after the updates
+	Capabilities string `json:"capabilities,omitempty"`
+	// DependencyOf contains the tools the tool was installed as a dependency
+	// of, empty when it was requested explicitly
+	DependencyOf []string `json:"dependency_of,omitempty"`
+	// Binaries are the additional binaries extracted from the release
+	// archive, removed along with the tool
+	Binaries []string `json:"binaries,omitempty"`
+	// BinaryHashes are the sha256 of the additional binaries
+	BinaryHashes map[string]string `json:"binary_hashes,omitempty"`
+	// Installed is when the tool was first installed
+	Installed *time.Time `json:"installed,omitempty"`
+	// Updated is when the tool was last installed or updated
+	Updated *time.Time `json:"updated,omitempty"`
+	// Checked is when the update of the tool was last checked
+	Checked *time.Time `json:"checked,omitempty"`
+	// History contains the versions replaced by updates and rollbacks, oldest first
+	History []Previous `json:"history,omitempty"`
+}
+
+// Previous is a version of a tool that was replaced
+type Previous struct {
+	Version string `json:"version"`
+	Source  Source `json:"source,omitempty"`
+	// Installed is when the version was installed
+	Installed *time.Time `json:"installed,omitempty"`
+	// Replaced is when the version was replaced
+	Replaced *time.Time `json:"replaced,omitempty"`
+}
+
+// State contains the recorded details of all the tools installed in a path
+type State struct {
+	Tools map[string]*Tool `json:"tools"`
+
+	mu       sync.RWMutex
+	location string
+	// loadErr is the error reading the state file, the file isn't
+	// overwritten then since the records of the other tools would be lost
+	loadErr error
+}
+
+// Load reads the state of the given binary path, a missing state file
+// results in an empty state. The state returned with an error can't be saved.
+func Load(path string) (*State, error) {
+	state := &State{
+		Tools:    make(map[string]*Tool),
+		location: filepath.Join(path, FileName),
+	}
+	b, err := os.ReadFile(state.location)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return state, nil
+		}
+		state.loadErr = err
+		return state, err
+	}
+	if err := json.Unmarshal(b, state); err != nil {
+		state.Tools = make(map[string]*Tool)
+		state.loadErr = fmt.Errorf("invalid state %s: %w", state.location, err)
+		return state, state.loadErr
+	}
+	if state.Tools == nil {
+		state.Tools = make(map[string]*Tool)
+	}
+	return state, nil
+}
+
+// Get returns the recorded details of a tool
+func (s *State) Get(name string) (*Tool, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	tool, ok := s.Tools[strings.ToLower(name)]
+	return tool, ok
+}
+
+// Set records the details of a tool
+func (s *State) Set(tool *Tool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.Tools[strings.ToLower(tool.Name)] = tool
+}
+
+// InstalledAt returns when the tool was first installed, now for a tool
+// that isn't recorded yet and the last update for the tools recorded before
+// the install time was
+func (s *State) InstalledAt(name string, now time.Time) *time.Time {
+	if previous, ok := s.Get(name); ok {
+		if previous.Installed != nil {
+			return previous.Installed
+		}
+		if previous.Updated != nil {
+			return previous.Updated
+		}
+	}
+	return &now
+}
+
+// HistoryOf returns the history of the tool once replaced by the given
+// version, the recorded version is added to the history when it differs
+func (s *State) HistoryOf(name, version string, now time.Time) []Previous {
+	previous, ok := s.Get(name)
+	if !ok {
+		return nil
+	}
+	history := append([]Previous(nil), previous.History...)
+	if previous.Version != "" && previous.Version != version {
+		history = append(history, Previous{Version: previous.Version, Source: previous.Source, Installed: previous.Updated, Replaced: &now})
+	}
+	return history
+}
+
+// Hashes returns the recorded sha256 of the binaries by file name
+func (s *State) Hashes() map[string]string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	hashes := make(map[string]string)
+	for _, tool := range s.Tools {
+		if tool.Hash != "" {
+			hashes[tool.Name] = tool.Hash
+		}
+		for binary, hash := range tool.BinaryHashes {
+			hashes[binary] = hash
+		}
+	}
+	return hashes
+}
+
+// Delete removes the recorded details of a tool
+func (s *State) Delete(name string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.Tools, strings.ToLower(name))
+}
+
+// Save writes the state to disk
+func (s *State) Save() error {
+	if s.loadErr != nil {
+		return fmt.Errorf("not overwriting the state that couldn't be read: %w", s.loadErr)
+	}
+	s.mu.RLock()
+	b, err := json.MarshalIndent(s, "", "  ")
+	s.mu.RUnlock()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(s.location), os.ModePerm); err != nil {
+		return err
+	}
+	// write to a temporary file first so an interrupted run can't leave a
+	// truncated state, its name is unique so concurrent writers don't share it
+	f, err := os.CreateTemp(filepath.Dir(s.location), FileName+".tmp-*")
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(b)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Chmod(f.Name(), 0644)
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), s.location)
+	}
+	if err != nil {
+		os.Remove(f.Name())
+	}
+	return err
+}
+
+// Hash returns the hex encoded sha256 of the given file
+func Hash(file string) (string, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
@@ -0,0 +1,90 @@
+package state
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestStateRoundTrip(t *testing.T) {
+	path, err := os.MkdirTemp("", "test-dir")
+	require.Nil(t, err)
+	defer os.RemoveAll(path)
+
+	// missing state file results in an empty state
+	st, err := Load(path)
+	require.Nil(t, err)
+	require.Empty(t, st.Tools)
+
+	st.Set(&Tool{Name: "dnsx", Version: "1.1.1", Asset: "dnsx_1.1.1_linux_amd64.zip"})
+	require.Nil(t, st.Save())
+
+	st, err = Load(path)
+	require.Nil(t, err)
+	tool, ok := st.Get("DNSX")
+	require.True(t, ok)
+	require.Equal(t, "1.1.1", tool.Version)
+
+	st.Delete("dnsx")
+	_, ok = st.Get("dnsx")
+	require.False(t, ok)
+}
+
+func TestHash(t *testing.T) {
+	path, err := os.MkdirTemp("", "test-dir")
+	require.Nil(t, err)
+	defer os.RemoveAll(path)
+
+	file := filepath.Join(path, "dnsx")
+	require.Nil(t, os.WriteFile(file, []byte("dnsx"), 0755))
+
+	hash, err := Hash(file)
+	require.Nil(t, err)
+	require.Equal(t, "974862cb71ca682f065cfa5686dcc54e66e4f81ddea3aff0551f294a0937c7c8", hash)
+}
+
+func TestInstalledAt(t *testing.T) {
+	st := &State{Tools: make(map[string]*Tool)}
+	installed := time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+	updated := time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC)
+	now := time.Date(2024, 5, 6, 0, 0, 0, 0, time.UTC)
+
+	require.Equal(t, now, *st.InstalledAt("dnsx", now))
+	st.Set(&Tool{Name: "dnsx", Updated: &updated})
+	require.Equal(t, updated, *st.InstalledAt("dnsx", now))
+	st.Set(&Tool{Name: "dnsx", Installed: &installed, Updated: &updated})
+	require.Equal(t, installed, *st.InstalledAt("dnsx", now))
+}
+
+func TestSaveUnreadableState(t *testing.T) {
+	path := t.TempDir()
+	truncated := []byte(`{"tools":{"dnsx":{"name":"dnsx","pinned":tr`)
+	require.Nil(t, os.WriteFile(filepath.Join(path, FileName), truncated, 0644))
+
+	st, err := Load(path)
+	require.NotNil(t, err)
+	st.Set(&Tool{Name: "httpx", Version: "1.3.7"})
+	require.NotNil(t, st.Save())
+	b, err := os.ReadFile(filepath.Join(path, FileName))
+	require.Nil(t, err)
+	require.Equal(t, truncated, b, "the unreadable state was overwritten")
+
+	// the temporary files are unique and removed once renamed
+	require.Nil(t, os.Remove(filepath.Join(path, FileName)))
+	st, err = Load(path)
+	require.Nil(t, err)
+	st.Set(&Tool{Name: "httpx", Version: "1.3.7"})
+	require.Nil(t, st.Save())
+	entries, err := os.ReadDir(path)
+	require.Nil(t, err)
+	require.Len(t, entries, 1)
+	if runtime.GOOS != "windows" {
+		info, err := entries[0].Info()
+		require.Nil(t, err)
+		require.Equal(t, os.FileMode(0644), info.Mode().Perm())
+	}
+}
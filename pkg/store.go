@@ -0,0 +1,133 @@
+package pkg
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/projectdiscovery/gologger"
+	"github.com/projectdiscovery/pdtm/pkg/state"
+)
+
+// BinaryStore is the directory keeping the installed binaries by their
+// sha256, the binaries of the paths are hard links to it so identical
+// binaries installed in several paths use the disk once (empty to disable)
+var BinaryStore string
+
+// storedBinaryPath returns the path of the binary with the given sha256 in the store
+func storedBinaryPath(sum string) string {
+	return filepath.Join(BinaryStore, "sha256", sum)
+}
+
+// dedupe replaces the binary with a hard link to the identical binary of
+// the store, adding it to the store when missing. The binary is kept as is
+// when it can't be linked (eg. the store is on another file system). The
+// links share the inode, so the binaries with capabilities or a mode of their
+// own and the systems using selinux labels aren't deduplicated.
+func dedupe(binary, sum string) {
+	if BinaryStore == "" || sum == "" || selinuxActive() {
+		return
+	}
+	stored := storedBinaryPath(sum)
+	// the stored binary may have been modified through any of its links
+	if hash, err := state.Hash(stored); err == nil && hash != sum {
+		gologger.Verbose().Msgf("removing the modified %s from the binary store", stored)
+		if err := os.Remove(stored); err != nil {
+			return
+		}
+	}
+	storedInfo, err := os.Stat(stored)
+	if err != nil {
+		if err := os.MkdirAll(filepath.Dir(stored), os.ModePerm); err != nil {
+			gologger.Verbose().Msgf("could not create the binary store: %s", err)
+			return
+		}
+		if err := os.Link(binary, stored); err != nil {
+			gologger.Verbose().Msgf("could not add %s to the binary store: %s", binary, err)
+		}
+		return
+	}
+	if binaryInfo, err := os.Stat(binary); err != nil || os.SameFile(binaryInfo, storedInfo) || binaryInfo.Mode() != storedInfo.Mode() {
+		return
+	}
+	// the link replaces the binary at once
+	tmp := binary + ".pdtm-link"
+	_ = os.Remove(tmp)
+	if err := os.Link(stored, tmp); err != nil {
+		gologger.Verbose().Msgf("could not link %s to the binary store: %s", binary, err)
+		return
+	}
+	if err := os.Rename(tmp, binary); err != nil {
+		_ = os.Remove(tmp)
+		gologger.Verbose().Msgf("could not link %s to the binary store: %s", binary, err)
+		return
+	}
+	gologger.Verbose().Msgf("linked %s to %s", binary, stored)
+}
+
+// unshare replaces the binary linked to the store with a copy of its own,
+// so its capabilities or mode aren't applied to the other paths
+func unshare(binary string) error {
+	fi, err := os.Stat(binary)
+	if err != nil {
+		return err
+	}
+	if links, ok := linkCount(fi); !ok || links < 2 {
+		return nil
+	}
+	src, err := os.Open(binary)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	tmp := binary + ".pdtm-unshare"
+	dst, err := os.OpenFile(tmp, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, fi.Mode().Perm())
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(dst, src)
+	if closeErr := dst.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp, binary)
+	}
+	if err != nil {
+		_ = os.Remove(tmp)
+		return err
+	}
+	labelBinary(binary)
+	return nil
+}
+
+// PruneStore removes the binaries of the store no longer linked from any
+// path, it returns the number of binaries removed and their size
+func PruneStore() (int, int64, error) {
+	if BinaryStore == "" {
+		return 0, 0, nil
+	}
+	entries, err := os.ReadDir(filepath.Join(BinaryStore, "sha256"))
+	if os.IsNotExist(err) {
+		return 0, 0, nil
+	}
+	if err != nil {
+		return 0, 0, err
+	}
+	var count int
+	var size int64
+	for _, entry := range entries {
+		fi, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		if links, ok := linkCount(fi); !ok || links > 1 {
+			continue
+		}
+		if err := os.Remove(storedBinaryPath(entry.Name())); err != nil {
+			return count, size, err
+		}
+		count++
+		size += fi.Size()
+	}
+	return count, size, nil
+}
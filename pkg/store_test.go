@@ -0,0 +1,85 @@
+package pkg
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/projectdiscovery/pdtm/pkg/state"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBinaryStore(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the store isn't pruned on windows")
+	}
+	useFakeGithub(t)
+	BinaryStore = t.TempDir()
+	defer func() { BinaryStore = "" }()
+	tool := GetToolStruct()
+
+	first, second := t.TempDir(), t.TempDir()
+	require.Nil(t, Install(first, tool))
+	require.Nil(t, Install(second, tool))
+	firstInfo, err := os.Stat(filepath.Join(first, "dnsx"))
+	require.Nil(t, err)
+	secondInfo, err := os.Stat(filepath.Join(second, "dnsx"))
+	require.Nil(t, err)
+	require.True(t, os.SameFile(firstInfo, secondInfo))
+
+	require.Nil(t, Remove(first, tool))
+	count, _, err := PruneStore()
+	require.Nil(t, err)
+	require.Zero(t, count)
+	require.Nil(t, Remove(second, tool))
+	count, _, err = PruneStore()
+	require.Nil(t, err)
+	require.Equal(t, 1, count)
+}
+
+func TestBinaryStoreModified(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the store isn't pruned on windows")
+	}
+	BinaryStore = t.TempDir()
+	defer func() { BinaryStore = "" }()
+	write := func(content string) (string, string) {
+		binary := filepath.Join(t.TempDir(), "dnsx")
+		require.Nil(t, os.WriteFile(binary, []byte(content), 0755))
+		hash, err := state.Hash(binary)
+		require.Nil(t, err)
+		return binary, hash
+	}
+
+	first, sum := write("dnsx")
+	dedupe(first, sum)
+	// the stored binary is modified through the first path
+	require.Nil(t, os.WriteFile(first, []byte("tampered"), 0755))
+	second, _ := write("dnsx")
+	dedupe(second, sum)
+	content, err := os.ReadFile(second)
+	require.Nil(t, err)
+	require.Equal(t, "dnsx", string(content), "the modified binary isn't linked")
+	hash, err := state.Hash(storedBinaryPath(sum))
+	require.Nil(t, err)
+	require.Equal(t, sum, hash)
+
+	// a binary getting capabilities gets a copy of its own
+	third, _ := write("dnsx")
+	dedupe(third, sum)
+	require.Nil(t, unshare(third))
+	secondInfo, err := os.Stat(second)
+	require.Nil(t, err)
+	thirdInfo, err := os.Stat(third)
+	require.Nil(t, err)
+	require.False(t, os.SameFile(secondInfo, thirdInfo))
+
+	// a binary with another mode isn't linked
+	fourth, _ := write("dnsx")
+	require.Nil(t, os.Chmod(fourth, 0700))
+	dedupe(fourth, sum)
+	fourthInfo, err := os.Stat(fourth)
+	require.Nil(t, err)
+	require.False(t, os.SameFile(secondInfo, fourthInfo))
+}
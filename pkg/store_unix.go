@@ -0,0 +1,17 @@
+//go:build !windows
+
+package pkg
+
+import (
+	"os"
+	"syscall"
+)
+
+// linkCount returns the number of hard links of the file
+func linkCount(fi os.FileInfo) (uint64, bool) {
+	stat, ok := fi.Sys().(*syscall.Stat_t)
+	if !ok {
+		return 0, false
+	}
+	return uint64(stat.Nlink), true
+}
@@ -0,0 +1,10 @@
+//go:build windows
+
+package pkg
+
+import "os"
+
+// linkCount isn't available from the file info on windows, the store isn't pruned
+func linkCount(fi os.FileInfo) (uint64, bool) {
+	return 0, false
+}
@@ -0,0 +1,94 @@
+package pkg
+
+import (
+	"fmt"
+	"strings"
+
+	ospath "github.com/projectdiscovery/pdtm/pkg/path"
+	"github.com/projectdiscovery/pdtm/pkg/state"
+	"github.com/projectdiscovery/pdtm/pkg/types"
+	"github.com/projectdiscovery/pdtm/pkg/version"
+	"golang.org/x/mod/module"
+)
+
+// VersionCheck contains the version of an installed tool according to the
+// state, the binary and its go build info, which only differ when the
+// binary was replaced behind pdtm (tampering or a botched manual install)
+type VersionCheck struct {
+	Name string `json:"name" yaml:"name"`
+	// Recorded is the version recorded when pdtm installed the tool
+	Recorded string `json:"recorded,omitempty" yaml:"recorded,omitempty"`
+	// Reported is the version printed by the binary with -version
+	Reported string `json:"reported,omitempty" yaml:"reported,omitempty"`
+	// Embedded is the module version of the go build info of the binary,
+	// empty for local builds and pseudo-versions
+	Embedded string `json:"embedded,omitempty" yaml:"embedded,omitempty"`
+	// Modified is true if the sha256 of the binary isn't the recorded one
+	Modified bool `json:"modified,omitempty" yaml:"modified,omitempty"`
+	// Mismatch describes the versions that differ, empty if they agree
+	Mismatch string `json:"mismatch,omitempty" yaml:"mismatch,omitempty"`
+}
+
+// CheckVersions cross-checks the recorded, reported and embedded versions
+// of the tool installed in path
+func CheckVersions(path string, tool types.Tool) (VersionCheck, error) {
+	check := VersionCheck{Name: tool.Name}
+	executablePath, exists := ospath.GetExecutablePath(path, tool.Name)
+	if !exists {
+		return check, fmt.Errorf(types.ErrToolNotFound, tool.Name, executablePath)
+	}
+	var expected string
+	if st, err := state.Load(path); err == nil {
+		if installed, ok := st.Get(tool.Name); ok {
+			// the nightly builds and git refs have no release version
+			if installed.Ref == "" && !installed.Nightly {
+				check.Recorded, expected = installed.Version, installed.Version
+			}
+			check.Modified = modified(path, installed)
+			// the binary of a re-tagged release reports the version it was built as
+			if installed.ArchiveVersion != "" && !check.Modified {
+				expected = installed.ArchiveVersion
+			}
+		}
+	}
+	if reported, err := version.ExtractInstalledVersion(tool, path); err == nil {
+		check.Reported = reported
+	}
+	if build, err := readBuildInfo(executablePath); err == nil && isReleaseVersion(build.Version) {
+		check.Embedded = build.Version
+	}
+
+	var versions []string
+	for _, source := range []struct{ name, version string }{
+		{"recorded", check.Recorded}, {"reported", check.Reported}, {"embedded", check.Embedded},
+	} {
+		if source.version != "" {
+			versions = append(versions, source.name+" "+source.version)
+		}
+	}
+	if !sameVersions(expected, check.Reported, check.Embedded) {
+		check.Mismatch = strings.Join(versions, ", ")
+	}
+	return check, nil
+}
+
+// isReleaseVersion returns true for the module versions of tagged releases
+func isReleaseVersion(v string) bool {
+	return strings.HasPrefix(v, "v") && !module.IsPseudoVersion(v) && !strings.Contains(v, "+")
+}
+
+// sameVersions returns true if the known versions are equal
+func sameVersions(versions ...string) bool {
+	var first string
+	for _, v := range versions {
+		v = strings.ToLower(strings.TrimPrefix(v, "v"))
+		switch {
+		case v == "":
+		case first == "":
+			first = v
+		case v != first:
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,168 @@
+package toolchain
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+
+	"github.com/projectdiscovery/pdtm/pkg/httpclient"
+	"golang.org/x/mod/modfile"
+	"golang.org/x/mod/module"
+)
+
+// autoSwitchVersion is the first go version downloading the toolchain
+// required by a module on its own (unless GOTOOLCHAIN=local)
+const autoSwitchVersion = "1.21"
+
+// Go is the version of a go binary
+type Go struct {
+	Version string
+	// Toolchain is the GOTOOLCHAIN setting, empty before go 1.21
+	Toolchain string
+}
+
+// InstalledVersion returns the version of the go binary (eg. 1.21.13)
+func InstalledVersion(goBinary string) (Go, error) {
+	output, err := exec.Command(goBinary, "env", "GOVERSION", "GOTOOLCHAIN").Output()
+	if err != nil {
+		return Go{}, err
+	}
+	lines := strings.Split(strings.TrimSpace(string(output)), "\n")
+	installed := Go{Version: strings.TrimPrefix(strings.TrimSpace(lines[0]), "go")}
+	if len(lines) > 1 {
+		installed.Toolchain = strings.TrimSpace(lines[1])
+	}
+	return installed, nil
+}
+
+// Satisfies returns true if go can build a module requiring the given go
+// version, either by itself or by switching to a newer toolchain
+func (g Go) Satisfies(required string) bool {
+	if !Older(g.Version, required) {
+		return true
+	}
+	return !Older(g.Version, autoSwitchVersion) && g.Toolchain != "local" && !strings.HasSuffix(g.Toolchain, "+local")
+}
+
+// RequiredVersion returns the go version required by the module of the
+// package at the given version (latest, tag or branch), as declared by the
+// go directive of its go.mod on the go module proxy. It returns an empty
+// version when go doesn't fetch the module through a proxy (GOPROXY=off or
+// direct, GONOPROXY and GOPRIVATE)
+func RequiredVersion(pkgPath, version string) (string, error) {
+	proxy, ok := moduleProxy(pkgPath)
+	if !ok {
+		return "", nil
+	}
+	// the module of the package is its longest path found on the proxy
+	parts := strings.Split(pkgPath, "/")
+	for i := len(parts); i >= 3; i-- {
+		modulePath := strings.Join(parts[:i], "/")
+		escaped, err := module.EscapePath(modulePath)
+		if err != nil {
+			return "", err
+		}
+		infoURL := proxy + escaped + "/@latest"
+		if version != "latest" {
+			escapedVersion, err := module.EscapeVersion(version)
+			if err != nil {
+				return "", err
+			}
+			infoURL = proxy + escaped + "/@v/" + escapedVersion + ".info"
+		}
+		data, found, err := fetch(infoURL)
+		if err != nil {
+			return "", err
+		}
+		if !found {
+			continue
+		}
+		var info struct{ Version string }
+		if err := json.Unmarshal(data, &info); err != nil {
+			return "", err
+		}
+		data, found, err = fetch(proxy + escaped + "/@v/" + info.Version + ".mod")
+		if err != nil || !found {
+			return "", fmt.Errorf("could not fetch the go.mod of %s@%s: %v", modulePath, info.Version, err)
+		}
+		modFile, err := modfile.ParseLax("go.mod", data, nil)
+		if err != nil {
+			return "", err
+		}
+		if modFile.Go == nil {
+			return "", nil
+		}
+		return modFile.Go.Version, nil
+	}
+	return "", fmt.Errorf("module of %s not found on %s", pkgPath, proxy)
+}
+
+// moduleProxy returns the first go module proxy of $GOPROXY for the package,
+// the default one when it isn't set. It returns false when go fetches the
+// package directly.
+func moduleProxy(pkgPath string) (string, bool) {
+	private := os.Getenv("GONOPROXY")
+	if private == "" {
+		private = os.Getenv("GOPRIVATE")
+	}
+	if private != "" && module.MatchPrefixPatterns(private, pkgPath) {
+		return "", false
+	}
+	proxies := strings.FieldsFunc(os.Getenv("GOPROXY"), func(r rune) bool { return r == ',' || r == '|' })
+	if len(proxies) == 0 {
+		return proxyURL, true
+	}
+	if proxy := proxies[0]; strings.HasPrefix(proxy, "https://") || strings.HasPrefix(proxy, "http://") {
+		return strings.TrimSuffix(proxy, "/") + "/", true
+	}
+	// off, direct or a file:// proxy
+	return "", false
+}
+
+// fetch returns the body of the url, found is false when the proxy doesn't know it
+func fetch(url string) (data []byte, found bool, err error) {
+	resp, err := httpclient.Client.Get(url)
+	if err != nil {
+		return nil, false, err
+	}
+	defer resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusOK:
+		data, err = io.ReadAll(resp.Body)
+		return data, err == nil, err
+	case http.StatusNotFound, http.StatusGone:
+		return nil, false, nil
+	default:
+		return nil, false, fmt.Errorf("unexpected status code %d from %s", resp.StatusCode, url)
+	}
+}
+
+// Older returns true if the go version a is older than b, the pre-releases
+// (eg. 1.21rc2) count as their release
+func Older(a, b string) bool {
+	va, vb := versionNumbers(a), versionNumbers(b)
+	for i := range va {
+		if va[i] != vb[i] {
+			return va[i] < vb[i]
+		}
+	}
+	return false
+}
+
+// versionNumbers returns the major, minor and patch numbers of a go version
+func versionNumbers(version string) [3]int {
+	var numbers [3]int
+	for i, part := range strings.SplitN(strings.TrimPrefix(version, "go"), ".", 3) {
+		end := strings.IndexFunc(part, func(r rune) bool { return r < '0' || r > '9' })
+		if end >= 0 {
+			part = part[:end]
+		}
+		numbers[i], _ = strconv.Atoi(part)
+	}
+	return numbers
+}
@@ -0,0 +1,78 @@
+package toolchain
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestOlder(t *testing.T) {
+	require.True(t, Older("1.20.5", "1.21"))
+	require.True(t, Older("1.21rc2", "1.21.1"))
+	require.False(t, Older("1.21.0", "1.21"))
+	require.False(t, Older("1.22", "1.21.13"))
+
+	require.False(t, Go{Version: "1.20.14"}.Satisfies("1.21"))
+	require.True(t, Go{Version: "1.21.13", Toolchain: "auto"}.Satisfies("1.22.0"))
+	require.False(t, Go{Version: "1.21.13", Toolchain: "local"}.Satisfies("1.22.0"))
+}
+
+func TestRequiredVersion(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/github.com/projectdiscovery/nuclei/v3/@latest", "/github.com/projectdiscovery/nuclei/v3/@v/v3.0.4.info":
+			_, _ = w.Write([]byte(`{"Version":"v3.0.4"}`))
+		case "/github.com/projectdiscovery/nuclei/v3/@v/v3.0.4.mod":
+			_, _ = w.Write([]byte("module github.com/projectdiscovery/nuclei/v3\n\ngo 1.21\n"))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+	t.Setenv("GOPROXY", server.URL+",direct")
+
+	for _, version := range []string{"latest", "v3.0.4"} {
+		required, err := RequiredVersion("github.com/projectdiscovery/nuclei/v3/cmd/nuclei", version)
+		require.Nil(t, err)
+		require.Equal(t, "1.21", required)
+	}
+	_, err := RequiredVersion("github.com/projectdiscovery/unknown/cmd/unknown", "latest")
+	require.NotNil(t, err)
+}
+
+func TestModuleProxy(t *testing.T) {
+	const pkgPath = "github.com/projectdiscovery/nuclei/v3/cmd/nuclei"
+	for _, test := range []struct {
+		proxy, noProxy, private string
+		expected                string
+	}{
+		{expected: proxyURL},
+		{proxy: "https://goproxy.example.com,direct", expected: "https://goproxy.example.com/"},
+		{proxy: "off"},
+		{proxy: "direct"},
+		{proxy: "direct,https://goproxy.example.com"},
+		{private: "github.com/projectdiscovery"},
+		{proxy: "https://goproxy.example.com", private: "github.com/projectdiscovery/*"},
+		{private: "github.com/other", expected: proxyURL},
+		// GONOPROXY takes precedence over GOPRIVATE
+		{noProxy: "github.com/other", private: "github.com/projectdiscovery", expected: proxyURL},
+		{noProxy: "*.com/projectdiscovery"},
+	} {
+		t.Setenv("GOPROXY", test.proxy)
+		t.Setenv("GONOPROXY", test.noProxy)
+		t.Setenv("GOPRIVATE", test.private)
+		proxy, ok := moduleProxy(pkgPath)
+		require.Equal(t, test.expected != "", ok, test)
+		require.Equal(t, test.expected, proxy, test)
+	}
+
+	// the check is skipped instead of querying the default proxy
+	t.Setenv("GOPROXY", "off")
+	t.Setenv("GONOPROXY", "")
+	t.Setenv("GOPRIVATE", "")
+	required, err := RequiredVersion(pkgPath, "latest")
+	require.Nil(t, err)
+	require.Empty(t, required)
+}
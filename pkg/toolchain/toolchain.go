@@ -0,0 +1,190 @@
+package toolchain
+
+import (
+	"archive/zip"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/projectdiscovery/gologger"
+	"github.com/projectdiscovery/pdtm/pkg/httpclient"
+	"golang.org/x/mod/sumdb/dirhash"
+)
+
+// Version is the go toolchain version downloaded when go is not installed,
+// a supported release recent enough for the go directive of the tools
+const Version = "go1.27.1"
+
+// modulePath is the module of the official go toolchains served by the go module proxy
+const modulePath = "golang.org/toolchain"
+
+// proxyURL is the go module proxy used to download the toolchain
+const proxyURL = "https://proxy.golang.org/"
+
+// GoBinary returns the path of the go binary of the toolchain installed in dir
+func GoBinary(dir string) string {
+	binary := filepath.Join(dir, Version, "bin", "go")
+	if runtime.GOOS == "windows" {
+		binary += ".exe"
+	}
+	return binary
+}
+
+// IsInstalled returns true if the toolchain is already installed in dir, the
+// toolchain is only moved to its directory once completely unpacked
+func IsInstalled(dir string) bool {
+	_, err := os.Stat(GoBinary(dir))
+	return err == nil
+}
+
+// Install downloads, verifies and unpacks the toolchain into dir and
+// returns the path of its go binary
+func Install(dir string) (string, error) {
+	if IsInstalled(dir) {
+		return GoBinary(dir), nil
+	}
+	version := fmt.Sprintf("v0.0.1-%s.%s-%s", Version, runtime.GOOS, runtime.GOARCH)
+	expectedHash, err := lookupHash(modulePath, version)
+	if err != nil {
+		return "", fmt.Errorf("could not verify %s toolchain for %s/%s: %s", Version, runtime.GOOS, runtime.GOARCH, err)
+	}
+
+	gologger.Info().Msgf("downloading %s toolchain...", Version)
+	archive, err := download(fmt.Sprintf("%s%s/@v/%s.zip", proxyURL, modulePath, version), expectedHash)
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(archive)
+
+	// unpack next to the toolchain directory so an interrupted unpack never
+	// leaves a partial toolchain in place
+	if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+		return "", err
+	}
+	tmpDir, err := os.MkdirTemp(dir, "."+Version+"-")
+	if err != nil {
+		return "", err
+	}
+	defer os.RemoveAll(tmpDir)
+	if err := unpack(archive, tmpDir, modulePath+"@"+version+"/"); err != nil {
+		return "", err
+	}
+	toolchainDir := filepath.Join(dir, Version)
+	// a partial toolchain unpacked by the previous versions of pdtm
+	if err := os.RemoveAll(toolchainDir); err != nil {
+		return "", err
+	}
+	if err := os.Rename(tmpDir, toolchainDir); err != nil {
+		return "", err
+	}
+	removeOtherVersions(dir)
+	gologger.Info().Msgf("installed %s toolchain in %s", Version, toolchainDir)
+	return GoBinary(dir), nil
+}
+
+// removeOtherVersions removes the toolchains of the previous versions and
+// the unpacks left by the interrupted installs
+func removeOtherVersions(dir string) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return
+	}
+	for _, entry := range entries {
+		name := strings.TrimPrefix(entry.Name(), ".")
+		if entry.IsDir() && strings.HasPrefix(name, "go") && entry.Name() != Version {
+			if err := os.RemoveAll(filepath.Join(dir, entry.Name())); err != nil {
+				gologger.Verbose().Msgf("could not remove the previous toolchain %s: %s", entry.Name(), err)
+			}
+		}
+	}
+}
+
+// download saves the archive into a temporary file and verifies its hash
+func download(url, expectedHash string) (string, error) {
+	resp, err := httpclient.Client.Get(url)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("unexpected status code %d while downloading %s", resp.StatusCode, url)
+	}
+
+	f, err := os.CreateTemp("", "pdtm-toolchain-*.zip")
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	if _, err := io.Copy(f, resp.Body); err != nil {
+		_ = os.Remove(f.Name())
+		return "", err
+	}
+	actualHash, err := dirhash.HashZip(f.Name(), dirhash.Hash1)
+	if err != nil {
+		_ = os.Remove(f.Name())
+		return "", err
+	}
+	if actualHash != expectedHash {
+		_ = os.Remove(f.Name())
+		return "", fmt.Errorf("toolchain hash mismatch: expected %s got %s", expectedHash, actualHash)
+	}
+	return f.Name(), nil
+}
+
+// unpack extracts the module zip into dst stripping the module prefix
+func unpack(archive, dst, prefix string) error {
+	zipReader, err := zip.OpenReader(archive)
+	if err != nil {
+		return err
+	}
+	defer zipReader.Close()
+
+	for _, f := range zipReader.File {
+		name := strings.TrimPrefix(f.Name, prefix)
+		filePath := filepath.Join(dst, filepath.FromSlash(name))
+		if !strings.HasPrefix(filePath, filepath.Clean(dst)+string(os.PathSeparator)) {
+			return fmt.Errorf("invalid file path in toolchain archive: %s", f.Name)
+		}
+		if f.FileInfo().IsDir() {
+			continue
+		}
+		if err := os.MkdirAll(filepath.Dir(filePath), os.ModePerm); err != nil {
+			return err
+		}
+		if err := extractFile(f, filePath, fileMode(name)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// fileMode returns the mode of an unpacked file since module zips don't
+// preserve the executable bit of the toolchain binaries
+func fileMode(name string) os.FileMode {
+	if strings.HasPrefix(name, "bin/") || strings.HasPrefix(name, "pkg/tool/") {
+		return 0755
+	}
+	return 0644
+}
+
+func extractFile(f *zip.File, filePath string, mode os.FileMode) error {
+	src, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	dst, err := os.OpenFile(filePath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, mode)
+	if err != nil {
+		return err
+	}
+	defer dst.Close()
+
+	_, err = io.Copy(dst, src)
+	return err
+}
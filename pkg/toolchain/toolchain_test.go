@@ -0,0 +1,32 @@
+package toolchain
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsInstalled(t *testing.T) {
+	dir := t.TempDir()
+	require.False(t, IsInstalled(dir))
+
+	// an interrupted unpack is left in its temporary directory
+	partial := filepath.Join(dir, "."+Version+"-123", "bin")
+	require.Nil(t, os.MkdirAll(partial, 0755))
+	require.Nil(t, os.WriteFile(filepath.Join(partial, filepath.Base(GoBinary(dir))), nil, 0755))
+	require.False(t, IsInstalled(dir))
+
+	require.Nil(t, os.MkdirAll(filepath.Dir(GoBinary(dir)), 0755))
+	require.Nil(t, os.WriteFile(GoBinary(dir), nil, 0755))
+	require.True(t, IsInstalled(dir))
+
+	// the previous toolchains and the interrupted unpacks are removed
+	require.Nil(t, os.MkdirAll(filepath.Join(dir, "go1.21.13", "bin"), 0755))
+	removeOtherVersions(dir)
+	entries, err := os.ReadDir(dir)
+	require.Nil(t, err)
+	require.Len(t, entries, 1)
+	require.Equal(t, Version, entries[0].Name())
+}
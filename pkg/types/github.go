@@ -0,0 +1,30 @@
+package types
+
+import (
+	"strings"
+
+	"github.com/google/go-github/github"
+)
+
+// GithubRelease is a github release, its assets are decoded with their
+// digest which the github client doesn't know about
+type GithubRelease struct {
+	github.RepositoryRelease
+	Assets []GithubAsset `json:"assets,omitempty"`
+}
+
+// GithubAsset is a release asset and its digest
+type GithubAsset struct {
+	github.ReleaseAsset
+	// Digest is computed by github on upload (eg. sha256:<hex>), it is only
+	// set for the assets uploaded since june 2025
+	Digest string `json:"digest,omitempty"`
+}
+
+// SHA256 returns the hex sha256 of the digest of the asset, empty if unknown
+func (a GithubAsset) SHA256() string {
+	if sum, ok := strings.CutPrefix(a.Digest, "sha256:"); ok {
+		return strings.ToLower(sum)
+	}
+	return ""
+}
@@ -1,25 +1,75 @@
 package types
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
 
-const Organization = "projectdiscovery"
+	"github.com/Masterminds/semver/v3"
+)
+
+const (
+	Organization = "projectdiscovery"
+	// Nightly is the version used to install the nightly build of a tool
+	Nightly = "nightly"
+)
 
 var (
-	ErrIsInstalled = errors.New("already installed")
-	ErrIsUpToDate  = errors.New("already up to date")
+	ErrIsInstalled    = errors.New("already installed")
+	ErrIsUpToDate     = errors.New("already up to date")
+	ErrIsPinned       = errors.New("pinned to the installed version")
+	ErrIsBuiltFromRef = errors.New("built from a git ref, reinstall to change it")
 
 	ErrNoAssetFound = "could not find release asset for your platform (%s/%s)"
 	ErrToolNotFound = "%s: tool not found in path %s: skipping"
 )
 
 type Tool struct {
-	Name          string            `json:"name"`
-	Repo          string            `json:"repo"`
-	Version       string            `json:"version"`
-	GoInstallPath string            `json:"go_install_path" yaml:"go_install_path"`
-	Requirements  []ToolRequirement `json:"requirements"`
-	Assets        map[string]string `json:"assets"`
-	InstallType   InstallType       `json:"install_type" yaml:"install_type"`
+	Name          string `json:"name"`
+	Repo          string `json:"repo"`
+	Version       string `json:"version"`
+	GoInstallPath string `json:"go_install_path" yaml:"go_install_path"`
+	// GoVersion is the minimum go version building the tool with go install,
+	// the go directive of its go.mod is used when not set
+	GoVersion    string            `json:"go_version,omitempty" yaml:"go_version,omitempty"`
+	Requirements []ToolRequirement `json:"requirements"`
+	Assets       map[string]string `json:"assets"`
+	// AssetSizes contains the size in bytes of the release assets (when known)
+	AssetSizes map[string]int64 `json:"asset_sizes,omitempty" yaml:"asset_sizes,omitempty"`
+	// Checksums contains the sha256 of the release assets returned by the pdtm api (or the provider)
+	Checksums map[string]string `json:"checksums,omitempty" yaml:"checksums,omitempty"`
+	// Digests contains the sha256 of the release assets reported by github
+	Digests     map[string]string `json:"digests,omitempty" yaml:"digests,omitempty"`
+	InstallType InstallType       `json:"install_type" yaml:"install_type"`
+	// Dependencies contains the names of the managed tools required by the tool
+	Dependencies []string `json:"dependencies,omitempty" yaml:"dependencies,omitempty"`
+	// PostInstall contains the steps making a fresh install of the tool usable
+	PostInstall []PostInstallStep `json:"post_install,omitempty" yaml:"post_install,omitempty"`
+	// Binaries contains the names of the additional binaries shipped in the
+	// release archive (eg. helper tools), extracted along with the tool
+	Binaries []string `json:"binaries,omitempty" yaml:"binaries,omitempty"`
+	// RenamedFrom contains the previous names of the tool, the binaries
+	// installed with these names are migrated on update
+	RenamedFrom []string `json:"renamed_from,omitempty" yaml:"renamed_from,omitempty"`
+	// Deprecated is the reason the tool is deprecated (eg. merged into another tool)
+	Deprecated string `json:"deprecated,omitempty" yaml:"deprecated,omitempty"`
+}
+
+// PostInstallStep is an initialization step run after installing a tool,
+// either running the tool with the given args, creating a default file or
+// setting the capabilities of the binary
+type PostInstallStep struct {
+	// Description is shown when asking for confirmation
+	Description string `json:"description,omitempty" yaml:"description,omitempty"`
+	// Args are passed to the installed tool (eg. [-update-templates])
+	Args []string `json:"args,omitempty" yaml:"args,omitempty"`
+	// File is created with the content if it doesn't exist (eg. $HOME/.config/notify/provider-config.yaml)
+	File    string `json:"file,omitempty" yaml:"file,omitempty"`
+	Content string `json:"content,omitempty" yaml:"content,omitempty"`
+	// Capabilities are set on the binary with setcap and re-applied after
+	// the updates (eg. cap_net_raw,cap_net_admin+eip), linux only
+	Capabilities string `json:"capabilities,omitempty" yaml:"capabilities,omitempty"`
 }
 
 type InstallType string
@@ -29,6 +79,59 @@ const (
 	Go     InstallType = "go"
 )
 
+// Channel is the release channel used to resolve the version of a tool
+type Channel string
+
+const (
+	Stable     Channel = "stable"
+	PreRelease Channel = "pre-release"
+)
+
+// GoBuildOptions contains the build settings used when installing tools with go install
+type GoBuildOptions struct {
+	GoFlags string `yaml:"goflags"`
+	LDFlags string `yaml:"ldflags"`
+	Tags    string `yaml:"tags"`
+	CGO     *bool  `yaml:"cgo"`
+}
+
+// GoBuildConfig contains the global go install build settings and their per tool overrides
+type GoBuildConfig struct {
+	GoBuildOptions `yaml:",inline"`
+	Tools          map[string]GoBuildOptions `yaml:"tools"`
+}
+
+// For returns the build settings of a tool, per tool settings take precedence
+// over global ones, the tool names are case insensitive
+func (c GoBuildConfig) For(toolName string) GoBuildOptions {
+	options := c.GoBuildOptions
+	override, ok := c.Tools[toolName]
+	if !ok {
+		for name, tool := range c.Tools {
+			if strings.EqualFold(name, toolName) {
+				override, ok = tool, true
+				break
+			}
+		}
+	}
+	if !ok {
+		return options
+	}
+	if override.GoFlags != "" {
+		options.GoFlags = override.GoFlags
+	}
+	if override.LDFlags != "" {
+		options.LDFlags = override.LDFlags
+	}
+	if override.Tags != "" {
+		options.Tags = override.Tags
+	}
+	if override.CGO != nil {
+		options.CGO = override.CGO
+	}
+	return options
+}
+
 type ToolRequirement struct {
 	OS            string                         `json:"os"`
 	Specification []ToolRequirementSpecification `json:"specification"`
@@ -36,12 +139,64 @@ type ToolRequirement struct {
 
 type ToolRequirementSpecification struct {
 	Name        string `json:"name"`
+	Version     string `json:"version,omitempty"`
 	Required    bool   `json:"required"`
 	Command     string `json:"command"`
 	Instruction string `json:"instruction"`
 }
 
+// ValidateRequirements checks the version constraints of the requirements of the tool
+func (t Tool) ValidateRequirements() error {
+	for _, requirement := range t.Requirements {
+		for _, spec := range requirement.Specification {
+			if err := spec.Validate(); err != nil {
+				return fmt.Errorf("%s: %w", t.Name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// Validate checks that the version constraint of the requirement (if any) can be parsed
+func (spec ToolRequirementSpecification) Validate() error {
+	if spec.Version == "" {
+		return nil
+	}
+	if _, err := semver.NewConstraint(spec.Version); err != nil {
+		return fmt.Errorf("invalid version constraint %q for requirement %s: %w", spec.Version, spec.Name, err)
+	}
+	return nil
+}
+
 type NucleiData struct {
 	IgnoreHash string `json:"ignore-hash"`
 	Tools      []Tool `json:"tools"`
 }
+
+// ProviderConfig contains the settings of the provider the tool releases are fetched from
+type ProviderConfig struct {
+	// Type is the type of the provider (github, gitlab, index or plugin)
+	Type string `yaml:"type"`
+	// URL is the base url of the provider instance (eg. https://gitlab.example.com)
+	// or the location of the index (eg. s3://bucket/pdtm/index.json)
+	URL string `yaml:"url"`
+	// Group is the group/organization containing the projects
+	Group string `yaml:"group"`
+	// Token is used to authenticate with the provider
+	Token string `yaml:"token"`
+	// Region is the aws region of the s3 bucket hosting the index
+	Region string `yaml:"region"`
+	// SigV4 signs the index requests with the aws credentials
+	SigV4 bool `yaml:"sigv4"`
+	// Command is the executable (and its arguments) of the plugin provider
+	Command string `yaml:"command"`
+}
+
+// WorkflowArtifact is an artifact of a github actions workflow run
+type WorkflowArtifact struct {
+	ID                 int64     `json:"id"`
+	Name               string    `json:"name"`
+	Expired            bool      `json:"expired"`
+	ArchiveDownloadURL string    `json:"archive_download_url"`
+	CreatedAt          time.Time `json:"created_at"`
+}
@@ -0,0 +1,17 @@
+package types
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGoBuildConfigFor(t *testing.T) {
+	config := GoBuildConfig{
+		GoBuildOptions: GoBuildOptions{GoFlags: "-trimpath", Tags: "netgo"},
+		Tools:          map[string]GoBuildOptions{"Naabu": {Tags: "pcap"}},
+	}
+	require.Equal(t, GoBuildOptions{GoFlags: "-trimpath", Tags: "pcap"}, config.For("naabu"))
+	require.Equal(t, GoBuildOptions{GoFlags: "-trimpath", Tags: "pcap"}, config.For("NAABU"))
+	require.Equal(t, GoBuildOptions{GoFlags: "-trimpath", Tags: "netgo"}, config.For("dnsx"))
+}
@@ -2,11 +2,11 @@ package pkg
 
 import (
 	"fmt"
-	"os"
 	"strings"
 
 	"github.com/charmbracelet/glamour"
 	ospath "github.com/projectdiscovery/pdtm/pkg/path"
+	"github.com/projectdiscovery/pdtm/pkg/state"
 	"github.com/projectdiscovery/pdtm/pkg/types"
 	"github.com/projectdiscovery/pdtm/pkg/version"
 	updateutils "github.com/projectdiscovery/utils/update"
@@ -15,40 +15,159 @@ import (
 )
 
 // Update updates a given tool
-func Update(path string, tool types.Tool, disableChangeLog bool) error {
+func Update(path string, tool types.Tool, disableChangeLog bool) (err error) {
+	defer func() { notifyFinished(path, tool, err, true) }()
+	if IsDataPack(tool) {
+		return updateDataPack(path, tool)
+	}
 	if executablePath, exists := ospath.GetExecutablePath(path, tool.Name); exists {
+		if isPinned(tool, path) {
+			return types.ErrIsPinned
+		}
+		if installedRef(tool, path) != "" {
+			return types.ErrIsBuiltFromRef
+		}
 		if isUpToDate(tool, path) {
 			return types.ErrIsUpToDate
 		}
 		gologger.Info().Msgf("updating %s...", tool.Name)
 
-		if len(tool.Assets) == 0 {
+		if installedWith(tool, path) != state.SourceGoInstall && len(tool.Assets) == 0 {
 			return fmt.Errorf(types.ErrNoAssetFound, tool.Name, executablePath)
 		}
-
-		if err := os.Remove(executablePath); err != nil {
+		// a release re-tagged with the same archive doesn't need a download
+		if assetName, digest, ok := unchangedDigest(tool, path); ok {
+			recordRelease(path, tool, assetName, digest)
+			gologger.Info().Msgf("updated %s to %s (same release archive as the installed version, nothing downloaded)", tool.Name, tool.Version)
+			return nil
+		}
+		// the replaced binary is kept for rollbacks and restored if the update fails
+		restore, err := storeVersion(path, tool, executablePath)
+		if err != nil {
 			return err
 		}
 
+		// keep tools on the method they were originally installed with
+		if installedWith(tool, path) == state.SourceGoInstall {
+			err := goInstall(tool, path, "")
+			if err == nil {
+				gologger.Info().Msgf("updated %s to %s with go install (%s)", tool.Name, tool.Version, au.BrightGreen("latest").String())
+				applyRetention(path)
+				return nil
+			}
+			if !HasAsset(tool) {
+				restore()
+				return err
+			}
+			gologger.Error().Msgf("error while updating %s with go install: %s", tool.Name, err)
+			gologger.Info().Msgf("trying to update %s using release binary", tool.Name)
+		}
+
 		version, err := install(tool, path)
 		if err != nil {
+			restore()
 			return err
 		}
-		if !disableChangeLog {
+		if !disableChangeLog && isGithub() {
 			showReleaseNotes(tool.Repo)
 		}
 		gologger.Info().Msgf("updated %s to %s (%s)", tool.Name, version, au.BrightGreen("latest").String())
+		applyRetention(path)
 		return nil
 	} else {
 		return fmt.Errorf(types.ErrToolNotFound, tool.Name, executablePath)
 	}
 }
 
+// InstalledVersion returns the version of the tool installed at path
+func InstalledVersion(tool types.Tool, path string) (string, error) {
+	if v, ok := recordedVersion(tool, path); ok {
+		return v, nil
+	}
+	return version.ExtractInstalledVersion(tool, path)
+}
+
 func isUpToDate(tool types.Tool, path string) bool {
+	// the recorded version can be trusted as long as the binary wasn't replaced
+	// since it was installed, which avoids executing every tool on update all
+	if v, ok := recordedVersion(tool, path); ok {
+		return strings.EqualFold(strings.TrimPrefix(tool.Version, "v"), strings.TrimPrefix(v, "v"))
+	}
 	v, err := version.ExtractInstalledVersion(tool, path)
 	return err == nil && strings.EqualFold(tool.Version, v)
 }
 
+// unchangedDigest returns the release asset of the tool and its sha256 when
+// the sha256 reported for the asset is the one of the installed archive
+func unchangedDigest(tool types.Tool, path string) (string, string, bool) {
+	st, err := state.Load(path)
+	if err != nil {
+		return "", "", false
+	}
+	installed, ok := st.Get(tool.Name)
+	if !ok || installed.Source != state.SourceRelease || installed.Digest == "" || IsNightly(tool.Version) {
+		return "", "", false
+	}
+	assetName, _, _ := findAsset(tool)
+	digest := tool.Digests[assetName]
+	if digest == "" {
+		digest = tool.Checksums[assetName]
+	}
+	if assetName == "" || !strings.EqualFold(digest, installed.Digest) {
+		return "", "", false
+	}
+	// the binary must still be the installed one
+	executablePath, exists := ospath.GetExecutablePath(path, tool.Name)
+	if !exists || installed.Hash == "" {
+		return "", "", false
+	}
+	if hash, err := state.Hash(executablePath); err != nil || hash != installed.Hash {
+		return "", "", false
+	}
+	return assetName, installed.Digest, true
+}
+
+func installedWith(tool types.Tool, path string) state.Source {
+	st, err := state.Load(path)
+	if err != nil {
+		return ""
+	}
+	if installed, ok := st.Get(tool.Name); ok {
+		return installed.Source
+	}
+	return ""
+}
+
+func installedRef(tool types.Tool, path string) string {
+	st, err := state.Load(path)
+	if err != nil {
+		return ""
+	}
+	if installed, ok := st.Get(tool.Name); ok {
+		return installed.Ref
+	}
+	return ""
+}
+
+func recordedVersion(tool types.Tool, path string) (string, bool) {
+	st, err := state.Load(path)
+	if err != nil {
+		return "", false
+	}
+	installed, ok := st.Get(tool.Name)
+	if !ok || installed.Hash == "" {
+		return "", false
+	}
+	executablePath, exists := ospath.GetExecutablePath(path, tool.Name)
+	if !exists {
+		return "", false
+	}
+	if hash, err := state.Hash(executablePath); err != nil || hash != installed.Hash {
+		return "", false
+	}
+	return installed.Version, true
+}
+
 func showReleaseNotes(toolname string) {
 	gh, err := updateutils.NewghReleaseDownloader(toolname)
 	if err != nil {
@@ -32,7 +32,7 @@ func GetUpdaterCallback(toolName string) func() {
 			return
 		}
 		err = pkg.Update(dp, tool, false)
-		if err == types.ErrIsUpToDate {
+		if err == types.ErrIsUpToDate || err == types.ErrIsPinned || err == types.ErrIsBuiltFromRef {
 			gologger.Info().Msgf("%s: %s", toolName, err)
 		} else {
 			gologger.Error().Msgf("error while updating %s: %s", toolName, err)
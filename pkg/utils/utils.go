@@ -6,10 +6,13 @@ import (
 	"io"
 	"net/http"
 	"os"
+	"sort"
 	"strings"
 
 	"github.com/logrusorgru/aurora/v4"
+	"github.com/projectdiscovery/pdtm/pkg/httpclient"
 	"github.com/projectdiscovery/pdtm/pkg/path"
+	"github.com/projectdiscovery/pdtm/pkg/signature"
 	"github.com/projectdiscovery/pdtm/pkg/types"
 	"github.com/projectdiscovery/pdtm/pkg/version"
 	updateutils "github.com/projectdiscovery/utils/update"
@@ -17,6 +20,11 @@ import (
 
 var host = getEnv("PDTM_SERVER", "https://api.pdtm.sh")
 
+// APIServer returns the url of the pdtm api
+func APIServer() string {
+	return host
+}
+
 func getEnv(key, defaultValue string) string {
 	value := os.Getenv(key)
 	if value == "" {
@@ -29,36 +37,106 @@ func getEnv(key, defaultValue string) string {
 var au = aurora.New(aurora.WithColors(true))
 
 func FetchToolList() ([]types.Tool, error) {
-	tools := make([]types.Tool, 0)
+	tools, _, err := FetchSignedToolList()
+	return tools, err
+}
 
+// SignedToolList is the tool list document returned by the pdtm api along with
+// its detached signature (empty when no registry key is set), kept as is so
+// that it can be verified again when read from a cache
+type SignedToolList struct {
+	Document  []byte `json:"document"`
+	Signature []byte `json:"signature,omitempty"`
+}
+
+// FetchSignedToolList returns the tool list of the pdtm api and the signed
+// document it was parsed from
+func FetchSignedToolList() ([]types.Tool, *SignedToolList, error) {
 	// Create the request URL with query parameters
 	reqURL := fmt.Sprintf("%s/api/v1/tools/?%s", host, updateutils.GetpdtmParams(""))
 
-	resp, err := http.Get(reqURL)
+	resp, err := httpclient.Client.Get(reqURL)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode == http.StatusOK {
 		body, err := io.ReadAll(resp.Body)
 		if err != nil {
-			return nil, err
+			return nil, nil, err
+		}
+		signed := &SignedToolList{Document: body}
+		if signature.Enabled() {
+			if signed.Signature, err = fetchSignature(reqURL); err != nil {
+				return nil, nil, err
+			}
 		}
-		err = json.Unmarshal(body, &tools)
+		tools, err := ParseToolList(signed)
 		if err != nil {
+			return nil, nil, err
+		}
+		return tools, signed, nil
+	}
+	return nil, nil, nil
+}
+
+// ParseToolList verifies the signature of the tool list document when a
+// registry key is set and returns its tools
+func ParseToolList(signed *SignedToolList) ([]types.Tool, error) {
+	if signature.Enabled() && len(signed.Signature) == 0 {
+		return nil, fmt.Errorf("%w: the tool list isn't signed", signature.ErrInvalidSignature)
+	}
+	if err := signature.Verify(signed.Document, signed.Signature); err != nil {
+		return nil, err
+	}
+	tools := make([]types.Tool, 0)
+	if err := json.Unmarshal(signed.Document, &tools); err != nil {
+		return nil, err
+	}
+	for _, tool := range tools {
+		if err := tool.ValidateRequirements(); err != nil {
 			return nil, err
 		}
-		return tools, nil
 	}
-	return nil, nil
+	return tools, nil
+}
+
+// verifySignature checks the detached signature of the registry document
+// fetched from the given url when a registry key is configured
+func verifySignature(documentURL string, document []byte) error {
+	if !signature.Enabled() {
+		return nil
+	}
+	sig, err := fetchSignature(documentURL)
+	if err != nil {
+		return err
+	}
+	return signature.Verify(document, sig)
+}
+
+// fetchSignature returns the detached signature of the registry document
+func fetchSignature(documentURL string) ([]byte, error) {
+	signatureURL, err := signature.URL(documentURL)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := httpclient.Client.Get(signatureURL)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%w: could not fetch signature (status code %d)", signature.ErrInvalidSignature, resp.StatusCode)
+	}
+	return io.ReadAll(resp.Body)
 }
 
 func fetchTool(toolName string) (types.Tool, error) {
 	var tool types.Tool
 	// Create the request URL to get tool
 	reqURL := fmt.Sprintf("%s/api/v1/tools/%s?%s", host, toolName, updateutils.GetpdtmParams(""))
-	resp, err := http.Get(reqURL)
+	resp, err := httpclient.Client.Get(reqURL)
 	if err != nil {
 		return tool, err
 	}
@@ -69,6 +147,10 @@ func fetchTool(toolName string) (types.Tool, error) {
 		if err != nil {
 			return tool, err
 		}
+		// the metadata is signed with the registry key like the tool list
+		if err := verifySignature(reqURL, body); err != nil {
+			return tool, err
+		}
 		// edge case for nuclei coz, the nuclei api send a list of tools including nuclei-templates
 		if toolName == "nuclei" {
 			var data types.NucleiData
@@ -82,14 +164,14 @@ func fetchTool(toolName string) (types.Tool, error) {
 					break
 				}
 			}
-			return tool, nil
+			return tool, tool.ValidateRequirements()
 		}
 
 		err = json.Unmarshal(body, &tool)
 		if err != nil {
 			return tool, err
 		}
-		return tool, nil
+		return tool, tool.ValidateRequirements()
 	}
 	return tool, nil
 }
@@ -103,6 +185,61 @@ func Contains(s []types.Tool, toolName string) (int, bool) {
 	return -1, false
 }
 
+// Suggest returns the names of the tools closest to the unknown tool name
+// by edit distance, closest first
+func Suggest(s []types.Tool, toolName string) []string {
+	toolName = strings.ToLower(toolName)
+	maxDistance := len(toolName) / 3
+	if maxDistance < 1 {
+		maxDistance = 1
+	}
+	type suggestion struct {
+		name     string
+		distance int
+	}
+	var suggestions []suggestion
+	for _, tool := range s {
+		if distance := editDistance(toolName, strings.ToLower(tool.Name)); distance <= maxDistance {
+			suggestions = append(suggestions, suggestion{name: tool.Name, distance: distance})
+		}
+	}
+	sort.SliceStable(suggestions, func(i, j int) bool {
+		return suggestions[i].distance < suggestions[j].distance
+	})
+	var names []string
+	for i := 0; i < len(suggestions) && i < 3; i++ {
+		names = append(names, suggestions[i].name)
+	}
+	return names
+}
+
+// editDistance returns the levenshtein distance of a and b
+func editDistance(a, b string) int {
+	previous := make([]int, len(b)+1)
+	current := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = previous[j-1] + cost
+			if previous[j]+1 < current[j] {
+				current[j] = previous[j] + 1
+			}
+			if current[j-1]+1 < current[j] {
+				current[j] = current[j-1] + 1
+			}
+		}
+		previous, current = current, previous
+	}
+	return previous[len(b)]
+}
+
 func InstalledVersion(tool types.Tool, basePath string, au *aurora.Aurora) string {
 	var msg string
 
@@ -131,11 +268,12 @@ func InstalledVersion(tool types.Tool, basePath string, au *aurora.Aurora) strin
 }
 
 func isOsAvailable(tool types.Tool) bool {
-	osData := path.CheckOS()
-	for asset := range tool.Assets {
-		expectedAssetPrefix := tool.Name + "_" + tool.Version + "_" + osData
-		if strings.Contains(asset, expectedAssetPrefix) {
-			return true
+	for _, osData := range path.CheckOSArchs() {
+		for asset := range tool.Assets {
+			expectedAssetPrefix := tool.Name + "_" + tool.Version + "_" + osData
+			if strings.Contains(asset, expectedAssetPrefix) {
+				return true
+			}
 		}
 	}
 	return false
@@ -0,0 +1,28 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/projectdiscovery/pdtm/pkg/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSuggest(t *testing.T) {
+	toolList := []types.Tool{{Name: "subfinder"}, {Name: "nuclei"}, {Name: "naabu"}, {Name: "dnsx"}, {Name: "tlsx"}}
+
+	require.Equal(t, []string{"subfinder"}, Suggest(toolList, "subfindr"))
+	require.Equal(t, []string{"nuclei"}, Suggest(toolList, "Nucleii"))
+	require.Equal(t, []string{"dnsx", "tlsx"}, Suggest(toolList, "dlsx"))
+	require.Empty(t, Suggest(toolList, "httpx-toolkit"))
+}
+
+func TestParseToolListConstraints(t *testing.T) {
+	valid := `[{"name": "naabu", "requirements": [{"os": "linux", "specification": [{"name": "libpcap", "version": ">=1.9"}]}]}]`
+	tools, err := ParseToolList(&SignedToolList{Document: []byte(valid)})
+	require.Nil(t, err)
+	require.Len(t, tools, 1)
+
+	invalid := `[{"name": "naabu", "requirements": [{"os": "linux", "specification": [{"name": "libpcap", "version": "at least 1.9"}]}]}]`
+	_, err = ParseToolList(&SignedToolList{Document: []byte(invalid)})
+	require.ErrorContains(t, err, `naabu: invalid version constraint "at least 1.9" for requirement libpcap`)
+}
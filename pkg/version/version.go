@@ -2,20 +2,37 @@ package version
 
 import (
 	"bytes"
+	"context"
 	"errors"
 	"os/exec"
 	"path/filepath"
 	"regexp"
 	"strings"
+	"time"
 
 	"github.com/projectdiscovery/pdtm/pkg/types"
 )
 
 var RegexVersionNumber = regexp.MustCompile(`(?m)[v\s](\d+\.\d+\.\d+)`)
 
+// ProbeTimeout bounds the run of the version probes (eg. <tool> --version),
+// a binary waiting for input or hanging is killed instead of blocking pdtm
+var ProbeTimeout = 10 * time.Second
+
+// Command returns the command of a version probe, killed after ProbeTimeout
+// along with the processes holding its output, the context must be
+// canceled once the command completes
+func Command(name string, args ...string) (*exec.Cmd, context.CancelFunc) {
+	ctx, cancel := context.WithTimeout(context.Background(), ProbeTimeout)
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.WaitDelay = time.Second
+	return cmd, cancel
+}
+
 func ExtractInstalledVersion(tool types.Tool, basePath string) (string, error) {
 	toolPath := filepath.Join(basePath, tool.Name)
-	cmd := exec.Command(toolPath, "--version")
+	cmd, cancel := Command(toolPath, "--version")
+	defer cancel()
 
 	var outb bytes.Buffer
 	cmd.Stdout = &outb
@@ -0,0 +1,34 @@
+package version
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/projectdiscovery/pdtm/pkg/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExtractInstalledVersionTimeout(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the tool is a shell script")
+	}
+	ProbeTimeout = 200 * time.Millisecond
+	defer func() { ProbeTimeout = 10 * time.Second }()
+
+	dir := t.TempDir()
+	script := "#!/bin/sh\nif [ -n \"$SLOW\" ]; then sleep 30; fi\necho \"nuclei v3.1.0\"\n"
+	require.Nil(t, os.WriteFile(filepath.Join(dir, "nuclei"), []byte(script), 0755))
+
+	installed, err := ExtractInstalledVersion(types.Tool{Name: "nuclei"}, dir)
+	require.Nil(t, err)
+	require.Equal(t, "3.1.0", installed)
+
+	t.Setenv("SLOW", "1")
+	start := time.Now()
+	_, err = ExtractInstalledVersion(types.Tool{Name: "nuclei"}, dir)
+	require.NotNil(t, err)
+	require.Less(t, time.Since(start), 5*time.Second)
+}
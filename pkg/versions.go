@@ -0,0 +1,371 @@
+package pkg
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/klauspost/compress/zstd"
+	"github.com/projectdiscovery/gologger"
+	ospath "github.com/projectdiscovery/pdtm/pkg/path"
+	"github.com/projectdiscovery/pdtm/pkg/state"
+	"github.com/projectdiscovery/pdtm/pkg/types"
+)
+
+// VersionsDir is the directory of the binary path keeping the binaries
+// replaced by the updates, so they can be rolled back without a download
+const VersionsDir = ".pdtm-versions"
+
+// storedExtension is the extension of the kept binaries, compressed with zstd
+const storedExtension = ".zst"
+
+// unknownVersion prefixes the versions the binaries whose version can't be
+// determined are kept under, followed by the time they were replaced
+const unknownVersion = "unknown"
+
+// storedVersionPath returns the path the binary of the tool version is kept at
+func storedVersionPath(path string, tool types.Tool, version string) string {
+	return filepath.Join(path, VersionsDir, strings.ToLower(tool.Name), strings.TrimPrefix(version, "v"), tool.Name+storedExtension)
+}
+
+// StoredVersions returns the versions of the tool kept in the versions
+// directory of path, oldest first
+func StoredVersions(path string, tool types.Tool) []string {
+	entries, err := os.ReadDir(filepath.Join(path, VersionsDir, strings.ToLower(tool.Name)))
+	if err != nil {
+		return nil
+	}
+	var versions []string
+	for _, entry := range entries {
+		if _, err := os.Stat(storedVersionPath(path, tool, entry.Name())); err == nil {
+			versions = append(versions, entry.Name())
+		}
+	}
+	// the versions that aren't semver (eg. unknown) are the oldest
+	sort.SliceStable(versions, func(i, j int) bool {
+		a, errA := semver.NewVersion(versions[i])
+		b, errB := semver.NewVersion(versions[j])
+		switch {
+		case errA != nil && errB != nil:
+			return versions[i] < versions[j]
+		case errA != nil || errB != nil:
+			return errA != nil
+		}
+		return a.LessThan(b)
+	})
+	return versions
+}
+
+// storeVersion compresses the installed binary of the tool to the versions
+// directory and removes it before it is replaced, it returns a function
+// restoring it when the replacement failed. Binaries of an unknown version
+// are kept as unknown-<time>, nightly builds are removed.
+func storeVersion(path string, tool types.Tool, executablePath string) (func(), error) {
+	installedVersion, err := InstalledVersion(tool, path)
+	if err != nil || installedVersion == "" {
+		gologger.Verbose().Msgf("could not determine the installed version of %s, keeping it as unknown", tool.Name)
+		installedVersion = unknownVersion + "-" + time.Now().UTC().Format("20060102T150405Z")
+	}
+	if IsNightly(installedVersion) {
+		if err := os.Remove(executablePath); err != nil && !os.IsNotExist(err) {
+			return nil, err
+		}
+		return func() {}, nil
+	}
+	stored := storedVersionPath(path, tool, installedVersion)
+	if err := os.MkdirAll(filepath.Dir(stored), os.ModePerm); err != nil {
+		return nil, err
+	}
+	// the modification time of the kept binary is when it was replaced, for the retention
+	if err := compressVersion(executablePath, stored); err != nil {
+		return nil, err
+	}
+	if err := os.Remove(executablePath); err != nil {
+		_ = os.Remove(stored)
+		return nil, err
+	}
+	// the versions kept by several paths share the store too, the shared
+	// file counts as kept at its last replacement for the retention
+	if hash, err := state.Hash(stored); err == nil {
+		dedupe(stored, hash)
+		now := time.Now()
+		_ = os.Chtimes(stored, now, now)
+	}
+	gologger.Verbose().Msgf("kept %s %s in %s", tool.Name, installedVersion, filepath.Dir(stored))
+	return func() {
+		if err := decompressVersion(stored, executablePath); err != nil {
+			gologger.Error().Msgf("could not restore %s %s: %s", tool.Name, installedVersion, err)
+			return
+		}
+		_ = os.RemoveAll(filepath.Dir(stored))
+	}, nil
+}
+
+// compressVersion writes the binary compressed with zstd to stored
+func compressVersion(binary, stored string) error {
+	src, err := os.Open(binary)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	dst, err := os.Create(stored)
+	if err != nil {
+		return err
+	}
+	zw, err := zstd.NewWriter(dst, zstd.WithEncoderLevel(zstd.SpeedBetterCompression))
+	if err == nil {
+		_, err = io.Copy(zw, src)
+		if closeErr := zw.Close(); err == nil {
+			err = closeErr
+		}
+	}
+	if closeErr := dst.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		_ = os.Remove(stored)
+	}
+	return err
+}
+
+// decompressVersion writes the binary kept at stored to binary
+func decompressVersion(stored, binary string) error {
+	src, err := os.Open(stored)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	zr, err := zstd.NewReader(src)
+	if err != nil {
+		return err
+	}
+	defer zr.Close()
+	// the binary may be a hard link to the store
+	_ = os.Remove(binary)
+	dst, err := os.OpenFile(binary, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0755)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(dst, zr)
+	if closeErr := dst.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		_ = os.Remove(binary)
+		return err
+	}
+	labelBinary(binary)
+	return nil
+}
+
+// Rollback replaces the installed binary of the tool with the given version,
+// the binary kept by a previous update is used when available and the
+// release of the version is installed otherwise
+func Rollback(path string, tool types.Tool, version string) (err error) {
+	defer func() { notifyFinished(path, tool, err, true) }()
+	if IsDataPack(tool) {
+		return fmt.Errorf("rollback of %s is not supported", tool.Name)
+	}
+	executablePath, exists := ospath.GetExecutablePath(path, tool.Name)
+	if !exists {
+		return fmt.Errorf(types.ErrToolNotFound, tool.Name, executablePath)
+	}
+	version = strings.TrimPrefix(version, "v")
+	if installedVersion, err := InstalledVersion(tool, path); err == nil && strings.EqualFold(strings.TrimPrefix(installedVersion, "v"), version) {
+		return fmt.Errorf("%s %s is already installed", tool.Name, version)
+	}
+
+	stored := storedVersionPath(path, tool, version)
+	_, storedErr := os.Stat(stored)
+	restore, err := storeVersion(path, tool, executablePath)
+	if err != nil {
+		return err
+	}
+	if storedErr == nil {
+		if err := decompressVersion(stored, executablePath); err != nil {
+			restore()
+			return err
+		}
+		_ = os.RemoveAll(filepath.Dir(stored))
+		tool.Version = version
+		record(path, tool, "", previousSource(path, tool, version), releaseOrigin(tool))
+		gologger.Info().Msgf("rolled back %s to %s (kept by a previous update)", tool.Name, version)
+		applyRetention(path)
+		return nil
+	}
+
+	resolved, err := ResolveVersion(tool, version)
+	if err != nil {
+		restore()
+		return err
+	}
+	tool = resolved
+	if _, err := install(resolved, path); err != nil {
+		restore()
+		return err
+	}
+	gologger.Info().Msgf("rolled back %s to %s", tool.Name, resolved.Version)
+	applyRetention(path)
+	return nil
+}
+
+// previousSource returns how the version of the tool was installed according
+// to its history, release if it isn't known
+func previousSource(path string, tool types.Tool, version string) state.Source {
+	st, err := state.Load(path)
+	if err != nil {
+		return state.SourceRelease
+	}
+	if installed, ok := st.Get(tool.Name); ok {
+		for i := len(installed.History) - 1; i >= 0; i-- {
+			previous := installed.History[i]
+			if strings.EqualFold(strings.TrimPrefix(previous.Version, "v"), version) && previous.Source != "" {
+				return previous.Source
+			}
+		}
+	}
+	return state.SourceRelease
+}
+
+// errNoPreviousVersion is returned when rolling back a tool without history
+var errNoPreviousVersion = errors.New("no previous version recorded")
+
+// PreviousVersion returns the version the tool was at before its last
+// update or rollback
+func PreviousVersion(path string, tool types.Tool) (string, error) {
+	st, err := state.Load(path)
+	if err != nil {
+		return "", err
+	}
+	installed, ok := st.Get(tool.Name)
+	if !ok || len(installed.History) == 0 {
+		return "", errNoPreviousVersion
+	}
+	return installed.History[len(installed.History)-1].Version, nil
+}
+
+// StoredVersion is a binary kept in the versions directory
+type StoredVersion struct {
+	Name    string `json:"name" yaml:"name"`
+	Version string `json:"version" yaml:"version"`
+	Size    int64  `json:"size" yaml:"size"`
+	// Stored is when the version was replaced and kept
+	Stored time.Time `json:"stored" yaml:"stored"`
+}
+
+// RetentionPolicy limits the versions kept for rollbacks
+type RetentionPolicy struct {
+	// Keep is the number of previous versions kept of each tool
+	Keep int
+	// MaxAge is the age after which the kept versions are removed (0 to disable)
+	MaxAge time.Duration
+	// MaxSize is the size in bytes of the versions directory above which the
+	// oldest versions are removed (0 to disable)
+	MaxSize int64
+}
+
+// Retention is the policy applied to the kept versions after the updates
+var Retention = RetentionPolicy{Keep: 2}
+
+// applyRetention removes the kept versions of path the retention policy
+// doesn't allow anymore
+func applyRetention(path string) {
+	removed, err := CollectVersions(path, Retention)
+	if err != nil {
+		gologger.Verbose().Msgf("could not remove the kept versions of %s: %s", path, err)
+	}
+	for _, version := range removed {
+		gologger.Verbose().Msgf("removed kept %s %s", version.Name, version.Version)
+	}
+}
+
+// CollectVersions removes the versions kept in the versions directory of path
+// the retention policy doesn't allow: the versions beyond the Keep most recent
+// ones of each installed tool, the versions older than MaxAge, the oldest
+// versions above MaxSize and every version of the tools no longer installed
+func CollectVersions(path string, policy RetentionPolicy) ([]StoredVersion, error) {
+	root := filepath.Join(path, VersionsDir)
+	entries, err := os.ReadDir(root)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var removed, kept []StoredVersion
+	remove := func(version StoredVersion) error {
+		if err := os.RemoveAll(filepath.Dir(storedVersionPath(path, types.Tool{Name: version.Name}, version.Version))); err != nil {
+			return err
+		}
+		removed = append(removed, version)
+		return nil
+	}
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		stored := storedTool(filepath.Join(root, entry.Name()))
+		if stored.Name == "" {
+			continue
+		}
+		_, installed := ospath.GetExecutablePath(path, stored.Name)
+		versions := StoredVersions(path, stored)
+		for i, version := range versions {
+			binary := storedVersionPath(path, stored, version)
+			fi, err := os.Stat(binary)
+			if err != nil {
+				continue
+			}
+			storedVersion := StoredVersion{Name: stored.Name, Version: version, Size: fi.Size(), Stored: fi.ModTime()}
+			expired := policy.MaxAge > 0 && time.Since(fi.ModTime()) > policy.MaxAge
+			if !installed || i < len(versions)-policy.Keep || expired {
+				if err := remove(storedVersion); err != nil {
+					return removed, err
+				}
+				continue
+			}
+			kept = append(kept, storedVersion)
+		}
+	}
+	if policy.MaxSize > 0 {
+		var size int64
+		for _, version := range kept {
+			size += version.Size
+		}
+		sort.SliceStable(kept, func(i, j int) bool { return kept[i].Stored.Before(kept[j].Stored) })
+		for i := 0; size > policy.MaxSize && i < len(kept); i++ {
+			if err := remove(kept[i]); err != nil {
+				return removed, err
+			}
+			size -= kept[i].Size
+		}
+	}
+	// only removes the directories once they are empty
+	for _, entry := range entries {
+		_ = os.Remove(filepath.Join(root, entry.Name()))
+	}
+	_ = os.Remove(root)
+	return removed, nil
+}
+
+// storedTool returns the tool whose versions are kept in dir, the name of the
+// tool is the name of the kept binaries
+func storedTool(dir string) types.Tool {
+	versions, err := os.ReadDir(dir)
+	if err != nil {
+		return types.Tool{}
+	}
+	for _, version := range versions {
+		binaries, err := os.ReadDir(filepath.Join(dir, version.Name()))
+		if err == nil && len(binaries) > 0 {
+			return types.Tool{Name: strings.TrimSuffix(binaries[0].Name(), storedExtension)}
+		}
+	}
+	return types.Tool{}
+}
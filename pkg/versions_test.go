@@ -0,0 +1,128 @@
+package pkg
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/projectdiscovery/pdtm/pkg/state"
+	"github.com/projectdiscovery/pdtm/pkg/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRollback(t *testing.T) {
+	fake := useFakeGithub(t)
+	path := t.TempDir()
+
+	previous, err := ResolveVersion(types.Tool{Name: "dnsx", Repo: "dnsx"}, "1.1.0")
+	require.Nil(t, err)
+	require.Nil(t, Install(path, previous))
+	previousBinary, err := os.ReadFile(filepath.Join(path, "dnsx"))
+	require.Nil(t, err)
+	latest := GetToolStruct()
+	require.Nil(t, Update(path, latest, true))
+	require.Equal(t, []string{"1.1.0"}, StoredVersions(path, latest))
+
+	version, err := PreviousVersion(path, latest)
+	require.Nil(t, err)
+	require.Equal(t, "1.1.0", version)
+
+	// the kept binary is restored without downloading it again
+	downloads := fake.Requests("DownloadAsset")
+	require.Nil(t, Rollback(path, latest, version))
+	require.Equal(t, downloads, fake.Requests("DownloadAsset"))
+	installed, err := InstalledVersion(latest, path)
+	require.Nil(t, err)
+	require.Equal(t, "1.1.0", installed)
+	require.Equal(t, []string{"1.1.1"}, StoredVersions(path, latest))
+	binary, err := os.ReadFile(filepath.Join(path, "dnsx"))
+	require.Nil(t, err)
+	require.Equal(t, previousBinary, binary)
+
+	st, err := state.Load(path)
+	require.Nil(t, err)
+	recorded, ok := st.Get("dnsx")
+	require.True(t, ok)
+	var history []string
+	for _, previous := range recorded.History {
+		history = append(history, previous.Version)
+	}
+	require.Equal(t, []string{"1.1.0", "1.1.1"}, history)
+
+	require.NotNil(t, Rollback(path, latest, "1.1.0"))
+	require.NotNil(t, Rollback(path, latest, "0.9.0"))
+	installed, err = InstalledVersion(latest, path)
+	require.Nil(t, err)
+	require.Equal(t, "1.1.0", installed)
+}
+
+func TestCollectVersions(t *testing.T) {
+	path := t.TempDir()
+	tool := types.Tool{Name: "dnsx"}
+	store := func(tool types.Tool, version string, age time.Duration) {
+		binary := storedVersionPath(path, tool, version)
+		require.Nil(t, os.MkdirAll(filepath.Dir(binary), os.ModePerm))
+		require.Nil(t, os.WriteFile(binary, []byte(version), 0755))
+		stored := time.Now().Add(-age)
+		require.Nil(t, os.Chtimes(binary, stored, stored))
+	}
+	store(tool, "1.0.0", 72*time.Hour)
+	store(tool, "1.1.0", 48*time.Hour)
+	store(tool, "1.2.0", time.Hour)
+	store(types.Tool{Name: "naabu"}, "2.0.0", time.Hour)
+	require.Nil(t, os.WriteFile(filepath.Join(path, "dnsx"), []byte("1.3.0"), 0755))
+
+	names := func(versions []StoredVersion) []string {
+		var names []string
+		for _, version := range versions {
+			names = append(names, version.Name+" "+version.Version)
+		}
+		return names
+	}
+	collected, err := CollectVersions(path, RetentionPolicy{Keep: 2, MaxAge: 60 * time.Hour})
+	require.Nil(t, err)
+	require.ElementsMatch(t, []string{"dnsx 1.0.0", "naabu 2.0.0"}, names(collected))
+	require.Equal(t, []string{"1.1.0", "1.2.0"}, StoredVersions(path, tool))
+	require.NoDirExists(t, filepath.Join(path, VersionsDir, "naabu"))
+
+	// the oldest versions are removed first above the maximum size
+	collected, err = CollectVersions(path, RetentionPolicy{Keep: 2, MaxSize: 5})
+	require.Nil(t, err)
+	require.Equal(t, []string{"dnsx 1.1.0"}, names(collected))
+	require.Equal(t, []string{"1.2.0"}, StoredVersions(path, tool))
+
+	collected, err = CollectVersions(path, RetentionPolicy{})
+	require.Nil(t, err)
+	require.Len(t, collected, 1)
+	require.NoDirExists(t, filepath.Join(path, VersionsDir))
+}
+
+func TestStoreUnknownVersion(t *testing.T) {
+	path := t.TempDir()
+	tool := types.Tool{Name: "dnsx"}
+	// neither recorded nor printing a version
+	executablePath := filepath.Join(path, "dnsx")
+	require.Nil(t, os.WriteFile(executablePath, []byte("custom build"), 0644))
+	store := func(version string) {
+		binary := storedVersionPath(path, tool, version)
+		require.Nil(t, os.MkdirAll(filepath.Dir(binary), os.ModePerm))
+		require.Nil(t, os.WriteFile(binary, []byte(version), 0755))
+	}
+	store("1.1.0")
+
+	restore, err := storeVersion(path, tool, executablePath)
+	require.Nil(t, err)
+	require.NoFileExists(t, executablePath)
+	versions := StoredVersions(path, tool)
+	require.Len(t, versions, 2)
+	require.True(t, strings.HasPrefix(versions[0], unknownVersion+"-"), "the unknown version should sort first: %v", versions)
+	require.Equal(t, "1.1.0", versions[1])
+
+	restore()
+	binary, err := os.ReadFile(executablePath)
+	require.Nil(t, err)
+	require.Equal(t, "custom build", string(binary))
+	require.Equal(t, []string{"1.1.0"}, StoredVersions(path, tool))
+}